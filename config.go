@@ -337,12 +337,14 @@ func registerFlags(flags *pflag.FlagSet, b *flagBindings) {
 	flags.BoolVarP(boolVar(&processor.Dryness), "dryness", "a", false, "calculate the DRYness of the project (implies --uloc)")
 	flags.BoolVar(boolVar(&processor.Cognitive), "cognitive", false, "calculate cognitive (nesting-weighted) complexity")
 	flags.BoolVar(boolVar(&processor.DisableCheckBinary), "binary", false, "disable binary file detection")
+	flags.BoolVar(boolVar(&processor.DisableClassifier), "no-classifier", false, "disable the statistical classifier used for extensions shared by several languages")
 	flags.BoolVar(boolVar(&processor.Files), "by-file", false, "display output for every file")
 	flags.BoolVar(boolVar(&processor.Ci), "ci", false, "enable CI output settings where stdout is ASCII")
 	flags.BoolVar(boolVar(&processor.Ignore), "no-ignore", false, "disables .ignore file logic")
 	flags.BoolVar(boolVar(&processor.SccIgnore), "no-scc-ignore", false, "disables .sccignore file logic")
 	flags.BoolVar(boolVar(&processor.GitIgnore), "no-gitignore", false, "disables .gitignore file logic")
 	flags.BoolVar(boolVar(&processor.GitModuleIgnore), "no-gitmodule", false, "disables .gitmodules file logic")
+	flags.BoolVar(boolVar(&processor.GitAttributes), "no-gitattributes", false, "disables .gitattributes linguist override logic")
 	flags.BoolVar(boolVar(&processor.CountIgnore), "count-ignore", false, "set to allow .gitignore and .ignore files to be counted")
 	flags.BoolVar(boolVar(&processor.CountUnsupported), "count-unsupported", false, "count files with an unrecognised language under an \"Unknown\" category as plain text")
 	flags.StringArrayVar(sliceVar(&processor.IgnoreFiles), "ignore-file", nil, "path to an additional gitignore-format ignore file, applied from the scan root; repeat to add more, later files and any in-tree ignore files take precedence")
@@ -363,7 +365,7 @@ func registerFlags(flags *pflag.FlagSet, b *flagBindings) {
 	// ReportOut to processor.DefaultReportName to tell "bare flag" apart from an
 	// explicit path, so the two must stay in sync.
 	flags.Lookup("report").NoOptDefVal = processor.DefaultReportName
-	flags.StringVar(strVar(&processor.ReportSkip), "report-skip", "", "comma-separated sections to omit (cocomo,locomo,hotspots,coupling,authors,timeline,files,uloc,linelength,card)")
+	flags.StringVar(strVar(&processor.ReportSkip), "report-skip", "", "comma-separated sections to omit (cocomo,locomo,hotspots,coupling,authors,age,timeline,files,uloc,linelength,card)")
 	flags.StringVar(strVar(&processor.ReportTitle), "report-title", "", "override the repo name shown in the report banner")
 	flags.StringSliceVarP(sliceVar(&processor.AllowListExtensions), "include-ext", "i", []string{}, "limit to file extensions [comma separated list: e.g. go,java,js]")
 	flags.StringSliceVarP(sliceVar(&processor.ExcludeListExtensions), "exclude-ext", "x", []string{}, "ignore file extensions (overrides include-ext) [comma separated list: e.g. go,java,js]")
@@ -423,19 +425,64 @@ func registerFlags(flags *pflag.FlagSet, b *flagBindings) {
 		processor.IgnoreGenerated = v
 		return nil
 	}))
+	flags.BoolFunc("vendor", "identify vendored files by path, such as vendor/, node_modules/ or *.min.js, or when marked linguist-vendored in .gitattributes", boolFunc(func(s string) error { // using func so that last flag wins
+		v, _ := strconv.ParseBool(s)
+		processor.Vendored = v
+		if v {
+			processor.IgnoreVendored = false
+		}
+		return nil
+	}))
+	flags.BoolFunc("no-vendor", "ignore vendored files in output (implies --vendor)", boolFunc(func(s string) error {
+		v, _ := strconv.ParseBool(s)
+		processor.IgnoreVendored = v
+		return nil
+	}))
+	flags.StringArrayVar(sliceVar(&processor.VendorPatterns), "vendor-pattern", nil, "glob marking files as vendored, or as not vendored when it starts with !; matched against the file name, or the whole path when it has a slash; repeat to add more, later patterns win (implies --vendor)")
+	flags.BoolFunc("docs", "identify files marked linguist-documentation in .gitattributes", boolFunc(func(s string) error { // using func so that last flag wins
+		v, _ := strconv.ParseBool(s)
+		processor.Documentation = v
+		if v {
+			processor.IgnoreDocumentation = false
+		}
+		return nil
+	}))
+	flags.BoolFunc("no-docs", "ignore documentation files in output (implies --docs)", boolFunc(func(s string) error {
+		v, _ := strconv.ParseBool(s)
+		processor.IgnoreDocumentation = v
+		return nil
+	}))
+	flags.StringVar(strVar(&processor.Embedded), "embedded", "", "count code embedded in Markdown fences, HTML script and style, Vue and Svelte blocks and heredocs with its own language [nested, language]; bare flag nests it under the host language")
+	flags.Lookup("embedded").NoOptDefVal = "nested"
+	flags.BoolVar(boolVar(&processor.Tests), "tests", false, "separate test files from production code in the output using per-language conventions")
+	flags.StringArrayVar(sliceVar(&processor.TestPatterns), "test-pattern", nil, "glob marking files as tests, or as production code when it starts with !; matched against the file name, or the whole path when it has a slash; repeat to add more, later patterns win (implies --tests)")
 	flags.IntVar(intVar(&processor.MinifiedGeneratedLineByteLength), "min-gen-line-length", 255, "number of bytes per average line for file to be considered minified or generated")
 	flags.StringArrayVarP(sliceVar(&processor.Exclude), "not-match", "M", []string{}, "ignore files and directories matching regular expression")
 	// Write flag: bound via b so config can never reach the real var.
 	flags.StringVarP(b.output, "output", "o", "", "output filename (default stdout)")
+	flags.BoolVar(boolVar(&processor.ShowProgress), "progress", false, "print progress of files processed and commits diffed to stderr")
 	flags.StringVarP(strVar(&processor.SortBy), "sort", "s", "files", "column to sort by [files, name, lines, blanks, code, comments, complexity]")
 	flags.BoolVarP(boolVar(&processor.Trace), "trace", "t", false, "enable trace output (not recommended when processing multiple files)")
 	flags.BoolVarP(boolVar(&processor.Verbose), "verbose", "v", false, "verbose output")
 	flags.BoolVarP(boolVar(&processor.More), "wide", "w", false, "wider output with additional statistics (implies --complexity)")
 	flags.BoolVar(boolVar(&processor.NoLarge), "no-large", false, "ignore files over certain byte and line size set by large-line-count and large-byte-count")
 	flags.BoolVar(boolVar(&processor.IncludeSymLinks), "include-symlinks", false, "if set will count symlink files")
+	flags.StringVar(strVar(&processor.Rev), "rev", "", "count the tree of a git revision (branch, tag, commit or e.g. HEAD~3) without checking it out; paths must be inside one repository, which can be bare")
+	flags.StringVar(strVar(&processor.DiffAgainst), "diff-against", "", "report per-language and per-file changes in counts from this git revision or path to the current paths [formats: tabular, csv, json, markdown]")
+	flags.StringArrayVar(sliceVar(&processor.Gates), "gate", nil, "quality gate rule; exits 1 listing the offending files when broken [max-complexity, max-cognitive, max-lines, max-code, max-bytes, min-comment-ratio=N, no-minified, no-generated; scope with language=A,B path=glob, e.g. \"max-complexity=25 language=Go\"]; repeat to add more")
+	flags.StringVar(strVar(&processor.GateFile), "gate-file", "", "file of quality gate rules, one --gate rule per line")
+	flags.BoolVar(boolVar(&processor.ByDir), "by-dir", false, "report counts rolled up into the directory tree with a per-language breakdown for each directory [formats: tabular, wide, json, csv, html, html-table]")
+	flags.IntVar(intVar(&processor.DirDepth), "dir-depth", 0, "how many directory levels below each path --by-dir reports, deeper files count towards their ancestor; 0 for no limit")
+	flags.BoolVar(boolVar(&processor.ByModule), "by-module", false, "report the languages and COCOMO/LOCOMO estimates of each module, rolling files up to the nearest directory with a go.mod, package.json, Cargo.toml, pom.xml, pyproject.toml or *.csproj [formats: tabular, json, csv]")
+	flags.BoolVar(boolVar(&processor.ByFunction), "by-function", false, "report each function's name, line span, code, complexity and cognitive complexity, most complex first, for Go, Rust, Python, Java, C#, C/C++, JavaScript/TypeScript, Kotlin, Swift, PHP, Scala and Dart [formats: tabular, json, csv]")
+	flags.IntVar(intVar(&processor.TopFunctions), "top-functions", 0, "only report this many of the most complex functions (implies --by-function)")
+	flags.BoolVar(boolVar(&processor.Archive), "archive", false, "count the contents of file arguments and of tar, tar.gz and zip files found while walking as directories")
 	flags.Int64Var(int64Var(&processor.LargeLineCount), "large-line-count", 40000, "number of lines a file can contain before being removed from output")
 	flags.Int64Var(int64Var(&processor.LargeByteCount), "large-byte-count", 1000000, "number of bytes a file can contain before being removed from output")
 	flags.StringVar(strVar(&processor.CountAs), "count-as", "", "count extension as language [e.g. jsp:htm,chead:\"C Header\" maps extension jsp to html and chead to C Header]")
+	flags.StringVar(strVar(&processor.LanguagesFile), "languages-file", "", "JSON file of extra languages, or replacements for built-in ones, in the languages.json schema; they take priority over the built-ins for their extensions, filenames and shebangs")
+	flags.BoolVar(boolVar(&processor.ValidateLanguages), "validate-languages", false, "check the language definitions in the given files, or the built-in ones when none are given, for mistakes and conflicts and count their samples; exits 1 on any error")
+	flags.StringVar(strVar(&processor.Explain), "explain", "", "explain how the given file is classified: the candidate languages, heuristics, keywords, remap rules and #! line checked and the final decision [formats: tabular, json]")
 	flags.StringArrayVar(sliceVar(&processor.CountAsPattern), "count-as-pattern", nil, "count files matching a path pattern as a new named category backed by a base language "+
 		"[repeatable; pattern is glob by default, prefix with re: for regex; "+
 		"e.g. *_spec.rb:\"Ruby Spec\":Ruby or re:\\.test\\.js$:\"JavaScript Tests\":JavaScript]")
@@ -459,9 +506,19 @@ func registerFlags(flags *pflag.FlagSet, b *flagBindings) {
 	flags.StringVar(strVar(&processor.CouplingFor), "coupling-for", "", "blast-radius view: given a file path, show what tends to change with it over recent git history")
 	flags.BoolVar(boolVar(&processor.CouplingWeighted), "coupling-weighted", false, "weight coupling by file complexity so pairs of complex files rank above generated/data-file churn (implies --coupling)")
 	flags.BoolVar(boolVar(&processor.ByAuthor), "by-author", false, "render the author rollup report (bus factor and last-toucher attribution over recent git history)")
+	flags.BoolVar(boolVar(&processor.CodeAge), "code-age", false, "render the code age report (median age and share of old code per language, directory and file over recent git history)")
+	flags.BoolVar(boolVar(&processor.ComplexityTrend), "complexity-trend", false, "render the complexity trend report (files ranked by complexity growth with sparklines over recent git history)")
+	flags.StringVar(strVar(&processor.ComplexityTrendFor), "complexity-trend-for", "", "given a file path, show its complexity after every commit over recent git history, following renames (implies --complexity-trend)")
+	flags.IntVar(intVar(&processor.HistoryJobWorkers), "history-job-workers", runtime.NumCPU(), "number of goroutine workers that diff and classify commits for the git history reports")
+	flags.BoolVar(boolVar(&processor.HistoryCache), "history-cache", false, "keep the classification of every blob the git history reports see on disk so repeated runs only classify new blobs")
+	flags.StringVar(strVar(&processor.HistoryCacheDir), "history-cache-dir", "", "directory for --history-cache; defaults to the repository's .git directory, or the user cache directory when that cannot be written")
+	flags.IntVar(intVar(&processor.HistoryCacheSize), "history-cache-size", 256, "size in megabytes past which --history-cache drops the blobs least recently used")
 	flags.IntVar(intVar(&processor.HistoryDepth), "depth", 1000, "commit window size for git history reports; 0 means entire history (large repos may be slow)")
+	flags.StringVar(strVar(&processor.HistorySince), "since", "", "limit git history reports to commits after a date, e.g. 2026-01-31 or \"3 months ago\"; walks every such commit unless --depth is set")
+	flags.StringVar(strVar(&processor.HistoryUntil), "until", "", "limit git history reports to commits before a date, e.g. 2026-01-31 or \"2 weeks ago\"")
+	flags.StringVar(strVar(&processor.HistoryRange), "range", "", "git revision range the history reports walk, e.g. v3.0.0..HEAD or v3.0.0..; walks every commit in it unless --depth is set")
 	flags.BoolVar(boolVar(&processor.Timeline), "timeline", false, "render an over-time view of recent git history; with --by-author runs the author timeline, alone runs the languages timeline")
-	flags.IntVar(intVar(&processor.HistoryBuckets), "buckets", 60, "time-bucket resolution for the git timeline reports")
+	flags.IntVar(intVar(&processor.HistoryBuckets), "buckets", 60, "time-bucket resolution for the git timeline and complexity trend reports")
 	// --no-fold-authors is read back via cmd.PersistentFlags().GetBool in Run, so
 	// its bound var is irrelevant; a throwaway sink is enough in both modes.
 	flags.BoolVar(new(bool), "no-fold-authors", false, "disable the name+email-domain identity folding fallback for git author reports (mailmap still applied)")
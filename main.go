@@ -47,6 +47,7 @@ func printFlagSuggestion(flagSet *pflag.FlagSet, unknownFlag string) {
 }
 
 //go:generate go run scripts/include.go
+//go:generate go run ./scripts/classifier
 func main() {
 	// f, _ := os.Create("scc.pprof")
 	// pprof.StartCPUProfile(f)
@@ -140,6 +141,43 @@ func main() {
   Count several paths at once:
     scc src/ docs/ README.md
 
+  Count a source tarball or zip without extracting it:
+    scc release.tar.gz
+    scc --archive --by-file vendor/
+
+  Count a tag or commit without checking it out (works on bare repositories):
+    scc --rev v3.0.0 .
+
+  Report how counts changed since a revision, or between two directories:
+    scc --diff-against main .
+    scc --diff-against ../release-1.0 --format markdown ../release-2.0
+
+  Fail a CI run when quality gates are broken (exit code 1, offending files listed):
+    scc --gate "max-complexity=25 language=Go" --gate "min-comment-ratio=10" --gate no-minified
+    scc --gate-file .scc-gates --format json
+
+  Roll the counts up into the directory tree, two levels deep, with languages per directory:
+    scc --by-dir --dir-depth 2 --wide
+
+  Size each module of a monorepo, found by its go.mod, package.json, Cargo.toml and so on:
+    scc --by-module --format csv
+
+  List the 20 most complex functions, or every function as CSV:
+    scc --top-functions 20
+    scc --by-function --format csv
+
+  Count an in-house language, or change a built-in one, without a new release:
+    scc --languages-file languages.json
+
+  Check a languages file for mistakes and count its samples before using it:
+    scc --validate-languages languages.json
+
+  Explain why a file is counted as the language it is:
+    scc --explain include/widget.h
+
+  Report vendored and documentation files marked in .gitattributes separately:
+    scc --vendor --docs
+
   Show a per-file breakdown instead of the per-language summary:
     scc --by-file
 
@@ -179,6 +217,10 @@ func main() {
 			processor.LocomoTPSSet = cmd.PersistentFlags().Changed("locomo-tps")
 			processor.LocomoCyclesSet = cmd.PersistentFlags().Changed("locomo-cycles")
 
+			// A history window limited by date or range is walked in full
+			// unless --depth was also given, which again only Changed() knows
+			processor.HistoryDepthSet = cmd.PersistentFlags().Changed("depth")
+
 			if v, err := cmd.PersistentFlags().GetBool("no-fold-authors"); err == nil && v {
 				processor.FoldAuthors = false
 			}
@@ -19,10 +19,23 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 )
 
-// mcpMu serializes MCP tool calls so concurrent requests
-// don't race on processor package globals.
+// mcpMu serializes MCP tool calls that still configure the processor
+// package globals so concurrent requests don't race on shared state.
 var mcpMu sync.Mutex
 
+var mcpLanguagesOnce sync.Once
+
+// mcpLoadLanguages builds the language database (ExtensionToLanguage etc.)
+// with lazy per-language feature loading, as Process() does on the cobra
+// path. It runs once per server because rebuilding the maps while an
+// analyze call is reading them would race.
+func mcpLoadLanguages() {
+	mcpLanguagesOnce.Do(func() {
+		processor.ConfigureLazy(true)
+		processor.ProcessConstants()
+	})
+}
+
 func startMCPServer() {
 	mcpServer := server.NewMCPServer(
 		"scc",
@@ -96,12 +109,21 @@ Walks the repo's git log (most recent commits first) and returns, per surviving
 - codeChurn / commentChurn: added lines classified as code vs comment
 - score: 0–100, normalised complexity × commits (higher = hotter)
 
-Also returns the history window walked (depth, commit count, date range). Requires path to be inside a git repository.`),
+Also returns the history window walked (depth, commit count, date range, and the since, until and range limits with the commits the range resolved to). Requires path to be inside a git repository.`),
 		mcp.WithString("path",
 			mcp.Description("Directory inside the git repository to analyze. Defaults to current directory."),
 		),
 		mcp.WithNumber("depth",
-			mcp.Description("Maximum number of recent commits to walk. Defaults to 1000. Set to 0 for unlimited (slower on large repos)."),
+			mcp.Description("Maximum number of recent commits to walk. Defaults to 1000, or to every commit in the window when since, until or range is set. Set to 0 for unlimited (slower on large repos)."),
+		),
+		mcp.WithString("since",
+			mcp.Description("Only walk commits made after this date, e.g. 2026-01-31 or \"3 months ago\"."),
+		),
+		mcp.WithString("until",
+			mcp.Description("Only walk commits made before this date, e.g. 2026-01-31 or \"2 weeks ago\"."),
+		),
+		mcp.WithString("range",
+			mcp.Description("Git revision range to walk instead of the history leading up to HEAD, e.g. v3.0.0..HEAD for everything since the v3.0.0 tag."),
 		),
 		mcp.WithNumber("limit",
 			mcp.Description("Maximum number of files to return, highest-scoring first. Defaults to 50. Set to -1 for unlimited."),
@@ -136,7 +158,16 @@ Requires path to be inside a git repository.`),
 			mcp.Description("Directory inside the git repository to analyze. Defaults to current directory."),
 		),
 		mcp.WithNumber("depth",
-			mcp.Description("Maximum number of recent commits to walk. Defaults to 1000. Set to 0 for unlimited (slower on large repos)."),
+			mcp.Description("Maximum number of recent commits to walk. Defaults to 1000, or to every commit in the window when since, until or range is set. Set to 0 for unlimited (slower on large repos)."),
+		),
+		mcp.WithString("since",
+			mcp.Description("Only walk commits made after this date, e.g. 2026-01-31 or \"3 months ago\"."),
+		),
+		mcp.WithString("until",
+			mcp.Description("Only walk commits made before this date, e.g. 2026-01-31 or \"2 weeks ago\"."),
+		),
+		mcp.WithString("range",
+			mcp.Description("Git revision range to walk instead of the history leading up to HEAD, e.g. v3.0.0..HEAD for everything since the v3.0.0 tag."),
 		),
 		mcp.WithNumber("limit",
 			mcp.Description("Maximum rows to return, strongest first — coupled files in per-file mode, file pairs in all-pairs mode. Defaults to 50. Set to -1 for unlimited."),
@@ -145,6 +176,26 @@ Requires path to be inside a git repository.`),
 
 	mcpServer.AddTool(couplingTool, mcpCouplingHandler)
 
+	explainTool := mcp.NewTool("explain",
+		mcp.WithDescription(`Explain why a file is classified as the language it is counted as. Use this when a file is counted as an unexpected language, for example a .h file counted as Objective C.
+
+Walks through the same detection steps as counting and returns:
+- matchedBy: what found the candidates — filename, extension, shebang, count-as-pattern, unsupported or none
+- candidates: the languages the filename or extension could be
+- heuristics: for each candidate heuristic, its pattern, the literals found in the file, whether the pattern was run (only when a literal is found) and whether it matched
+- keywords: for candidates without heuristics, the keywords found
+- fallback: the candidate used when nothing else decides
+- shebang: the #! line and the language it names, for files without an extension
+- language: the final decision, empty when the file would be skipped
+- reason: why that language was chosen`),
+		mcp.WithString("file",
+			mcp.Required(),
+			mcp.Description("Path of the file to explain."),
+		),
+	)
+
+	mcpServer.AddTool(explainTool, mcpExplainHandler)
+
 	errLogger := log.New(os.Stderr, "scc-mcp: ", log.LstdFlags)
 	if err := server.ServeStdio(mcpServer, server.WithErrorLogger(errLogger)); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "scc-mcp: server error: %v\n", err)
@@ -237,30 +288,17 @@ func mcpAnalyzeHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 		return mcp.NewToolResultError(fmt.Sprintf("path cannot be accessed: %s: %v", absPath, err)), nil
 	}
 
-	// Serialize access to processor globals so concurrent MCP
-	// requests don't race on shared state.
-	mcpMu.Lock()
-	defer mcpMu.Unlock()
-
-	// Configure processor globals for this request.
-	// Some defaults are normally set by cobra flags which the MCP path
-	// bypasses, so we set them explicitly here.
-	processor.DirFilePaths = []string{absPath}
-	processor.Format = "json"
-	processor.Cocomo = false
-	processor.Size = false
-	processor.Files = false
-	processor.PathDenyList = []string{".git", ".hg", ".svn"}
-	processor.ExcludeFilename = []string{"package-lock.json", "Cargo.lock", "yarn.lock", "pubspec.lock", "Podfile.lock", "pnpm-lock.yaml"}
+	// Each request runs its own Analyzer, so concurrent analyze calls don't
+	// share any counting state and need not hold mcpMu.
+	opts := processor.DefaultOptions()
+	opts.Paths = []string{absPath}
 
 	if sortBy, ok := args["sort"].(string); ok && sortBy != "" {
-		processor.SortBy = sortBy
-	} else {
-		processor.SortBy = "files"
+		opts.SortBy = sortBy
 	}
 
 	if byFile, ok := args["by_file"].(bool); ok && byFile {
-		processor.Files = true
+		opts.ByFile = true
 	}
 
 	fileLimit := 10 // default limit when by_file is true
@@ -273,59 +311,44 @@ func mcpAnalyzeHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 	}
 
 	if includeExt, ok := args["include_ext"].(string); ok && includeExt != "" {
-		processor.AllowListExtensions = splitAndTrimExtensions(includeExt)
-	} else {
-		processor.AllowListExtensions = []string{}
+		opts.IncludeExtensions = splitAndTrimExtensions(includeExt)
 	}
 
 	if excludeExt, ok := args["exclude_ext"].(string); ok && excludeExt != "" {
-		processor.ExcludeListExtensions = splitAndTrimExtensions(excludeExt)
-	} else {
-		processor.ExcludeListExtensions = []string{}
+		opts.ExcludeExtensions = splitAndTrimExtensions(excludeExt)
 	}
 
 	if noDups, ok := args["no_duplicates"].(bool); ok && noDups {
-		processor.Duplicates = true
-	} else {
-		processor.Duplicates = false
+		opts.Duplicates = true
 	}
 
 	if noMinGen, ok := args["no_min_gen"].(bool); ok && noMinGen {
-		processor.IgnoreMinifiedGenerate = true
-		// GeneratedMarkers is normally set by cobra flag defaults which
-		// the MCP path bypasses, so set them here.
-		if len(processor.GeneratedMarkers) == 0 {
-			processor.GeneratedMarkers = []string{"do not edit", "<auto-generated />"}
-		}
-	} else {
-		processor.IgnoreMinifiedGenerate = false
+		opts.IgnoreMinified = true
+		opts.IgnoreGenerated = true
 	}
 
 	if cognitive, ok := args["cognitive"].(bool); ok && cognitive {
-		processor.Cognitive = true
-	} else {
-		processor.Cognitive = false
+		opts.Cognitive = true
 	}
 
-	if locomo, ok := args["locomo"].(bool); ok && locomo {
-		processor.Locomo = true
-	} else {
-		processor.Locomo = false
+	locomo := false
+	if l, ok := args["locomo"].(bool); ok && l {
+		locomo = true
 	}
 
-	if locomoPreset, ok := args["locomo_preset"].(string); ok && locomoPreset != "" {
-		processor.LocomoPresetName = locomoPreset
-	} else {
-		processor.LocomoPresetName = "medium"
+	locomoPreset := "medium"
+	if p, ok := args["locomo_preset"].(string); ok && p != "" {
+		locomoPreset = p
 	}
 
-	processor.ConfigureLazy(true)
-
 	// Run the analysis
-	language, err := processor.ProcessResult()
+	mcpLoadLanguages()
+	analyzer := processor.NewAnalyzer(opts)
+	language, err := analyzer.Languages(mcpProgressContext(ctx, request))
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("analysis failed: %v", err)), nil
 	}
+	opts = analyzer.Options()
 
 	// Build response
 	var totals mcpTotals
@@ -344,12 +367,12 @@ func mcpAnalyzeHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 			Bytes:      l.Bytes,
 		}
 
-		if processor.Files && len(l.Files) > 0 {
+		if opts.ByFile && len(l.Files) > 0 {
 			files := l.Files
 			// Sort files within each language by the same criteria
 			// used for languages so per-file output is ordered and
 			// limit returns the top N rather than an arbitrary slice.
-			sortFileJobs(files)
+			sortFileJobs(files, opts.SortBy)
 			if fileLimit > 0 && len(files) > fileLimit {
 				files = files[:fileLimit]
 			}
@@ -405,9 +428,14 @@ func mcpAnalyzeHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 		EstimatedPeople:         estimatedPeople,
 	}
 
-	// LOCOMO estimate if requested
-	if processor.Locomo {
+	// LOCOMO estimate if requested. The estimator still reads its preset
+	// from the processor globals, so that part stays serialized.
+	if locomo {
+		mcpMu.Lock()
+		processor.LocomoPresetName = locomoPreset
 		result := processor.LocomoEstimate(totals.Code, totals.Complexity)
+		mcpMu.Unlock()
+
 		resp.LOCOMO = &mcpLOCOMO{
 			Cost:                  result.Cost,
 			InputTokens:           result.InputTokens,
@@ -454,12 +482,17 @@ func mcpHotspotsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	// HistoryDepth is normally set by a cobra flag default which the MCP path
 	// bypasses, so set it explicitly. Default mirrors the CLI's 1000.
 	processor.HistoryDepth = 1000
+	processor.HistoryDepthSet = false
 	if d, ok := args["depth"].(float64); ok {
 		if d < 0 {
 			d = 0
 		}
 		processor.HistoryDepth = int(d)
+		processor.HistoryDepthSet = true
 	}
+	processor.HistorySince, _ = args["since"].(string)
+	processor.HistoryUntil, _ = args["until"].(string)
+	processor.HistoryRange, _ = args["range"].(string)
 
 	fileLimit := 50 // default cap so responses stay bounded for the model
 	if l, ok := args["limit"].(float64); ok {
@@ -470,15 +503,11 @@ func mcpHotspotsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 		}
 	}
 
-	// Build the language database (ExtensionToLanguage etc.) and enable lazy
-	// per-language feature loading. Process() does this before the hotspots
-	// report on the cobra path; the analyze handler gets it via ProcessResult.
-	// Without ProcessConstants the HEAD tree can't be classified, so every
-	// file is dropped and the report comes back empty.
-	processor.ProcessConstants()
-	processor.ConfigureLazy(true)
+	// Without the language database the HEAD tree can't be classified, so
+	// every file is dropped and the report comes back empty.
+	mcpLoadLanguages()
 
-	out, err := processor.HotspotsJSONReport(absPath, fileLimit)
+	out, err := processor.HotspotsJSONReport(mcpProgressContext(ctx, request), absPath, fileLimit)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("hotspots analysis failed: %v", err)), nil
 	}
@@ -511,12 +540,17 @@ func mcpCouplingHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	defer mcpMu.Unlock()
 
 	processor.HistoryDepth = 1000
+	processor.HistoryDepthSet = false
 	if d, ok := args["depth"].(float64); ok {
 		if d < 0 {
 			d = 0
 		}
 		processor.HistoryDepth = int(d)
+		processor.HistoryDepthSet = true
 	}
+	processor.HistorySince, _ = args["since"].(string)
+	processor.HistoryUntil, _ = args["until"].(string)
+	processor.HistoryRange, _ = args["range"].(string)
 
 	fileLimit := 50
 	if l, ok := args["limit"].(float64); ok {
@@ -527,15 +561,14 @@ func mcpCouplingHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 		}
 	}
 
-	processor.ProcessConstants()
-	processor.ConfigureLazy(true)
+	mcpLoadLanguages()
 
 	// file set → per-file blast radius; file omitted → repo-wide all-pairs.
 	var out string
 	if target != "" {
-		out, err = processor.CouplingForJSONReport(absPath, target, fileLimit)
+		out, err = processor.CouplingForJSONReport(mcpProgressContext(ctx, request), absPath, target, fileLimit)
 	} else {
-		out, err = processor.CouplingJSONReport(absPath, fileLimit)
+		out, err = processor.CouplingJSONReport(mcpProgressContext(ctx, request), absPath, fileLimit)
 	}
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("coupling analysis failed: %v", err)), nil
@@ -544,14 +577,70 @@ func mcpCouplingHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	return mcp.NewToolResultText(out), nil
 }
 
+func mcpExplainHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	file, _ := args["file"].(string)
+	if file == "" {
+		return mcp.NewToolResultError("file is required"), nil
+	}
+
+	absPath, err := filepath.Abs(file)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid file: %v", err)), nil
+	}
+
+	// Explaining reads the same processor globals the other tools set, so
+	// it is serialized with them.
+	mcpMu.Lock()
+	defer mcpMu.Unlock()
+
+	mcpLoadLanguages()
+
+	out, err := processor.ExplainJSONReport(absPath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("explain failed: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(out), nil
+}
+
+// mcpProgressContext attaches a progress hook to ctx that forwards updates to
+// the client as notifications/progress when the request carried a
+// progressToken. Counting runs report files processed out of files walked so
+// far, history walks report commits diffed out of the window.
+func mcpProgressContext(ctx context.Context, request mcp.CallToolRequest) context.Context {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return ctx
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return ctx
+	}
+
+	token := request.Params.Meta.ProgressToken
+	return processor.WithProgress(ctx, func(p processor.Progress) {
+		params := map[string]any{"progressToken": token}
+		if p.CommitsTotal != 0 {
+			params["progress"] = p.CommitsDiffed
+			params["total"] = p.CommitsTotal
+			params["message"] = fmt.Sprintf("diffed %d of %d commits", p.CommitsDiffed, p.CommitsTotal)
+		} else {
+			params["progress"] = p.FilesProcessed
+			params["message"] = fmt.Sprintf("processed %d of %d files found", p.FilesProcessed, p.FilesWalked)
+		}
+		_ = srv.SendNotificationToClient(ctx, "notifications/progress", params)
+	})
+}
+
 func jsonMarshal(v any) ([]byte, error) {
 	return json.MarshalIndent(v, "", "  ")
 }
 
-// sortFileJobs sorts a slice of FileJob pointers using the current
-// processor.SortBy value so that the most relevant files come first.
-func sortFileJobs(files []*processor.FileJob) {
-	switch processor.SortBy {
+// sortFileJobs sorts a slice of FileJob pointers by the same sortBy value
+// used for the language summary so that the most relevant files come first.
+func sortFileJobs(files []*processor.FileJob, sortBy string) {
+	switch sortBy {
 	case "name", "names", "language", "languages", "lang", "langs":
 		slices.SortFunc(files, func(a, b *processor.FileJob) int {
 			return strings.Compare(a.Filename, b.Filename)
@@ -343,3 +343,43 @@ func TestMcpAnalyzeCognitiveNoLeak(t *testing.T) {
 		t.Fatalf("code total changed across calls: %d then %d", first.Totals.Code, second.Totals.Code)
 	}
 }
+
+// TestMCPExplain: the explain tool returns the same trace as --explain --format
+// json for the given file.
+func TestMCPExplain(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "widget.h")
+	if err := os.WriteFile(file, []byte("@interface Widget\n@end\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "explain"
+	req.Params.Arguments = map[string]any{"file": file}
+	res, err := mcpExplainHandler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handler returned transport error: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("expected success, got error: %s", resultText(t, res))
+	}
+
+	var doc struct {
+		Report     string   `json:"report"`
+		Candidates []string `json:"candidates"`
+		Language   string   `json:"language"`
+		Reason     string   `json:"reason"`
+	}
+	if err := jsoniter.Unmarshal([]byte(resultText(t, res)), &doc); err != nil {
+		t.Fatalf("unmarshal explain JSON: %v\n%s", err, resultText(t, res))
+	}
+	if doc.Report != "explain" || doc.Language != "Objective C" || len(doc.Candidates) != 3 || doc.Reason == "" {
+		t.Errorf("unexpected explanation %s", resultText(t, res))
+	}
+
+	req.Params.Arguments = map[string]any{"file": dir}
+	res, err = mcpExplainHandler(context.Background(), req)
+	if err != nil || !res.IsError {
+		t.Errorf("expected a tool error for a directory got %v %v", res, err)
+	}
+}
@@ -0,0 +1,578 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/boyter/gocodewalker"
+)
+
+// Options configures an Analyzer. Every field mirrors the CLI flag of the same
+// name so the behaviour of a library run matches `scc` with those flags set.
+// DefaultOptions returns the values the CLI uses when no flags are supplied.
+//
+// The language registry (languages.json plus anything added through
+// --count-as / --count-as-pattern) is process-wide and is configured through
+// ProcessConstants; Options only covers how a single run walks and counts.
+type Options struct {
+	// Paths are the files and directories to count. Empty means ".".
+	Paths []string
+	// Rev counts Paths as they are in the tree of this git revision instead
+	// of on disk (--rev). The paths must be inside a single repository.
+	Rev string
+
+	// ExcludeDirs are directory names skipped while walking (--exclude-dir).
+	ExcludeDirs []string
+	// NotMatch are regular expressions matched against file and directory
+	// paths; matches are skipped (--not-match).
+	NotMatch []string
+	// IncludeExtensions limits counting to these extensions (--include-ext).
+	IncludeExtensions []string
+	// ExcludeExtensions skips these extensions, overriding IncludeExtensions (--exclude-ext).
+	ExcludeExtensions []string
+	// ExcludeFilenames skips files whose name contains any of these (--exclude-file).
+	ExcludeFilenames []string
+	// IgnoreFiles are additional gitignore-format files applied from the scan root (--ignore-file).
+	IgnoreFiles []string
+
+	NoGitIgnore     bool // --no-gitignore
+	NoIgnore        bool // --no-ignore
+	NoSccIgnore     bool // --no-scc-ignore
+	NoGitModule     bool // --no-gitmodule
+	IncludeSymLinks bool // --include-symlinks
+	// NoGitAttributes skips the linguist-language, linguist-generated,
+	// linguist-vendored and linguist-documentation overrides read from
+	// .gitattributes files (--no-gitattributes).
+	NoGitAttributes bool
+	// Archive opens every file path and any tar, tar.gz or zip found while
+	// walking as an archive, counting its entries as a directory (--archive).
+	// Without it only file paths with an archive extension are opened.
+	Archive          bool
+	CountIgnore      bool // --count-ignore
+	CountUnsupported bool // --count-unsupported
+
+	// NoLarge drops files over LargeLineCount lines or LargeByteCount bytes (--no-large).
+	NoLarge        bool
+	LargeLineCount int64
+	LargeByteCount int64
+
+	NoComplexity       bool // --no-complexity
+	Cognitive          bool // --cognitive, implies complexity counting
+	DisableCheckBinary bool // --binary
+	DisableClassifier  bool // --no-classifier
+	Duplicates         bool // --no-duplicates, drops files whose content was already counted
+
+	Minified         bool     // --min
+	Generated        bool     // --gen
+	IgnoreMinified   bool     // --no-min, implies Minified
+	IgnoreGenerated  bool     // --no-gen, implies Generated
+	GeneratedMarkers []string // --generated-markers
+	// Vendored and Documentation report vendored files, found by path or
+	// marked linguist-vendored, and files marked linguist-documentation
+	// under their own "(vendor)" and "(docs)" languages (--vendor, --docs);
+	// IgnoreVendored and IgnoreDocumentation drop them (--no-vendor,
+	// --no-docs) and imply the former. VendorPatterns are extra globs marking
+	// vendored files, or files that are not when they start with !, and imply
+	// Vendored (--vendor-pattern).
+	Vendored            bool
+	Documentation       bool
+	IgnoreVendored      bool
+	IgnoreDocumentation bool
+	VendorPatterns      []string
+	// Tests separates test files from production code, filling FileJob.Test
+	// and LanguageSummary.Production and Test (--tests). TestPatterns are
+	// extra globs marking tests, or production code when they start with !,
+	// and imply Tests (--test-pattern).
+	Tests        bool
+	TestPatterns []string
+	// Embedded counts the code in Markdown fences, HTML script and style
+	// elements, Vue and Svelte blocks and heredocs naming a language with
+	// that language, filling FileJob.Embedded (--embedded). "nested" adds it
+	// to LanguageSummary.Embedded of the host language and "language" moves
+	// it out of the host into the summary of its own language.
+	Embedded string
+	// MinifiedGeneratedLineByteLength is the average bytes per line at which a
+	// file is considered minified (--min-gen-line-length).
+	MinifiedGeneratedLineByteLength int
+
+	Uloc    bool // --uloc, fills LanguageSummary.ULOC
+	MaxMean bool // --character, fills FileJob.LineLength
+
+	RemapAll     string // --remap-all
+	RemapUnknown string // --remap-unknown
+
+	// ByFile keeps every FileJob on its LanguageSummary.Files (--by-file).
+	ByFile bool
+	// SortBy orders the language summary (--sort).
+	SortBy string
+	// NoCocomo leaves out the COCOMO estimates and Locomo adds the LOCOMO
+	// ones to the reports that give them per group (--no-cocomo, --locomo).
+	NoCocomo bool
+	Locomo   bool
+
+	FileListQueueSize         int // --file-list-queue-size
+	FileProcessJobWorkers     int // --file-process-job-workers
+	FileSummaryJobQueueSize   int // --file-summary-job-queue-size
+	DirectoryWalkerJobWorkers int // --directory-walker-job-workers
+
+	// lineDigests keeps a hash of every line of each counted file, which is
+	// what --diff-against compares once the content buffer has been reused
+	lineDigests bool
+	// functions splits each counted file into its functions for --by-function
+	functions bool
+}
+
+// DefaultOptions returns the Options the CLI runs with when no flags are set.
+func DefaultOptions() Options {
+	return Options{
+		Paths:                           []string{"."},
+		ExcludeDirs:                     []string{".git", ".hg", ".svn"},
+		ExcludeFilenames:                []string{"package-lock.json", "Cargo.lock", "yarn.lock", "pubspec.lock", "Podfile.lock", "pnpm-lock.yaml"},
+		GeneratedMarkers:                []string{"do not edit", "<auto-generated />"},
+		MinifiedGeneratedLineByteLength: 255,
+		LargeLineCount:                  40000,
+		LargeByteCount:                  1000000,
+		SortBy:                          "files",
+		FileListQueueSize:               runtime.NumCPU(),
+		FileProcessJobWorkers:           runtime.NumCPU(),
+		FileSummaryJobQueueSize:         runtime.NumCPU(),
+		DirectoryWalkerJobWorkers:       8,
+	}
+}
+
+// optionsFromGlobals snapshots the package-level flags into Options. This is
+// how the CLI and the other global-driven entry points sit on top of Analyzer.
+func optionsFromGlobals() Options {
+	return Options{
+		Paths:                           DirFilePaths,
+		Rev:                             Rev,
+		ExcludeDirs:                     PathDenyList,
+		NotMatch:                        Exclude,
+		IncludeExtensions:               AllowListExtensions,
+		ExcludeExtensions:               ExcludeListExtensions,
+		ExcludeFilenames:                ExcludeFilename,
+		IgnoreFiles:                     IgnoreFiles,
+		NoGitIgnore:                     GitIgnore,
+		NoIgnore:                        Ignore,
+		NoSccIgnore:                     SccIgnore,
+		NoGitModule:                     GitModuleIgnore,
+		IncludeSymLinks:                 IncludeSymLinks,
+		NoGitAttributes:                 GitAttributes,
+		Archive:                         Archive,
+		CountIgnore:                     CountIgnore,
+		CountUnsupported:                CountUnsupported,
+		NoLarge:                         NoLarge,
+		LargeLineCount:                  LargeLineCount,
+		LargeByteCount:                  LargeByteCount,
+		NoComplexity:                    Complexity,
+		Cognitive:                       Cognitive,
+		DisableCheckBinary:              DisableCheckBinary,
+		DisableClassifier:               DisableClassifier,
+		Duplicates:                      Duplicates,
+		Minified:                        Minified,
+		Generated:                       Generated,
+		IgnoreMinified:                  IgnoreMinified,
+		IgnoreGenerated:                 IgnoreGenerated,
+		GeneratedMarkers:                GeneratedMarkers,
+		Vendored:                        Vendored,
+		Documentation:                   Documentation,
+		IgnoreVendored:                  IgnoreVendored,
+		VendorPatterns:                  VendorPatterns,
+		Tests:                           Tests,
+		TestPatterns:                    TestPatterns,
+		Embedded:                        Embedded,
+		IgnoreDocumentation:             IgnoreDocumentation,
+		MinifiedGeneratedLineByteLength: MinifiedGeneratedLineByteLength,
+		Uloc:                            UlocMode,
+		MaxMean:                         MaxMean,
+		RemapAll:                        RemapAll,
+		RemapUnknown:                    RemapUnknown,
+		ByFile:                          Files,
+		SortBy:                          SortBy,
+		NoCocomo:                        Cocomo,
+		Locomo:                          Locomo,
+		FileListQueueSize:               FileListQueueSize,
+		FileProcessJobWorkers:           FileProcessJobWorkers,
+		FileSummaryJobQueueSize:         FileSummaryJobQueueSize,
+		DirectoryWalkerJobWorkers:       DirectoryWalkerJobWorkers,
+	}
+}
+
+// locationRoots returns Paths as they begin the Location of the files a run
+// counts, which for Rev is relative to the repository root
+func (o *Options) locationRoots() ([]string, error) {
+	roots := o.Paths
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	if o.Rev != "" {
+		_, inTree, err := revisionPaths(roots)
+		return inTree, err
+	}
+	out := make([]string, 0, len(roots))
+	for _, r := range roots {
+		out = append(out, filepath.Clean(r))
+	}
+	return out, nil
+}
+
+// normalise applies the same implications processFlags applies to the
+// package-level flags, and fills in anything that must be non-zero for the
+// pipeline to make progress.
+func (o *Options) normalise() {
+	if o.IgnoreMinified {
+		o.Minified = true
+	}
+	if o.IgnoreGenerated {
+		o.Generated = true
+	}
+	if o.IgnoreVendored || len(o.VendorPatterns) != 0 {
+		o.Vendored = true
+	}
+	if o.IgnoreDocumentation {
+		o.Documentation = true
+	}
+	if len(o.TestPatterns) != 0 {
+		o.Tests = true
+	}
+	if o.Cognitive {
+		o.NoComplexity = false
+	}
+	if len(o.Paths) == 0 {
+		o.Paths = []string{"."}
+	}
+	o.SortBy = strings.ToLower(o.SortBy)
+
+	// Trailing slashes never match a directory name, see issue #250
+	excludeDirs := make([]string, 0, len(o.ExcludeDirs))
+	for _, path := range o.ExcludeDirs {
+		excludeDirs = append(excludeDirs, strings.TrimRight(path, "/"))
+	}
+	o.ExcludeDirs = excludeDirs
+
+	if o.FileListQueueSize <= 0 {
+		o.FileListQueueSize = runtime.NumCPU()
+	}
+	if o.FileProcessJobWorkers <= 0 {
+		o.FileProcessJobWorkers = runtime.NumCPU()
+	}
+	if o.FileSummaryJobQueueSize <= 0 {
+		o.FileSummaryJobQueueSize = runtime.NumCPU()
+	}
+	if o.DirectoryWalkerJobWorkers <= 0 {
+		o.DirectoryWalkerJobWorkers = 8
+	}
+}
+
+// countSettings builds the per-file counting settings for a run.
+func (o *Options) countSettings(features *featureCache) *countSettings {
+	return &countSettings{
+		features:               features,
+		cognitive:              o.Cognitive,
+		duplicates:             o.Duplicates,
+		checkBinary:            !o.DisableCheckBinary,
+		noLarge:                o.NoLarge,
+		largeLineCount:         o.LargeLineCount,
+		uloc:                   o.Uloc,
+		maxMean:                o.MaxMean,
+		generated:              o.Generated,
+		generatedMarkers:       o.GeneratedMarkers,
+		minified:               o.Minified,
+		minifiedLineByteLength: o.MinifiedGeneratedLineByteLength,
+	}
+}
+
+// Analyzer counts a set of paths according to its Options. Unlike Process and
+// ProcessResult it reads none of the package-level flags and every call runs
+// with its own duplicate, visited-path and ULOC state, so any number of
+// Analyzers, or calls on one Analyzer, can run concurrently.
+type Analyzer struct {
+	opts     Options
+	features *featureCache
+}
+
+// NewAnalyzer returns an Analyzer for opts. The built-in language database is
+// loaded on first use if ProcessConstants has not been called.
+func NewAnalyzer(opts Options) *Analyzer {
+	opts.normalise()
+	ensureLanguageMaps()
+
+	return &Analyzer{
+		opts:     opts,
+		features: newFeatureCache(opts.NoComplexity),
+	}
+}
+
+// Options returns the normalised options the Analyzer runs with.
+func (a *Analyzer) Options() Options {
+	return a.opts
+}
+
+// Run walks and counts the configured paths, returning the per-language
+// summary sorted by Options.SortBy and every counted file ordered by location.
+// When ctx is cancelled the walk stops, files not yet read are skipped and
+// ctx.Err() is returned. Progress is reported to any ProgressFunc attached to
+// ctx with WithProgress.
+func (a *Analyzer) Run(ctx context.Context) ([]LanguageSummary, []*FileJob, error) {
+	run, output, err := a.start(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a.collect(ctx, run, output)
+}
+
+// collect drains a started run into the sorted language summary and the
+// files ordered by location.
+func (a *Analyzer) collect(ctx context.Context, run *processorContext, output chan *FileJob) ([]LanguageSummary, []*FileJob, error) {
+	var files []*FileJob
+	aggregateInput := make(chan *FileJob, a.opts.FileSummaryJobQueueSize)
+	go func() {
+		for job := range output {
+			files = append(files, job)
+			aggregateInput <- job
+		}
+		close(aggregateInput)
+	}()
+
+	language := aggregateLanguageSummaryFor(aggregateInput, run.uloc, a.opts.ByFile, a.opts.Tests, a.opts.Embedded)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	language = sortLanguageSummaryBy(language, a.opts.SortBy)
+
+	// The worker pool can interleave file emissions
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Location < files[j].Location
+	})
+
+	return language, files, nil
+}
+
+// Languages runs the analysis and returns only the per-language summary.
+func (a *Analyzer) Languages(ctx context.Context) ([]LanguageSummary, error) {
+	language, _, err := a.Run(ctx)
+	return language, err
+}
+
+// Files runs the analysis and returns only the counted files.
+func (a *Analyzer) Files(ctx context.Context) ([]*FileJob, error) {
+	_, files, err := a.Run(ctx)
+	return files, err
+}
+
+// start sets up a fresh run and returns its context along with the channel
+// the counted files are delivered on. The channel is closed once every file
+// has been processed, or drained after ctx is cancelled.
+func (a *Analyzer) start(ctx context.Context) (*processorContext, chan *FileJob, error) {
+	if a.opts.Rev != "" {
+		return a.startRevision(ctx)
+	}
+
+	filePaths := []string{}
+	dirPaths := []string{}
+	archivePaths := []string{}
+
+	for _, f := range a.opts.Paths {
+		fpath := filepath.Clean(f)
+
+		s, err := os.Stat(fpath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("file or directory could not be read: %s", fpath)
+		}
+
+		switch {
+		case s.IsDir():
+			dirPaths = append(dirPaths, fpath)
+		case s.Mode().IsRegular() && (a.opts.Archive || isArchiveName(fpath)):
+			// Opened when its turn comes so only one archive is held at a time
+			if err := checkArchive(fpath); err != nil {
+				return nil, nil, err
+			}
+			archivePaths = append(archivePaths, fpath)
+		default:
+			filePaths = append(filePaths, fpath)
+		}
+	}
+
+	run := newProcessorContext(ctx, &a.opts, a.features)
+
+	printDebugF("NumCPU: %d", runtime.NumCPU())
+	printDebugF("SortBy: %s", a.opts.SortBy)
+	printDebugF("PathDenyList: %v", a.opts.ExcludeDirs)
+
+	potentialFilesQueue := make(chan *gocodewalker.File, a.opts.FileListQueueSize) // files that pass the .gitignore checks
+	fileListQueue := make(chan *FileJob, a.opts.FileListQueueSize)                 // Files ready to be read from disk
+	fileSummaryJobQueue := make(chan *FileJob, a.opts.FileSummaryJobQueueSize)     // Files ready to be summarised
+
+	fileWalker := gocodewalker.NewParallelFileWalker(dirPaths, potentialFilesQueue)
+	fileWalker.SetErrorHandler(func(e error) bool {
+		printError(e.Error())
+		return true
+	})
+	fileWalker.IgnoreGitIgnore = a.opts.NoGitIgnore
+	fileWalker.IgnoreIgnoreFile = a.opts.NoIgnore
+	fileWalker.IgnoreGitModules = a.opts.NoGitModule
+	fileWalker.IncludeHidden = true
+	fileWalker.ExcludeDirectory = a.opts.ExcludeDirs
+	fileWalker.SetConcurrency(a.opts.DirectoryWalkerJobWorkers)
+
+	if !a.opts.NoSccIgnore {
+		fileWalker.CustomIgnore = []string{".sccignore"}
+	}
+	fileWalker.CustomIgnoreFiles = a.opts.IgnoreFiles
+
+	var excludePathRegexes []*regexp.Regexp
+	for _, exclude := range a.opts.NotMatch {
+		regexpResult, err := regexp.Compile(exclude)
+		if err == nil {
+			fileWalker.ExcludeFilenameRegex = append(fileWalker.ExcludeFilenameRegex, regexpResult)
+			fileWalker.ExcludeDirectoryRegex = append(fileWalker.ExcludeDirectoryRegex, regexpResult)
+			excludePathRegexes = append(excludePathRegexes, regexpResult)
+		} else {
+			printError(err.Error())
+		}
+	}
+
+	walkDone := make(chan struct{})
+	go func() {
+		err := fileWalker.Start()
+		if err != nil {
+			printError(err.Error())
+		}
+		close(walkDone)
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			fileWalker.Terminate()
+		case <-walkDone:
+		}
+	}()
+
+	go func() {
+		for _, f := range filePaths {
+			fileInfo, err := os.Lstat(f)
+			if err != nil {
+				continue
+			}
+
+			fileJob := run.newFileJob(f, f, fileInfo)
+			if fileJob != nil {
+				run.progress.fileWalked()
+				fileListQueue <- fileJob
+			}
+		}
+
+		for _, f := range archivePaths {
+			if run.cancelled() {
+				break
+			}
+			if err := run.walkArchive(f, fileListQueue); err != nil {
+				printError(err.Error())
+			}
+		}
+
+		for fi := range potentialFilesQueue {
+			if run.cancelled() {
+				continue
+			}
+
+			shouldExclude := false
+			for _, re := range excludePathRegexes {
+				if re.MatchString(fi.Location) {
+					shouldExclude = true
+					break
+				}
+			}
+			if shouldExclude {
+				continue
+			}
+
+			fileInfo, err := os.Lstat(fi.Location)
+			if err != nil {
+				continue
+			}
+			run.modules.see(fi.Location, fi.Filename)
+
+			if a.opts.Archive && fileInfo.Mode().IsRegular() && isArchiveName(fi.Filename) {
+				if err := run.walkArchive(fi.Location, fileListQueue); err != nil {
+					printError(err.Error())
+				}
+				continue
+			}
+
+			if !fileInfo.IsDir() {
+				fileJob := run.newFileJob(fi.Location, fi.Filename, fileInfo)
+				if fileJob != nil {
+					run.progress.fileWalked()
+					fileListQueue <- fileJob
+				}
+			}
+		}
+
+		close(fileListQueue)
+	}()
+
+	go run.fileProcessorWorker(fileListQueue, fileSummaryJobQueue)
+
+	return run, fileSummaryJobQueue, nil
+}
+
+// featureCache holds the LanguageFeatures built for one Analyzer. It is kept
+// apart from the shared LanguageFeatures map because the token tries differ
+// depending on whether complexity is counted, which is a per-run option.
+// Features are built the first time a language is seen.
+type featureCache struct {
+	noComplexity bool
+	mu           sync.Mutex
+	features     map[string]LanguageFeature
+}
+
+func newFeatureCache(noComplexity bool) *featureCache {
+	return &featureCache{
+		noComplexity: noComplexity,
+		features:     map[string]LanguageFeature{},
+	}
+}
+
+// get returns the features for name, building them on first use. Names that
+// are not in the language database get empty features, which count every
+// non-blank line as code.
+func (c *featureCache) get(name string) LanguageFeature {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f, ok := c.features[name]; ok {
+		return f
+	}
+
+	f := buildLanguageFeature(name, languageDatabase[name], c.noComplexity)
+	c.features[name] = f
+	return f
+}
+
+// languageMapsMutex guards the lazy build in ensureLanguageMaps
+var languageMapsMutex sync.Mutex
+
+// ensureLanguageMaps builds ExtensionToLanguage and the other lookup maps from
+// the language database if nothing has done so yet, so an Analyzer works
+// without the caller having to run ProcessConstants first.
+func ensureLanguageMaps() {
+	languageMapsMutex.Lock()
+	defer languageMapsMutex.Unlock()
+
+	if len(ExtensionToLanguage) != 0 {
+		return
+	}
+	buildLanguageMaps()
+}
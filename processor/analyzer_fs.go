@@ -0,0 +1,282 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// RunFS is Run over fsys instead of the local disk. Options.Paths are slash
+// separated paths within fsys, "." being its root, and Options.IgnoreFiles are
+// read from fsys too. Ignore files, the extension and name filters, NotMatch
+// and the duplicate, minified and generated checks apply exactly as they do
+// when walking a directory, so an embed.FS, fstest.MapFS or a tree fetched
+// from object storage counts the same as the files would on disk.
+func (a *Analyzer) RunFS(ctx context.Context, fsys fs.FS) ([]LanguageSummary, []*FileJob, error) {
+	run, output, err := a.startFS(ctx, fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a.collect(ctx, run, output)
+}
+
+// startFS is start for a walk of fsys
+func (a *Analyzer) startFS(ctx context.Context, fsys fs.FS) (*processorContext, chan *FileJob, error) {
+	roots := make([]string, 0, len(a.opts.Paths))
+	for _, p := range a.opts.Paths {
+		p = path.Clean(strings.TrimPrefix(p, "/"))
+		if !fs.ValidPath(p) {
+			return nil, nil, fmt.Errorf("file or directory could not be read: %s", p)
+		}
+		if _, err := fs.Stat(fsys, p); err != nil {
+			return nil, nil, fmt.Errorf("file or directory could not be read: %s", p)
+		}
+		roots = append(roots, p)
+	}
+
+	run := newProcessorContext(ctx, &a.opts, a.features)
+	fileListQueue := make(chan *FileJob, a.opts.FileListQueueSize)
+	fileSummaryJobQueue := make(chan *FileJob, a.opts.FileSummaryJobQueueSize)
+
+	walker := newFSWalker(run, fsys, fileListQueue)
+	var global []gitignore.Pattern
+	for _, name := range a.opts.IgnoreFiles {
+		global = append(global, walker.readIgnore(path.Clean(name), nil)...)
+	}
+
+	go func() {
+		for _, root := range roots {
+			walker.walkRoot(root, global)
+		}
+		close(fileListQueue)
+	}()
+
+	go run.fileProcessorWorker(fileListQueue, fileSummaryJobQueue)
+
+	return run, fileSummaryJobQueue, nil
+}
+
+// fsIgnore holds the ignore patterns in force for one directory of an fs.FS
+// walk. They are kept apart by source so that, as in the directory walker, a
+// later source that matches overrides an earlier one: --ignore-file, then
+// .gitignore, then .ignore, then .sccignore, then .gitmodules.
+type fsIgnore struct {
+	global  []gitignore.Pattern
+	git     []gitignore.Pattern
+	ignore  []gitignore.Pattern
+	custom  []gitignore.Pattern
+	modules []gitignore.Pattern
+}
+
+// match reports whether the slash separated path p is ignored. Within one
+// source the last matching pattern wins, which is what gitignore specifies.
+func (i fsIgnore) match(p string, isDir bool) bool {
+	parts := strings.Split(p, "/")
+	ignored := false
+	for _, set := range [][]gitignore.Pattern{i.global, i.git, i.ignore, i.custom, i.modules} {
+		for j := len(set) - 1; j >= 0; j-- {
+			if r := set[j].Match(parts, isDir); r != gitignore.NoMatch {
+				ignored = r == gitignore.Exclude
+				break
+			}
+		}
+	}
+	return ignored
+}
+
+// fsWalker walks an fs.FS applying the same rules gocodewalker applies to a
+// directory on disk. The walk is sequential; reading and counting the files
+// is still spread over the worker pool.
+type fsWalker struct {
+	fsys     fs.FS
+	opts     *Options
+	run      *processorContext
+	notMatch []*regexp.Regexp
+	output   chan *FileJob
+	prefix   string         // OS directory locations are reported under, empty for none
+	attrs    *gitAttributes // .gitattributes read from fsys, nil with --no-gitattributes
+}
+
+func newFSWalker(run *processorContext, fsys fs.FS, output chan *FileJob) *fsWalker {
+	w := &fsWalker{
+		fsys:   fsys,
+		opts:   run.options(),
+		run:    run,
+		output: output,
+	}
+	if !w.opts.NoGitAttributes {
+		w.attrs = newGitAttributes(fsys)
+	}
+	for _, exclude := range w.opts.NotMatch {
+		re, err := regexp.Compile(exclude)
+		if err != nil {
+			printError(err.Error())
+			continue
+		}
+		w.notMatch = append(w.notMatch, re)
+	}
+	return w
+}
+
+// walkRoot queues root if it is a file or everything below it that is not
+// ignored if it is a directory. global are the --ignore-file patterns.
+func (w *fsWalker) walkRoot(root string, global []gitignore.Pattern) {
+	info, err := fs.Stat(w.fsys, root)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	if info.IsDir() {
+		w.walk(root, fsIgnore{global: global})
+	} else {
+		w.emit(root, path.Base(root), info)
+	}
+}
+
+// readIgnore parses the ignore file at name with its patterns scoped to domain
+func (w *fsWalker) readIgnore(name string, domain []string) []gitignore.Pattern {
+	f, err := w.fsys.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	return parseIgnoreFile(f, domain)
+}
+
+func (w *fsWalker) walk(dir string, ignore fsIgnore) {
+	if w.run.cancelled() {
+		return
+	}
+
+	entries, err := fs.ReadDir(w.fsys, dir)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+
+	// Pick up the ignore files in this directory first as they apply to its
+	// files as well as everything below it. Clipping before appending keeps
+	// sibling directories from sharing the new patterns.
+	domain := splitDomain(dir)
+	if !w.opts.NoGitIgnore {
+		ignore.git = append(slices.Clip(ignore.git), w.readIgnore(path.Join(dir, ".gitignore"), domain)...)
+		ignore.git = append(ignore.git, w.readIgnore(path.Join(dir, ".git", "info", "exclude"), domain)...)
+	}
+	if !w.opts.NoIgnore {
+		ignore.ignore = append(slices.Clip(ignore.ignore), w.readIgnore(path.Join(dir, ".ignore"), domain)...)
+	}
+	if !w.opts.NoSccIgnore {
+		ignore.custom = append(slices.Clip(ignore.custom), w.readIgnore(path.Join(dir, ".sccignore"), domain)...)
+	}
+	if !w.opts.NoGitModule {
+		if content, err := fs.ReadFile(w.fsys, path.Join(dir, ".gitmodules")); err == nil {
+			ignore.modules = slices.Clip(ignore.modules)
+			for _, module := range gitModulePaths(string(content)) {
+				ignore.modules = append(ignore.modules, gitignore.ParsePattern(module, domain))
+			}
+		}
+	}
+
+	var dirs []fs.DirEntry
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, entry)
+			continue
+		}
+
+		location := path.Join(dir, entry.Name())
+		if ignore.match(location, false) || w.excluded(location, entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		w.emit(location, entry.Name(), info)
+	}
+
+	for _, entry := range dirs {
+		location := path.Join(dir, entry.Name())
+		if ignore.match(location, true) || w.excluded(location, entry.Name()) {
+			continue
+		}
+		if slices.ContainsFunc(w.opts.ExcludeDirs, func(deny string) bool {
+			return isSuffixPath(location, deny)
+		}) {
+			continue
+		}
+		w.walk(location, ignore)
+	}
+}
+
+// excluded applies --not-match to an entry's name and location
+func (w *fsWalker) excluded(location, name string) bool {
+	for _, re := range w.notMatch {
+		if re.MatchString(name) || re.MatchString(location) {
+			return true
+		}
+	}
+	return false
+}
+
+// emit queues a file for counting. Symlinks are resolved through fsys when
+// they are being counted, as fs.Stat follows them.
+func (w *fsWalker) emit(location, name string, info fs.FileInfo) {
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if !w.opts.IncludeSymLinks {
+			printWarnF("skipping symlink file: %s", name)
+			return
+		}
+		resolved, err := fs.Stat(w.fsys, location)
+		if err != nil {
+			printError(err.Error())
+			return
+		}
+		info = resolved
+	}
+
+	display := location
+	if w.prefix != "" {
+		display = filepath.Join(w.prefix, filepath.FromSlash(location))
+	}
+	w.run.modules.see(display, name)
+	if job := w.run.newAttributedFileJob(display, name, info, w.attrs.lookup(location)); job != nil {
+		job.fsys = w.fsys
+		job.fsysName = location
+		w.run.progress.fileWalked()
+		w.output <- job
+	}
+}
+
+// isSuffixPath reports whether the slash separated path p ends with the
+// directory suffix, matching whole path elements only.
+func isSuffixPath(p, suffix string) bool {
+	suffix = strings.Trim(suffix, "/")
+	if suffix == "" {
+		return false
+	}
+	return p == suffix || strings.HasSuffix(p, "/"+suffix)
+}
+
+var gitModulePathRegex = regexp.MustCompile(`^\s*path\s*=\s*(.*)`)
+
+// gitModulePaths returns the submodule paths listed in a .gitmodules file
+func gitModulePaths(content string) []string {
+	var out []string
+	for line := range strings.SplitSeq(content, "\n") {
+		if m := gitModulePathRegex.FindStringSubmatch(line); m != nil {
+			out = append(out, strings.TrimSpace(m[1]))
+		}
+	}
+	return out
+}
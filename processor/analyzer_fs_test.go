@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var analyzerFSFixture = map[string]string{
+	".gitignore":           "build/\n*.log\n",
+	".sccignore":           "skip.go\n",
+	"main.go":              "package main\n\nfunc main() {\n\tif true {\n\t}\n}\n",
+	"copy.go":              "package main\n\nfunc main() {\n\tif true {\n\t}\n}\n",
+	"skip.go":              "package main\n",
+	"debug.log":            "not counted\n",
+	"build/out.go":         "package build\n",
+	"lib/.ignore":          "gen_*.py\n",
+	"lib/util.py":          "def f():\n    return 1\n",
+	"lib/gen_util.py":      "def g():\n    return 2\n",
+	"lib/app.min.js":       "var a=1;" + strings.Repeat("b", 400) + "\n",
+	"docs/readme.md":       "# Readme\n",
+	"vendor/dep/dep.go":    "package dep\n",
+	"third/.gitmodules":    "[submodule \"sub\"]\n\tpath = sub\n",
+	"third/sub/module.go":  "package sub\n",
+	"third/keep/keep.go":   "package keep\n",
+	"generated/api_gen.go": "// Code generated. DO NOT EDIT.\npackage generated\n",
+}
+
+func analyzerFSOptions() Options {
+	opts := DefaultOptions()
+	opts.Duplicates = true
+	opts.IgnoreMinified = true
+	opts.IgnoreGenerated = true
+	opts.ExcludeExtensions = []string{"md"}
+	opts.NotMatch = []string{"vendor"}
+	return opts
+}
+
+func locations(files []*FileJob) []string {
+	out := make([]string, 0, len(files))
+	for _, f := range files {
+		out = append(out, filepath.ToSlash(f.Location))
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestAnalyzerRunFS(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, content := range analyzerFSFixture {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+
+	_, files, err := NewAnalyzer(analyzerFSOptions()).RunFS(context.Background(), fsys)
+	if err != nil {
+		t.Fatalf("RunFS: %v", err)
+	}
+
+	got := locations(files)
+	// copy.go and main.go are duplicates so only one of them survives
+	if len(got) != 3 || !slices.Contains(got, "lib/util.py") || !slices.Contains(got, "third/keep/keep.go") {
+		t.Fatalf("unexpected files %v", got)
+	}
+}
+
+func TestAnalyzerRunFSMatchesDirectoryWalk(t *testing.T) {
+	dir := t.TempDir()
+	fsys := fstest.MapFS{}
+	for name, content := range analyzerFSFixture {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Duplicates are order dependent so leave them out of the comparison
+	opts := analyzerFSOptions()
+	opts.Duplicates = false
+
+	fsLanguage, fsFiles, err := NewAnalyzer(opts).RunFS(context.Background(), fsys)
+	if err != nil {
+		t.Fatalf("RunFS: %v", err)
+	}
+
+	opts.Paths = []string{dir}
+	diskLanguage, diskFiles, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var diskRelative []string
+	for _, l := range locations(diskFiles) {
+		rel, _ := filepath.Rel(dir, l)
+		diskRelative = append(diskRelative, filepath.ToSlash(rel))
+	}
+	slices.Sort(diskRelative)
+
+	if !slices.Equal(locations(fsFiles), diskRelative) {
+		t.Errorf("expected files %v got %v", diskRelative, locations(fsFiles))
+	}
+	if len(fsLanguage) != len(diskLanguage) {
+		t.Fatalf("expected %d languages got %d", len(diskLanguage), len(fsLanguage))
+	}
+	for i := range diskLanguage {
+		if fsLanguage[i].Name != diskLanguage[i].Name || fsLanguage[i].Code != diskLanguage[i].Code || fsLanguage[i].Complexity != diskLanguage[i].Complexity {
+			t.Errorf("language %d: expected %+v got %+v", i, diskLanguage[i], fsLanguage[i])
+		}
+	}
+}
+
+func TestAnalyzerRunFSSubPath(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/one.go": &fstest.MapFile{Data: []byte("package a\n")},
+		"b/two.go": &fstest.MapFile{Data: []byte("package b\n")},
+	}
+
+	opts := DefaultOptions()
+	opts.Paths = []string{"b"}
+	_, files, err := NewAnalyzer(opts).RunFS(context.Background(), fsys)
+	if err != nil {
+		t.Fatalf("RunFS: %v", err)
+	}
+	if got := locations(files); !slices.Equal(got, []string{"b/two.go"}) {
+		t.Errorf("expected [b/two.go] got %v", got)
+	}
+
+	opts.Paths = []string{"missing"}
+	if _, _, err := NewAnalyzer(opts).RunFS(context.Background(), fsys); err == nil {
+		t.Error("expected error for missing path")
+	}
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func writeAnalyzerFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"main.go":      "package main\n\n// main entry\nfunc main() {\n\tif true {\n\t\tprintln(1)\n\t}\n}\n",
+		"copy.go":      "package main\n\n// main entry\nfunc main() {\n\tif true {\n\t\tprintln(1)\n\t}\n}\n",
+		"lib/util.py":  "# helper\ndef f(x):\n    if x:\n        return 1\n    return 2\n",
+		"lib/notes.md": "# Notes\n\nSome text.\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func findLanguage(language []LanguageSummary, name string) (LanguageSummary, bool) {
+	for _, l := range language {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return LanguageSummary{}, false
+}
+
+func TestAnalyzerRun(t *testing.T) {
+	dir := writeAnalyzerFixture(t)
+
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	opts.ByFile = true
+
+	language, files, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(files) != 4 {
+		t.Fatalf("expected 4 files got %d", len(files))
+	}
+	for i := 1; i < len(files); i++ {
+		if files[i-1].Location > files[i].Location {
+			t.Errorf("files not ordered by location: %s before %s", files[i-1].Location, files[i].Location)
+		}
+	}
+
+	goSummary, ok := findLanguage(language, "Go")
+	if !ok {
+		t.Fatalf("expected Go in summary")
+	}
+	if goSummary.Count != 2 || len(goSummary.Files) != 2 {
+		t.Errorf("expected 2 Go files got count %d files %d", goSummary.Count, len(goSummary.Files))
+	}
+	if goSummary.Complexity != 2 {
+		t.Errorf("expected Go complexity 2 got %d", goSummary.Complexity)
+	}
+}
+
+func TestAnalyzerOptionsDoNotLeak(t *testing.T) {
+	dir := writeAnalyzerFixture(t)
+
+	plain := DefaultOptions()
+	plain.Paths = []string{dir}
+
+	tuned := plain
+	tuned.Duplicates = true
+	tuned.NoComplexity = true
+	tuned.ExcludeExtensions = []string{"md"}
+	tuned.Uloc = true
+
+	var wg sync.WaitGroup
+	results := make([][]LanguageSummary, 20)
+	errs := make([]error, len(results))
+	for i := range results {
+		opts := plain
+		if i%2 == 1 {
+			opts = tuned
+		}
+		wg.Add(1)
+		go func(i int, opts Options) {
+			defer wg.Done()
+			results[i], errs[i] = NewAnalyzer(opts).Languages(context.Background())
+		}(i, opts)
+	}
+	wg.Wait()
+
+	for i, language := range results {
+		if errs[i] != nil {
+			t.Fatalf("run %d: %v", i, errs[i])
+		}
+
+		goSummary, _ := findLanguage(language, "Go")
+		_, hasMarkdown := findLanguage(language, "Markdown")
+
+		if i%2 == 0 {
+			if goSummary.Count != 2 || goSummary.Complexity != 2 || !hasMarkdown || goSummary.ULOC != 0 {
+				t.Errorf("run %d with default options got %+v markdown %v", i, goSummary, hasMarkdown)
+			}
+		} else {
+			if goSummary.Count != 1 || goSummary.Complexity != 0 || hasMarkdown || goSummary.ULOC == 0 {
+				t.Errorf("run %d with tuned options got %+v markdown %v", i, goSummary, hasMarkdown)
+			}
+		}
+	}
+}
+
+func TestAnalyzerMatchesProcessResult(t *testing.T) {
+	dir := writeAnalyzerFixture(t)
+
+	ProcessConstants()
+	prevPaths := DirFilePaths
+	DirFilePaths = []string{dir}
+	defer func() { DirFilePaths = prevPaths }()
+
+	expected, err := ProcessResult(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessResult: %v", err)
+	}
+
+	actual, err := NewAnalyzer(optionsFromGlobals()).Languages(context.Background())
+	if err != nil {
+		t.Fatalf("Languages: %v", err)
+	}
+
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %d languages got %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		if expected[i].Name != actual[i].Name || expected[i].Code != actual[i].Code || expected[i].Complexity != actual[i].Complexity {
+			t.Errorf("language %d: expected %+v got %+v", i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestAnalyzerMissingPath(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Paths = []string{filepath.Join(t.TempDir(), "missing")}
+
+	if _, err := NewAnalyzer(opts).Languages(context.Background()); err == nil {
+		t.Error("expected error for missing path")
+	}
+}
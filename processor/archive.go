@@ -0,0 +1,366 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// archiveExtensions are the file names counted as archives without --archive
+var archiveExtensions = []string{".tar", ".tar.gz", ".tgz", ".zip"}
+
+// isArchiveName reports whether name looks like an archive scc can count
+func isArchiveName(name string) bool {
+	name = strings.ToLower(name)
+	return slices.ContainsFunc(archiveExtensions, func(ext string) bool {
+		return strings.HasSuffix(name, ext)
+	})
+}
+
+// Archive formats, told apart by content rather than name
+const (
+	archiveZip   = "zip"
+	archiveTarGz = "tar.gz"
+	archiveTar   = "tar"
+)
+
+// openArchive opens the tar, tar.gz or zip archive at filename as a read-only
+// fs.FS with every entry below a directory named after the archive, so that
+// walked with its OS directory as the prefix counted files show up as
+// dist/release.tar.gz/src/main.go. Zip entries are read on demand from
+// the open file, which the returned Closer releases; tar has no index so its
+// entries are held in memory, apart from the content of files --no-large
+// would drop anyway, and the Closer is nil. Either way nothing else refers to
+// the archive, so it is released once the files read through it are counted.
+func openArchive(filename string, opts *Options) (*archiveFS, io.Closer, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+
+	kind, err := archiveFormat(f, filename)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+
+	archive := newArchiveFS(filepath.Base(filename))
+	archive.parent = filepath.Dir(filename)
+
+	switch kind {
+	case archiveZip:
+		err = archive.addZip(f, info.Size())
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, fmt.Errorf("read zip archive %s: %w", filename, err)
+		}
+		return archive, f, nil
+	case archiveTarGz:
+		gz, err := gzip.NewReader(bufio.NewReader(f))
+		if err == nil {
+			err = archive.addTar(gz, opts)
+		}
+		_ = f.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("read tar.gz archive %s: %w", filename, err)
+		}
+	default:
+		err = archive.addTar(bufio.NewReader(f), opts)
+		_ = f.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("read tar archive %s: %w", filename, err)
+		}
+	}
+
+	return archive, nil, nil
+}
+
+// checkArchive returns the error openArchive would for a file that is not an
+// archive at all, without reading any of it beyond the header
+func checkArchive(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = archiveFormat(f, filename)
+	return err
+}
+
+// archiveFormat returns the format of the archive f from its first bytes,
+// leaving f at the start
+func archiveFormat(f *os.File, filename string) (string, error) {
+	magic := make([]byte, 262)
+	n, _ := io.ReadFull(f, magic)
+	magic = magic[:n]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")) || bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return archiveZip, nil
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return archiveTarGz, nil
+	case len(magic) >= 262 && string(magic[257:262]) == "ustar":
+		return archiveTar, nil
+	}
+	return "", fmt.Errorf("%s is not a tar, tar.gz or zip archive", filename)
+}
+
+// walkArchive opens the archive at filename and queues its files on output.
+// The workers read them through the archive, so anything it holds open is
+// only closed once they are all done.
+func (ctx *processorContext) walkArchive(filename string, output chan *FileJob) error {
+	archive, closer, err := openArchive(filename, ctx.options())
+	if err != nil {
+		return err
+	}
+	if closer != nil {
+		ctx.closers = append(ctx.closers, closer)
+	}
+	walker := newFSWalker(ctx, archive, output)
+	walker.prefix = archive.parent
+	walker.walkRoot(archive.root, nil)
+	return nil
+}
+
+// closeAll closes every closer, used to release archives a run no longer needs
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		_ = c.Close()
+	}
+}
+
+// archiveFS is a read-only fs.FS over the entries of one archive
+type archiveFS struct {
+	root    string                   // the archive's base name, holding every entry
+	parent  string                   // OS directory the archive is in
+	entries map[string]*archiveEntry // by slash path, directories included
+}
+
+// archiveEntry is a file or directory in an archiveFS. It is its own
+// fs.FileInfo and fs.DirEntry.
+type archiveEntry struct {
+	name     string
+	size     int64
+	mode     fs.FileMode
+	modTime  time.Time
+	data     []byte    // tar content
+	zip      *zip.File // zip content, read when opened
+	children []*archiveEntry
+}
+
+func newArchiveFS(root string) *archiveFS {
+	a := &archiveFS{
+		root:    root,
+		entries: map[string]*archiveEntry{},
+	}
+	a.entries["."] = &archiveEntry{name: ".", mode: fs.ModeDir | 0o555}
+	a.entries[root] = &archiveEntry{name: root, mode: fs.ModeDir | 0o555}
+	a.entries["."].children = []*archiveEntry{a.entries[root]}
+	return a
+}
+
+// add places entry at name below the archive root, creating any missing
+// parent directories. Names that would escape the root are dropped.
+func (a *archiveFS) add(name string, entry *archiveEntry) {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if name == "." || !fs.ValidPath(name) {
+		return
+	}
+	full := path.Join(a.root, name)
+	if existing, ok := a.entries[full]; ok {
+		// Directories can be listed after the files inside them
+		if existing.IsDir() && entry.IsDir() {
+			return
+		}
+		printWarnF("skipping duplicate archive entry: %s", full)
+		return
+	}
+
+	entry.name = path.Base(full)
+	a.entries[full] = entry
+	parent := a.dir(path.Dir(full))
+	parent.children = append(parent.children, entry)
+}
+
+// dir returns the directory at full, creating it and its parents if needed
+func (a *archiveFS) dir(full string) *archiveEntry {
+	if d, ok := a.entries[full]; ok {
+		return d
+	}
+	d := &archiveEntry{name: path.Base(full), mode: fs.ModeDir | 0o555}
+	a.entries[full] = d
+	parent := a.dir(path.Dir(full))
+	parent.children = append(parent.children, d)
+	return d
+}
+
+func (a *archiveFS) addZip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		info := f.FileInfo()
+		if info.IsDir() {
+			a.add(f.Name, &archiveEntry{mode: fs.ModeDir | 0o555, modTime: info.ModTime()})
+			continue
+		}
+		a.add(f.Name, &archiveEntry{
+			size:    int64(f.UncompressedSize64),
+			mode:    info.Mode(),
+			modTime: info.ModTime(),
+			zip:     f,
+		})
+	}
+	return nil
+}
+
+func (a *archiveFS) addTar(r io.Reader, opts *Options) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			a.add(hdr.Name, &archiveEntry{mode: fs.ModeDir | 0o555, modTime: hdr.ModTime})
+		case tar.TypeReg:
+			entry := &archiveEntry{
+				size:    hdr.Size,
+				mode:    fs.FileMode(hdr.Mode).Perm(),
+				modTime: hdr.ModTime,
+			}
+			// The walk drops these on size alone so never hold their content
+			if !(opts.NoLarge && hdr.Size >= opts.LargeByteCount) {
+				entry.data, err = io.ReadAll(tr)
+				if err != nil {
+					return err
+				}
+			}
+			a.add(hdr.Name, entry)
+		case tar.TypeLink:
+			// A hard link is another name for a file earlier in the archive,
+			// so it shares that file's content
+			target, ok := a.entries[path.Join(a.root, path.Clean(strings.TrimPrefix(hdr.Linkname, "/")))]
+			if !ok || !target.mode.IsRegular() {
+				printWarnF("skipping archive hard link with no file to link to: %s", hdr.Name)
+				continue
+			}
+			a.add(hdr.Name, &archiveEntry{
+				size:    target.size,
+				mode:    target.mode,
+				modTime: hdr.ModTime,
+				data:    target.data,
+			})
+		case tar.TypeSymlink:
+			a.add(hdr.Name, &archiveEntry{mode: fs.ModeSymlink | 0o777, modTime: hdr.ModTime})
+		}
+	}
+}
+
+func (a *archiveFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	entry, ok := a.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if entry.IsDir() {
+		return &archiveDir{entry: entry}, nil
+	}
+	if entry.mode&fs.ModeSymlink != 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.zip != nil {
+		rc, err := entry.zip.Open()
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &archiveFile{entry: entry, reader: rc, closer: rc}, nil
+	}
+	return &archiveFile{entry: entry, reader: bytes.NewReader(entry.data)}, nil
+}
+
+func (e *archiveEntry) Name() string               { return e.name }
+func (e *archiveEntry) Size() int64                { return e.size }
+func (e *archiveEntry) Mode() fs.FileMode          { return e.mode }
+func (e *archiveEntry) ModTime() time.Time         { return e.modTime }
+func (e *archiveEntry) IsDir() bool                { return e.mode.IsDir() }
+func (e *archiveEntry) Sys() any                   { return nil }
+func (e *archiveEntry) Type() fs.FileMode          { return e.mode.Type() }
+func (e *archiveEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+// archiveFile is an open file of an archiveFS
+type archiveFile struct {
+	entry  *archiveEntry
+	reader io.Reader
+	closer io.Closer
+}
+
+func (f *archiveFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *archiveFile) Read(b []byte) (int, error) { return f.reader.Read(b) }
+func (f *archiveFile) Close() error {
+	if f.closer != nil {
+		return f.closer.Close()
+	}
+	return nil
+}
+
+// archiveDir is an open directory of an archiveFS
+type archiveDir struct {
+	entry  *archiveEntry
+	offset int
+}
+
+func (d *archiveDir) Stat() (fs.FileInfo, error) { return d.entry, nil }
+func (d *archiveDir) Close() error               { return nil }
+func (d *archiveDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.entry.name, Err: errors.New("is a directory")}
+}
+
+func (d *archiveDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entry.children[d.offset:]
+	if n > 0 && len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(remaining) {
+		remaining = remaining[:n]
+	}
+	d.offset += len(remaining)
+
+	out := make([]fs.DirEntry, 0, len(remaining))
+	for _, e := range remaining {
+		out = append(out, e)
+	}
+	return out, nil
+}
@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var archiveFixture = map[string]string{
+	".gitignore":        "build/\n",
+	"src/main.go":       "package main\n\nfunc main() {\n\tif true {\n\t}\n}\n",
+	"src/util.py":       "def f():\n    return 1\n",
+	"build/out.go":      "package build\n",
+	"vendor/dep/dep.go": "package dep\n",
+	"big/large.go":      "package big\n\n// " + strings.Repeat("x", 200) + "\n",
+}
+
+func sortedNames(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func tarBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range sortedNames(files) {
+		content := files[name]
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeArchive(t *testing.T, dir, name string, files map[string]string) string {
+	t.Helper()
+	var content []byte
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, n := range sortedNames(files) {
+			w, err := zw.Create(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(files[n])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		content = buf.Bytes()
+	case strings.HasSuffix(name, ".gz"):
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(tarBytes(t, files)); err != nil {
+			t.Fatal(err)
+		}
+		if err := gw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		content = buf.Bytes()
+	default:
+		content = tarBytes(t, files)
+	}
+
+	p := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// archiveLocations returns the counted locations relative to the directory
+// the archive was written to
+func archiveLocations(t *testing.T, dir string, files []*FileJob) []string {
+	t.Helper()
+	prefix := filepath.ToSlash(filepath.Clean(dir)) + "/"
+	var out []string
+	for _, l := range locations(files) {
+		out = append(out, strings.TrimPrefix(l, prefix))
+	}
+	return out
+}
+
+func TestAnalyzerRunArchive(t *testing.T) {
+	for _, name := range []string{"release.tar", "release.tar.gz", "release.zip"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := writeArchive(t, dir, name, archiveFixture)
+
+			opts := DefaultOptions()
+			opts.Paths = []string{archive}
+			opts.ByFile = true
+			opts.ExcludeDirs = []string{"vendor"}
+			opts.NoLarge = true
+			opts.LargeByteCount = 100
+
+			language, files, err := NewAnalyzer(opts).Run(context.Background())
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+
+			expected := []string{name + "/src/main.go", name + "/src/util.py"}
+			if got := archiveLocations(t, dir, files); !slices.Equal(got, expected) {
+				t.Fatalf("expected %v got %v", expected, got)
+			}
+
+			goSummary, ok := findLanguage(language, "Go")
+			if !ok || goSummary.Code != 5 || goSummary.Complexity != 1 {
+				t.Errorf("unexpected Go summary %+v", goSummary)
+			}
+		})
+	}
+}
+
+func TestAnalyzerRunArchiveIncludeExt(t *testing.T) {
+	dir := t.TempDir()
+	archive := writeArchive(t, dir, "drop.tgz", archiveFixture)
+
+	opts := DefaultOptions()
+	opts.Paths = []string{archive}
+	opts.IncludeExtensions = []string{"py"}
+
+	_, files, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := archiveLocations(t, dir, files); !slices.Equal(got, []string{"drop.tgz/src/util.py"}) {
+		t.Errorf("expected only util.py got %v", got)
+	}
+}
+
+func TestAnalyzerRunArchiveFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, dir, "third_party/dep.zip", map[string]string{"dep.go": "package dep\n"})
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	oddlyNamed := writeArchive(t, t.TempDir(), "sources.bin.tar", map[string]string{"a.go": "package a\n"})
+	if err := os.Rename(oddlyNamed, strings.TrimSuffix(oddlyNamed, ".tar")); err != nil {
+		t.Fatal(err)
+	}
+	oddlyNamed = strings.TrimSuffix(oddlyNamed, ".tar")
+
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+
+	// Archives found while walking are only opened with --archive
+	_, files, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := archiveLocations(t, dir, files); !slices.Equal(got, []string{"main.go"}) {
+		t.Errorf("expected only main.go without --archive got %v", got)
+	}
+
+	opts.Archive = true
+	_, files, err = NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	expected := []string{"main.go", "third_party/dep.zip/dep.go"}
+	if got := archiveLocations(t, dir, files); !slices.Equal(got, expected) {
+		t.Errorf("expected %v with --archive got %v", expected, got)
+	}
+
+	// With --archive a file path is opened whatever its name
+	opts.Paths = []string{oddlyNamed}
+	_, files, err = NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(files) != 1 || !strings.HasSuffix(files[0].Location, "sources.bin/a.go") {
+		t.Errorf("expected sources.bin/a.go got %v", locations(files))
+	}
+
+	opts.Paths = []string{filepath.Join(dir, "main.go")}
+	if _, _, err := NewAnalyzer(opts).Run(context.Background()); err == nil {
+		t.Error("expected error opening main.go as an archive")
+	}
+}
+
+func TestAnalyzerRunArchiveHardLinks(t *testing.T) {
+	content := "package a\n\nfunc A() {\n\tif true {\n\t}\n}\n"
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range []*tar.Header{
+		{Name: "src/a.go", Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg},
+		{Name: "copy/a.go", Linkname: "src/a.go", Typeflag: tar.TypeLink},
+		{Name: "gone.go", Linkname: "missing.go", Typeflag: tar.TypeLink},
+		{Name: "alias.go", Linkname: "src/a.go", Typeflag: tar.TypeSymlink},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "links.tar")
+	if err := os.WriteFile(archive, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Paths = []string{archive}
+
+	language, files, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// The hard link is counted as the file it links to, the dangling one and
+	// the symlink are not
+	expected := []string{"links.tar/copy/a.go", "links.tar/src/a.go"}
+	if got := archiveLocations(t, dir, files); !slices.Equal(got, expected) {
+		t.Fatalf("expected %v got %v", expected, got)
+	}
+	if goSummary, _ := findLanguage(language, "Go"); goSummary.Code != 10 || goSummary.Complexity != 2 {
+		t.Errorf("expected the hard link to share the content got %+v", goSummary)
+	}
+	for _, f := range files {
+		if f.fsys != nil {
+			t.Errorf("expected %s to let go of the archive once read", f.Location)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	glanguage "golang.org/x/text/language"
+	gmessage "golang.org/x/text/message"
+)
+
+// DirSummary is the rollup of every file at or below one directory
+type DirSummary struct {
+	Name       string // last path element, or the path given for a root
+	Path       string // slash separated and joined to the path given, empty for a multi-root Total
+	Files      int64
+	Lines      int64
+	Code       int64
+	Comment    int64
+	Blank      int64
+	Complexity int64
+	Bytes      int64
+	Languages  []DirLanguage
+	Children   []*DirSummary
+
+	languages map[string]*DirLanguage
+	children  map[string]*DirSummary
+}
+
+// DirLanguage is one language's share of a DirSummary
+type DirLanguage struct {
+	Name       string
+	Files      int64
+	Lines      int64
+	Code       int64
+	Comment    int64
+	Blank      int64
+	Complexity int64
+	Bytes      int64
+}
+
+func newDirSummary(name, p string) *DirSummary {
+	return &DirSummary{
+		Name:      name,
+		Path:      p,
+		languages: map[string]*DirLanguage{},
+		children:  map[string]*DirSummary{},
+	}
+}
+
+func (d *DirSummary) add(f *FileJob) {
+	d.Files++
+	d.Lines += f.Lines
+	d.Code += f.Code
+	d.Comment += f.Comment
+	d.Blank += f.Blank
+	d.Complexity += f.Complexity
+	d.Bytes += f.Bytes
+
+	l, ok := d.languages[f.Language]
+	if !ok {
+		l = &DirLanguage{Name: f.Language}
+		d.languages[f.Language] = l
+	}
+	l.Files++
+	l.Lines += f.Lines
+	l.Code += f.Code
+	l.Comment += f.Comment
+	l.Blank += f.Blank
+	l.Complexity += f.Complexity
+	l.Bytes += f.Bytes
+}
+
+func (d *DirSummary) child(name string) *DirSummary {
+	c, ok := d.children[name]
+	if !ok {
+		c = newDirSummary(name, path.Join(d.Path, name))
+		d.children[name] = c
+	}
+	return c
+}
+
+// buildDirTree rolls files up into the directories they are in, stopping
+// depth levels below the roots with anything deeper counted in its ancestor
+// at that level. A depth of 0 or less means no limit. With several roots the
+// returned node is a "Total" holding one child per root.
+func buildDirTree(files []*FileJob, roots []string, depth int, sortBy string) *DirSummary {
+	nodes := make([]*DirSummary, len(roots))
+	for i, root := range roots {
+		root = path.Clean(filepath.ToSlash(root))
+		nodes[i] = newDirSummary(root, root)
+	}
+
+	for _, f := range files {
+		location := filepath.ToSlash(f.Location)
+		node := nodes[0]
+		rel := location
+		for i, root := range roots {
+			root = path.Clean(filepath.ToSlash(root))
+			if root == "." || location == root || strings.HasPrefix(location, root+"/") {
+				node = nodes[i]
+				rel = deltaKey([]string{root}, location)
+				break
+			}
+		}
+
+		node.add(f)
+		dirs := strings.Split(rel, "/")
+		dirs = dirs[:len(dirs)-1]
+		for level, name := range dirs {
+			if depth > 0 && level >= depth {
+				break
+			}
+			node = node.child(name)
+			node.add(f)
+		}
+	}
+
+	top := nodes[0]
+	if len(nodes) > 1 {
+		top = newDirSummary("Total", "")
+		for _, n := range nodes {
+			top.Files += n.Files
+			top.Lines += n.Lines
+			top.Code += n.Code
+			top.Comment += n.Comment
+			top.Blank += n.Blank
+			top.Complexity += n.Complexity
+			top.Bytes += n.Bytes
+			for _, l := range n.languages {
+				t, ok := top.languages[l.Name]
+				if !ok {
+					t = &DirLanguage{Name: l.Name}
+					top.languages[l.Name] = t
+				}
+				t.Files += l.Files
+				t.Lines += l.Lines
+				t.Code += l.Code
+				t.Comment += l.Comment
+				t.Blank += l.Blank
+				t.Complexity += l.Complexity
+				t.Bytes += l.Bytes
+			}
+			top.children[n.Name] = n
+		}
+	}
+
+	top.finish(sortBy)
+	return top
+}
+
+// finish turns the lookup maps into the sorted exported slices
+func (d *DirSummary) finish(sortBy string) {
+	d.Languages = make([]DirLanguage, 0, len(d.languages))
+	for _, l := range d.languages {
+		d.Languages = append(d.Languages, *l)
+	}
+	sort.Slice(d.Languages, func(i, j int) bool {
+		if d.Languages[i].Code != d.Languages[j].Code {
+			return d.Languages[i].Code > d.Languages[j].Code
+		}
+		return d.Languages[i].Name < d.Languages[j].Name
+	})
+
+	d.Children = make([]*DirSummary, 0, len(d.children))
+	for _, c := range d.children {
+		c.finish(sortBy)
+		d.Children = append(d.Children, c)
+	}
+	sortDirSummaries(d.Children, sortBy)
+}
+
+// sortDirSummaries orders directories by the --sort column, largest first,
+// falling back to the name
+func sortDirSummaries(dirs []*DirSummary, sortBy string) {
+	value := func(d *DirSummary) int64 {
+		switch sortBy {
+		case "line", "lines":
+			return d.Lines
+		case "blank", "blanks":
+			return d.Blank
+		case "code":
+			return d.Code
+		case "comment", "comments":
+			return d.Comment
+		case "complexity":
+			return d.Complexity
+		case "byte", "bytes":
+			return d.Bytes
+		case "name", "names", "language", "languages", "lang":
+			return 0
+		}
+		return d.Files
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		vi, vj := value(dirs[i]), value(dirs[j])
+		if vi != vj {
+			return vi > vj
+		}
+		return dirs[i].Name < dirs[j].Name
+	})
+}
+
+// walk calls fn for d and everything below it, depth first in sorted order
+func (d *DirSummary) walk(fn func(d *DirSummary, level int)) {
+	var visit func(d *DirSummary, level int)
+	visit = func(d *DirSummary, level int) {
+		fn(d, level)
+		for _, c := range d.Children {
+			visit(c, level+1)
+		}
+	}
+	visit(d, 0)
+}
+
+// runByDirReport is the dispatch entry point called from Process() when
+// --by-dir is set
+func runByDirReport(ctx context.Context) error {
+	opts := optionsFromGlobals()
+	roots, err := opts.locationRoots()
+	if err != nil {
+		return err
+	}
+	_, files, err := NewAnalyzer(opts).Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	tree := buildDirTree(files, roots, DirDepth, strings.ToLower(SortBy))
+	out, err := renderDirTree(tree)
+	if err != nil {
+		return err
+	}
+	if FileOutput == "" {
+		fmt.Print(out)
+	} else {
+		if err := os.WriteFile(FileOutput, []byte(out), 0644); err != nil {
+			return err
+		}
+		fmt.Println("results written to " + FileOutput)
+	}
+	return nil
+}
+
+func renderDirTree(tree *DirSummary) (string, error) {
+	switch strings.ToLower(Format) {
+	case "", "tabular":
+		return renderDirTreeTabular(tree, More), nil
+	case "wide":
+		return renderDirTreeTabular(tree, true), nil
+	case "json":
+		return renderDirTreeJSON(tree)
+	case "csv":
+		return renderDirTreeCSV(tree)
+	case "html":
+		return `<html lang="en"><head><meta charset="utf-8" /><title>scc html output</title><style>table { border-collapse: collapse; }td, th { border: 1px solid #999; padding: 0.5rem; text-align: left;}</style></head><body>` +
+			renderDirTreeHTMLTable(tree) +
+			"</body></html>\n", nil
+	case "html-table":
+		return renderDirTreeHTMLTable(tree), nil
+	default:
+		return "", fmt.Errorf("unsupported --format %q for --by-dir (supported: tabular, wide, json, csv, html, html-table)", Format)
+	}
+}
+
+// Tabular column formats. Each level of the tree indents the name by two.
+//
+//	%-24s %6s %9s %7s %8s %9s %10s
+//	24 + 1 + 6 + 1 + 9 + 1 + 7 + 1 + 8 + 1 + 9 + 1 + 10 = 79
+var tabularDirFormat = "%-24s %6s %9s %7s %8s %9s %10s\n"
+
+// dirTreeIndent is the indent for each level of the tabular tree
+const dirTreeIndent = "  "
+
+// renderDirTreeTabular prints the tree, with each directory's languages below
+// it when languages is set
+func renderDirTreeTabular(tree *DirSummary, languages bool) string {
+	brk := tabularBreakFor(false)
+	p := gmessage.NewPrinter(glanguage.Make(os.Getenv("LANG")))
+	count := func(n int64) string { return formatWithCommas(p, n) }
+
+	var sb strings.Builder
+	sb.WriteString(brk)
+	_, _ = fmt.Fprintf(&sb, tabularDirFormat, "Directory", "Files", "Lines", "Blanks", "Comments", "Code", "Complexity")
+	sb.WriteString(brk)
+
+	tree.walk(func(d *DirSummary, level int) {
+		name := d.Name
+		if level > 0 {
+			name += "/"
+		}
+		indent := strings.Repeat(dirTreeIndent, level)
+		name = indent + unicodeAwareTrim(name, max(24-len(indent), 8))
+		_, _ = fmt.Fprintf(&sb, tabularDirFormat, unicodeAwareRightPad(name, 24),
+			count(d.Files), count(d.Lines), count(d.Blank), count(d.Comment), count(d.Code), count(d.Complexity))
+
+		if !languages {
+			return
+		}
+		indent += dirTreeIndent + "· "
+		for _, l := range d.Languages {
+			name := indent + unicodeAwareTrim(l.Name, max(24-len(indent), 8))
+			_, _ = fmt.Fprintf(&sb, tabularDirFormat, unicodeAwareRightPad(name, 24),
+				count(l.Files), count(l.Lines), count(l.Blank), count(l.Comment), count(l.Code), count(l.Complexity))
+		}
+	})
+	sb.WriteString(brk)
+	return sb.String()
+}
+
+func renderDirTreeJSON(tree *DirSummary) (string, error) {
+	b, err := jsoniter.Marshal(tree)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// renderDirTreeCSV flattens the tree into one row per directory followed by a
+// row per language in it. Language is empty on the directory rows.
+func renderDirTreeCSV(tree *DirSummary) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	_ = w.Write([]string{"Path", "Depth", "Language", "Files", "Lines", "Code", "Comments", "Blanks", "Complexity", "Bytes"})
+
+	row := func(p string, level int, language string, files, lines, code, comment, blank, complexity, bytes int64) {
+		_ = w.Write([]string{
+			p, strconv.Itoa(level), language,
+			strconv.FormatInt(files, 10),
+			strconv.FormatInt(lines, 10),
+			strconv.FormatInt(code, 10),
+			strconv.FormatInt(comment, 10),
+			strconv.FormatInt(blank, 10),
+			strconv.FormatInt(complexity, 10),
+			strconv.FormatInt(bytes, 10),
+		})
+	}
+	tree.walk(func(d *DirSummary, level int) {
+		row(d.Path, level, "", d.Files, d.Lines, d.Code, d.Comment, d.Blank, d.Complexity, d.Bytes)
+		for _, l := range d.Languages {
+			row(d.Path, level, l.Name, l.Files, l.Lines, l.Code, l.Comment, l.Blank, l.Complexity, l.Bytes)
+		}
+	})
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func renderDirTreeHTMLTable(tree *DirSummary) string {
+	var sb strings.Builder
+	sb.WriteString(`<table id="scc-table">
+	<thead><tr>
+		<th>Directory</th>
+		<th>Files</th>
+		<th>Lines</th>
+		<th>Blank</th>
+		<th>Comment</th>
+		<th>Code</th>
+		<th>Complexity</th>
+		<th>Bytes</th>
+		<th>Languages</th>
+	</tr></thead>
+	<tbody>`)
+
+	tree.walk(func(d *DirSummary, level int) {
+		names := make([]string, 0, len(d.Languages))
+		for _, l := range d.Languages {
+			names = append(names, fmt.Sprintf("%s (%d)", html.EscapeString(l.Name), l.Code))
+		}
+		_, _ = fmt.Fprintf(&sb, `<tr>
+		<td style="padding-left: %.1frem">%s</td>
+		<td>%d</td>
+		<td>%d</td>
+		<td>%d</td>
+		<td>%d</td>
+		<td>%d</td>
+		<td>%d</td>
+		<td>%d</td>
+		<td>%s</td>
+	</tr>`, 0.5+float64(level), html.EscapeString(d.Name), d.Files, d.Lines, d.Blank, d.Comment, d.Code, d.Complexity, d.Bytes, strings.Join(names, ", "))
+	})
+
+	sb.WriteString(`</tbody>
+	</table>`)
+	return sb.String()
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func byDirFixture() []*FileJob {
+	return []*FileJob{
+		{Location: "src/main.go", Language: "Go", Lines: 10, Code: 8, Blank: 2, Bytes: 100},
+		{Location: "src/api/api.go", Language: "Go", Lines: 20, Code: 15, Comment: 5, Complexity: 3, Bytes: 200},
+		{Location: "src/api/v1/handler.py", Language: "Python", Lines: 5, Code: 5, Complexity: 1, Bytes: 50},
+		{Location: "docs/README.md", Language: "Markdown", Lines: 4, Code: 3, Blank: 1, Bytes: 40},
+		{Location: "Makefile", Language: "Makefile", Lines: 2, Code: 2, Bytes: 20},
+	}
+}
+
+func findDir(d *DirSummary, p string) *DirSummary {
+	var found *DirSummary
+	d.walk(func(d *DirSummary, _ int) {
+		if d.Path == p {
+			found = d
+		}
+	})
+	return found
+}
+
+func TestBuildDirTree(t *testing.T) {
+	tree := buildDirTree(byDirFixture(), []string{"."}, 0, "files")
+	if tree.Files != 5 || tree.Code != 33 || len(tree.Languages) != 4 {
+		t.Fatalf("unexpected root %+v", tree)
+	}
+	if len(tree.Children) != 2 || tree.Children[0].Name != "src" {
+		t.Fatalf("expected src then docs got %+v", tree.Children)
+	}
+
+	api := findDir(tree, "src/api")
+	if api == nil || api.Files != 2 || api.Code != 20 || api.Complexity != 4 || len(api.Languages) != 2 {
+		t.Fatalf("unexpected src/api %+v", api)
+	}
+	if api.Languages[0].Name != "Go" || api.Languages[0].Code != 15 {
+		t.Errorf("expected Go first in src/api got %+v", api.Languages)
+	}
+	if v1 := findDir(tree, "src/api/v1"); v1 == nil || v1.Files != 1 || len(v1.Children) != 0 {
+		t.Errorf("unexpected src/api/v1 %+v", v1)
+	}
+}
+
+func TestBuildDirTreeDepth(t *testing.T) {
+	tree := buildDirTree(byDirFixture(), []string{"."}, 1, "files")
+	src := findDir(tree, "src")
+	if src == nil || src.Files != 3 || len(src.Children) != 0 {
+		t.Fatalf("expected everything under src rolled into it got %+v", src)
+	}
+	if findDir(tree, "src/api") != nil {
+		t.Error("src/api is below --dir-depth 1")
+	}
+}
+
+func TestBuildDirTreeRoots(t *testing.T) {
+	files := []*FileJob{
+		{Location: "a/x.go", Language: "Go", Code: 1},
+		{Location: "a/sub/y.go", Language: "Go", Code: 2},
+		{Location: "b/z.py", Language: "Python", Code: 4},
+	}
+	tree := buildDirTree(files, []string{"a", "./b/"}, 0, "code")
+	if tree.Name != "Total" || tree.Code != 7 || len(tree.Children) != 2 {
+		t.Fatalf("unexpected total %+v", tree)
+	}
+	// Sorted by code so b comes first
+	if tree.Children[0].Path != "b" || tree.Children[1].Path != "a" {
+		t.Errorf("unexpected roots %+v", tree.Children)
+	}
+	if sub := findDir(tree, "a/sub"); sub == nil || sub.Code != 2 {
+		t.Errorf("unexpected a/sub %+v", sub)
+	}
+}
+
+func TestRenderDirTree(t *testing.T) {
+	tree := buildDirTree(byDirFixture(), []string{"."}, 0, "files")
+	saved := Format
+	t.Cleanup(func() { Format = saved })
+
+	Format = "json"
+	out, err := renderDirTree(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc DirSummary
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc.Files != 5 || len(doc.Children) != 2 || len(doc.Children[0].Children) != 1 || doc.Children[0].Children[0].Path != "src/api" {
+		t.Errorf("unexpected json %s", out)
+	}
+
+	Format = "csv"
+	out, err = renderDirTree(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	// header, 5 directories and 4+2+2+1+1 language rows
+	if len(records) != 1+5+10 || records[1][0] != "." || records[1][2] != "" {
+		t.Errorf("unexpected csv\n%s", out)
+	}
+
+	Format = "wide"
+	out, err = renderDirTree(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "\n    api/ ") || !strings.Contains(out, "· Python") {
+		t.Errorf("unexpected tabular\n%s", out)
+	}
+
+	Format = "html-table"
+	out, err = renderDirTree(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `<td style="padding-left: 3.5rem">v1</td>`) {
+		t.Errorf("unexpected html\n%s", out)
+	}
+
+	Format = "sql"
+	if _, err := renderDirTree(tree); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+)
+
+// classifierMinTokens is how many tokens of a file the samples of the
+// candidates must contain before the classifier trusts its score
+const classifierMinTokens = 5
+
+// classifierTopTokens is how many of the tokens favouring a language most are
+// kept for --explain
+const classifierTopTokens = 5
+
+// classifierMaxToken is the longest token kept, anything longer is a literal
+// or data rather than something that tells languages apart
+const classifierMaxToken = 32
+
+// ClassifierModel is what the statistical classifier knows about one
+// language, counted from its sample files by scripts/classifier
+type ClassifierModel struct {
+	Total  int            // tokens in the samples
+	Tokens map[string]int // how often each token was seen in the samples
+}
+
+// ClassifierScore is how well the tokens of a file fit one candidate language
+type ClassifierScore struct {
+	Language  string   `json:"language"`
+	Score     float64  `json:"score"`     // log probability, the highest wins
+	Tokens    int      `json:"tokens"`    // tokens of the file found in the samples of any candidate
+	TopTokens []string `json:"topTokens"` // the tokens that favour this language most over the others
+}
+
+// ClassifierTokens splits content into the tokens the classifier counts:
+// identifiers and keywords, identifiers led by one of @ \ # $ % such as
+// @interface or \begin, and runs of up to three punctuation characters.
+// Numbers and anything longer than classifierMaxToken are dropped. It is
+// exported so scripts/classifier trains on exactly what files are scored on.
+func ClassifierTokens(content []byte) []string {
+	var tokens []string
+	emit := func(token []byte) {
+		if len(token) <= classifierMaxToken {
+			tokens = append(tokens, string(token))
+		}
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case isClassifierWordStart(c) || (strings.IndexByte(`@\#$%`, c) != -1 && i+1 < len(content) && isClassifierWordStart(content[i+1])):
+			j := i + 1
+			for j < len(content) && isClassifierWord(content[j]) {
+				j++
+			}
+			emit(content[i:j])
+			i = j
+		case c >= '0' && c <= '9':
+			for i < len(content) && (isClassifierWord(content[i]) || content[i] == '.') {
+				i++
+			}
+		case c <= ' ' || c >= 0x80:
+			i++
+		default:
+			j := i + 1
+			for j < len(content) && j-i < 3 && isClassifierPunctuation(content[j]) {
+				j++
+			}
+			emit(content[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+func isClassifierWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isClassifierWord(c byte) bool {
+	return isClassifierWordStart(c) || (c >= '0' && c <= '9')
+}
+
+func isClassifierPunctuation(c byte) bool {
+	return c > ' ' && c < 0x80 && !isClassifierWord(c)
+}
+
+// classifyContent scores content against every candidate the classifier has
+// samples for, using naive Bayes over token counts with add one smoothing.
+// Only tokens seen in the samples of at least one of those candidates are
+// counted, so a large sample set is not penalised for the words it lacks.
+// It reports false when fewer than two candidates have samples or too few of
+// the tokens are known, returning whatever scores it has for --explain.
+func classifyContent(possibleLanguages []string, content []byte) (string, []ClassifierScore, bool) {
+	var trained []string
+	for _, lan := range possibleLanguages {
+		if _, ok := classifierModels[lan]; ok {
+			trained = append(trained, lan)
+		}
+	}
+	if len(trained) < 2 {
+		return "", nil, false
+	}
+
+	counts := map[string]int{}
+	known := 0
+	for _, token := range ClassifierTokens(content) {
+		for _, lan := range trained {
+			if _, ok := classifierModels[lan].Tokens[token]; ok {
+				counts[token]++
+				known++
+				break
+			}
+		}
+	}
+
+	logp := func(lan, token string) float64 {
+		m := classifierModels[lan]
+		return math.Log(float64(m.Tokens[token]+1) / float64(m.Total+classifierVocabulary))
+	}
+
+	scores := make([]ClassifierScore, 0, len(trained))
+	for _, lan := range trained {
+		score := ClassifierScore{Language: lan, Tokens: known, TopTokens: []string{}}
+		type gain struct {
+			token string
+			by    float64
+		}
+		var gains []gain
+		for token, n := range counts {
+			p := logp(lan, token)
+			score.Score += float64(n) * p
+
+			best := math.Inf(-1)
+			for _, other := range trained {
+				if other != lan {
+					best = max(best, logp(other, token))
+				}
+			}
+			if p > best {
+				gains = append(gains, gain{token, float64(n) * (p - best)})
+			}
+		}
+		slices.SortFunc(gains, func(a, b gain) int {
+			if order := cmp.Compare(b.by, a.by); order != 0 {
+				return order
+			}
+			return strings.Compare(a.token, b.token)
+		})
+		for _, g := range gains[:min(len(gains), classifierTopTokens)] {
+			score.TopTokens = append(score.TopTokens, g.token)
+		}
+		scores = append(scores, score)
+	}
+	slices.SortFunc(scores, func(a, b ClassifierScore) int {
+		if order := cmp.Compare(b.Score, a.Score); order != 0 {
+			return order
+		}
+		return strings.Compare(a.Language, b.Language)
+	})
+
+	if known < classifierMinTokens {
+		return "", scores, false
+	}
+	return scores[0].Language, scores, true
+}
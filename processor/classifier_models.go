@@ -0,0 +1,1390 @@
+// Code generated by scripts/classifier using 'go generate'. DO NOT EDIT.
+package processor
+
+// classifierVocabulary is how many distinct tokens all the samples hold
+const classifierVocabulary = 1028
+
+// classifierModels are the token counts of the samples of each language
+var classifierModels = map[string]ClassifierModel{
+	"Coq": {
+		Total: 394,
+		Tokens: map[string]int{
+			"'":              6,
+			"'))":            1,
+			"'.":             2,
+			"'].":            1,
+			"(":              18,
+			"(*":             1,
+			"(**":            1,
+			")":              11,
+			")).":            1,
+			"),":             2,
+			").":             1,
+			"*":              1,
+			"*)":             2,
+			"+":              2,
+			"++":             1,
+			",":              3,
+			"-":              6,
+			"->":             5,
+			".":              43,
+			":":              27,
+			"::":             2,
+			":=":             5,
+			";":              3,
+			"<-":             2,
+			"=":              4,
+			"=>":             4,
+			"A":              19,
+			"Admitted":       1,
+			"Arith":          1,
+			"Coq":            2,
+			"Definition":     1,
+			"End":            1,
+			"Fixpoint":       2,
+			"H":              1,
+			"Hypothesis":     1,
+			"IH":             2,
+			"IHn":            2,
+			"Import":         3,
+			"Inductive":      1,
+			"Lemma":          2,
+			"List":           1,
+			"ListNotations":  1,
+			"Lists":          1,
+			"Monoid":         2,
+			"Natural":        1,
+			"O":              2,
+			"PeanoNat":       1,
+			"Proof":          4,
+			"Prop":           1,
+			"Qed":            3,
+			"Record":         1,
+			"Require":        2,
+			"Reversing":      1,
+			"S":              5,
+			"Section":        1,
+			"Theorem":        2,
+			"Type":           5,
+			"Variable":       1,
+			"[]":             1,
+			"[].":            1,
+			"[|":             2,
+			"];":             1,
+			"_correct":       1,
+			"acc":            7,
+			"and":            1,
+			"app_assoc":      1,
+			"app_nil_r":      1,
+			"apply":          3,
+			"arith":          1,
+			"as":             2,
+			"auto":           1,
+			"carrier_op":     1,
+			"destruct":       1,
+			"double":         3,
+			"double_even":    1,
+			"end":            2,
+			"ev_0":           2,
+			"ev_SS":          2,
+			"even":           5,
+			"evenness":       1,
+			"exact":          1,
+			"forall":         5,
+			"induction":      2,
+			"intros":         4,
+			"l":              12,
+			"list":           6,
+			"lists":          1,
+			"m":              4,
+			"match":          2,
+			"monoid":         1,
+			"n":              16,
+			"nat":            5,
+			"numbers":        1,
+			"omega":          1,
+			"op":             5,
+			"op_assoc":       1,
+			"plus_comm":      1,
+			"plus_n_O":       1,
+			"reflexivity":    3,
+			"rev":            6,
+			"rev_append":     4,
+			"rev_append_rev": 2,
+			"rewrite":        4,
+			"simpl":          4,
+			"unfold":         1,
+			"unit":           1,
+			"with":           3,
+			"x":              6,
+			"xs":             3,
+			"y":              3,
+			"z":              3,
+			"{":              3,
+			"|":              6,
+			"}":              2,
+			"}.":             1,
+		},
+	},
+	"IEC61131-3": {
+		Total: 224,
+		Tokens: map[string]int{
+			"\"":                  6,
+			"#":                   1,
+			"#Average":            2,
+			"#Count":              2,
+			"#RampTimer":          2,
+			"#Running":            2,
+			"#Speed":              3,
+			"#SpeedSetpoint":      2,
+			"#Start":              1,
+			"#State":              5,
+			"#Stop":               1,
+			"#Sum":                6,
+			"#Values":             1,
+			"#i":                  1,
+			"'":                   2,
+			"(":                   1,
+			"(#":                  2,
+			")":                   1,
+			");":                  2,
+			"*":                   1,
+			"+":                   1,
+			",":                   1,
+			"-":                   1,
+			"-#":                  1,
+			".":                   2,
+			"/":                   2,
+			":":                   18,
+			":=":                  19,
+			";":                   31,
+			"<":                   1,
+			"=":                   1,
+			">":                   1,
+			"AND":                 1,
+			"Array":               1,
+			"Average":             1,
+			"BEGIN":               3,
+			"BY":                  1,
+			"Bool":                4,
+			"CASE":                1,
+			"Count":               2,
+			"DATA_BLOCK":          1,
+			"DO":                  2,
+			"ELSE":                1,
+			"ELSIF":               1,
+			"END_CASE":            1,
+			"END_DATA_BLOCK":      1,
+			"END_FOR":             1,
+			"END_FUNCTION":        1,
+			"END_FUNCTION_BLOCK":  1,
+			"END_IF":              3,
+			"END_STRUCT":          1,
+			"END_VAR":             5,
+			"END_WHILE":           1,
+			"ET":                  1,
+			"Enabled":             1,
+			"FALSE":               1,
+			"FOR":                 1,
+			"FUNCTION":            1,
+			"FUNCTION_BLOCK":      1,
+			"IF":                  3,
+			"IN":                  1,
+			"INT_TO_REAL":         1,
+			"Int":                 3,
+			"Limit":               1,
+			"MotorControl":        1,
+			"OF":                  1,
+			"PT":                  1,
+			"Q":                   1,
+			"RampTimer":           2,
+			"Real":                6,
+			"Running":             1,
+			"S7_Optimized_Access": 1,
+			"STRUCT":              1,
+			"Settings":            1,
+			"Speed":               1,
+			"SpeedSetpoint":       1,
+			"Start":               1,
+			"State":               1,
+			"Stop":                1,
+			"Sum":                 2,
+			"T":                   1,
+			"THEN":                4,
+			"TIME_TO_REAL":        1,
+			"TO":                  1,
+			"TON":                 1,
+			"TRUE":                4,
+			"VAR":                 1,
+			"VAR_INPUT":           2,
+			"VAR_OUTPUT":          1,
+			"VAR_TEMP":            1,
+			"VERSION":             1,
+			"Values":              1,
+			"WHILE":               1,
+			"[":                   1,
+			"[#":                  1,
+			"]":                   1,
+			"];":                  1,
+			"i":                   2,
+			"of":                  1,
+			"{":                   1,
+			"}":                   1,
+		},
+	},
+	"LaTeX": {
+		Total: 378,
+		Tokens: map[string]int{
+			"&":                   6,
+			"&=":                  2,
+			"(":                   1,
+			")":                   1,
+			"+":                   1,
+			",":                   5,
+			"-":                   1,
+			".":                   8,
+			".}":                  2,
+			".}.":                 1,
+			":":                   8,
+			"=":                   3,
+			"A":                   1,
+			"As":                  1,
+			"Author":              2,
+			"B":                   1,
+			"Classify":            1,
+			"Code":                1,
+			"Counting":            2,
+			"Discussion":          1,
+			"Files":               1,
+			"Introduction":        1,
+			"Lines":               1,
+			"Measured":            1,
+			"Method":              1,
+			"Quickly":             1,
+			"Read":                1,
+			"Results":             1,
+			"Run":                 1,
+			"Seconds":             1,
+			"Section":             1,
+			"See":                 1,
+			"T":                   1,
+			"Table":               1,
+			"The":                 1,
+			"Throughput":          1,
+			"Tool":                1,
+			"We":                  1,
+			"[":                   3,
+			"\\\\":                4,
+			"\\and":               1,
+			"\\author":            1,
+			"\\autoref":           1,
+			"\\begin":             9,
+			"\\bibliography":      1,
+			"\\bibliographystyle": 1,
+			"\\caption":           2,
+			"\\centering":         2,
+			"\\chapter":           1,
+			"\\cite":              1,
+			"\\date":              1,
+			"\\documentclass":     1,
+			"\\emph":              1,
+			"\\end":               9,
+			"\\footnote":          1,
+			"\\frac":              1,
+			"\\hline":             3,
+			"\\in":                1,
+			"\\includegraphics":   1,
+			"\\item":              4,
+			"\\label":             5,
+			"\\maketitle":         1,
+			"\\newcommand":        1,
+			"\\quad":              1,
+			"\\renewcommand":      1,
+			"\\section":           2,
+			"\\subsection":        1,
+			"\\sum_":              1,
+			"\\text":              1,
+			"\\textbf":            3,
+			"\\texttt":            1,
+			"\\textwidth":         1,
+			"\\title":             1,
+			"\\usepackage":        4,
+			"\\{":                 1,
+			"\\}":                 1,
+			"]":                   2,
+			"]{":                  3,
+			"a":                   4,
+			"a4paper":             1,
+			"abstract":            2,
+			"align":               2,
+			"amsmath":             1,
+			"amssymb":             1,
+			"an":                  1,
+			"and":                 2,
+			"approach":            1,
+			"article":             1,
+			"b":                   1,
+			"baselinestretch":     1,
+			"by":                  1,
+			"byte":                1,
+			"c":                   1,
+			"c_i":                 2,
+			"ch":                  1,
+			"code":                1,
+			"counting":            1,
+			"d":                   1,
+			"describe":            1,
+			"document":            2,
+			"each":                2,
+			"enumerate":           2,
+			"equation":            2,
+			"fast":                1,
+			"faster":              1,
+			"fig":                 2,
+			"figure":              2,
+			"file":                2,
+			"footnote":            1,
+			"graphicx":            1,
+			"h":                   1,
+			"harder":              1,
+			"helps":               1,
+			"htbp":                1,
+			"hyperref":            1,
+			"i":                   1,
+			"idle":                1,
+			"inputenc":            1,
+			"intro":               1,
+			"is":                  2,
+			"it":                  1,
+			"itemize":             2,
+			"knuth1984":           1,
+			"large":               1,
+			"lines":               1,
+			"looks":               1,
+			"lrr":                 1,
+			"machine":             2,
+			"method":              2,
+			"much":                1,
+			"n":                   2,
+			"no":                  1,
+			"of":                  1,
+			"on":                  1,
+			"once":                1,
+			"option":              1,
+			"other":               1,
+			"pdf":                 1,
+			"plain":               1,
+			"ref":                 2,
+			"refs":                1,
+			"results":             3,
+			"scc":                 3,
+			"sec":                 3,
+			"see":                 1,
+			"shows":               1,
+			"size":                1,
+			"source":              1,
+			"speed":               3,
+			"state":               1,
+			"tab":                 2,
+			"table":               2,
+			"tabular":             2,
+			"textsc":              1,
+			"than":                1,
+			"the":                 2,
+			"times":               1,
+			"to":                  1,
+			"today":               1,
+			"tool":                1,
+			"utf8":                1,
+			"where":               1,
+			"width":               1,
+			"with":                1,
+			"{":                   49,
+			"{--":                 1,
+			"{\\":                 3,
+			"}":                   48,
+			"}.":                  1,
+			"}[":                  2,
+			"}^{":                 1,
+			"}{":                  3,
+			"}{\\":                1,
+			"}}":                  1,
+			"~\\":                 2,
+		},
+	},
+	"MATLAB": {
+		Total: 513,
+		Tokens: map[string]int{
+			"%":           7,
+			"%%":          1,
+			"%d":          1,
+			"'":           11,
+			"'))":         1,
+			"');":         7,
+			"',":          8,
+			"'}":          1,
+			"(":           34,
+			"('":          10,
+			"(['":         1,
+			")":           12,
+			")')":         1,
+			"))":          2,
+			")))":         1,
+			"));":         4,
+			");":          5,
+			"*":           14,
+			"+":           7,
+			",":           28,
+			"-":           5,
+			"-')":         1,
+			"-',":         1,
+			".":           10,
+			"/":           3,
+			":":           6,
+			";":           20,
+			"<":           1,
+			"<=":          2,
+			"=":           26,
+			">":           1,
+			"A":           5,
+			"Access":      1,
+			"Account":     4,
+			"Amplitude":   1,
+			"Balance":     7,
+			"H":           7,
+			"History":     2,
+			"K":           3,
+			"KALMAN":      1,
+			"Kalman":      1,
+			"LineWidth":   1,
+			"Moving":      1,
+			"NaN":         1,
+			"Owner":       2,
+			"P":           11,
+			"Plot":        1,
+			"Predict":     1,
+			"Q":           3,
+			"R":           3,
+			"Raw":         1,
+			"S":           2,
+			"SAVE":        1,
+			"Time":        1,
+			"Update":      1,
+			"[":           2,
+			"\\n":         1,
+			"]":           2,
+			"]);":         1,
+			"a":           3,
+			"abs":         1,
+			"account":     1,
+			"all":         2,
+			"amount":      9,
+			"and":         3,
+			"any":         1,
+			"average":     2,
+			"b":           1,
+			"balance":     2,
+			"bank":        1,
+			"be":          1,
+			"case":        1,
+			"char":        1,
+			"classdef":    1,
+			"clc":         1,
+			"clear":       1,
+			"close":       1,
+			"covariance":  1,
+			"deposit":     2,
+			"disp":        1,
+			"elseif":      1,
+			"end":         15,
+			"error":       2,
+			"estimate":    1,
+			"eye":         1,
+			"figure":      1,
+			"filter":      1,
+			"for":         1,
+			"fprintf":     1,
+			"frequency":   1,
+			"fs":          3,
+			"function":    4,
+			"gcf":         1,
+			"getenv":      1,
+			"given":       1,
+			"grid":        1,
+			"handle":      1,
+			"if":          4,
+			"invalid":     1,
+			"isnan":       1,
+			"its":         1,
+			"k":           4,
+			"kalman":      2,
+			"length":      2,
+			"linear":      1,
+			"lower":       1,
+			"max":         1,
+			"mean":        1,
+			"measurement": 1,
+			"methods":     1,
+			"mismatch":    1,
+			"moving":      1,
+			"must":        1,
+			"nargin":      1,
+			"new":         1,
+			"noisy":       1,
+			"not":         1,
+			"num2str":     1,
+			"numel":       2,
+			"obj":         11,
+			"of":          1,
+			"ok":          3,
+			"on":          1,
+			"one":         1,
+			"otherwise":   1,
+			"owner":       2,
+			"peak":        1,
+			"pi":          1,
+			"plot":        2,
+			"png":         1,
+			"positive":    1,
+			"predict":     1,
+			"private":     1,
+			"produced":    1,
+			"properties":  2,
+			"r":           1,
+			"randn":       1,
+			"returns":     1,
+			"s":           1,
+			"sampling":    1,
+			"saveas":      1,
+			"saving":      1,
+			"signal":      9,
+			"simple":      1,
+			"sin":         1,
+			"size":        3,
+			"smoothed":    6,
+			"smoothing":   1,
+			"sprintf":     1,
+			"state":       1,
+			"step":        1,
+			"struct":      1,
+			"subplot":     2,
+			"switch":      1,
+			"t":           5,
+			"the":         2,
+			"title":       2,
+			"true":        1,
+			"type":        1,
+			"update":      1,
+			"values":      1,
+			"warning":     1,
+			"window":      5,
+			"withdraw":    1,
+			"x":           9,
+			"xlabel":      1,
+			"yes":         1,
+			"ylabel":      1,
+			"z":           4,
+			"zeros":       1,
+			"{":           1,
+			"{'":          1,
+			"{}":          1,
+			"}":           1,
+			"~=":          1,
+		},
+	},
+	"Objective C": {
+		Total: 479,
+		Tokens: map[string]int{
+			"\"":                            8,
+			"\",":                           2,
+			"\"])":                          1,
+			"\"];":                          2,
+			"#import":                       5,
+			"%@":                            1,
+			"%@!":                           1,
+			"%@\"":                          3,
+			"(":                             17,
+			"(![":                           2,
+			"()":                            1,
+			"(),":                           1,
+			"(@\"":                          3,
+			")":                             12,
+			");":                            2,
+			"*":                             8,
+			"*)":                            11,
+			"**)":                           1,
+			"*>":                            1,
+			"+":                             1,
+			",":                             8,
+			"-":                             8,
+			".":                             15,
+			"/":                             2,
+			":":                             11,
+			":&":                            1,
+			":(":                            9,
+			":@\"":                          4,
+			":[[":                           1,
+			";":                             10,
+			"<":                             6,
+			"=":                             10,
+			"==":                            1,
+			">":                             5,
+			"@\"":                           1,
+			"@[@":                           1,
+			"@autoreleasepool":              1,
+			"@catch":                        1,
+			"@end":                          6,
+			"@implementation":               3,
+			"@interface":                    2,
+			"@property":                     2,
+			"@protocol":                     1,
+			"@try":                          1,
+			"Ada":                           1,
+			"AppDelegate":                   2,
+			"AppReady":                      1,
+			"BOOL":                          3,
+			"Foundation":                    2,
+			"Friendly":                      3,
+			"Grace":                         1,
+			"Greeter":                       3,
+			"Hello":                         1,
+			"NO":                            1,
+			"NSArray":                       1,
+			"NSDictionary":                  1,
+			"NSError":                       2,
+			"NSException":                   1,
+			"NSLog":                         3,
+			"NSMutableArray":                2,
+			"NSNotificationCenter":          1,
+			"NSObject":                      2,
+			"NSString":                      10,
+			"NSUInteger":                    1,
+			"NSUserDefaults":                2,
+			"UIApplication":                 2,
+			"UIKit":                         2,
+			"UINavigationController":        1,
+			"UIScreen":                      1,
+			"UIWindow":                      1,
+			"ViewController":                3,
+			"Widget":                        4,
+			"YES":                           2,
+			"[":                             11,
+			"[[":                            6,
+			"[])":                           1,
+			"]":                             7,
+			"])":                            1,
+			"]);":                           1,
+			"];":                            14,
+			"]];":                           1,
+			"^{":                            1,
+			"_items":                        1,
+			"_title":                        1,
+			"addItem":                       1,
+			"addObject":                     1,
+			"added":                         1,
+			"alloc":                         5,
+			"application":                   3,
+			"applicationWillResignActive":   1,
+			"argc":                          1,
+			"argv":                          1,
+			"array":                         1,
+			"assign":                        1,
+			"boolForKey":                    1,
+			"bounds":                        1,
+			"bundle":                        1,
+			"char":                          1,
+			"const":                         1,
+			"controller":                    2,
+			"copy":                          1,
+			"could":                         1,
+			"count":                         2,
+			"defaultCenter":                 1,
+			"defaults":                      4,
+			"didFinishLaunchingWithOptions": 1,
+			"dispatch_async":                1,
+			"dispatch_get_main_queue":       1,
+			"error":                         5,
+			"exception":                     1,
+			"for":                           1,
+			"forKey":                        1,
+			"greet":                         3,
+			"greeter":                       2,
+			"h":                             5,
+			"id":                            1,
+			"if":                            4,
+			"in":                            1,
+			"init":                          2,
+			"initWithFrame":                 1,
+			"initWithNibName":               1,
+			"initWithRootViewController":    1,
+			"initWithTitle":                 2,
+			"instancetype":                  1,
+			"int":                           2,
+			"item":                          4,
+			"items":                         3,
+			"launchOptions":                 1,
+			"launched":                      2,
+			"loaded":                        1,
+			"localizedDescription":          1,
+			"main":                          1,
+			"mainScreen":                    1,
+			"makeKeyAndVisible":             1,
+			"name":                          5,
+			"names":                         2,
+			"nil":                           5,
+			"nonatomic":                     2,
+			"not":                           1,
+			"object":                        1,
+			"postNotificationName":          1,
+			"return":                        9,
+			"rootViewController":            1,
+			"save":                          2,
+			"saveState":                     2,
+			"self":                          12,
+			"setBool":                       1,
+			"standardUserDefaults":          1,
+			"store":                         1,
+			"stringWithFormat":              1,
+			"strong":                        1,
+			"super":                         1,
+			"synchronize":                   1,
+			"title":                         5,
+			"to":                            1,
+			"void":                          2,
+			"widgetWithTitle":               1,
+			"window":                        3,
+			"{":                             17,
+			"}":                             17,
+			"});":                           1,
+		},
+	},
+	"Scallop": {
+		Total: 323,
+		Tokens: map[string]int{
+			"\"":                       13,
+			"\")":                      2,
+			"\"),":                     12,
+			"\",":                      6,
+			"(":                        37,
+			"(\"":                      7,
+			")":                        22,
+			"))":                       2,
+			"),":                       5,
+			")}":                       2,
+			"+":                        1,
+			",":                        34,
+			"-":                        2,
+			"//":                       1,
+			":":                        7,
+			":-":                       1,
+			"::(":                      2,
+			":=":                       1,
+			"=":                        13,
+			">":                        1,
+			"@demand":                  1,
+			"CS":                       4,
+			"Math":                     3,
+			"Object":                   1,
+			"String":                   3,
+			"Students":                 1,
+			"_":                        1,
+			"a":                        12,
+			"alice":                    2,
+			"and":                      5,
+			"b":                        6,
+			"bf":                       1,
+			"bob":                      2,
+			"c":                        8,
+			"case":                     1,
+			"class":                    1,
+			"classes":                  3,
+			"count":                    2,
+			"count_enroll_cs_in_class": 2,
+			"cycle":                    2,
+			"digit":                    1,
+			"edge":                     5,
+			"enroll":                   3,
+			"enrolment":                1,
+			"fib":                      5,
+			"i32":                      1,
+			"is":                       1,
+			"jenny":                    2,
+			"jerry":                    2,
+			"john":                     2,
+			"n":                        4,
+			"name":                     2,
+			"no_cycle":                 1,
+			"not":                      1,
+			"o":                        2,
+			"obj_color":                1,
+			"path":                     6,
+			"query":                    3,
+			"reachable_count":          2,
+			"rel":                      15,
+			"s":                        3,
+			"student":                  3,
+			"subject":                  1,
+			"tom":                      2,
+			"type":                     3,
+			"usize":                    2,
+			"where":                    1,
+			"x":                        4,
+			"y1":                       2,
+			"y2":                       2,
+			"{":                        4,
+			"{(":                       1,
+			"}":                        3,
+		},
+	},
+	"TeX": {
+		Total: 208,
+		Tokens: map[string]int{
+			"#":            7,
+			"#\\":          1,
+			"$$\\":         1,
+			"%":            1,
+			"&":            2,
+			"&\\":          1,
+			"'":            1,
+			",":            2,
+			".\\":          1,
+			".}":           3,
+			".~":           2,
+			"/\\":          2,
+			"=":            10,
+			"=\\":          1,
+			"A":            3,
+			"Ada":          1,
+			"Cards":        1,
+			"Dear":         1,
+			"Engine":       1,
+			"It":           1,
+			"James":        1,
+			"L":            1,
+			"London":       1,
+			"Lovelace":     1,
+			"Note":         1,
+			"Operations":   1,
+			"Plain":        1,
+			"Sir":          1,
+			"Square":       1,
+			"St":           1,
+			"Store":        1,
+			"TeX":          1,
+			"The":          1,
+			"Variables":    1,
+			"Yours":        1,
+			"\\@":          1,
+			"\\bye":        1,
+			"\\catcode":    2,
+			"\\centerline": 1,
+			"\\cr":         3,
+			"\\day":        1,
+			"\\def":        5,
+			"\\edef":       1,
+			"\\eject":      1,
+			"\\else":       1,
+			"\\fi":         1,
+			"\\font":       1,
+			"\\halign":     1,
+			"\\hbox":       1,
+			"\\hfil":       1,
+			"\\hfill":      1,
+			"\\hsize":      2,
+			"\\ifx":        1,
+			"\\input":      1,
+			"\\it":         1,
+			"\\itemcount":  4,
+			"\\let":        1,
+			"\\letterdate": 2,
+			"\\letterhead": 2,
+			"\\month":      1,
+			"\\newcount":   1,
+			"\\noindent":   2,
+			"\\numbered":   3,
+			"\\oldpar":     1,
+			"\\over":       2,
+			"\\par":        2,
+			"\\parindent":  1,
+			"\\parskip":    1,
+			"\\relax":      1,
+			"\\signature":  2,
+			"\\the":        1,
+			"\\titlefont":  1,
+			"\\today":      1,
+			"\\undefined":  1,
+			"\\vfill":      1,
+			"\\vsize":      1,
+			"\\vskip":      2,
+			"\\year":       1,
+			"^":            2,
+			"`\\@":         2,
+			"a":            1,
+			"act":          1,
+			"advance":      1,
+			"algebraic":    1,
+			"at":           1,
+			"besides":      1,
+			"bf":           1,
+			"by":           1,
+			"cmbx12":       1,
+			"engine":       1,
+			"faithfully":   1,
+			"for":          1,
+			"hbox":         2,
+			"hfil":         3,
+			"infty":        1,
+			"letter":       1,
+			"letterhead":   2,
+			"macros":       2,
+			"may":          1,
+			"n":            2,
+			"number":       4,
+			"on":           1,
+			"other":        1,
+			"par":          1,
+			"patterns":     1,
+			"pi":           1,
+			"plus":         1,
+			"quad":         1,
+			"s":            1,
+			"simple":       1,
+			"sum_":         1,
+			"the":          1,
+			"things":       1,
+			"titlefont":    1,
+			"to":           1,
+			"today":        1,
+			"upon":         1,
+			"vbox":         1,
+			"vskip":        1,
+			"weaves":       1,
+			"{":            6,
+			"{#":           1,
+			"{#\\":         1,
+			"{\\":          10,
+			"{\\@":         1,
+			"}":            10,
+			"}$$":          1,
+			"}\\":          1,
+			"}^\\":         1,
+			"}{":           1,
+			"}}}":          1,
+		},
+	},
+	"TemplateToolkit": {
+		Total: 291,
+		Tokens: map[string]int{
+			"\",":          1,
+			"%":            3,
+			"%]":           27,
+			"%]\"":         3,
+			"%]<":          6,
+			"'":            4,
+			"'%":           1,
+			"')":           1,
+			"',":           1,
+			"(":            2,
+			"(\"?":         1,
+			"('/":          1,
+			")":            3,
+			",":            2,
+			"-":            2,
+			"-%":           2,
+			"-%]":          2,
+			"-->":          1,
+			".":            15,
+			"..":           1,
+			".</":          1,
+			"/":            5,
+			"<":            9,
+			"<!-":          1,
+			"</":           3,
+			"=":            6,
+			"=\"[":         3,
+			"=$":           1,
+			"==":           1,
+			">":            14,
+			">[%":          5,
+			"BLOCK":        2,
+			"CATCH":        1,
+			"DEFAULT":      1,
+			"ELSE":         1,
+			"ELSIF":        1,
+			"END":          9,
+			"FOR":          1,
+			"FOREACH":      1,
+			"Generated":    1,
+			"IF":           3,
+			"IN":           1,
+			"INCLUDE":      2,
+			"MACRO":        1,
+			"No":           1,
+			"OR":           1,
+			"PROCESS":      1,
+			"SET":          1,
+			"Shared":       1,
+			"TRY":          1,
+			"USE":          1,
+			"Untitled":     1,
+			"Users":        1,
+			"WRAPPER":      1,
+			"Y":            1,
+			"[":            1,
+			"[%":           28,
+			"[%#":          1,
+			"[%-":          1,
+			"]":            1,
+			"a":            4,
+			"admin":        2,
+			"c":            1,
+			"class":        1,
+			"current_page": 1,
+			"d":            1,
+			"date":         3,
+			"error":        1,
+			"file":         1,
+			"footer":       1,
+			"for":          1,
+			"format":       1,
+			"found":        1,
+			"h1":           2,
+			"href":         2,
+			"html":         2,
+			"id":           1,
+			"info":         1,
+			"last":         3,
+			"last_page":    1,
+			"layout":       1,
+			"li":           2,
+			"link":         2,
+			"loop":         1,
+			"m":            1,
+			"macros":       1,
+			"missing":      1,
+			"name":         1,
+			"now":          1,
+			"p":            4,
+			"page":         8,
+			"pager":        6,
+			"parity":       1,
+			"result":       1,
+			"size":         1,
+			"span":         2,
+			"strong":       2,
+			"templates":    1,
+			"text":         2,
+			"the":          1,
+			"title":        3,
+			"tt":           3,
+			"ul":           2,
+			"uri_for":      1,
+			"url":          3,
+			"user":         5,
+			"users":        3,
+			"year":         1,
+			"|":            2,
+			"||":           1,
+		},
+	},
+	"Treetop": {
+		Total: 252,
+		Tokens: map[string]int{
+			"'":            11,
+			"'\"'":         3,
+			"'('":          1,
+			"')'":          1,
+			"','":          2,
+			"'-'":          2,
+			"'/'":          1,
+			"':'":          1,
+			"'['":          1,
+			"']'":          1,
+			"'{'":          1,
+			"'}'":          1,
+			"(":            5,
+			"('*":          1,
+			"('+":          1,
+			"('.":          1,
+			"('\\":         1,
+			")":            3,
+			")*":           2,
+			")*)":          2,
+			"*":            1,
+			"+":            1,
+			",":            1,
+			"-":            4,
+			".":            4,
+			".))":          1,
+			"/":            11,
+			":":            1,
+			":(":           2,
+			":(!":          1,
+			"<":            10,
+			">":            10,
+			"?":            10,
+			"Arithmetic":   1,
+			"ArrayNode":    1,
+			"DocumentNode": 1,
+			"FalseNode":    1,
+			"JSON":         1,
+			"JSONParser":   1,
+			"NullNode":     1,
+			"NumberNode":   1,
+			"ObjectNode":   1,
+			"PairNode":     1,
+			"ParenNode":    1,
+			"StringNode":   1,
+			"TrueNode":     1,
+			"Whitespace":   1,
+			"[":            5,
+			"[\\":          1,
+			"\\'":          1,
+			"\\n":          1,
+			"\\t":          1,
+			"]":            1,
+			"]*":           2,
+			"]+":           2,
+			"]+)":          1,
+			"additive":     2,
+			"array":        2,
+			"chars":        1,
+			"def":          2,
+			"document":     1,
+			"e":            2,
+			"elements":     1,
+			"end":          21,
+			"false":        1,
+			"false_value":  2,
+			"grammar":      2,
+			"include":      1,
+			"inject":       1,
+			"items":        1,
+			"key":          1,
+			"module":       1,
+			"multitive":    3,
+			"null":         1,
+			"null_value":   2,
+			"number":       4,
+			"object":       2,
+			"pair":         3,
+			"pairs":        1,
+			"primary":      3,
+			"rule":         16,
+			"s":            1,
+			"space":        7,
+			"spacing":      13,
+			"string":       3,
+			"sum":          2,
+			"text_value":   1,
+			"to_i":         1,
+			"true":         1,
+			"true_value":   2,
+			"value":        8,
+			"{":            3,
+			"|":            2,
+			"}":            3,
+		},
+	},
+	"V": {
+		Total: 292,
+		Tokens: map[string]int{
+			"!":           2,
+			"![]":         1,
+			"${":          3,
+			"&":           1,
+			"')":          3,
+			"(":           8,
+			"('":          6,
+			"('$":         1,
+			"()":          10,
+			"([]":         1,
+			")":           8,
+			")!":          2,
+			"*":           1,
+			"++":          1,
+			",":           2,
+			".":           21,
+			".@":          1,
+			":":           4,
+			":${":         1,
+			"://":         1,
+			":=":          5,
+			"<-":          1,
+			"=":           2,
+			"==":          1,
+			">=":          1,
+			"Counter":     3,
+			"Mutex":       1,
+			"User":        5,
+			"[":           1,
+			"]":           1,
+			"_":           1,
+			"active":      3,
+			"age":         2,
+			"assert":      1,
+			"bool":        2,
+			"c":           5,
+			"can_vote":    2,
+			"cap":         1,
+			"ch":          3,
+			"chan":        1,
+			"count":       3,
+			"counter":     2,
+			"data":        2,
+			"deactivate":  2,
+			"decode":      1,
+			"defer":       1,
+			"else":        1,
+			"eprintln":    1,
+			"err":         1,
+			"error":       1,
+			"exit":        1,
+			"failed":      1,
+			"false":       1,
+			"fn":          7,
+			"for":         2,
+			"get":         1,
+			"http":        3,
+			"if":          1,
+			"import":      5,
+			"in":          1,
+			"inc":         2,
+			"int":         5,
+			"json":        3,
+			"listening":   1,
+			"load_users":  2,
+			"localhost":   1,
+			"lock":        1,
+			"main":        2,
+			"match":       1,
+			"module":      2,
+			"mu":          3,
+			"mut":         7,
+			"name":        2,
+			"net":         1,
+			"no":          1,
+			"on":          1,
+			"or":          2,
+			"os":          2,
+			"path":        2,
+			"port":        5,
+			"println":     3,
+			"privileged":  1,
+			"pub":         3,
+			"read_file":   1,
+			"resp":        2,
+			"return":      4,
+			"second":      1,
+			"serve":       1,
+			"server":      2,
+			"sleep":       1,
+			"spawn":       1,
+			"status_code": 1,
+			"string":      2,
+			"struct":      2,
+			"sync":        2,
+			"time":        3,
+			"u":           4,
+			"unlock":      1,
+			"user":        5,
+			"users":       3,
+			"{":           20,
+			"{}":          1,
+			"}":           18,
+			"}')":         3,
+			"}()":         1,
+			"}/'":         1,
+			"}:":          1,
+		},
+	},
+	"Verilog": {
+		Total: 291,
+		Tokens: map[string]int{
+			"\");":        1,
+			"#(":          1,
+			"$display":    1,
+			"&":           1,
+			"'":           14,
+			"(":           7,
+			"(!":          1,
+			"(\"":         1,
+			")":           9,
+			");":          2,
+			"+":           2,
+			",":           9,
+			"-":           1,
+			"/":           1,
+			"//":          1,
+			":":           10,
+			";":           24,
+			"<=":          18,
+			"=":           6,
+			"==":          1,
+			"@(":          2,
+			"DATA":        3,
+			"IDLE":        5,
+			"START":       3,
+			"STOP":        3,
+			"WIDTH":       3,
+			"[":           6,
+			"]":           5,
+			"];":          1,
+			"`":           1,
+			"always":      2,
+			"and":         1,
+			"assign":      1,
+			"b0":          4,
+			"b00":         1,
+			"b01":         1,
+			"b1":          4,
+			"b10":         1,
+			"b11":         1,
+			"begin":       11,
+			"bit":         1,
+			"bit_index":   6,
+			"busy":        4,
+			"case":        1,
+			"clk":         4,
+			"count":       5,
+			"counter":     2,
+			"d0":          1,
+			"d7":          1,
+			"data":        2,
+			"default":     1,
+			"else":        3,
+			"en":          2,
+			"enable":      1,
+			"end":         11,
+			"endcase":     1,
+			"endmodule":   2,
+			"if":          5,
+			"initial":     1,
+			"input":       7,
+			"localparam":  4,
+			"module":      2,
+			"negedge":     1,
+			"or":          1,
+			"output":      4,
+			"overflow":    2,
+			"parameter":   1,
+			"posedge":     2,
+			"ready":       1,
+			"reg":         6,
+			"reset":       1,
+			"reset_n":     3,
+			"rst":         2,
+			"shift":       3,
+			"start":       2,
+			"state":       8,
+			"synchronous": 1,
+			"timescale":   1,
+			"tx":          5,
+			"uart_tx":     2,
+			"wire":        4,
+			"with":        1,
+			"{":           2,
+			"}};":         1,
+		},
+	},
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const classifierSamples = "../scripts/classifier/samples"
+
+func TestClassifierTokens(t *testing.T) {
+	got := strings.Join(ClassifierTokens([]byte("@interface Foo : NSObject\n\\begin{x} #include <a.h>\nx := 42 + 3.5e10; $var %{\n")), " ")
+	expected := "@interface Foo : NSObject \\begin { x } #include < a . h > x := + ; $var %{"
+	if got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+	if got := ClassifierTokens([]byte(strings.Repeat("a", classifierMaxToken+1) + " b")); len(got) != 1 || got[0] != "b" {
+		t.Errorf("expected long tokens to be dropped got %v", got)
+	}
+}
+
+func TestClassifierModelsUpToDate(t *testing.T) {
+	dirs, err := os.ReadDir(classifierSamples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != len(classifierModels) {
+		t.Errorf("expected %d models got %d, run go generate", len(dirs), len(classifierModels))
+	}
+	for _, dir := range dirs {
+		files, err := os.ReadDir(filepath.Join(classifierSamples, dir.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		total := 0
+		for _, f := range files {
+			content, err := os.ReadFile(filepath.Join(classifierSamples, dir.Name(), f.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			total += len(ClassifierTokens(content))
+		}
+		if classifierModels[dir.Name()].Total != total {
+			t.Errorf("%s: expected %d tokens got %d, run go generate", dir.Name(), total, classifierModels[dir.Name()].Total)
+		}
+	}
+}
+
+func TestClassifyContent(t *testing.T) {
+	ProcessConstants()
+	for _, tc := range []struct {
+		candidates []string
+		content    string
+		expected   string
+	}{
+		{[]string{"MATLAB", "Objective C"}, "x = linspace(0, 1, 100);\ny = x.^2;\nplot(x, y);\ndisp(sum(y));\n", "MATLAB"},
+		{[]string{"MATLAB", "Objective C"}, "- (NSString *)name {\n    return [self.person fullName];\n}\n", "Objective C"},
+		{[]string{"Coq", "V", "Verilog"}, "Lemma foo : forall n, n = n.\nProof. intros. reflexivity. Qed.\n", "Coq"},
+		{[]string{"Coq", "V", "Verilog"}, "module top(input clk, output reg q);\n  always @(posedge clk) q <= ~q;\nendmodule\n", "Verilog"},
+		{[]string{"Coq", "V", "Verilog"}, "fn main() {\n\tname := 'world'\n\tprintln('hello ${name}')\n}\n", "V"},
+		{[]string{"LaTeX", "TeX"}, "\\section{Intro}\n\\begin{itemize}\\item one\\end{itemize}\n", "LaTeX"},
+		{[]string{"LaTeX", "TeX"}, "\\def\\x{1}\\hbox{\\bf hi}\\vskip 12pt\\bye\n", "TeX"},
+		{[]string{"TemplateToolkit", "Treetop"}, "[% FOREACH item IN list %]<li>[% item %]</li>[% END %]\n", "TemplateToolkit"},
+		{[]string{"TemplateToolkit", "Treetop"}, "grammar Words\n  rule word\n    [a-z]+\n  end\nend\n", "Treetop"},
+	} {
+		got, scores, ok := classifyContent(tc.candidates, []byte(tc.content))
+		if !ok || got != tc.expected {
+			t.Errorf("%q: expected %s got %s %+v", tc.content, tc.expected, got, scores)
+		}
+	}
+
+	// Too few known tokens, or fewer than two candidates with samples
+	if _, scores, ok := classifyContent([]string{"MATLAB", "Objective C"}, []byte("x\n")); ok || len(scores) != 2 {
+		t.Errorf("expected scores without a result got %v %+v", ok, scores)
+	}
+	if _, scores, ok := classifyContent([]string{"C Header", "Objective C"}, []byte("@interface Foo\n@end\n")); ok || scores != nil {
+		t.Errorf("expected nothing for one trained candidate got %v %+v", ok, scores)
+	}
+}
+
+func TestDetermineLanguageClassifier(t *testing.T) {
+	ProcessConstants()
+	content := []byte("- (NSString *)name {\n    return [self.person fullName];\n}\n")
+	candidates := []string{"MATLAB", "Objective C"}
+
+	if got := determineLanguage("helper.m", "", candidates, content, sharedLanguageFeature, true); got != "Objective C" {
+		t.Errorf("expected the classifier to pick Objective C got %s", got)
+	}
+	if got := determineLanguage("helper.m", "", candidates, content, sharedLanguageFeature, false); got != "MATLAB" {
+		t.Errorf("expected the keyword fallback without the classifier got %s", got)
+	}
+
+	// Keywords that settle it are not overridden
+	coq := []byte("Require Hypothesis Inductive\n")
+	if got := determineLanguage("x.v", "", []string{"Coq", "Verilog", "V"}, coq, sharedLanguageFeature, true); got != "Coq" {
+		t.Errorf("expected Coq got %s", got)
+	}
+}
+
+func TestExplainClassifier(t *testing.T) {
+	ProcessConstants()
+	dir := writeTree(t, map[string]string{
+		"helper.m": "- (NSString *)name {\n    return [self.person fullName];\n}\n",
+	})
+	opts := DefaultOptions()
+	e, err := explainLanguage(&opts, filepath.Join(dir, "helper.m"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Language != "Objective C" || len(e.Classifier) != 2 || e.Classifier[0].Language != "Objective C" || !strings.Contains(e.Reason, "classifier") {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+
+	opts.DisableClassifier = true
+	if e, _ := explainLanguage(&opts, filepath.Join(dir, "helper.m")); e.Language != "MATLAB" || len(e.Classifier) != 0 {
+		t.Errorf("expected --no-classifier to skip the classifier got %+v", e)
+	}
+}
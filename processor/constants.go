@@ -1596,6 +1596,14 @@ var languageDatabase = map[string]Language{
 		Heuristics:      []Heuristic{},
 		FileNames:       []string{},
 		SheBangs:        []string{},
+		Samples: []LanguageSample{
+			{
+				Content: "#include <stdio.h>\n\n/* multi\n * line */\nint main(void) {\n    char *s = \"/* not a comment */\";\n    return 0; // done\n}\n",
+				Code:    5,
+				Comment: 2,
+				Blank:   1,
+			},
+		},
 	},
 	"C Header": {
 		LineComment: []string{
@@ -5025,6 +5033,14 @@ var languageDatabase = map[string]Language{
 		Heuristics:      []Heuristic{},
 		FileNames:       []string{},
 		SheBangs:        []string{},
+		Samples: []LanguageSample{
+			{
+				Content: "package main\n\n// main prints a greeting\nfunc main() {\n\t/* block\n\t   comment */\n\tprintln(\"// not a comment\")\n}\n",
+				Code:    4,
+				Comment: 3,
+				Blank:   1,
+			},
+		},
 	},
 	"Go Template": {
 		LineComment: []string{},
@@ -6346,6 +6362,14 @@ var languageDatabase = map[string]Language{
 		SheBangs: []string{
 			"node",
 		},
+		Samples: []LanguageSample{
+			{
+				Content: "// helper\nconst a = `template ${1}\n// still a string`;\n\nfunction f() { return a }\n",
+				Code:    3,
+				Comment: 1,
+				Blank:   1,
+			},
+		},
 	},
 	"JavaServer Pages": {
 		LineComment: []string{
@@ -10141,6 +10165,14 @@ var languageDatabase = map[string]Language{
 			"python2",
 			"python3",
 		},
+		Samples: []LanguageSample{
+			{
+				Content: "# comment\nimport os\n\n\ndef main():\n    \"\"\"Docstring.\"\"\"\n    return os.name  # trailing\n",
+				Code:    3,
+				Comment: 2,
+				Blank:   2,
+			},
+		},
 	},
 	"Q#": {
 		LineComment: []string{
@@ -11150,6 +11182,14 @@ var languageDatabase = map[string]Language{
 		Heuristics:      []Heuristic{},
 		FileNames:       []string{},
 		SheBangs:        []string{},
+		Samples: []LanguageSample{
+			{
+				Content: "/* outer /* nested */ still a comment */\nfn main() {\n    let s = \"/* text */\";\n}\n",
+				Code:    3,
+				Comment: 1,
+				Blank:   0,
+			},
+		},
 	},
 	"SAS": {
 		LineComment: []string{
@@ -11789,6 +11829,14 @@ var languageDatabase = map[string]Language{
 		SheBangs: []string{
 			"sh",
 		},
+		Samples: []LanguageSample{
+			{
+				Content: "#!/bin/sh\n# comment\necho \"# not a comment\"\n\nexit 0\n",
+				Code:    2,
+				Comment: 2,
+				Blank:   1,
+			},
+		},
 	},
 	"Sieve": {
 		LineComment: []string{
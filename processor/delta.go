@@ -0,0 +1,706 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	glanguage "golang.org/x/text/language"
+	gmessage "golang.org/x/text/message"
+)
+
+// Statuses of a file in a delta
+const (
+	deltaAdded    = "added"
+	deltaRemoved  = "removed"
+	deltaModified = "modified"
+	deltaRenamed  = "renamed"
+)
+
+// renameSimilarity is how alike, as a share of lines, a removed and an added
+// file must be to be paired as a rename. It matches git's default of 50%.
+const renameSimilarity = 0.5
+
+// renameLimit caps the removed and added files compared line by line when
+// looking for renames, as git's diff.renameLimit does. Identical content is
+// always paired whatever the number of files.
+const renameLimit = 1000
+
+// deltaCounts are the counts on one side of a delta
+type deltaCounts struct {
+	Files      int64 `json:"files"`
+	Lines      int64 `json:"lines"`
+	Code       int64 `json:"code"`
+	Comment    int64 `json:"comment"`
+	Blank      int64 `json:"blank"`
+	Complexity int64 `json:"complexity"`
+	ULOC       int64 `json:"uloc"`
+}
+
+func (c deltaCounts) sub(o deltaCounts) deltaCounts {
+	return deltaCounts{
+		Files:      c.Files - o.Files,
+		Lines:      c.Lines - o.Lines,
+		Code:       c.Code - o.Code,
+		Comment:    c.Comment - o.Comment,
+		Blank:      c.Blank - o.Blank,
+		Complexity: c.Complexity - o.Complexity,
+		ULOC:       c.ULOC - o.ULOC,
+	}
+}
+
+func fileCounts(f *FileJob) deltaCounts {
+	if f == nil {
+		return deltaCounts{}
+	}
+	return deltaCounts{
+		Files:      1,
+		Lines:      f.Lines,
+		Code:       f.Code,
+		Comment:    f.Comment,
+		Blank:      f.Blank,
+		Complexity: f.Complexity,
+		ULOC:       int64(f.Uloc),
+	}
+}
+
+// languageDelta is the change in one language's totals
+type languageDelta struct {
+	Name   string
+	Before deltaCounts
+	After  deltaCounts
+}
+
+// fileDelta is one file that differs between the two sides. Previous is the
+// name it had before for a rename.
+type fileDelta struct {
+	Status   string
+	Location string
+	Previous string
+	Language string
+	Before   deltaCounts
+	After    deltaCounts
+}
+
+// deltaResult is everything --diff-against reports
+type deltaResult struct {
+	Against   string
+	Languages []languageDelta
+	Total     languageDelta
+	Files     []fileDelta
+}
+
+// deltaSide is one counted side of a delta with its files keyed by their path
+// below whichever of the roots they were found under
+type deltaSide struct {
+	language []LanguageSummary
+	files    map[string]*FileJob
+}
+
+// countDeltaSide runs opts and keys the files it counts
+func countDeltaSide(ctx context.Context, opts Options) (*deltaSide, error) {
+	// Per file ULOC is only worked out when asked for
+	opts.Uloc = true
+	opts.lineDigests = true
+
+	roots, err := opts.locationRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	language, files, err := NewAnalyzer(opts).Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	side := &deltaSide{language: language, files: make(map[string]*FileJob, len(files))}
+	for _, f := range files {
+		side.files[deltaKey(roots, f.Location)] = f
+	}
+	return side, nil
+}
+
+// deltaKey is location relative to the first root it is under, so the same
+// file counted from two different directories or trees lines up
+func deltaKey(roots []string, location string) string {
+	location = filepath.ToSlash(location)
+	for _, root := range roots {
+		root = path.Clean(filepath.ToSlash(root))
+		switch {
+		case root == ".":
+			return location
+		case location == root:
+			return path.Base(location)
+		case strings.HasPrefix(location, root+"/"):
+			return location[len(root)+1:]
+		}
+	}
+	return location
+}
+
+// deltaAgainstOptions returns the options counting the other side of a delta.
+// An existing path is counted with the same filters as the current side; any
+// other value is taken to be a git revision of the current paths.
+func deltaAgainstOptions(current Options, against string) Options {
+	opts := current
+	if _, err := os.Stat(against); err == nil {
+		opts.Paths = []string{against}
+		opts.Rev = ""
+	} else {
+		opts.Rev = against
+	}
+	return opts
+}
+
+// computeDelta counts the current options and against, which is a path or
+// git revision, and works out what changed going from against to current.
+func computeDelta(ctx context.Context, current Options, against string) (*deltaResult, error) {
+	before, err := countDeltaSide(ctx, deltaAgainstOptions(current, against))
+	if err != nil {
+		return nil, fmt.Errorf("--diff-against %s: %w", against, err)
+	}
+	after, err := countDeltaSide(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &deltaResult{Against: against}
+	result.Languages, result.Total = languageDeltas(before.language, after.language)
+	result.Files = fileDeltas(before.files, after.files)
+	return result, nil
+}
+
+func summaryCounts(l LanguageSummary) deltaCounts {
+	return deltaCounts{
+		Files:      l.Count,
+		Lines:      l.Lines,
+		Code:       l.Code,
+		Comment:    l.Comment,
+		Blank:      l.Blank,
+		Complexity: l.Complexity,
+		ULOC:       int64(l.ULOC),
+	}
+}
+
+// languageDeltas pairs the language summaries of both sides, leaving out
+// languages that did not change. Total ULOC is the sum of the languages as
+// lines are only unique within a language.
+func languageDeltas(before, after []LanguageSummary) ([]languageDelta, languageDelta) {
+	byName := map[string]*languageDelta{}
+	var names []string
+	get := func(name string) *languageDelta {
+		if d, ok := byName[name]; ok {
+			return d
+		}
+		d := &languageDelta{Name: name}
+		byName[name] = d
+		names = append(names, name)
+		return d
+	}
+	for _, l := range before {
+		get(l.Name).Before = summaryCounts(l)
+	}
+	for _, l := range after {
+		get(l.Name).After = summaryCounts(l)
+	}
+
+	total := languageDelta{Name: "Total"}
+	var out []languageDelta
+	for _, name := range names {
+		d := *byName[name]
+		total.Before = addCounts(total.Before, d.Before)
+		total.After = addCounts(total.After, d.After)
+		if d.Before != d.After {
+			out = append(out, d)
+		}
+	}
+
+	// Largest change in code first, then by name so the order is stable
+	sort.Slice(out, func(i, j int) bool {
+		ci, cj := absInt64(out[i].After.Code-out[i].Before.Code), absInt64(out[j].After.Code-out[j].Before.Code)
+		if ci != cj {
+			return ci > cj
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, total
+}
+
+func addCounts(a, b deltaCounts) deltaCounts {
+	return deltaCounts{
+		Files:      a.Files + b.Files,
+		Lines:      a.Lines + b.Lines,
+		Code:       a.Code + b.Code,
+		Comment:    a.Comment + b.Comment,
+		Blank:      a.Blank + b.Blank,
+		Complexity: a.Complexity + b.Complexity,
+		ULOC:       a.ULOC + b.ULOC,
+	}
+}
+
+func absInt64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// fileDeltas compares the files of both sides by key. Files only on one side
+// are paired up as renames where their content is the same or similar enough,
+// and unchanged files are left out. Renamed files are deleted from the maps.
+func fileDeltas(before, after map[string]*FileJob) []fileDelta {
+	var out []fileDelta
+	var removed, added []string
+	for key, b := range before {
+		a, ok := after[key]
+		if !ok {
+			removed = append(removed, key)
+			continue
+		}
+		if slices.Equal(a.lineDigests, b.lineDigests) {
+			continue
+		}
+		out = append(out, fileDelta{
+			Status:   deltaModified,
+			Location: key,
+			Language: a.Language,
+			Before:   fileCounts(b),
+			After:    fileCounts(a),
+		})
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	for _, pair := range detectRenames(removed, added, before, after) {
+		b, a := before[pair[0]], after[pair[1]]
+		out = append(out, fileDelta{
+			Status:   deltaRenamed,
+			Location: pair[1],
+			Previous: pair[0],
+			Language: a.Language,
+			Before:   fileCounts(b),
+			After:    fileCounts(a),
+		})
+		delete(before, pair[0])
+		delete(after, pair[1])
+	}
+
+	for _, key := range removed {
+		if b, ok := before[key]; ok {
+			out = append(out, fileDelta{Status: deltaRemoved, Location: key, Language: b.Language, Before: fileCounts(b)})
+		}
+	}
+	for _, key := range added {
+		if a, ok := after[key]; ok {
+			out = append(out, fileDelta{Status: deltaAdded, Location: key, Language: a.Language, After: fileCounts(a)})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Location < out[j].Location
+	})
+	return out
+}
+
+// detectRenames pairs removed files with added ones, exact copies first and
+// then, where there are few enough files, those sharing at least
+// renameSimilarity of their lines. Each pair is removed key, added key.
+func detectRenames(removed, added []string, before, after map[string]*FileJob) [][2]string {
+	var pairs [][2]string
+	usedAdded := map[string]bool{}
+	usedRemoved := map[string]bool{}
+
+	byContent := map[uint64][]string{}
+	for _, key := range added {
+		c := contentDigest(after[key].lineDigests)
+		byContent[c] = append(byContent[c], key)
+	}
+	for _, key := range removed {
+		c := contentDigest(before[key].lineDigests)
+		candidates := byContent[c]
+		if len(candidates) == 0 {
+			continue
+		}
+		// Prefer the copy with the same file name, otherwise the first
+		pick := 0
+		for i, c := range candidates {
+			if path.Base(c) == path.Base(key) {
+				pick = i
+				break
+			}
+		}
+		pairs = append(pairs, [2]string{key, candidates[pick]})
+		usedRemoved[key] = true
+		usedAdded[candidates[pick]] = true
+		byContent[c] = append(candidates[:pick:pick], candidates[pick+1:]...)
+	}
+
+	var restRemoved, restAdded []string
+	for _, key := range removed {
+		if !usedRemoved[key] {
+			restRemoved = append(restRemoved, key)
+		}
+	}
+	for _, key := range added {
+		if !usedAdded[key] {
+			restAdded = append(restAdded, key)
+		}
+	}
+	if len(restRemoved) == 0 || len(restAdded) == 0 || len(restRemoved) > renameLimit || len(restAdded) > renameLimit {
+		return pairs
+	}
+
+	type candidate struct {
+		removed, added string
+		score          float64
+	}
+	addedLines := make(map[string]map[uint64]int, len(restAdded))
+	for _, key := range restAdded {
+		addedLines[key] = lineCounts(after[key].lineDigests)
+	}
+
+	var candidates []candidate
+	for _, r := range restRemoved {
+		removedLines := lineCounts(before[r].lineDigests)
+		for _, a := range restAdded {
+			if before[r].Language != after[a].Language {
+				continue
+			}
+			if score := lineSimilarity(removedLines, addedLines[a]); score >= renameSimilarity {
+				candidates = append(candidates, candidate{removed: r, added: a, score: score})
+			}
+		}
+	}
+
+	// Most similar pairs first so each file goes to its best match
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	for _, c := range candidates {
+		if usedRemoved[c.removed] || usedAdded[c.added] {
+			continue
+		}
+		usedRemoved[c.removed] = true
+		usedAdded[c.added] = true
+		pairs = append(pairs, [2]string{c.removed, c.added})
+	}
+	return pairs
+}
+
+// lineDigests hashes each line of content
+func lineDigests(content []byte) []uint64 {
+	var out []uint64
+	for line := range bytes.SplitSeq(content, []byte("\n")) {
+		h := fnv.New64a()
+		_, _ = h.Write(line)
+		out = append(out, h.Sum64())
+	}
+	return out
+}
+
+// contentDigest folds the line digests of a file into one hash of its content
+func contentDigest(lines []uint64) uint64 {
+	h := fnv.New64a()
+	var b [8]byte
+	for _, l := range lines {
+		binary.LittleEndian.PutUint64(b[:], l)
+		_, _ = h.Write(b[:])
+	}
+	return h.Sum64()
+}
+
+// lineCounts returns how many times each line, by digest, appears in a file
+func lineCounts(lines []uint64) map[uint64]int {
+	counts := make(map[uint64]int, len(lines))
+	for _, l := range lines {
+		counts[l]++
+	}
+	return counts
+}
+
+// lineSimilarity is the share of lines two files have in common
+func lineSimilarity(a, b map[uint64]int) float64 {
+	common, total := 0, 0
+	for h, n := range a {
+		common += min(n, b[h])
+		total += n
+	}
+	for _, n := range b {
+		total += n
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(2*common) / float64(total)
+}
+
+// runDiffReport is the dispatch entry point called from Process() when
+// --diff-against is set
+func runDiffReport(ctx context.Context) error {
+	result, err := computeDelta(ctx, optionsFromGlobals(), DiffAgainst)
+	if err != nil {
+		return err
+	}
+	out, err := renderDelta(result)
+	if err != nil {
+		return err
+	}
+	if FileOutput == "" {
+		fmt.Print(out)
+	} else {
+		if err := os.WriteFile(FileOutput, []byte(out), 0644); err != nil {
+			return err
+		}
+		fmt.Println("results written to " + FileOutput)
+	}
+	return nil
+}
+
+func renderDelta(d *deltaResult) (string, error) {
+	switch strings.ToLower(Format) {
+	case "", "tabular", "wide":
+		return renderDeltaTabular(d), nil
+	case "csv":
+		return renderDeltaCSV(d)
+	case "json":
+		return renderDeltaJSON(d)
+	case "markdown", "md":
+		return renderDeltaMarkdown(d), nil
+	default:
+		return "", fmt.Errorf("unsupported --format %q for --diff-against (supported: tabular, csv, json, markdown)", Format)
+	}
+}
+
+// signedCount renders n with its sign and thousands separators, 0 unsigned
+func signedCount(p *gmessage.Printer, n int64) string {
+	if n > 0 {
+		return "+" + formatWithCommas(p, n)
+	}
+	return formatWithCommas(p, n)
+}
+
+// deltaStatusLetter is the one letter status git diff --name-status uses
+func deltaStatusLetter(status string) string {
+	switch status {
+	case deltaAdded:
+		return "A"
+	case deltaRemoved:
+		return "D"
+	case deltaRenamed:
+		return "R"
+	}
+	return "M"
+}
+
+func (f fileDelta) name() string {
+	if f.Status == deltaRenamed {
+		return f.Previous + " → " + f.Location
+	}
+	return f.Location
+}
+
+// Tabular column formats.
+//
+//	%-20s %7s %9s %9s %8s %10s %9s
+//	20 + 1 + 7 + 1 + 9 + 1 + 9 + 1 + 8 + 1 + 10 + 1 + 9 = 78
+//	%1s %-26s %9s %9s %8s %10s %9s
+//	1 + 1 + 26 + 1 + 9 + 1 + 9 + 1 + 8 + 1 + 10 + 1 + 9 = 78
+var tabularDeltaLanguageFormat = "%-20s %7s %9s %9s %8s %10s %9s\n"
+var tabularDeltaFileFormat = "%1s %-26s %9s %9s %8s %10s %9s\n"
+
+func renderDeltaTabular(d *deltaResult) string {
+	brk := tabularBreakFor(false)
+	printer := gmessage.NewPrinter(glanguage.Make(os.Getenv("LANG")))
+
+	var sb strings.Builder
+	sb.WriteString(brk)
+	sb.WriteString("Delta · " + d.Against + " → current\n")
+	sb.WriteString(brk)
+
+	_, _ = fmt.Fprintf(&sb, tabularDeltaLanguageFormat, "Language", "Files", "Code", "Comment", "Blank", "Complexity", "ULOC")
+	sb.WriteString(brk)
+	row := func(name string, c deltaCounts) {
+		_, _ = fmt.Fprintf(&sb, tabularDeltaLanguageFormat,
+			trimNameShort(LanguageSummary{Name: name}, name),
+			signedCount(printer, c.Files), signedCount(printer, c.Code), signedCount(printer, c.Comment),
+			signedCount(printer, c.Blank), signedCount(printer, c.Complexity), signedCount(printer, c.ULOC))
+	}
+	for _, l := range d.Languages {
+		row(l.Name, l.After.sub(l.Before))
+	}
+	sb.WriteString(brk)
+	row("Total", d.Total.After.sub(d.Total.Before))
+	sb.WriteString(brk)
+
+	if len(d.Files) == 0 {
+		return sb.String()
+	}
+
+	_, _ = fmt.Fprintf(&sb, tabularDeltaFileFormat, "", "File", "Code", "Comment", "Blank", "Complexity", "ULOC")
+	sb.WriteString(brk)
+	counts := map[string]int{}
+	for _, f := range d.Files {
+		counts[f.Status]++
+		c := f.After.sub(f.Before)
+		fileCol := unicodeAwareRightPad(unicodeAwareTrim(f.name(), 25), 26)
+		_, _ = fmt.Fprintf(&sb, tabularDeltaFileFormat, deltaStatusLetter(f.Status), fileCol,
+			signedCount(printer, c.Code), signedCount(printer, c.Comment), signedCount(printer, c.Blank),
+			signedCount(printer, c.Complexity), signedCount(printer, c.ULOC))
+	}
+	sb.WriteString(brk)
+	_, _ = fmt.Fprintf(&sb, "%d added · %d removed · %d modified · %d renamed\n",
+		counts[deltaAdded], counts[deltaRemoved], counts[deltaModified], counts[deltaRenamed])
+	sb.WriteString(brk)
+	return sb.String()
+}
+
+func renderDeltaMarkdown(d *deltaResult) string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "### Delta against `%s`\n\n", d.Against)
+
+	sb.WriteString("| Language | Files | Code | Comment | Blank | Complexity | ULOC |\n")
+	sb.WriteString("|:---|---:|---:|---:|---:|---:|---:|\n")
+	row := func(name string, c deltaCounts) {
+		_, _ = fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s | %s |\n", markdownEscape(name),
+			signedPlain(c.Files), signedPlain(c.Code), signedPlain(c.Comment),
+			signedPlain(c.Blank), signedPlain(c.Complexity), signedPlain(c.ULOC))
+	}
+	for _, l := range d.Languages {
+		row(l.Name, l.After.sub(l.Before))
+	}
+	row("**Total**", d.Total.After.sub(d.Total.Before))
+
+	if len(d.Files) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("\n| Status | File | Language | Code | Comment | Blank | Complexity | ULOC |\n")
+	sb.WriteString("|:---|:---|:---|---:|---:|---:|---:|---:|\n")
+	for _, f := range d.Files {
+		c := f.After.sub(f.Before)
+		_, _ = fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s | %s | %s |\n",
+			f.Status, markdownEscape(f.name()), markdownEscape(f.Language),
+			signedPlain(c.Code), signedPlain(c.Comment), signedPlain(c.Blank),
+			signedPlain(c.Complexity), signedPlain(c.ULOC))
+	}
+	return sb.String()
+}
+
+// signedPlain is signedCount without locale formatting, for machine and
+// markdown output
+func signedPlain(n int64) string {
+	if n > 0 {
+		return fmt.Sprintf("+%d", n)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// markdownEscape keeps pipes in names from breaking a markdown table
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func renderDeltaCSV(d *deltaResult) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	_ = w.Write([]string{
+		"Type", "Status", "Name", "Previous", "Language",
+		"Files", "Code", "Comment", "Blank", "Complexity", "ULOC",
+	})
+
+	record := func(kind, status, name, previous, language string, c deltaCounts) {
+		_ = w.Write([]string{
+			kind, status, name, previous, language,
+			fmt.Sprintf("%d", c.Files),
+			fmt.Sprintf("%d", c.Code),
+			fmt.Sprintf("%d", c.Comment),
+			fmt.Sprintf("%d", c.Blank),
+			fmt.Sprintf("%d", c.Complexity),
+			fmt.Sprintf("%d", c.ULOC),
+		})
+	}
+	for _, l := range d.Languages {
+		record("language", "", l.Name, "", l.Name, l.After.sub(l.Before))
+	}
+	record("total", "", "Total", "", "", d.Total.After.sub(d.Total.Before))
+	for _, f := range d.Files {
+		record("file", f.Status, f.Location, f.Previous, f.Language, f.After.sub(f.Before))
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+type deltaJSONLanguage struct {
+	Name   string      `json:"name"`
+	Delta  deltaCounts `json:"delta"`
+	Before deltaCounts `json:"before"`
+	After  deltaCounts `json:"after"`
+}
+
+type deltaJSONFile struct {
+	Status   string      `json:"status"`
+	File     string      `json:"file"`
+	Previous string      `json:"previous,omitempty"`
+	Language string      `json:"language"`
+	Delta    deltaCounts `json:"delta"`
+	Before   deltaCounts `json:"before"`
+	After    deltaCounts `json:"after"`
+}
+
+type deltaJSONDoc struct {
+	Report    string              `json:"report"`
+	Against   string              `json:"against"`
+	Languages []deltaJSONLanguage `json:"languages"`
+	Total     deltaJSONLanguage   `json:"total"`
+	Files     []deltaJSONFile     `json:"files"`
+}
+
+func renderDeltaJSON(d *deltaResult) (string, error) {
+	doc := deltaJSONDoc{
+		Report:    "delta",
+		Against:   d.Against,
+		Languages: make([]deltaJSONLanguage, 0, len(d.Languages)),
+		Total:     deltaJSONLanguage{Name: "Total", Delta: d.Total.After.sub(d.Total.Before), Before: d.Total.Before, After: d.Total.After},
+		Files:     make([]deltaJSONFile, 0, len(d.Files)),
+	}
+	for _, l := range d.Languages {
+		doc.Languages = append(doc.Languages, deltaJSONLanguage{Name: l.Name, Delta: l.After.sub(l.Before), Before: l.Before, After: l.After})
+	}
+	for _, f := range d.Files {
+		doc.Files = append(doc.Files, deltaJSONFile{
+			Status:   f.Status,
+			File:     f.Location,
+			Previous: f.Previous,
+			Language: f.Language,
+			Delta:    f.After.sub(f.Before),
+			Before:   f.Before,
+			After:    f.After,
+		})
+	}
+	b, err := jsoniter.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const deltaLongGo = "package long\n\nfunc A() {}\nfunc B() {}\nfunc C() {}\nfunc D() {}\nfunc E() {}\nfunc F() {}\n"
+
+func deltaFixture(t *testing.T) *deltaResult {
+	t.Helper()
+	ProcessConstants()
+	before := writeTree(t, map[string]string{
+		"same.go":     "package same\n",
+		"edit.go":     "package edit\n\nfunc main() {\n}\n",
+		"gone.py":     "x = 1\n",
+		"old/move.go": "package move\n\n// moved as is\nfunc M() {}\n",
+		"long.go":     deltaLongGo,
+	})
+	after := writeTree(t, map[string]string{
+		"same.go":     "package same\n",
+		"edit.go":     "package edit\n\n// main entry\nfunc main() {\n\tif true {\n\t}\n}\n",
+		"new.py":      "def f():\n    return 1\n",
+		"new/move.go": "package move\n\n// moved as is\nfunc M() {}\n",
+		"renamed.go":  deltaLongGo + "func G() {}\n",
+	})
+
+	opts := DefaultOptions()
+	opts.Paths = []string{after}
+	result, err := computeDelta(context.Background(), opts, before)
+	if err != nil {
+		t.Fatalf("computeDelta: %v", err)
+	}
+	return result
+}
+
+func TestComputeDelta(t *testing.T) {
+	result := deltaFixture(t)
+
+	got := map[string]fileDelta{}
+	for _, f := range result.Files {
+		got[f.Location] = f
+	}
+	if len(got) != 5 {
+		t.Fatalf("expected 5 changed files got %+v", result.Files)
+	}
+
+	if f := got["edit.go"]; f.Status != deltaModified || f.After.Code-f.Before.Code != 2 || f.After.Comment-f.Before.Comment != 1 || f.After.Complexity-f.Before.Complexity != 1 {
+		t.Errorf("unexpected edit.go delta %+v", f)
+	}
+	if f := got["new.py"]; f.Status != deltaAdded || f.Before.Files != 0 || f.After.Code != 2 {
+		t.Errorf("unexpected new.py delta %+v", f)
+	}
+	if f := got["gone.py"]; f.Status != deltaRemoved || f.After.Files != 0 || f.Before.Code != 1 {
+		t.Errorf("unexpected gone.py delta %+v", f)
+	}
+	if f := got["new/move.go"]; f.Status != deltaRenamed || f.Previous != "old/move.go" || f.After != f.Before {
+		t.Errorf("unexpected new/move.go delta %+v", f)
+	}
+	if f := got["renamed.go"]; f.Status != deltaRenamed || f.Previous != "long.go" || f.After.Code-f.Before.Code != 1 {
+		t.Errorf("unexpected renamed.go delta %+v", f)
+	}
+
+	total := result.Total.After.sub(result.Total.Before)
+	if total.Files != 0 || total.Code != 4 {
+		t.Errorf("expected 0 files and +4 code in total got %+v", total)
+	}
+	for _, l := range result.Languages {
+		if l.Name == "Python" && l.After.sub(l.Before).Code != 1 {
+			t.Errorf("expected +1 Python code got %+v", l)
+		}
+	}
+}
+
+func TestComputeDeltaRevision(t *testing.T) {
+	dir := makeFixtureRepo(t, []map[string]string{
+		{"a.go": "package a\n", "b.go": "package b\n"},
+		{"a.go": "package a\n\nfunc A() {}\n", "c.go": "package c\n"},
+	})
+	// b.go is carried over unchanged so only a.go and c.go are reported
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	opts.Rev = "HEAD"
+
+	result, err := computeDelta(context.Background(), opts, "HEAD~1")
+	if err != nil {
+		t.Fatalf("computeDelta: %v", err)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("expected a.go modified and c.go added got %+v", result.Files)
+	}
+	if result.Files[0].Location != "a.go" || result.Files[0].Status != deltaModified {
+		t.Errorf("unexpected %+v", result.Files[0])
+	}
+	if result.Files[1].Location != "c.go" || result.Files[1].Status != deltaAdded {
+		t.Errorf("unexpected %+v", result.Files[1])
+	}
+
+	if _, err := computeDelta(context.Background(), opts, "no-such-ref"); err == nil {
+		t.Error("expected error for unknown revision")
+	}
+}
+
+func TestRenderDelta(t *testing.T) {
+	result := deltaFixture(t)
+	saved := Format
+	t.Cleanup(func() { Format = saved })
+
+	Format = "json"
+	out, err := renderDelta(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc deltaJSONDoc
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out)
+	}
+	if doc.Report != "delta" || len(doc.Files) != 5 || doc.Total.Delta.Code != 4 {
+		t.Errorf("unexpected json %s", out)
+	}
+
+	Format = "csv"
+	out, err = renderDelta(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	// header, the languages, total and the files
+	if len(records) != 1+len(result.Languages)+1+5 {
+		t.Errorf("unexpected csv rows %d\n%s", len(records), out)
+	}
+
+	Format = "markdown"
+	out, err = renderDelta(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "| renamed | old/move.go → new/move.go | Go | 0 |") {
+		t.Errorf("unexpected markdown\n%s", out)
+	}
+
+	Format = "tabular"
+	out, err = renderDelta(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "1 added · 1 removed · 1 modified · 2 renamed") {
+		t.Errorf("unexpected tabular\n%s", out)
+	}
+
+	Format = "html"
+	if _, err := renderDelta(result); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -18,9 +18,16 @@ var (
 
 // DetectLanguage detects a language based on the filename returns the language extension and error
 func DetectLanguage(name string) ([]string, string) {
+	return detectLanguage(name, len(AllowListExtensions) != 0)
+}
+
+// detectLanguage is DetectLanguage with the extension allow list state passed
+// in. When an allow list is active only extensions are considered, so files
+// matched by full name or shebang cannot slip past it.
+func detectLanguage(name string, allowList bool) ([]string, string) {
 	extension := ""
 
-	if len(AllowListExtensions) == 0 {
+	if !allowList {
 		// Check the full name for special languages such as xmake.lua, meson.build, ...
 		lang, ok := FilenameToLanguage[strings.ToLower(name)]
 		if ok {
@@ -189,14 +196,21 @@ func anchoredContains(content, lit []byte) bool {
 	}
 }
 
-func guessByHeuristics(filename string, possibleLanguages []string, toCheck []byte) (string, bool) {
+// sharedLanguageFeature returns the features for name from the shared
+// LanguageFeatures map
+func sharedLanguageFeature(name string) LanguageFeature {
+	LanguageFeaturesMutex.Lock()
+	langFeatures := LanguageFeatures[name]
+	LanguageFeaturesMutex.Unlock()
+	return langFeatures
+}
+
+func guessByHeuristics(filename string, possibleLanguages []string, toCheck []byte, features func(string) LanguageFeature) (string, bool) {
 	guesses := make([]languageGuess, 0, len(possibleLanguages))
 	hasHeuristics := false
 
 	for _, lan := range possibleLanguages {
-		LanguageFeaturesMutex.Lock()
-		langFeatures := LanguageFeatures[lan]
-		LanguageFeaturesMutex.Unlock()
+		langFeatures := features(lan)
 
 		if len(langFeatures.Heuristics) == 0 {
 			continue
@@ -239,7 +253,18 @@ func guessByHeuristics(filename string, possibleLanguages []string, toCheck []by
 
 // DetermineLanguage given a filename, fallback language, possible languages and content make a guess to the type.
 // If multiple possible it will guess based on keywords similar to how https://github.com/vmchale/polyglot does
+// A Vim or Emacs modeline naming one of the possible languages wins, and names the language of a file with no
+// extension and no #! line, or an extension scc does not know, outright.
+// When the heuristics and keywords leave it open the statistical classifier picks among the candidates it has
+// samples for, unless DisableClassifier is set.
 func DetermineLanguage(filename string, fallbackLanguage string, possibleLanguages []string, content []byte) string {
+	return determineLanguage(filename, fallbackLanguage, possibleLanguages, content, sharedLanguageFeature, !DisableClassifier)
+}
+
+// determineLanguage is DetermineLanguage reading heuristics and keywords
+// through features rather than the shared LanguageFeatures map, consulting
+// the statistical classifier when classify is set.
+func determineLanguage(filename string, fallbackLanguage string, possibleLanguages []string, content []byte, features func(string) LanguageFeature, classify bool) string {
 	// If being called through an API it's possible nothing is set here and as
 	// such should just return as the Language value should have already been set
 	if len(possibleLanguages) == 0 {
@@ -250,11 +275,25 @@ func DetermineLanguage(filename string, fallbackLanguage string, possibleLanguag
 	// in which case we set it and return
 	// or we have multiple in which case we try to determine it heuristically
 	if len(possibleLanguages) == 1 {
-		return possibleLanguages[0]
+		// Files with no extension or an unknown one can still say what they
+		// are in a modeline, though a #! line is left to DetectSheBang
+		only := possibleLanguages[0]
+		if (only == SheBang && !bytes.HasPrefix(content, []byte("#!"))) || only == UnknownLanguage {
+			if lang, _, ok := detectModeline(content); ok {
+				printWarnF("detected modeline %s for %s", lang, filename)
+				return lang
+			}
+		}
+		return only
 	}
 
 	startTime := makeTimestampNano()
 
+	if lang, _, ok := modelineChoice(content, possibleLanguages); ok {
+		printWarnF("detected modeline %s for %s", lang, filename)
+		return lang
+	}
+
 	toCheck := content
 	if len(content) > 20_000 {
 		toCheck = content[:20_000]
@@ -264,18 +303,43 @@ func DetermineLanguage(filename string, fallbackLanguage string, possibleLanguag
 	// such as .h between C / C++ / Objective-C.
 	// Based on how linguist does it https://github.com/github-linguist/linguist/
 	// which should be fine as its under MIT license
-	if lang, ok := guessByHeuristics(filename, possibleLanguages, toCheck); ok {
+	if lang, ok := guessByHeuristics(filename, possibleLanguages, toCheck, features); ok {
 		printTraceF("nanoseconds to guess language: %s: %d", filename, makeTimestampNano()-startTime)
 		return lang
 	}
 
+	lang, decided := guessByKeywords(possibleLanguages, toCheck, features)
+
+	// Only when the keywords leave it open, which for many shared extensions
+	// means falling back to a candidate without keywords or the first one
+	if !decided && classify {
+		if classified, _, ok := classifyContent(possibleLanguages, toCheck); ok {
+			printWarnF("guessing language %s for file %s via the classifier", classified, filename)
+			printTraceF("nanoseconds to guess language: %s: %d", filename, makeTimestampNano()-startTime)
+			return classified
+		}
+	}
+
+	printWarnF("guessing language %s for file %s", lang, filename)
+	printTraceF("nanoseconds to guess language: %s: %d", filename, makeTimestampNano()-startTime)
+
+	if lang != "" {
+		return lang
+	}
+
+	return fallbackLanguage
+}
+
+// guessByKeywords picks among the candidates without heuristics by how many of
+// their keywords toCheck holds. It reports false when that settles nothing:
+// no keyword was found, the most found is shared, or too few were found to
+// beat the candidate without keywords, which is then returned as the fallback.
+func guessByKeywords(possibleLanguages []string, toCheck []byte, features func(string) LanguageFeature) (string, bool) {
 	primary := ""
 
 	toSort := make([]languageGuess, 0, len(possibleLanguages))
 	for _, lan := range possibleLanguages {
-		LanguageFeaturesMutex.Lock()
-		langFeatures := LanguageFeatures[lan]
-		LanguageFeaturesMutex.Unlock()
+		langFeatures := features(lan)
 
 		// We only do language checks if no heuristics exist
 		if len(langFeatures.Heuristics) != 0 {
@@ -318,16 +382,13 @@ func DetermineLanguage(filename string, fallbackLanguage string, possibleLanguag
 		// OK at this point we have a primary, which means we want 3 or more matches to count as something else
 		if toSort[0].Count < 3 {
 			// we didn't find enough results, so lets return the primary in this case
-			return primary
+			return primary, false
 		}
 	}
 
-	printWarnF("guessing language %s for file %s", toSort[0].Name, filename)
-	printTraceF("nanoseconds to guess language: %s: %d", filename, makeTimestampNano()-startTime)
-
-	if len(toSort) != 0 {
-		return toSort[0].Name
+	if len(toSort) == 0 {
+		return "", false
 	}
-
-	return fallbackLanguage
+	decided := toSort[0].Count != 0 && (len(toSort) == 1 || toSort[1].Count != toSort[0].Count)
+	return toSort[0].Name, decided
 }
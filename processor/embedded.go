@@ -0,0 +1,411 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bytes"
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// The ways --embedded reports the code found embedded in another language
+const (
+	// embeddedNested keeps the counts of the host file and breaks them down
+	// into rows for each embedded language under the host language
+	embeddedNested = "nested"
+	// embeddedLanguage takes the embedded lines out of the host file and
+	// counts them under the language they are written in
+	embeddedLanguage = "language"
+)
+
+// embeddedAliases maps the names fences, lang attributes and heredoc tags
+// commonly use which are not the name of the scc language they stand for
+var embeddedAliases = map[string]string{
+	"golang": "Go",
+	"js":     "JavaScript",
+	"md":     "Markdown",
+	"mysql":  "SQL",
+	"pgsql":  "SQL",
+	"psql":   "SQL",
+	"py":     "Python",
+	"rb":     "Ruby",
+	"sh":     "Shell",
+	"ts":     "TypeScript",
+	"yml":    "YAML",
+}
+
+// embeddedHeredocHosts are the languages whose heredocs are checked for a tag
+// naming a language, such as <<~SQL or <<'PYTHON'
+var embeddedHeredocHosts = []string{"BASH", "Shell", "Zsh", "Ruby", "Perl", "PHP"}
+
+// embeddedRegion is the run of whole lines of a file written in another
+// language, from the line after the one opening it to the line closing it
+type embeddedRegion struct {
+	language string
+	start    int // offset of the first line
+	end      int // offset just past the last line
+}
+
+// EmbeddedCount is what the code of one language embedded in a file, or in
+// the files of a language, counts to. Count is the number of regions.
+type EmbeddedCount struct {
+	Language string
+	CodeSplit
+}
+
+var (
+	htmlBlockRegex     = regexp.MustCompile(`(?i)<(script|style|template)(\s[^>]*)?>`)
+	htmlAttributeRegex = regexp.MustCompile(`(?i)\b(lang|type)\s*=\s*["']?([^"'\s>]+)`)
+	heredocRegex       = regexp.MustCompile(`<<<?[-~]?\s*(['"]?)([A-Za-z_][A-Za-z0-9_]*)['"]?`)
+)
+
+// findEmbeddedRegions returns the regions of content, a file in language
+// host, written in another language: Markdown code fences with an info
+// string, HTML script and style elements, the template, script and style
+// blocks of Vue and Svelte components and heredocs whose tag names a
+// language. Regions naming a language scc does not know are left to the
+// host.
+func findEmbeddedRegions(host string, content []byte) []embeddedRegion {
+	var regions []embeddedRegion
+	switch {
+	case host == "Markdown":
+		regions = markdownFences(content)
+	case host == "HTML" || host == "Svelte":
+		regions = htmlBlocks(content, false)
+	case host == "Vue":
+		regions = htmlBlocks(content, true)
+	case slices.Contains(embeddedHeredocHosts, host):
+		regions = heredocs(content)
+	}
+	return slices.DeleteFunc(regions, func(r embeddedRegion) bool {
+		return r.language == host || r.end <= r.start
+	})
+}
+
+// embeddedLanguageNamed resolves the language a fence info string or lang
+// attribute names, by alias, name or extension. Heredoc tags are resolved
+// without extensions as EOF, DOC or DATA would otherwise name one.
+func embeddedLanguageNamed(name string, extensions bool) (string, bool) {
+	name = strings.ToLower(strings.TrimPrefix(strings.Trim(name, "{}.,"), "language-"))
+	if language, ok := embeddedAliases[name]; ok {
+		return language, true
+	}
+	if extensions {
+		return modelineLanguage(name)
+	}
+	for language := range languageDatabase {
+		if strings.EqualFold(language, name) {
+			return language, true
+		}
+	}
+	return "", false
+}
+
+// lineStarts returns the offset each line of content starts at, followed by
+// the length of content
+func lineStarts(content []byte) []int {
+	starts := []int{0}
+	for i, b := range content {
+		if b == '\n' && i+1 < len(content) {
+			starts = append(starts, i+1)
+		}
+	}
+	return append(starts, len(content))
+}
+
+// markdownFences finds the ``` and ~~~ code fences whose info string names a
+// language. A fence left open runs to the end of the file as in CommonMark.
+func markdownFences(content []byte) []embeddedRegion {
+	var regions []embeddedRegion
+	starts := lineStarts(content)
+	line := func(i int) string {
+		return strings.TrimRight(string(content[starts[i]:starts[i+1]]), "\r\n")
+	}
+
+	for i := 0; i < len(starts)-1; i++ {
+		text := line(i)
+		trimmed := strings.TrimLeft(text, " ")
+		if len(text)-len(trimmed) > 3 || len(trimmed) < 3 || (trimmed[0] != '`' && trimmed[0] != '~') {
+			continue
+		}
+		marker := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+		info := trimmed[len(marker):]
+		if len(marker) < 3 || (marker[0] == '`' && strings.Contains(info, "`")) {
+			continue
+		}
+
+		end := len(starts) - 1
+		for j := i + 1; j < len(starts)-1; j++ {
+			closing := strings.TrimLeft(line(j), " ")
+			if len(line(j))-len(closing) <= 3 && strings.HasPrefix(closing, marker) && strings.Trim(closing, marker[:1]+" \t") == "" {
+				end = j
+				break
+			}
+		}
+
+		if fields := strings.Fields(info); len(fields) != 0 {
+			if language, ok := embeddedLanguageNamed(fields[0], true); ok {
+				regions = append(regions, embeddedRegion{language: language, start: starts[i+1], end: starts[end]})
+			}
+		}
+		i = end
+	}
+	return regions
+}
+
+// htmlBlocks finds script and style elements, and with sfc the template block
+// of a single file component, using their lang or type attribute for the
+// language. A Vue template holds nested template elements so it runs to the
+// last closing tag.
+func htmlBlocks(content []byte, sfc bool) []embeddedRegion {
+	var regions []embeddedRegion
+	// Lowered by hand so the offsets stay those of content
+	lower := make([]byte, len(content))
+	for i, c := range content {
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+
+	for offset := 0; offset < len(content); {
+		match := htmlBlockRegex.FindSubmatchIndex(content[offset:])
+		if match == nil {
+			break
+		}
+		tag := strings.ToLower(string(content[offset+match[2] : offset+match[3]]))
+		var attributes []byte
+		if match[4] != -1 {
+			attributes = content[offset+match[4] : offset+match[5]]
+		}
+		bodyStart := offset + match[1]
+		if tag == "template" && !sfc {
+			offset = bodyStart
+			continue
+		}
+
+		closing := []byte("</" + tag)
+		var bodyEnd int
+		if tag == "template" {
+			bodyEnd = bytes.LastIndex(lower, closing)
+		} else if i := bytes.Index(lower[bodyStart:], closing); i != -1 {
+			bodyEnd = bodyStart + i
+		} else {
+			bodyEnd = -1
+		}
+		if bodyEnd < bodyStart {
+			break
+		}
+		offset = bodyEnd + len(closing)
+
+		language, ok := htmlBlockLanguage(tag, attributes)
+		if !ok {
+			continue
+		}
+
+		// Only the whole lines between the opening and closing tags
+		start := bytes.IndexByte(content[bodyStart:bodyEnd], '\n')
+		if start == -1 {
+			continue
+		}
+		end := bytes.LastIndexByte(content[:bodyEnd], '\n') + 1
+		regions = append(regions, embeddedRegion{language: language, start: bodyStart + start + 1, end: end})
+	}
+	return regions
+}
+
+// htmlBlockLanguage is the language of a script, style or template block
+func htmlBlockLanguage(tag string, attributes []byte) (string, bool) {
+	kind, value := "", ""
+	for _, attribute := range htmlAttributeRegex.FindAllSubmatch(attributes, -1) {
+		kind, value = strings.ToLower(string(attribute[1])), strings.ToLower(string(attribute[2]))
+		if kind == "lang" {
+			break
+		}
+	}
+
+	if kind == "lang" {
+		return embeddedLanguageNamed(value, true)
+	}
+	switch tag {
+	case "script":
+		switch value {
+		case "", "module", "text/javascript", "application/javascript", "text/babel":
+			return "JavaScript", true
+		case "application/json", "application/ld+json", "importmap":
+			return "JSON", true
+		case "text/typescript", "application/typescript":
+			return "TypeScript", true
+		}
+		// Templates and other data kept in script elements
+		return "", false
+	case "style":
+		return "CSS", true
+	}
+	return "HTML", true
+}
+
+// heredocs finds heredocs whose tag names a language, running from the line
+// after the one starting them to the line holding only the tag, which may be
+// indented and for PHP followed by punctuation
+func heredocs(content []byte) []embeddedRegion {
+	var regions []embeddedRegion
+	starts := lineStarts(content)
+
+	for i := 0; i < len(starts)-1; i++ {
+		match := heredocRegex.FindSubmatch(content[starts[i]:starts[i+1]])
+		if match == nil {
+			continue
+		}
+		language, ok := embeddedLanguageNamed(string(match[2]), false)
+		if !ok {
+			continue
+		}
+		tag := string(match[2])
+		for j := i + 1; j < len(starts)-1; j++ {
+			text := strings.TrimSpace(string(content[starts[j]:starts[j+1]]))
+			if text == tag || (strings.HasPrefix(text, tag) && strings.Trim(text[len(tag):], ";,) ") == "") {
+				regions = append(regions, embeddedRegion{language: language, start: starts[i+1], end: starts[j]})
+				i = j
+				break
+			}
+		}
+	}
+	return regions
+}
+
+// countEmbedded counts the regions of job, a file in host, written in other
+// languages into job.Embedded. With mode embeddedLanguage their lines are
+// taken out of the counts of job.
+func (ctx *processorContext) countEmbedded(job *FileJob, host, mode string) {
+	regions := findEmbeddedRegions(host, job.Content)
+	if len(regions) == 0 {
+		return
+	}
+
+	var embedded []EmbeddedCount
+	for _, region := range regions {
+		ctx.loadLanguageFeature(region.language)
+		part := ctx.countEmbeddedContent(region.language, job.Content[region.start:region.end])
+		part.Count = 1
+		embedded = addEmbedded(embedded, []EmbeddedCount{{Language: region.language, CodeSplit: part}})
+	}
+	job.Embedded = embedded
+
+	if mode != embeddedLanguage {
+		return
+	}
+	var rest []byte
+	offset := 0
+	for _, region := range regions {
+		rest = append(rest, job.Content[offset:region.start]...)
+		offset = region.end
+	}
+	rest = append(rest, job.Content[offset:]...)
+	remaining := ctx.countEmbeddedContent(host, rest)
+	job.Bytes, job.Lines, job.Code, job.Comment, job.Blank = remaining.Bytes, remaining.Lines, remaining.Code, remaining.Comment, remaining.Blank
+	job.Complexity, job.Cognitive = remaining.Complexity, remaining.Cognitive
+}
+
+// countEmbeddedContent counts content as a file in language on its own
+func (ctx *processorContext) countEmbeddedContent(language string, content []byte) CodeSplit {
+	part := &FileJob{
+		Language: language,
+		Content:  content,
+		Bytes:    int64(len(content)),
+		settings: ctx.embeddedSettings,
+	}
+	CountStats(part)
+	return CodeSplit{
+		Lines:      part.Lines,
+		Code:       part.Code,
+		Comment:    part.Comment,
+		Blank:      part.Blank,
+		Complexity: part.Complexity,
+		Cognitive:  part.Cognitive,
+		Bytes:      part.Bytes,
+	}
+}
+
+// addEmbedded merges more into counts by language, keeping the languages
+// with the most code first
+func addEmbedded(counts, more []EmbeddedCount) []EmbeddedCount {
+	for _, m := range more {
+		i := slices.IndexFunc(counts, func(c EmbeddedCount) bool { return c.Language == m.Language })
+		if i == -1 {
+			counts = append(counts, m)
+			continue
+		}
+		c := &counts[i].CodeSplit
+		c.Count += m.Count
+		c.Lines += m.Lines
+		c.Code += m.Code
+		c.Comment += m.Comment
+		c.Blank += m.Blank
+		c.Complexity += m.Complexity
+		c.Cognitive += m.Cognitive
+		c.Bytes += m.Bytes
+	}
+	slices.SortFunc(counts, func(a, b EmbeddedCount) int {
+		if order := cmp.Compare(b.Code, a.Code); order != 0 {
+			return order
+		}
+		return strings.Compare(a.Language, b.Language)
+	})
+	return counts
+}
+
+// embeddedTotal is what all the code embedded in job counts to
+func embeddedTotal(job *FileJob) CodeSplit {
+	var total CodeSplit
+	for _, e := range job.Embedded {
+		total.Count += e.Count
+		total.Lines += e.Lines
+		total.Code += e.Code
+		total.Comment += e.Comment
+		total.Blank += e.Blank
+		total.Complexity += e.Complexity
+		total.Cognitive += e.Cognitive
+		total.Bytes += e.Bytes
+	}
+	return total
+}
+
+// foldEmbedded adds the code embedded in job to langs, either under the
+// language of job or, with mode embeddedLanguage, to the languages it is
+// written in. Those do not count the file, which remains one of its host.
+func foldEmbedded(langs map[string]LanguageSummary, job *FileJob, mode string) {
+	if len(job.Embedded) == 0 {
+		return
+	}
+	if mode != embeddedLanguage {
+		l := langs[job.Language]
+		l.Embedded = addEmbedded(slices.Clone(l.Embedded), job.Embedded)
+		langs[job.Language] = l
+		return
+	}
+	for _, e := range job.Embedded {
+		l := langs[e.Language]
+		l.Name = e.Language
+		l.Lines += e.Lines
+		l.Code += e.Code
+		l.Comment += e.Comment
+		l.Blank += e.Blank
+		l.Complexity += e.Complexity
+		l.Cognitive += e.Cognitive
+		l.Bytes += e.Bytes
+		langs[e.Language] = l
+	}
+}
+
+// embeddedRowName labels the row of code in language nested under its host,
+// trimmed to width runes like the language names are when width is set
+func embeddedRowName(language string, width int) string {
+	name := []rune("↳ " + language)
+	if width > 0 && len(name) > width {
+		return string(name[:width-1]) + "…"
+	}
+	return string(name)
+}
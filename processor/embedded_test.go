@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"strings"
+	"testing"
+)
+
+func embeddedLanguages(content string, regions []embeddedRegion) []string {
+	var got []string
+	for _, r := range regions {
+		got = append(got, r.language+":"+strings.TrimSpace(content[r.start:r.end]))
+	}
+	return got
+}
+
+func TestFindEmbeddedRegions(t *testing.T) {
+	ProcessConstants()
+
+	for _, tc := range []struct {
+		name     string
+		host     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "markdown fences",
+			host:     "Markdown",
+			content:  "# Title\n\n```go\npackage main\n```\n\n~~~ py\nx = 1\n~~~\n\n```\nplain\n```\n\n```nosuchlanguage\nx\n```\n",
+			expected: []string{"Go:package main", "Python:x = 1"},
+		},
+		{
+			name:     "markdown fence in a longer fence",
+			host:     "Markdown",
+			content:  "````markdown\n```go\nx\n```\n````\n",
+			expected: nil,
+		},
+		{
+			name:     "markdown unclosed fence",
+			host:     "Markdown",
+			content:  "```sh\necho a\necho b\n",
+			expected: []string{"Shell:echo a\necho b"},
+		},
+		{
+			name:     "html script and style",
+			host:     "HTML",
+			content:  "<html>\n<STYLE>\nbody {}\n</STYLE>\n<script type=\"text/javascript\">\nvar a;\n</script>\n<script type=\"text/template\">\n<b></b>\n</script>\n<script src=\"a.js\"></script>\n</html>\n",
+			expected: []string{"CSS:body {}", "JavaScript:var a;"},
+		},
+		{
+			name:     "vue component",
+			host:     "Vue",
+			content:  "<template>\n  <div>\n    <template v-if=\"a\"><b></b></template>\n  </div>\n</template>\n<script lang=\"ts\">\nlet a: number\n</script>\n<style lang=\"scss\" scoped>\na {}\n</style>\n",
+			expected: []string{"HTML:<div>\n    <template v-if=\"a\"><b></b></template>\n  </div>", "TypeScript:let a: number", "Sass:a {}"},
+		},
+		{
+			name:     "heredocs",
+			host:     "BASH",
+			content:  "cat <<EOF\nhello\nEOF\npsql <<-'SQL'\n\tSELECT 1;\n\tSQL\n",
+			expected: []string{"SQL:SELECT 1;"},
+		},
+		{
+			name:     "other hosts",
+			host:     "Go",
+			content:  "```go\nx\n```\n",
+			expected: nil,
+		},
+	} {
+		got := embeddedLanguages(tc.content, findEmbeddedRegions(tc.host, []byte(tc.content)))
+		if strings.Join(got, "|") != strings.Join(tc.expected, "|") {
+			t.Errorf("%s: expected %q got %q", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestEmbeddedRowName(t *testing.T) {
+	if got := embeddedRowName("Go", 15); got != "↳ Go" {
+		t.Errorf("expected ↳ Go got %s", got)
+	}
+	if got := embeddedRowName("Visual Basic for Applications", 15); got != "↳ Visual Basic…" {
+		t.Errorf("expected the name to be trimmed got %s", got)
+	}
+}
+
+func TestEmbeddedRun(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"README.md":  "# Title\n\n```go\npackage main\n\nfunc main() {\n\tif true {\n\t}\n}\n```\n",
+		"index.html": "<html>\n<script>\nvar a = 1;\n</script>\n</html>\n",
+	})
+	plain := runTree(t, dir, DefaultOptions())
+	if len(plain) != 2 || plain["Markdown"].Embedded != nil {
+		t.Fatalf("expected nothing embedded by default got %+v", plain)
+	}
+
+	opts := DefaultOptions()
+	opts.Embedded = embeddedNested
+	nested := runTree(t, dir, opts)
+	markdown := nested["Markdown"]
+	if markdown.Code != plain["Markdown"].Code || len(markdown.Embedded) != 1 {
+		t.Fatalf("expected the host counts to stay and Go nested got %+v", markdown)
+	}
+	if golang := markdown.Embedded[0]; golang.Language != "Go" || golang.Count != 1 || golang.Code != 5 || golang.Blank != 1 || golang.Complexity != 1 {
+		t.Errorf("unexpected embedded Go %+v", golang)
+	}
+	if html := nested["HTML"]; len(html.Embedded) != 1 || html.Embedded[0].Language != "JavaScript" {
+		t.Errorf("unexpected embedded HTML %+v", html.Embedded)
+	}
+
+	opts.Embedded = embeddedLanguage
+	moved := runTree(t, dir, opts)
+	if len(moved) != 4 || moved["Go"].Count != 0 || moved["Go"].Code != 5 || moved["Markdown"].Embedded != nil {
+		t.Fatalf("expected the embedded code under its own language got %+v", moved)
+	}
+	var lines int64
+	for _, l := range moved {
+		lines += l.Lines
+	}
+	if total := plain["Markdown"].Lines + plain["HTML"].Lines; lines != total {
+		t.Errorf("expected the %d lines to be kept got %d", total, lines)
+	}
+}
+
+func TestFileSummarizeEmbedded(t *testing.T) {
+	Embedded = embeddedNested
+	defer func() { Embedded = "" }()
+
+	jobs := func() chan *FileJob {
+		input := make(chan *FileJob, 2)
+		input <- &FileJob{Language: "Markdown", Location: "a.md", Filename: "a.md", Lines: 10, Code: 8, Blank: 2, Embedded: []EmbeddedCount{{Language: "Go", CodeSplit: CodeSplit{Count: 1, Lines: 4, Code: 4}}}}
+		input <- &FileJob{Language: "Markdown", Location: "b.md", Filename: "b.md", Lines: 6, Code: 6, Embedded: []EmbeddedCount{{Language: "Go", CodeSplit: CodeSplit{Count: 2, Lines: 3, Code: 3}}}}
+		close(input)
+		return input
+	}
+
+	for name, format := range map[string]func(chan *FileJob) string{"short": fileSummarizeShort, "wide": fileSummarizeLong} {
+		got := format(jobs())
+		if !strings.Contains(got, "↳ Go") || strings.Count(got, "↳") != 1 {
+			t.Errorf("%s: expected one nested Go row\n%s", name, got)
+		}
+		if !strings.Contains(got, " 3 ") || !strings.Contains(got, " 7 ") {
+			t.Errorf("%s: expected the Go rows of both files to add up\n%s", name, got)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// explainReadLimit is how much of a file the heuristics and keywords look at
+const explainReadLimit = 20_000
+
+// HeuristicTrace is one heuristic of a candidate language checked while
+// explaining a file
+type HeuristicTrace struct {
+	Language      string   `json:"language"`
+	Index         int      `json:"index"`
+	Pattern       string   `json:"pattern"`
+	Literals      []string `json:"literals"`
+	LiteralsFound []string `json:"literalsFound"`
+	Ran           bool     `json:"ran"` // false when no literal was found so the pattern was skipped
+	Matched       bool     `json:"matched"`
+}
+
+// KeywordTrace is the keyword count of a candidate language without
+// heuristics
+type KeywordTrace struct {
+	Language string   `json:"language"`
+	Found    []string `json:"found"`
+	Keywords int      `json:"keywords"`
+}
+
+// RemapTrace is a --remap-all or --remap-unknown rule that matched the file
+type RemapTrace struct {
+	Flag     string `json:"flag"`
+	Pattern  string `json:"pattern"`
+	Language string `json:"language"`
+}
+
+// ShebangTrace is the result of looking up the #! line of a file
+type ShebangTrace struct {
+	Line     string `json:"line"`
+	Language string `json:"language,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// LanguageExplanation walks through how scc classifies a file, step by step
+type LanguageExplanation struct {
+	File       string            `json:"file"`
+	Filename   string            `json:"filename"`
+	Extension  string            `json:"extension"`
+	MatchedBy  string            `json:"matchedBy"` // filename, extension, shebang, gitattributes, count-as-pattern, unsupported or none
+	Candidates []string          `json:"candidates"`
+	Attributes string            `json:"gitattributes,omitempty"` // the .gitattributes file setting linguist-language
+	CountRule  string            `json:"countRule,omitempty"`
+	Heuristics []HeuristicTrace  `json:"heuristics,omitempty"`
+	Keywords   []KeywordTrace    `json:"keywords,omitempty"`
+	Classifier []ClassifierScore `json:"classifier,omitempty"` // best first, when the keywords left the choice open
+	Fallback   string            `json:"fallback,omitempty"`
+	Modeline   string            `json:"modeline,omitempty"` // the Vim or Emacs modeline that named the language
+	Remaps     []RemapTrace      `json:"remaps,omitempty"`
+	Shebang    *ShebangTrace     `json:"shebang,omitempty"`
+	Language   string            `json:"language"` // empty when the file would be skipped
+	Reason     string            `json:"reason"`
+}
+
+// explainLanguage classifies the file at path the way a counting run with
+// opts would, recording each step. The decisions are made by the same
+// functions the run uses, so the result always matches what is counted.
+func explainLanguage(opts *Options, path string) (*LanguageExplanation, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory, not a file", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// All of it as a modeline can be on one of the last lines
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(path)
+	allowList := len(opts.IncludeExtensions) != 0
+	e := &LanguageExplanation{File: path, Filename: name}
+
+	candidates, extension := detectLanguage(name, allowList)
+	e.Extension = extension
+	_, byName := FilenameToLanguage[strings.ToLower(name)]
+	switch {
+	case len(candidates) == 0:
+		e.MatchedBy = "none"
+	case !allowList && byName:
+		e.MatchedBy = "filename"
+	case len(candidates) == 1 && candidates[0] == SheBang:
+		e.MatchedBy = "shebang"
+	default:
+		e.MatchedBy = "extension"
+	}
+
+	if !opts.NoGitAttributes {
+		if attrs := newGitAttributes(nil).lookup(path); attrs.language != "" {
+			candidates = []string{attrs.language}
+			e.MatchedBy = "gitattributes"
+			e.Attributes = attrs.languageFrom
+		}
+	}
+
+	for _, r := range compiledCountRules {
+		if r.re.MatchString(path) {
+			candidates = []string{r.name}
+			e.MatchedBy = "count-as-pattern"
+			e.Attributes = ""
+			e.CountRule = r.pattern
+			break
+		}
+	}
+
+	if len(candidates) == 0 {
+		if !opts.CountUnsupported {
+			e.Candidates = []string{}
+			e.Reason = "no language claims the filename or extension so the file is skipped"
+			return e, nil
+		}
+		candidates = []string{UnknownLanguage}
+		e.MatchedBy = "unsupported"
+	}
+	// The order only matters for display, determineLanguage sorts its guesses
+	e.Candidates = slices.Sorted(slices.Values(candidates))
+
+	ctx := &processorContext{remap: newRemapConfig(opts.RemapAll, opts.RemapUnknown)}
+	job := &FileJob{Location: path, Filename: name, Content: content}
+
+	// As in processFile --remap-unknown comes before anything in the file
+	remapped := false
+	if e.MatchedBy == "shebang" && len(ctx.remap.unknown) != 0 {
+		e.Remaps = append(e.Remaps, matchedRemaps("--remap-unknown", ctx.remap.unknown, content)...)
+		job.Language = SheBang
+		remapped = ctx.unknownRemapLanguage(job)
+		if remapped {
+			e.Reason = fmt.Sprintf("--remap-unknown rule %q matched", e.Remaps[len(e.Remaps)-1].Pattern)
+		}
+	}
+
+	if !remapped {
+		features := newFeatureCache(false)
+		job.Language = determineLanguage(name, "", e.Candidates, content, features.get, !opts.DisableClassifier)
+		line := ""
+		if lang, l, ok := detectModeline(content); ok && (lang == job.Language || lang+" Header" == job.Language) {
+			line = l
+		}
+		if line != "" && (len(e.Candidates) != 1 || e.Candidates[0] != job.Language) {
+			e.Modeline = line
+			e.Reason = fmt.Sprintf("the modeline %q names %s", line, job.Language)
+		} else {
+			e.Reason = e.explainCandidates(content, features.get, job.Language, !opts.DisableClassifier)
+		}
+	}
+
+	if len(ctx.remap.all) != 0 {
+		e.Remaps = append(e.Remaps, matchedRemaps("--remap-all", ctx.remap.all, content)...)
+		if ctx.hardRemapLanguage(job) {
+			e.Reason = fmt.Sprintf("--remap-all rule %q matched", e.Remaps[len(e.Remaps)-1].Pattern)
+		}
+	}
+
+	if job.Language == SheBang {
+		cutoff := min(200, len(content))
+		line, _, _ := bytes.Cut(content[:cutoff], []byte{'\n'})
+		e.Shebang = &ShebangTrace{Line: string(line)}
+
+		lang, err := DetectSheBang(content[:cutoff])
+		if err != nil {
+			e.Shebang.Error = err.Error()
+			e.Reason = fmt.Sprintf("the file has no extension, no modeline and its #! line could not be matched (%v) so it is skipped", err)
+			job.Language = ""
+		} else {
+			e.Shebang.Language = lang
+			e.Reason = fmt.Sprintf("the #! line names a command of %s", lang)
+			job.Language = lang
+		}
+	}
+
+	e.Language = job.Language
+	return e, nil
+}
+
+// explainCandidates records the heuristics, keywords and classifier scores
+// behind the choice of language among the candidates, mirroring
+// determineLanguage, and returns why language was picked
+func (e *LanguageExplanation) explainCandidates(content []byte, features func(string) LanguageFeature, language string, classify bool) string {
+	if len(e.Candidates) == 1 {
+		switch e.MatchedBy {
+		case "filename":
+			return fmt.Sprintf("the filename %q belongs to %s", strings.ToLower(e.Filename), language)
+		case "count-as-pattern":
+			return fmt.Sprintf("the path matched --count-as-pattern %q", e.CountRule)
+		case "gitattributes":
+			return fmt.Sprintf("%s sets linguist-language for the file", e.Attributes)
+		case "unsupported":
+			return "no language claims the file so --count-unsupported counts it as " + UnknownLanguage
+		case "shebang":
+			return "the file has no extension so its #! line is checked"
+		}
+		return fmt.Sprintf("%s is the only language for the extension %q", language, e.Extension)
+	}
+
+	toCheck := content[:min(len(content), explainReadLimit)]
+	best := map[string]int{}
+	for _, lan := range e.Candidates {
+		for i, h := range features(lan).Heuristics {
+			trace := HeuristicTrace{Language: lan, Index: i, Pattern: h.Re.String(), Literals: []string{}, LiteralsFound: []string{}}
+			for _, lit := range h.Literals {
+				trace.Literals = append(trace.Literals, string(lit))
+				single := CompiledHeuristic{Literals: [][]byte{lit}, Anchored: h.Anchored}
+				if heuristicCanMatch(single, toCheck) {
+					trace.LiteralsFound = append(trace.LiteralsFound, string(lit))
+				}
+			}
+			trace.Ran = heuristicCanMatch(h, toCheck)
+			trace.Matched = trace.Ran && h.Re.Match(toCheck)
+			if trace.Matched {
+				best[lan]++
+			}
+			e.Heuristics = append(e.Heuristics, trace)
+		}
+	}
+	if best[language] != 0 {
+		return fmt.Sprintf("%s matched the most heuristics (%d)", language, best[language])
+	}
+
+	for _, lan := range e.Candidates {
+		f := features(lan)
+		if len(f.Heuristics) != 0 {
+			continue
+		}
+		trace := KeywordTrace{Language: lan, Found: []string{}, Keywords: len(f.Keywords)}
+		for i, key := range f.KeywordBytes {
+			if bytes.Contains(toCheck, key) {
+				trace.Found = append(trace.Found, f.Keywords[i])
+			}
+		}
+		if len(f.Keywords) == 0 && (e.Fallback == "" || lan < e.Fallback) {
+			e.Fallback = lan
+		}
+		e.Keywords = append(e.Keywords, trace)
+	}
+	slices.SortStableFunc(e.Keywords, func(a, b KeywordTrace) int {
+		if order := cmp.Compare(len(b.Found), len(a.Found)); order != 0 {
+			return order
+		}
+		return strings.Compare(a.Language, b.Language)
+	})
+
+	if _, decided := guessByKeywords(e.Candidates, toCheck, features); !decided && classify {
+		classified, scores, ok := classifyContent(e.Candidates, toCheck)
+		e.Classifier = scores
+		if ok && classified == language {
+			return fmt.Sprintf("no heuristic or keyword settled it and the classifier scored %s highest over %d tokens", language, scores[0].Tokens)
+		}
+	}
+
+	if e.Fallback == language && (len(e.Keywords) == 0 || len(e.Keywords[0].Found) < 3) {
+		return fmt.Sprintf("no heuristic matched and no language had 3 or more keywords, so %s is used as it has neither", language)
+	}
+	for _, k := range e.Keywords {
+		if k.Language == language {
+			return fmt.Sprintf("no heuristic matched and %s had the most keywords (%d)", language, len(k.Found))
+		}
+	}
+	return fmt.Sprintf("nothing told the candidates apart so %s is used", language)
+}
+
+func matchedRemaps(flag string, rules []remapRule, content []byte) []RemapTrace {
+	var traces []RemapTrace
+	cutoff := min(1000, len(content))
+	for _, rule := range rules {
+		if bytes.Contains(content[:cutoff], rule.pattern) {
+			traces = append(traces, RemapTrace{Flag: flag, Pattern: string(rule.pattern), Language: rule.language})
+		}
+	}
+	return traces
+}
+
+// ExplainJSONReport explains how the file at path is classified using the
+// default options, for callers such as the MCP server that want the JSON
+// rather than the stdout side effects of runExplainReport
+func ExplainJSONReport(path string) (string, error) {
+	opts := DefaultOptions()
+	e, err := explainLanguage(&opts, path)
+	if err != nil {
+		return "", err
+	}
+	return renderExplanationJSON(e)
+}
+
+// runExplainReport is the dispatch entry point called from Process() when
+// --explain is set
+func runExplainReport(path string) error {
+	opts := optionsFromGlobals()
+	e, err := explainLanguage(&opts, path)
+	if err != nil {
+		return err
+	}
+
+	out, err := renderExplanation(e)
+	if err != nil {
+		return err
+	}
+	if FileOutput == "" {
+		fmt.Print(out)
+	} else {
+		if err := os.WriteFile(FileOutput, []byte(out), 0644); err != nil {
+			return err
+		}
+		fmt.Println("results written to " + FileOutput)
+	}
+	return nil
+}
+
+func renderExplanation(e *LanguageExplanation) (string, error) {
+	switch strings.ToLower(Format) {
+	case "", "tabular", "wide":
+		return renderExplanationTabular(e), nil
+	case "json":
+		return renderExplanationJSON(e)
+	default:
+		return "", fmt.Errorf("unsupported --format %q for --explain (supported: tabular, json)", Format)
+	}
+}
+
+func renderExplanationTabular(e *LanguageExplanation) string {
+	var sb strings.Builder
+	row := func(label, format string, a ...any) {
+		fmt.Fprintf(&sb, "%-12s %s\n", label, fmt.Sprintf(format, a...))
+	}
+
+	row("File", "%s", e.File)
+	switch e.MatchedBy {
+	case "none":
+		row("Candidates", "none for the extension %q", e.Extension)
+	case "shebang":
+		row("Candidates", "none as there is no extension, so a modeline or the #! line is checked")
+	case "count-as-pattern":
+		row("Candidates", "%s by --count-as-pattern %q", strings.Join(e.Candidates, ", "), e.CountRule)
+	case "gitattributes":
+		row("Candidates", "%s by linguist-language in %s", strings.Join(e.Candidates, ", "), e.Attributes)
+	case "extension":
+		row("Candidates", "%s by the extension %q", strings.Join(e.Candidates, ", "), e.Extension)
+	default:
+		row("Candidates", "%s by %s", strings.Join(e.Candidates, ", "), e.MatchedBy)
+	}
+
+	if len(e.Heuristics) != 0 {
+		sb.WriteString("Heuristics\n")
+		for _, h := range e.Heuristics {
+			result := "no literal found, skipped"
+			switch {
+			case h.Matched:
+				result = "matched"
+			case h.Ran:
+				result = "did not match"
+			}
+			if len(h.LiteralsFound) != 0 {
+				result = fmt.Sprintf("found %s, %s", strings.Join(h.LiteralsFound, " "), result)
+			}
+			fmt.Fprintf(&sb, "  %s heuristics[%d] %s\n    %s\n", h.Language, h.Index, h.Pattern, result)
+		}
+	}
+	if len(e.Keywords) != 0 {
+		sb.WriteString("Keywords\n")
+		for _, k := range e.Keywords {
+			fmt.Fprintf(&sb, "  %s %d of %d", k.Language, len(k.Found), k.Keywords)
+			if len(k.Found) != 0 {
+				fmt.Fprintf(&sb, ": %s", strings.Join(k.Found, " "))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if len(e.Classifier) != 0 {
+		fmt.Fprintf(&sb, "Classifier   %d tokens known\n", e.Classifier[0].Tokens)
+		for _, c := range e.Classifier {
+			fmt.Fprintf(&sb, "  %s %.2f", c.Language, c.Score)
+			if len(c.TopTokens) != 0 {
+				fmt.Fprintf(&sb, ": %s", strings.Join(c.TopTokens, " "))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if e.Modeline != "" {
+		row("Modeline", "%q", e.Modeline)
+	}
+	if e.Fallback != "" {
+		row("Fallback", "%s", e.Fallback)
+	}
+	for _, r := range e.Remaps {
+		row("Remap", "%s %q to %s", r.Flag, r.Pattern, r.Language)
+	}
+	if e.Shebang != nil {
+		if e.Shebang.Error != "" {
+			row("Shebang", "%q: %s", e.Shebang.Line, e.Shebang.Error)
+		} else {
+			row("Shebang", "%q is %s", e.Shebang.Line, e.Shebang.Language)
+		}
+	}
+
+	language := e.Language
+	if language == "" {
+		language = "skipped"
+	}
+	row("Language", "%s", language)
+	row("Reason", "%s", e.Reason)
+	return sb.String()
+}
+
+func renderExplanationJSON(e *LanguageExplanation) (string, error) {
+	out, err := jsoniter.Marshal(struct {
+		Report string `json:"report"`
+		*LanguageExplanation
+	}{"explain", e})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestExplainLanguage(t *testing.T) {
+	ProcessConstants()
+	dir := writeTree(t, map[string]string{
+		"widget.h":    "#import <Foundation/Foundation.h>\n@interface Widget\n@end\n",
+		"plain.h":     "int x;\n",
+		"deploy.yaml": "name: x\n",
+		"run":         "#!/usr/bin/env python3\nprint(1)\n",
+		"mystery":     "#!/opt/custom/tool\n",
+		"notes.zzz":   "hello\n",
+		"Dockerfile":  "FROM scratch\n",
+	})
+	explain := func(opts Options, name string) *LanguageExplanation {
+		t.Helper()
+		e, err := explainLanguage(&opts, filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return e
+	}
+
+	e := explain(DefaultOptions(), "widget.h")
+	if e.Language != "Objective C" || e.MatchedBy != "extension" || strings.Join(e.Candidates, ",") != "C Header,C++ Header,Objective C" {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+	matched := false
+	for _, h := range e.Heuristics {
+		if h.Language == "Objective C" && h.Matched && h.Ran && strings.Join(h.LiteralsFound, ",") == "@interface,@end,#import" {
+			matched = true
+		}
+		if h.Language == "C++ Header" && (h.Ran || len(h.LiteralsFound) != 0) {
+			t.Errorf("expected C++ Header heuristics to be skipped got %+v", h)
+		}
+	}
+	if !matched || e.Reason != "Objective C matched the most heuristics (1)" {
+		t.Errorf("expected the Objective C heuristic to decide got %+v", e)
+	}
+
+	if e := explain(DefaultOptions(), "plain.h"); e.Language != "C Header" || e.Fallback != "C Header" || !strings.Contains(e.Reason, "no heuristic matched") {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+	if e := explain(DefaultOptions(), "deploy.yaml"); e.Language != "YAML" || len(e.Keywords) != 2 || e.Keywords[0].Language != "CloudFormation (YAML)" {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+	if e := explain(DefaultOptions(), "Dockerfile"); e.Language != "Dockerfile" || e.MatchedBy != "filename" {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+
+	e = explain(DefaultOptions(), "run")
+	if e.Language != "Python" || e.MatchedBy != "shebang" || e.Shebang == nil || e.Shebang.Line != "#!/usr/bin/env python3" {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+	if e := explain(DefaultOptions(), "mystery"); e.Language != "" || e.Shebang == nil || e.Shebang.Error == "" {
+		t.Errorf("expected an unknown #! to be skipped got %+v", e)
+	}
+
+	opts := DefaultOptions()
+	opts.RemapUnknown = "custom/tool:Shell"
+	if e := explain(opts, "mystery"); e.Language != "Shell" || len(e.Remaps) != 1 || e.Remaps[0].Flag != "--remap-unknown" || e.Shebang != nil {
+		t.Errorf("expected --remap-unknown to apply got %+v", e)
+	}
+	opts = DefaultOptions()
+	opts.RemapAll = "@interface:C++ Header"
+	if e := explain(opts, "widget.h"); e.Language != "C++ Header" || e.Reason != `--remap-all rule "@interface" matched` {
+		t.Errorf("expected --remap-all to apply got %+v", e)
+	}
+
+	if e := explain(DefaultOptions(), "notes.zzz"); e.Language != "" || e.MatchedBy != "none" {
+		t.Errorf("expected an unknown extension to be skipped got %+v", e)
+	}
+	opts = DefaultOptions()
+	opts.CountUnsupported = true
+	if e := explain(opts, "notes.zzz"); e.Language != UnknownLanguage || e.MatchedBy != "unsupported" {
+		t.Errorf("expected --count-unsupported to count it got %+v", e)
+	}
+
+	saved := compiledCountRules
+	t.Cleanup(func() { compiledCountRules = saved })
+	compiledCountRules = []compiledCountRule{{re: regexp.MustCompile(`notes\.zzz$`), name: "Plain Text", pattern: "*.zzz"}}
+	if e := explain(DefaultOptions(), "notes.zzz"); e.Language != "Plain Text" || e.CountRule != "*.zzz" {
+		t.Errorf("expected --count-as-pattern to apply got %+v", e)
+	}
+
+	if _, err := explainLanguage(&opts, dir); err == nil {
+		t.Error("expected error for a directory")
+	}
+}
+
+func TestRenderExplanation(t *testing.T) {
+	e := &LanguageExplanation{
+		File:       "a.h",
+		Filename:   "a.h",
+		Extension:  "h",
+		MatchedBy:  "extension",
+		Candidates: []string{"C Header", "Objective C"},
+		Heuristics: []HeuristicTrace{{Language: "Objective C", Pattern: "@end", Literals: []string{"@end"}, LiteralsFound: []string{"@end"}, Ran: true, Matched: true}},
+		Language:   "Objective C",
+		Reason:     "Objective C matched the most heuristics (1)",
+	}
+	saved := Format
+	t.Cleanup(func() { Format = saved })
+
+	Format = "json"
+	out, err := renderExplanation(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Report string `json:"report"`
+		LanguageExplanation
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc.Report != "explain" || doc.Language != "Objective C" || len(doc.Heuristics) != 1 || !doc.Heuristics[0].Matched {
+		t.Errorf("unexpected json %s", out)
+	}
+
+	Format = "tabular"
+	out, err = renderExplanation(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`Candidates   C Header, Objective C by the extension "h"`,
+		"  Objective C heuristics[0] @end\n    found @end, matched\n",
+		"Language     Objective C\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in\n%s", expected, out)
+		}
+	}
+
+	Format = "csv"
+	if _, err := renderExplanation(e); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
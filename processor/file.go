@@ -49,8 +49,22 @@ func cleanVisitedPaths() {
 }
 
 func newFileJob(path, name string, fileInfo os.FileInfo) *FileJob {
-	if NoLarge {
-		if fileInfo.Size() >= LargeByteCount {
+	ctx := processorContext{}
+	return ctx.newFileJob(path, name, fileInfo)
+}
+
+func (ctx *processorContext) newFileJob(path, name string, fileInfo os.FileInfo) *FileJob {
+	return ctx.newAttributedFileJob(path, name, fileInfo, ctx.attributes.lookup(path))
+}
+
+// newAttributedFileJob is newFileJob for a file with the .gitattributes
+// overrides attrs, which walkers not reading from the local disk look up
+// themselves
+func (ctx *processorContext) newAttributedFileJob(path, name string, fileInfo os.FileInfo, attrs linguistAttributes) *FileJob {
+	opts := ctx.options()
+
+	if opts.NoLarge {
+		if fileInfo.Size() >= opts.LargeByteCount {
 			printWarnF("skipping large file due to byte size: %s", path)
 			return nil
 		}
@@ -60,7 +74,7 @@ func newFileJob(path, name string, fileInfo os.FileInfo) *FileJob {
 	// Check if the file is a symlink and if we want to count those then work out its path and rejig
 	// everything so we can count the real file to ensure the counts are correct
 	if fileInfo.Mode()&os.ModeSymlink == os.ModeSymlink {
-		if !IncludeSymLinks {
+		if !opts.IncludeSymLinks {
 			printWarnF("skipping symlink file: %s", name)
 			return nil
 		}
@@ -92,22 +106,31 @@ func newFileJob(path, name string, fileInfo os.FileInfo) *FileJob {
 	}
 
 	// Prevent duplicate processing and loops
-	if _, exists := visitedPaths.Load(realPath); exists {
+	visited := ctx.visitedPaths()
+	if _, exists := visited.Load(realPath); exists {
 		printWarnF("skipping already processed file: %s", realPath)
 		return nil
 	}
-	visitedPaths.Store(realPath, true)
+	visited.Store(realPath, true)
+
+	language, extension := detectLanguage(name, len(opts.IncludeExtensions) != 0)
 
-	language, extension := DetectLanguage(name)
+	// linguist-language in .gitattributes names the language outright, even
+	// for an extension scc does not know
+	if attrs.language != "" {
+		language = []string{attrs.language}
+	}
 
 	// Path pattern count rules can relabel a file to a new minted category and
 	// can also rescue files that normal detection would otherwise skip. First
-	// matching rule wins, evaluated against the full path as supplied.
+	// matching rule wins, evaluated against the full path as supplied. Being
+	// given on the command line they win over .gitattributes.
 	if len(compiledCountRules) != 0 {
 		for _, r := range compiledCountRules {
 			if r.re.MatchString(path) {
 				language = []string{r.name}
-				LoadLanguageFeature(r.name)
+				attrs.language = ""
+				ctx.loadLanguageFeature(r.name)
 				break
 			}
 		}
@@ -120,7 +143,7 @@ func newFileJob(path, name string, fileInfo os.FileInfo) *FileJob {
 	// yet recognise.
 	unsupported := false
 	if len(language) == 0 {
-		if !CountUnsupported {
+		if !opts.CountUnsupported {
 			printWarnF("skipping file unknown extension: %s", name)
 			return nil
 		}
@@ -129,9 +152,9 @@ func newFileJob(path, name string, fileInfo os.FileInfo) *FileJob {
 	}
 
 	// check if extensions in the allow list, which should limit to just those extensions
-	if len(AllowListExtensions) != 0 {
+	if len(opts.IncludeExtensions) != 0 {
 		ok := false
-		for _, x := range AllowListExtensions {
+		for _, x := range opts.IncludeExtensions {
 			if x == extension {
 				ok = true
 			}
@@ -144,9 +167,9 @@ func newFileJob(path, name string, fileInfo os.FileInfo) *FileJob {
 	}
 
 	// check if we should exclude this type
-	if len(ExcludeListExtensions) != 0 {
+	if len(opts.ExcludeExtensions) != 0 {
 		ok := true
-		for _, x := range ExcludeListExtensions {
+		for _, x := range opts.ExcludeExtensions {
 			if x == extension {
 				ok = false
 			}
@@ -158,9 +181,9 @@ func newFileJob(path, name string, fileInfo os.FileInfo) *FileJob {
 		}
 	}
 
-	if len(ExcludeFilename) != 0 {
+	if len(opts.ExcludeFilenames) != 0 {
 		ok := true
-		for _, x := range ExcludeFilename {
+		for _, x := range opts.ExcludeFilenames {
 			if strings.Contains(name, x) {
 				ok = false
 			}
@@ -176,11 +199,11 @@ func newFileJob(path, name string, fileInfo os.FileInfo) *FileJob {
 	// load; loading it would also miss the language database entirely.
 	if !unsupported {
 		for _, l := range language {
-			LoadLanguageFeature(l)
+			ctx.loadLanguageFeature(l)
 		}
 	}
 
-	if !CountIgnore {
+	if !opts.CountIgnore {
 		for _, l := range language {
 			if l == "ignore" || l == "gitignore" {
 				return nil
@@ -195,5 +218,6 @@ func newFileJob(path, name string, fileInfo os.FileInfo) *FileJob {
 		Extension:         extension,
 		PossibleLanguages: language,
 		Bytes:             fileInfo.Size(),
+		attributes:        attrs,
 	}
 }
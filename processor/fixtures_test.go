@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeTree writes files, keyed by slash separated path, under a new
+// temporary directory and returns the directory
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// runTree counts dir with opts and returns the language summary keyed by
+// language name
+func runTree(t *testing.T, dir string, opts Options) map[string]LanguageSummary {
+	t.Helper()
+	opts.Paths = []string{dir}
+	summary, _, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return languagesByName(summary)
+}
+
+func languagesByName(summary []LanguageSummary) map[string]LanguageSummary {
+	langs := make(map[string]LanguageSummary, len(summary))
+	for _, s := range summary {
+		langs[s.Name] = s
+	}
+	return langs
+}
+
+// languageCounts lists the file count of each language by name, such as
+// "C=1,Go=2", so a whole run can be compared in one go
+func languageCounts(langs map[string]LanguageSummary) string {
+	var out []string
+	for _, name := range slices.Sorted(maps.Keys(langs)) {
+		out = append(out, name+"="+strconv.FormatInt(langs[name].Count, 10))
+	}
+	return strings.Join(out, ",")
+}
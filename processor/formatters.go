@@ -211,6 +211,15 @@ func fileSummarizeMulti(input chan *FileJob) string {
 }
 
 func aggregateLanguageSummary(input chan *FileJob) []LanguageSummary {
+	return aggregateLanguageSummaryFor(input, ulocCounts, Files, Tests, Embedded)
+}
+
+// aggregateLanguageSummaryFor folds the file jobs into per language summaries
+// taking the ULOC counts from uloc and keeping the files when keepFiles is set.
+// With tests set each summary also splits its counts between test files and
+// production code, and embedded is the --embedded mode used to fold in the
+// code embedded in the files.
+func aggregateLanguageSummaryFor(input chan *FileJob, uloc *ulocCounter, keepFiles, tests bool, embedded string) []LanguageSummary {
 	langs := map[string]LanguageSummary{}
 
 	for res := range input {
@@ -218,7 +227,7 @@ func aggregateLanguageSummary(input chan *FileJob) []LanguageSummary {
 
 		if !ok {
 			files := []*FileJob{}
-			if Files {
+			if keepFiles {
 				files = append(files, res)
 			}
 
@@ -238,7 +247,7 @@ func aggregateLanguageSummary(input chan *FileJob) []LanguageSummary {
 		} else {
 			tmp := langs[res.Language]
 			files := tmp.Files
-			if Files {
+			if keepFiles {
 				files = append(files, res)
 			}
 
@@ -254,13 +263,21 @@ func aggregateLanguageSummary(input chan *FileJob) []LanguageSummary {
 				Files:      files,
 				Bytes:      res.Bytes + tmp.Bytes,
 				ULOC:       0,
+				Production: tmp.Production,
+				Test:       tmp.Test,
+				Embedded:   tmp.Embedded,
 			}
 		}
+
+		if tests {
+			langs[res.Language] = langs[res.Language].withTests(res)
+		}
+		foldEmbedded(langs, res, embedded)
 	}
 
 	language := make([]LanguageSummary, 0, len(langs))
 	for _, summary := range langs {
-		summary.ULOC = len(ulocLanguageCount[summary.Name]) // for #498
+		summary.ULOC = uloc.languageCount(summary.Name) // for #498
 		language = append(language, summary)
 	}
 
@@ -268,11 +285,15 @@ func aggregateLanguageSummary(input chan *FileJob) []LanguageSummary {
 }
 
 func sortLanguageSummary(language []LanguageSummary) []LanguageSummary {
+	return sortLanguageSummaryBy(language, SortBy)
+}
+
+func sortLanguageSummaryBy(language []LanguageSummary, sortBy string) []LanguageSummary {
 	// Cater for the common case of adding plural even for those options that don't make sense
 	// as it's quite common for those who English is not a first language to make a simple mistake
 	// NB in any non name cases if the values are the same we sort by name to ensure
 	// deterministic output
-	switch SortBy {
+	switch sortBy {
 	case "name", "names", "language", "languages", "lang", "langs":
 		slices.SortFunc(language, func(a, b LanguageSummary) int {
 			return strings.Compare(a.Name, b.Name)
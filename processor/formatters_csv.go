@@ -39,6 +39,14 @@ func toCSVSummary(input chan *FileJob) string {
 	if Cognitive {
 		record = append(record, "Cognitive")
 	}
+	splitAt := len(record)
+	if Tests {
+		for _, part := range []string{"Production", "Test"} {
+			for _, column := range []string{"Files", "Lines", "Code", "Comments", "Blanks", "Complexity"} {
+				record = append(record, part+" "+column)
+			}
+		}
+	}
 
 	b := &bytes.Buffer{}
 	w := csv.NewWriter(b)
@@ -53,10 +61,21 @@ func toCSVSummary(input chan *FileJob) string {
 		record[5] = strconv.FormatInt(result.Complexity, 10)
 		record[6] = strconv.FormatInt(result.Bytes, 10)
 		record[7] = strconv.FormatInt(result.Count, 10)
-		record[8] = strconv.Itoa(len(ulocLanguageCount[result.Name]))
+		record[8] = strconv.Itoa(ulocCounts.languageCount(result.Name))
 		if Cognitive {
 			record[9] = strconv.FormatInt(result.Cognitive, 10)
 		}
+		if Tests {
+			production, test := CodeSplit{}, CodeSplit{}
+			if result.Production != nil {
+				production, test = *result.Production, *result.Test
+			}
+			for i, split := range []CodeSplit{production, test} {
+				for j, value := range []int64{split.Count, split.Lines, split.Code, split.Comment, split.Blank, split.Complexity} {
+					record[splitAt+i*6+j] = strconv.FormatInt(value, 10)
+				}
+			}
+		}
 		_ = w.Write(record)
 	}
 
@@ -157,6 +176,9 @@ func toCSVFiles(input chan *FileJob) string {
 		if Cognitive {
 			row = append(row, strconv.FormatInt(result.Cognitive, 10))
 		}
+		if Tests {
+			row = append(row, strconv.FormatBool(result.Test))
+		}
 		records = append(records, row)
 	}
 
@@ -177,6 +199,9 @@ func toCSVFiles(input chan *FileJob) string {
 	if Cognitive {
 		header = append(header, "Cognitive")
 	}
+	if Tests {
+		header = append(header, "Test")
+	}
 	recordsEnd := [][]string{header}
 
 	recordsEnd = append(recordsEnd, records...)
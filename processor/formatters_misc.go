@@ -258,6 +258,7 @@ func toHtml(input chan *FileJob) string {
 func toHtmlTable(input chan *FileJob) string {
 	languages := map[string]LanguageSummary{}
 	var sumFiles, sumLines, sumCode, sumComment, sumBlank, sumComplexity, sumBytes int64 = 0, 0, 0, 0, 0, 0, 0
+	sumSplit := LanguageSummary{}
 
 	for res := range input {
 		sumFiles++
@@ -267,6 +268,15 @@ func toHtmlTable(input chan *FileJob) string {
 		sumBlank += res.Blank
 		sumComplexity += res.Complexity
 		sumBytes += res.Bytes
+		if Embedded == embeddedLanguage {
+			e := embeddedTotal(res)
+			sumLines += e.Lines
+			sumCode += e.Code
+			sumComment += e.Comment
+			sumBlank += e.Blank
+			sumComplexity += e.Complexity
+			sumBytes += e.Bytes
+		}
 
 		_, ok := languages[res.Language]
 
@@ -299,8 +309,17 @@ func toHtmlTable(input chan *FileJob) string {
 				Count:      tmp.Count + 1,
 				Files:      files,
 				Bytes:      tmp.Bytes + res.Bytes,
+				Production: tmp.Production,
+				Test:       tmp.Test,
+				Embedded:   tmp.Embedded,
 			}
 		}
+
+		if Tests {
+			languages[res.Language] = languages[res.Language].withTests(res)
+			sumSplit = sumSplit.withTests(res)
+		}
+		foldEmbedded(languages, res, Embedded)
 	}
 
 	language := make([]LanguageSummary, 0, len(languages))
@@ -337,7 +356,15 @@ func toHtmlTable(input chan *FileJob) string {
 		<th>%d</th>
 		<th>%d</th>
 		<th>%d</th>
-	</tr>`, html.EscapeString(r.Name), len(r.Files), r.Lines, r.Blank, r.Comment, r.Code, r.Complexity, r.Bytes, len(ulocLanguageCount[r.Name]))
+	</tr>`, html.EscapeString(r.Name), len(r.Files), r.Lines, r.Blank, r.Comment, r.Code, r.Complexity, r.Bytes, ulocCounts.languageCount(r.Name))
+
+		if r.Test != nil && r.Test.Count != 0 {
+			writeHtmlSplit(str, "td", "(production)", *r.Production)
+			writeHtmlSplit(str, "td", "(test)", *r.Test)
+		}
+		for _, e := range r.Embedded {
+			writeHtmlSplit(str, "td", embeddedRowName(e.Language, 0), e.CodeSplit)
+		}
 
 		if Files {
 			sortSummaryFiles(&r)
@@ -370,7 +397,12 @@ func toHtmlTable(input chan *FileJob) string {
 		<th>%d</th>
 		<th>%d</th>
 		<th>%d</th>
-	</tr>`, sumFiles, sumLines, sumBlank, sumComment, sumCode, sumComplexity, sumBytes, len(ulocGlobalCount))
+	</tr>`, sumFiles, sumLines, sumBlank, sumComment, sumCode, sumComplexity, sumBytes, ulocCounts.globalCount())
+
+	if Tests && sumSplit.Production != nil {
+		writeHtmlSplit(str, "th", "Production", *sumSplit.Production)
+		writeHtmlSplit(str, "th", "Test", *sumSplit.Test)
+	}
 
 	hasCostOutput := false
 	if !Cocomo {
@@ -400,3 +432,19 @@ func toHtmlTable(input chan *FileJob) string {
 
 	return str.String()
 }
+
+// writeHtmlSplit writes the test or production part of a language, or of the
+// total, as a row of the html table using cell for its cells
+func writeHtmlSplit(str *strings.Builder, cell, name string, split CodeSplit) {
+	_, _ = fmt.Fprintf(str, `<tr>
+		<%[1]s>%[2]s</%[1]s>
+		<%[1]s>%[3]d</%[1]s>
+		<%[1]s>%[4]d</%[1]s>
+		<%[1]s>%[5]d</%[1]s>
+		<%[1]s>%[6]d</%[1]s>
+		<%[1]s>%[7]d</%[1]s>
+		<%[1]s>%[8]d</%[1]s>
+		<%[1]s>%[9]d</%[1]s>
+		<%[1]s></%[1]s>
+	</tr>`, cell, name, split.Count, split.Lines, split.Blank, split.Comment, split.Code, split.Complexity, split.Bytes)
+}
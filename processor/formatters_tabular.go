@@ -24,6 +24,7 @@ var tabularShortUlocLanguageFormatBody = "(ULOC) %30d\n"
 var tabularShortPercentLanguageFormatBody = "Percentage %13.1f%% %10.1f%% %8.1f%% %8.1f%% %9.1f%% %9.1f%%\n"
 var tabularShortUlocGlobalFormatBody = "Unique Lines of Code (ULOC) %9d\n"
 var tabularShortDrynessFormatBody = "DRYness %% %27.2f\n"
+var tabularShortTestRatioFormatBody = "Test to Production Code %13.2f\n"
 
 var tabularShortFormatHeadNoComplexity = "%-21s %11s %11s %10s %11s %10s\n"
 var tabularShortFormatBodyNoComplexity = "%-21s %11d %11d %10d %11d %10d\n"
@@ -54,6 +55,7 @@ var tabularWideUlocLanguageFormatBody = "(ULOC) %39d\n"
 var tabularWideUlocGlobalFormatBody = "Unique Lines of Code (ULOC) %18d\n"
 var tabularWideFormatBodyPercent = "Percentage %21.1f%% %11.1f%% %9.1f%% %9.1f%% %11.1f%% %9.1f%%\n"
 var tabularWideDrynessFormatBody = "DRYness %% %36.2f\n"
+var tabularWideTestRatioFormatBody = "Test to Production Code %22.2f\n"
 
 func fileSummarizeLong(input chan *FileJob) string {
 	str := &strings.Builder{}
@@ -71,6 +73,7 @@ func fileSummarizeLong(input chan *FileJob) string {
 
 	langs := map[string]LanguageSummary{}
 	var sumFiles, sumLines, sumCode, sumComment, sumBlank, sumComplexity, sumCognitive, sumBytes int64 = 0, 0, 0, 0, 0, 0, 0, 0
+	sumSplit := LanguageSummary{}
 
 	p := gmessage.NewPrinter(glanguage.Make(os.Getenv("LANG")))
 
@@ -83,6 +86,17 @@ func fileSummarizeLong(input chan *FileJob) string {
 		sumComplexity += res.Complexity
 		sumCognitive += res.Cognitive
 		sumBytes += res.Bytes
+		if Embedded == embeddedLanguage {
+			// The embedded code moved out of the file still adds to the total
+			e := embeddedTotal(res)
+			sumLines += e.Lines
+			sumCode += e.Code
+			sumComment += e.Comment
+			sumBlank += e.Blank
+			sumComplexity += e.Complexity
+			sumCognitive += e.Cognitive
+			sumBytes += e.Bytes
+		}
 
 		var weightedComplexity float64
 		if res.Code != 0 {
@@ -124,8 +138,17 @@ func fileSummarizeLong(input chan *FileJob) string {
 				Count:      tmp.Count + 1,
 				Files:      files,
 				LineLength: lineLength,
+				Production: tmp.Production,
+				Test:       tmp.Test,
+				Embedded:   tmp.Embedded,
 			}
 		}
+
+		if Tests {
+			langs[res.Language] = langs[res.Language].withTests(res)
+			sumSplit = sumSplit.withTests(res)
+		}
+		foldEmbedded(langs, res, Embedded)
 	}
 
 	language := make([]LanguageSummary, 0, len(langs))
@@ -157,6 +180,18 @@ func fileSummarizeLong(input chan *FileJob) string {
 			_, _ = p.Fprintf(str, tabularWideFormatBody, trimmedName, summary.Count, summary.Lines, summary.Blank, summary.Comment, summary.Code, summary.Complexity, summaryWeightedComplexity)
 		}
 
+		split := summary.Test != nil && summary.Test.Count != 0
+		if split {
+			writeTabularWideSplit(p, str, "(production)", *summary.Production)
+			writeTabularWideSplit(p, str, "(test)", *summary.Test)
+		}
+		for _, e := range summary.Embedded {
+			writeTabularWideSplit(p, str, embeddedRowName(e.Language, longNameTruncate), e.CodeSplit)
+		}
+		if (split || len(summary.Embedded) != 0) && !Percent && !UlocMode && !Files && summary.Name != language[len(language)-1].Name {
+			str.WriteString(tabularWideBreakCi)
+		}
+
 		if Percent {
 			_, _ = p.Fprintf(str,
 				tabularWideFormatBodyPercent,
@@ -180,7 +215,7 @@ func fileSummarizeLong(input chan *FileJob) string {
 		}
 
 		if UlocMode {
-			_, _ = p.Fprintf(str, tabularWideUlocLanguageFormatBody, len(ulocLanguageCount[summary.Name]))
+			_, _ = p.Fprintf(str, tabularWideUlocLanguageFormatBody, ulocCounts.languageCount(summary.Name))
 			if !Files && summary.Name != language[len(language)-1].Name {
 				str.WriteString(tabularWideBreakCi)
 			}
@@ -218,10 +253,17 @@ func fileSummarizeLong(input chan *FileJob) string {
 	}
 	str.WriteString(getTabularWideBreak())
 
+	if Tests && sumSplit.Production != nil {
+		writeTabularWideSplit(p, str, "Production", *sumSplit.Production)
+		writeTabularWideSplit(p, str, "Test", *sumSplit.Test)
+		_, _ = p.Fprintf(str, tabularWideTestRatioFormatBody, testRatio(*sumSplit.Production, *sumSplit.Test))
+		str.WriteString(getTabularWideBreak())
+	}
+
 	if UlocMode {
-		_, _ = p.Fprintf(str, tabularWideUlocGlobalFormatBody, len(ulocGlobalCount))
+		_, _ = p.Fprintf(str, tabularWideUlocGlobalFormatBody, ulocCounts.globalCount())
 		if Dryness {
-			dryness := float64(len(ulocGlobalCount)) / float64(sumLines)
+			dryness := float64(ulocCounts.globalCount()) / float64(sumLines)
 			_, _ = p.Fprintf(str, tabularWideDrynessFormatBody, dryness)
 		}
 		str.WriteString(getTabularWideBreak())
@@ -244,6 +286,21 @@ func fileSummarizeLong(input chan *FileJob) string {
 	return str.String()
 }
 
+// writeTabularWideSplit writes the test or production part of a language, or
+// of the total, as a row of the wide layout
+func writeTabularWideSplit(p *gmessage.Printer, str *strings.Builder, name string, split CodeSplit) {
+	var weightedComplexity float64
+	if split.Code != 0 {
+		weightedComplexity = (float64(split.Complexity) / float64(split.Code)) * 100
+	}
+
+	if Cognitive {
+		_, _ = p.Fprintf(str, tabularWideFormatBodyCognitive, name, split.Count, split.Lines, split.Blank, split.Comment, split.Code, split.Complexity, split.Cognitive, weightedComplexity)
+	} else {
+		_, _ = p.Fprintf(str, tabularWideFormatBody, name, split.Count, split.Lines, split.Blank, split.Comment, split.Code, split.Complexity, weightedComplexity)
+	}
+}
+
 // We need to trim the file display for tabular output formats which this does in a unicode aware way
 // to avoid cutting bytes... note that it needs to be expanded to deal with longer display characters at some
 // point in the future
@@ -271,6 +328,16 @@ func unicodeAwareRightPad(tmp string, size int) string {
 	return runewidth.FillRight(tmp, size)
 }
 
+// writeTabularShortSplit writes the test or production part of a language, or
+// of the total, as a row of the short layout
+func writeTabularShortSplit(p *gmessage.Printer, str *strings.Builder, name string, split CodeSplit) {
+	if !Complexity {
+		_, _ = p.Fprintf(str, tabularShortFormatBody, name, split.Count, split.Lines, split.Blank, split.Comment, split.Code, activeComplexity(split.Complexity, split.Cognitive))
+	} else {
+		_, _ = p.Fprintf(str, tabularShortFormatBodyNoComplexity, name, split.Count, split.Lines, split.Blank, split.Comment, split.Code)
+	}
+}
+
 func fileSummarizeShort(input chan *FileJob) string {
 	str := &strings.Builder{}
 
@@ -287,6 +354,7 @@ func fileSummarizeShort(input chan *FileJob) string {
 
 	lang := map[string]LanguageSummary{}
 	var sumFiles, sumLines, sumCode, sumComment, sumBlank, sumComplexity, sumCognitive, sumBytes int64 = 0, 0, 0, 0, 0, 0, 0, 0
+	sumSplit := LanguageSummary{}
 
 	p := gmessage.NewPrinter(glanguage.Make(os.Getenv("LANG")))
 
@@ -299,6 +367,17 @@ func fileSummarizeShort(input chan *FileJob) string {
 		sumComplexity += res.Complexity
 		sumCognitive += res.Cognitive
 		sumBytes += res.Bytes
+		if Embedded == embeddedLanguage {
+			// The embedded code moved out of the file still adds to the total
+			e := embeddedTotal(res)
+			sumLines += e.Lines
+			sumCode += e.Code
+			sumComment += e.Comment
+			sumBlank += e.Blank
+			sumComplexity += e.Complexity
+			sumCognitive += e.Cognitive
+			sumBytes += e.Bytes
+		}
 
 		_, ok := lang[res.Language]
 
@@ -334,8 +413,17 @@ func fileSummarizeShort(input chan *FileJob) string {
 				Count:      tmp.Count + 1,
 				Files:      files,
 				LineLength: lineLength,
+				Production: tmp.Production,
+				Test:       tmp.Test,
+				Embedded:   tmp.Embedded,
 			}
 		}
+
+		if Tests {
+			lang[res.Language] = lang[res.Language].withTests(res)
+			sumSplit = sumSplit.withTests(res)
+		}
+		foldEmbedded(lang, res, Embedded)
 	}
 
 	language := make([]LanguageSummary, 0, len(lang))
@@ -361,6 +449,16 @@ func fileSummarizeShort(input chan *FileJob) string {
 			_, _ = p.Fprintf(str, tabularShortFormatBodyNoComplexity, trimmedName, summary.Count, summary.Lines, summary.Blank, summary.Comment, summary.Code)
 		}
 
+		if summary.Test != nil && summary.Test.Count != 0 {
+			writeTabularShortSplit(p, str, "(production)", *summary.Production)
+			writeTabularShortSplit(p, str, "(test)", *summary.Test)
+			addBreak = true
+		}
+		for _, e := range summary.Embedded {
+			writeTabularShortSplit(p, str, embeddedRowName(e.Language, shortNameTruncate), e.CodeSplit)
+			addBreak = true
+		}
+
 		if Percent {
 			if !Complexity {
 				_, _ = p.Fprintf(str,
@@ -415,9 +513,9 @@ func fileSummarizeShort(input chan *FileJob) string {
 
 		if UlocMode {
 			if !Complexity {
-				_, _ = p.Fprintf(str, tabularShortUlocLanguageFormatBody, len(ulocLanguageCount[summary.Name]))
+				_, _ = p.Fprintf(str, tabularShortUlocLanguageFormatBody, ulocCounts.languageCount(summary.Name))
 			} else {
-				_, _ = p.Fprintf(str, tabularShortUlocLanguageFormatBodyNoComplexity, len(ulocLanguageCount[summary.Name]))
+				_, _ = p.Fprintf(str, tabularShortUlocLanguageFormatBodyNoComplexity, ulocCounts.languageCount(summary.Name))
 			}
 
 			addBreak = true
@@ -440,10 +538,17 @@ func fileSummarizeShort(input chan *FileJob) string {
 	}
 	str.WriteString(getTabularShortBreak())
 
+	if Tests && sumSplit.Production != nil {
+		writeTabularShortSplit(p, str, "Production", *sumSplit.Production)
+		writeTabularShortSplit(p, str, "Test", *sumSplit.Test)
+		_, _ = p.Fprintf(str, tabularShortTestRatioFormatBody, testRatio(*sumSplit.Production, *sumSplit.Test))
+		str.WriteString(getTabularShortBreak())
+	}
+
 	if UlocMode {
-		_, _ = p.Fprintf(str, tabularShortUlocGlobalFormatBody, len(ulocGlobalCount))
+		_, _ = p.Fprintf(str, tabularShortUlocGlobalFormatBody, ulocCounts.globalCount())
 		if Dryness {
-			dryness := float64(len(ulocGlobalCount)) / float64(sumLines)
+			dryness := float64(ulocCounts.globalCount()) / float64(sumLines)
 			_, _ = p.Fprintf(str, tabularShortDrynessFormatBody, dryness)
 		}
 		str.WriteString(getTabularShortBreak())
@@ -0,0 +1,438 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	glanguage "golang.org/x/text/language"
+	gmessage "golang.org/x/text/message"
+)
+
+// FunctionSummary is the span and counts of one function found in a file
+type FunctionSummary struct {
+	Name       string
+	Location   string
+	Language   string
+	StartLine  int64 // 1 based, the line the declaration starts on
+	EndLine    int64
+	Lines      int64
+	Code       int64
+	Complexity int64
+	Cognitive  int64
+}
+
+// functionDetector finds the functions of one family of languages. Headers
+// are matched against each line with comments and strings blanked out, the
+// last submatch being the name. Brace languages end a function at the brace
+// closing its body, indent languages at the first code line that is indented
+// no deeper than the declaration.
+type functionDetector struct {
+	headers []*regexp.Regexp
+	indent  bool
+	// reject are names a header can match that are really statements
+	reject map[string]bool
+	// statements are first words of a line that make it a statement rather
+	// than a declaration, such as the return in return foo(x)
+	statements map[string]bool
+}
+
+// functionHeaderLines is how many lines a declaration may span before its
+// body starts
+const functionHeaderLines = 8
+
+var cFamilyKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"return": true, "else": true, "new": true, "sizeof": true, "do": true,
+	"throw": true, "case": true, "delete": true, "await": true, "yield": true,
+	"using": true, "lock": true, "foreach": true, "synchronized": true,
+	"function": true, "typeof": true, "defined": true,
+}
+
+// jsStatementKeywords are cFamilyKeywords less function, which starts a
+// JavaScript declaration rather than a statement
+var jsStatementKeywords = func() map[string]bool {
+	keywords := maps.Clone(cFamilyKeywords)
+	delete(keywords, "function")
+	return keywords
+}()
+
+var (
+	// [modifiers and return type]... name(
+	cFamilyHeader = regexp.MustCompile(`^\s*(?:[\w<>\[\],.*&:~?]+\s+)+[*&]*([A-Za-z_~][\w:~]*)\s*\(`)
+	jsHeaders     = []*regexp.Regexp{
+		regexp.MustCompile(`\bfunction\s*\*?\s*([A-Za-z_$][\w$]*)\s*\(`),
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s*)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|[A-Za-z_$][\w$]*\s*=>)`),
+		regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|async|get|set|readonly|override|abstract)\s+)*\*?([A-Za-z_$][\w$]*)\s*(?:<[^>]*>)?\s*\([^;]*\)\s*(?::\s*[^{;=]+)?\{`),
+	}
+)
+
+var functionDetectors = func() map[string]*functionDetector {
+	cFamily := &functionDetector{headers: []*regexp.Regexp{cFamilyHeader}, reject: cFamilyKeywords, statements: cFamilyKeywords}
+	js := &functionDetector{headers: jsHeaders, reject: cFamilyKeywords, statements: jsStatementKeywords}
+
+	detectors := map[string]*functionDetector{
+		"Go": {headers: []*regexp.Regexp{
+			regexp.MustCompile(`^func\s*(?:\(\s*(?:\w+\s+)?\*?\s*([\w.]+)(?:\[[^\]]*\])?\s*\)\s*)?([A-Za-z_]\w*)`),
+		}},
+		"Rust": {headers: []*regexp.Regexp{
+			regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:(?:const|async|unsafe|extern(?:\s+"[^"]*")?)\s+)*fn\s+([A-Za-z_]\w*)`),
+		}},
+		"Python": {indent: true, headers: []*regexp.Regexp{
+			regexp.MustCompile(`^\s*(?:async\s+)?def\s+([A-Za-z_]\w*)`),
+		}},
+		"Kotlin": {headers: []*regexp.Regexp{
+			regexp.MustCompile(`\bfun\s+(?:<[^>]*>\s*)?(?:[\w.]+\.)?([A-Za-z_]\w*)\s*\(`),
+		}},
+		"Swift": {headers: []*regexp.Regexp{
+			regexp.MustCompile(`\bfunc\s+([A-Za-z_]\w*)`),
+			regexp.MustCompile(`^\s*(?:(?:public|private|internal|fileprivate|open|convenience|required|override)\s+)*(init|deinit)\b`),
+		}},
+		"PHP": {headers: []*regexp.Regexp{
+			regexp.MustCompile(`\bfunction\s+&?([A-Za-z_]\w*)\s*\(`),
+		}},
+		"Scala": {headers: []*regexp.Regexp{
+			regexp.MustCompile(`\bdef\s+([A-Za-z_]\w*)`),
+		}},
+	}
+	for _, name := range []string{"C", "C Header", "C++", "C++ Header", "C#", "Java", "Dart"} {
+		detectors[name] = cFamily
+	}
+	for _, name := range []string{"JavaScript", "JSX", "TypeScript", "TSX"} {
+		detectors[name] = js
+	}
+	return detectors
+}()
+
+// functionLanguages are the languages --by-function can split into functions
+func functionLanguages() []string {
+	names := make([]string, 0, len(functionDetectors))
+	for name := range functionDetectors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// findFunctions splits a counted file into its functions. It needs the
+// content still in place along with the byte classification, per line
+// complexity and the type of each line, so it runs in the worker.
+func findFunctions(job *FileJob, lineTypes []LineType) []FunctionSummary {
+	detector, ok := functionDetectors[job.Language]
+	if !ok || job.ContentByteType == nil {
+		return nil
+	}
+
+	lines := bytes.Split(job.FilterContentByType(ByteTypeCode), []byte("\n"))
+	var spans [][3]int64 // start, end and index of the name in names
+	var names []string
+
+	for i := 0; i < len(lines); i++ {
+		name, ok := detector.match(lines[i])
+		if !ok {
+			continue
+		}
+		var end int
+		if detector.indent {
+			end = indentBlockEnd(lines, i)
+		} else {
+			end, ok = braceBlockEnd(lines, i)
+			if !ok {
+				continue
+			}
+		}
+		spans = append(spans, [3]int64{int64(i + 1), int64(end + 1), int64(len(names))})
+		names = append(names, name)
+		// Anything declared inside, such as closures and nested functions,
+		// counts towards this function
+		i = end
+	}
+
+	functions := make([]FunctionSummary, 0, len(spans))
+	for _, span := range spans {
+		f := FunctionSummary{
+			Name:      names[span[2]],
+			Location:  job.Location,
+			Language:  job.Language,
+			StartLine: span[0],
+			EndLine:   span[1],
+			Lines:     span[1] - span[0] + 1,
+		}
+		for line := span[0] - 1; line < span[1]; line++ {
+			if line < int64(len(lineTypes)) && lineTypes[line] == LINE_CODE {
+				f.Code++
+			}
+			if line < int64(len(job.ComplexityLine)) {
+				f.Complexity += job.ComplexityLine[line]
+			}
+			if line < int64(len(job.CognitiveLine)) {
+				f.Cognitive += job.CognitiveLine[line]
+			}
+		}
+		functions = append(functions, f)
+	}
+	return functions
+}
+
+// match returns the function name declared on line, joining a Go method's
+// receiver type to its name
+func (d *functionDetector) match(line []byte) (string, bool) {
+	for _, re := range d.headers {
+		m := re.FindSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := string(m[len(m)-1])
+		if d.reject[name] {
+			continue
+		}
+		if len(m) > 2 && len(m[1]) != 0 {
+			name = string(m[1]) + "." + name
+		}
+		if d.statements != nil {
+			if first := strings.Fields(string(line)); len(first) != 0 && d.statements[first[0]] {
+				continue
+			}
+		}
+		return name, true
+	}
+	return "", false
+}
+
+// braceBlockEnd finds the line the body opened after the header on line
+// start closes on. A ';' before any '{' means a declaration without a body.
+func braceBlockEnd(lines [][]byte, start int) (int, bool) {
+	depth := 0
+	opened := false
+	for i := start; i < len(lines); i++ {
+		for _, c := range lines[i] {
+			switch c {
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+				if opened && depth == 0 {
+					return i, true
+				}
+			case ';':
+				if !opened {
+					return 0, false
+				}
+			}
+		}
+		if !opened && i-start >= functionHeaderLines {
+			return 0, false
+		}
+	}
+	return len(lines) - 1, opened
+}
+
+// indentBlockEnd finds the last line of the indented body declared on line
+// start, past any signature that continues over several lines
+func indentBlockEnd(lines [][]byte, start int) int {
+	indent := leadingSpace(lines[start])
+
+	// Brackets left open carry the signature onto the following lines
+	depth := 0
+	i := start
+	for ; i < len(lines); i++ {
+		depth += bytes.Count(lines[i], []byte("(")) + bytes.Count(lines[i], []byte("["))
+		depth -= bytes.Count(lines[i], []byte(")")) + bytes.Count(lines[i], []byte("]"))
+		if depth <= 0 {
+			break
+		}
+	}
+
+	end := i
+	for i++; i < len(lines); i++ {
+		if len(bytes.TrimSpace(lines[i])) == 0 {
+			continue
+		}
+		if leadingSpace(lines[i]) <= indent {
+			break
+		}
+		end = i
+	}
+	return end
+}
+
+func leadingSpace(line []byte) int {
+	return len(line) - len(bytes.TrimLeft(line, " \t"))
+}
+
+// sortFunctions orders functions by the --sort column, most complex first
+// by default
+func sortFunctions(functions []FunctionSummary, sortBy string) {
+	value := func(f FunctionSummary) int64 {
+		switch sortBy {
+		case "line", "lines":
+			return f.Lines
+		case "code":
+			return f.Code
+		case "cognitive":
+			return f.Cognitive
+		}
+		return f.Complexity
+	}
+	slices.SortStableFunc(functions, func(a, b FunctionSummary) int {
+		if sortBy == "name" || sortBy == "names" {
+			if order := strings.Compare(a.Name, b.Name); order != 0 {
+				return order
+			}
+		}
+		if order := cmp.Compare(value(b), value(a)); order != 0 {
+			return order
+		}
+		if order := cmp.Compare(b.Cognitive, a.Cognitive); order != 0 {
+			return order
+		}
+		if order := strings.Compare(a.Location, b.Location); order != 0 {
+			return order
+		}
+		return cmp.Compare(a.StartLine, b.StartLine)
+	})
+}
+
+// functionOptions turns on what finding functions relies on
+func functionOptions(opts Options) Options {
+	opts.functions = true
+	opts.NoComplexity = false
+	opts.Cognitive = true
+	return opts
+}
+
+// countFunctions runs opts and returns every function found, sorted
+func countFunctions(ctx context.Context, opts Options) ([]FunctionSummary, error) {
+	_, files, err := NewAnalyzer(functionOptions(opts)).Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var functions []FunctionSummary
+	for _, f := range files {
+		functions = append(functions, f.functions...)
+	}
+	sortFunctions(functions, strings.ToLower(opts.SortBy))
+	return functions, nil
+}
+
+// runByFunctionReport is the dispatch entry point called from Process() when
+// --by-function or --top-functions is set
+func runByFunctionReport(ctx context.Context) error {
+	functions, err := countFunctions(ctx, optionsFromGlobals())
+	if err != nil {
+		return err
+	}
+	if TopFunctions > 0 && len(functions) > TopFunctions {
+		functions = functions[:TopFunctions]
+	}
+
+	out, err := renderFunctions(functions)
+	if err != nil {
+		return err
+	}
+	if FileOutput == "" {
+		fmt.Print(out)
+	} else {
+		if err := os.WriteFile(FileOutput, []byte(out), 0644); err != nil {
+			return err
+		}
+		fmt.Println("results written to " + FileOutput)
+	}
+	return nil
+}
+
+func renderFunctions(functions []FunctionSummary) (string, error) {
+	switch strings.ToLower(Format) {
+	case "", "tabular", "wide":
+		return renderFunctionsTabular(functions), nil
+	case "json":
+		return renderFunctionsJSON(functions)
+	case "csv":
+		return renderFunctionsCSV(functions)
+	default:
+		return "", fmt.Errorf("unsupported --format %q for --by-function (supported: tabular, json, csv)", Format)
+	}
+}
+
+// Tabular column formats. Location is the file and the line the function
+// starts on, trimmed from the left so the file name stays visible.
+//
+//	%-20s %-23s %6s %6s %10s %9s
+//	20 + 1 + 23 + 1 + 6 + 1 + 6 + 1 + 10 + 1 + 9 = 79
+var (
+	tabularFunctionFormatHead = "%-20s %-23s %6s %6s %10s %9s\n"
+	tabularFunctionFormatBody = "%-20s %-23s %6d %6d %10d %9d\n"
+)
+
+func renderFunctionsTabular(functions []FunctionSummary) string {
+	brk := tabularBreakFor(false)
+	p := gmessage.NewPrinter(glanguage.Make(os.Getenv("LANG")))
+
+	var sb strings.Builder
+	sb.WriteString(brk)
+	_, _ = fmt.Fprintf(&sb, tabularFunctionFormatHead, "Function", "Location", "Lines", "Code", "Complexity", "Cognitive")
+	sb.WriteString(brk)
+	for _, f := range functions {
+		location := fmt.Sprintf("%s:%d", f.Location, f.StartLine)
+		_, _ = fmt.Fprintf(&sb, tabularFunctionFormatBody,
+			unicodeAwareRightPad(unicodeAwareTrim(f.Name, 19), 20),
+			unicodeAwareRightPad(unicodeAwareTrim(location, 22), 23),
+			f.Lines, f.Code, f.Complexity, f.Cognitive)
+	}
+	if len(functions) == 0 {
+		_, _ = fmt.Fprintf(&sb, "No functions found (supported: %s)\n", strings.Join(functionLanguages(), ", "))
+	}
+	sb.WriteString(brk)
+	_, _ = p.Fprintf(&sb, "%d functions\n", len(functions))
+	return sb.String()
+}
+
+type functionJSONDoc struct {
+	Report    string            `json:"report"`
+	Functions []FunctionSummary `json:"functions"`
+}
+
+func renderFunctionsJSON(functions []FunctionSummary) (string, error) {
+	if functions == nil {
+		functions = []FunctionSummary{}
+	}
+	b, err := jsoniter.Marshal(functionJSONDoc{Report: "functions", Functions: functions})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func renderFunctionsCSV(functions []FunctionSummary) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	_ = w.Write([]string{"Location", "Language", "Function", "StartLine", "EndLine", "Lines", "Code", "Complexity", "Cognitive"})
+	for _, f := range functions {
+		_ = w.Write([]string{
+			f.Location, f.Language, f.Name,
+			strconv.FormatInt(f.StartLine, 10),
+			strconv.FormatInt(f.EndLine, 10),
+			strconv.FormatInt(f.Lines, 10),
+			strconv.FormatInt(f.Code, 10),
+			strconv.FormatInt(f.Complexity, 10),
+			strconv.FormatInt(f.Cognitive, 10),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
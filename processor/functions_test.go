@@ -0,0 +1,250 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func functionFixture(t *testing.T) map[string]FunctionSummary {
+	t.Helper()
+	ProcessConstants()
+	dir := writeTree(t, map[string]string{
+		"main.go": `package main
+
+// Ignored func fake() { in a comment
+func (s *Server) Start() error {
+	if s == nil {
+		return nil
+	}
+	go func() {
+		for {
+		}
+	}()
+	return nil
+}
+
+func helper() {}
+`,
+		"app.py": `class Shape:
+    def area(self,
+             scale):
+        """docstring with def fake():"""
+        if scale:
+            return 1
+
+        return 0
+
+def top():
+    def inner():
+        pass
+    return inner
+`,
+		"Main.java": `public class Main {
+    public static void main(String[] args) {
+        String s = "{";
+        if (args.length > 0) {
+            System.out.println(s);
+        }
+    }
+
+    abstract void nothing();
+
+    private int size() { return 0; }
+}
+`,
+		"app.ts": `export function load(path: string): number {
+  if (path) { return 1 }
+  return 0
+}
+
+const add = (a: number, b: number) => {
+  return a + b
+}
+
+class Box {
+  async open(): Promise<void> {
+    while (true) {}
+  }
+}
+`,
+		"app.js": `function foo(a) {
+  if (a) {
+    return 1
+  }
+  for (let i = 0; i < a; i++) {
+  }
+  return 0
+}
+
+if (debug) {
+  foo(1)
+}
+`,
+		"lib.rs": `pub fn parse(s: &str) -> bool {
+    match s {
+        _ => true,
+    }
+}
+
+impl Thing {
+    fn new() -> Self {
+        Thing {}
+    }
+}
+`,
+		"util.c": `#include <stdio.h>
+
+int count(int n);
+
+static int count(int n) {
+    if (n > 0) {
+        return n;
+    }
+    return 0;
+}
+`,
+	})
+
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	functions, err := countFunctions(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("countFunctions: %v", err)
+	}
+	got := map[string]FunctionSummary{}
+	for _, f := range functions {
+		got[f.Language+" "+f.Name] = f
+	}
+	return got
+}
+
+func TestFindFunctions(t *testing.T) {
+	got := functionFixture(t)
+
+	expected := map[string][2]int64{ // start and end line
+		"Go Server.Start": {4, 13},
+		"Go helper":       {15, 15},
+		"Python area":     {2, 8},
+		"Python top":      {10, 13},
+		"Java main":       {2, 7},
+		"Java size":       {11, 11},
+		"TypeScript load": {1, 4},
+		"TypeScript add":  {6, 8},
+		"TypeScript open": {11, 13},
+		"JavaScript foo":  {1, 8},
+		"Rust parse":      {1, 5},
+		"Rust new":        {8, 10},
+		"C count":         {5, 10},
+	}
+	for name, span := range expected {
+		f, ok := got[name]
+		if !ok {
+			t.Errorf("%s not found", name)
+			continue
+		}
+		if f.StartLine != span[0] || f.EndLine != span[1] {
+			t.Errorf("%s: expected lines %d-%d got %d-%d", name, span[0], span[1], f.StartLine, f.EndLine)
+		}
+	}
+	if len(got) != len(expected) {
+		t.Errorf("expected %d functions got %d: %v", len(expected), len(got), got)
+	}
+
+	if f := got["Go Server.Start"]; f.Complexity != 4 || f.Code != 10 || f.Cognitive <= f.Complexity {
+		t.Errorf("unexpected counts for Server.Start %+v", f)
+	}
+	if f := got["Python area"]; f.Complexity != 1 || f.Lines != 7 {
+		t.Errorf("unexpected counts for area %+v", f)
+	}
+}
+
+func TestFunctionDetectorMatch(t *testing.T) {
+	js := functionDetectors["JavaScript"]
+	for line, expected := range map[string]string{
+		"function foo(a) {":               "foo",
+		"async function load(path) {":     "load",
+		"  if (a) {":                      "",
+		"  for (let i = 0; i < a; i++) {": "",
+		"  while (true) {":                "",
+		"  return run(a)":                 "",
+	} {
+		name, ok := js.match([]byte(line))
+		if ok != (expected != "") || name != expected {
+			t.Errorf("%q: expected %q got %q %v", line, expected, name, ok)
+		}
+	}
+
+	c := functionDetectors["C"]
+	for _, line := range []string{"  if (n > 0) {", "  return count(n);", "  else if (x) {"} {
+		if name, ok := c.match([]byte(line)); ok {
+			t.Errorf("%q: expected no function got %q", line, name)
+		}
+	}
+}
+
+func TestSortFunctions(t *testing.T) {
+	functions := []FunctionSummary{
+		{Name: "b", Location: "a.go", Complexity: 1, Lines: 50},
+		{Name: "a", Location: "a.go", Complexity: 5, Lines: 10},
+		{Name: "c", Location: "b.go", Complexity: 5, Lines: 20, Cognitive: 9},
+	}
+	sortFunctions(functions, "files")
+	if functions[0].Name != "c" || functions[1].Name != "a" {
+		t.Errorf("expected most complex first got %+v", functions)
+	}
+	sortFunctions(functions, "lines")
+	if functions[0].Name != "b" {
+		t.Errorf("expected longest first got %+v", functions)
+	}
+}
+
+func TestRenderFunctions(t *testing.T) {
+	functions := []FunctionSummary{{Name: "main", Location: "main.go", Language: "Go", StartLine: 3, EndLine: 9, Lines: 7, Code: 6, Complexity: 2, Cognitive: 3}}
+	saved := Format
+	t.Cleanup(func() { Format = saved })
+
+	Format = "json"
+	out, err := renderFunctions(functions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc functionJSONDoc
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc.Report != "functions" || len(doc.Functions) != 1 || doc.Functions[0] != functions[0] {
+		t.Errorf("unexpected json %s", out)
+	}
+
+	Format = "csv"
+	out, err = renderFunctions(functions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if len(records) != 2 || strings.Join(records[1], ",") != "main.go,Go,main,3,9,7,6,2,3" {
+		t.Errorf("unexpected csv\n%s", out)
+	}
+
+	Format = "tabular"
+	out, err = renderFunctions(functions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "main.go:3") || !strings.Contains(out, "1 functions") {
+		t.Errorf("unexpected tabular\n%s", out)
+	}
+
+	Format = "html"
+	if _, err := renderFunctions(functions); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
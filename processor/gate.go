@@ -0,0 +1,489 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Quality gate metrics. The max- metrics and the no- checks apply to each
+// file, min-comment-ratio to each language over the files in scope.
+const (
+	gateMaxComplexity   = "max-complexity"
+	gateMaxCognitive    = "max-cognitive"
+	gateMaxLines        = "max-lines"
+	gateMaxCode         = "max-code"
+	gateMaxBytes        = "max-bytes"
+	gateMinCommentRatio = "min-comment-ratio"
+	gateNoMinified      = "no-minified"
+	gateNoGenerated     = "no-generated"
+)
+
+// gateMetric is a metric a rule can check and whether it takes a limit
+type gateMetric struct {
+	name     string
+	hasLimit bool
+}
+
+// gateMetrics lists the metrics in the order they are documented
+var gateMetrics = []gateMetric{
+	{gateMaxComplexity, true},
+	{gateMaxCognitive, true},
+	{gateMaxLines, true},
+	{gateMaxCode, true},
+	{gateMaxBytes, true},
+	{gateMinCommentRatio, true},
+	{gateNoMinified, false},
+	{gateNoGenerated, false},
+}
+
+// gateRule is one parsed --gate rule, for example
+// "max-complexity=25 language=Go,Java path=src/*"
+type gateRule struct {
+	Source    string // the rule as written
+	Metric    string
+	Limit     float64
+	Languages []string       // empty means every language
+	Path      *regexp.Regexp // nil means every path; a glob in the rule
+}
+
+// parseGateRule parses a rule of the form metric[=limit] [language=a,b] [path=glob].
+// The path glob is matched against the whole location as --count-as-pattern
+// matches, so "*" crosses directories.
+func parseGateRule(s string) (gateRule, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return gateRule{}, fmt.Errorf("empty rule")
+	}
+
+	rule := gateRule{Source: strings.Join(fields, " ")}
+	name, value, hasValue := strings.Cut(fields[0], "=")
+	idx := slices.IndexFunc(gateMetrics, func(m gateMetric) bool {
+		return m.name == name
+	})
+	if idx == -1 {
+		names := make([]string, 0, len(gateMetrics))
+		for _, m := range gateMetrics {
+			names = append(names, m.name)
+		}
+		return gateRule{}, fmt.Errorf("unknown metric %q (supported: %s)", name, strings.Join(names, ", "))
+	}
+	rule.Metric = name
+
+	switch {
+	case gateMetrics[idx].hasLimit && !hasValue:
+		return gateRule{}, fmt.Errorf("%s needs a limit, e.g. %s=10", name, name)
+	case !gateMetrics[idx].hasLimit && hasValue:
+		return gateRule{}, fmt.Errorf("%s does not take a limit", name)
+	case hasValue:
+		limit, err := strconv.ParseFloat(value, 64)
+		if err != nil || limit < 0 {
+			return gateRule{}, fmt.Errorf("invalid limit %q for %s", value, name)
+		}
+		rule.Limit = limit
+	}
+
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return gateRule{}, fmt.Errorf("expected language=... or path=... got %q", field)
+		}
+		switch key {
+		case "language", "lang":
+			rule.Languages = append(rule.Languages, strings.Split(value, ",")...)
+		case "path":
+			re, err := regexp.Compile(globToRegex(value))
+			if err != nil {
+				return gateRule{}, fmt.Errorf("invalid path glob %q: %w", value, err)
+			}
+			rule.Path = re
+		default:
+			return gateRule{}, fmt.Errorf("unknown scope %q (supported: language, path)", key)
+		}
+	}
+	return rule, nil
+}
+
+// readGateFile parses a rules file holding one rule per line. Blank lines and
+// lines starting with # are skipped.
+func readGateFile(name string) ([]gateRule, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []gateRule
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		rule, err := parseGateRule(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// gateRules collects the rules given by --gate and --gate-file
+func gateRules() ([]gateRule, error) {
+	var rules []gateRule
+	for _, s := range Gates {
+		rule, err := parseGateRule(s)
+		if err != nil {
+			return nil, fmt.Errorf("--gate %q: %w", s, err)
+		}
+		rules = append(rules, rule)
+	}
+	if GateFile != "" {
+		fromFile, err := readGateFile(GateFile)
+		if err != nil {
+			return nil, fmt.Errorf("--gate-file: %w", err)
+		}
+		rules = append(rules, fromFile...)
+	}
+	return rules, nil
+}
+
+// applies reports whether a file is in the rule's scope. The language is
+// matched without the (gen), (min), (vendor) or (docs) mark a run may add.
+func (r gateRule) applies(f *FileJob) bool {
+	language := baseLanguage(f.Language)
+	if len(r.Languages) != 0 && !slices.ContainsFunc(r.Languages, func(l string) bool {
+		return strings.EqualFold(l, language)
+	}) {
+		return false
+	}
+	return r.Path == nil || r.Path.MatchString(filepath.ToSlash(f.Location))
+}
+
+// languageMarks are appended to the language of generated, minified,
+// vendored and documentation files when they are counted apart
+var languageMarks = []string{" (gen)", " (min)", " (vendor)", " (docs)"}
+
+// baseLanguage returns language without any of languageMarks
+func baseLanguage(language string) string {
+	for {
+		i := slices.IndexFunc(languageMarks, func(mark string) bool {
+			return strings.HasSuffix(language, mark)
+		})
+		if i == -1 {
+			return language
+		}
+		language = strings.TrimSuffix(language, languageMarks[i])
+	}
+}
+
+// gateViolation is a file, or for min-comment-ratio a language, that broke a rule
+type gateViolation struct {
+	Rule     string
+	File     string
+	Language string
+	Value    float64
+	Limit    float64
+}
+
+// gateResult is the outcome of every rule over one run
+type gateResult struct {
+	Rules      []gateRule
+	Failed     map[string]int // violations by rule source
+	Violations []gateViolation
+}
+
+func (g *gateResult) passed() bool {
+	return len(g.Violations) == 0
+}
+
+// evaluateGates checks files against rules. no-minified and no-generated skip
+// the files vendor tells apart as vendored, as checked in third party code is
+// expected to carry build output.
+func evaluateGates(rules []gateRule, files []*FileJob, vendor *vendorRules) *gateResult {
+	result := &gateResult{Rules: rules, Failed: map[string]int{}}
+
+	for _, rule := range rules {
+		var violations []gateViolation
+		if rule.Metric == gateMinCommentRatio {
+			violations = commentRatioViolations(rule, files)
+		} else {
+			for _, f := range files {
+				if !rule.applies(f) {
+					continue
+				}
+				if value, broken := rule.fileValue(f, vendor); broken {
+					violations = append(violations, gateViolation{
+						Rule:     rule.Source,
+						File:     f.Location,
+						Language: f.Language,
+						Value:    value,
+						Limit:    rule.Limit,
+					})
+				}
+			}
+		}
+
+		// Worst first so the top of a long list is what needs looking at,
+		// which for a minimum is the lowest value
+		lowestFirst := rule.Metric == gateMinCommentRatio
+		sort.Slice(violations, func(i, j int) bool {
+			if violations[i].Value != violations[j].Value {
+				return (violations[i].Value > violations[j].Value) != lowestFirst
+			}
+			return violations[i].File+violations[i].Language < violations[j].File+violations[j].Language
+		})
+		result.Failed[rule.Source] += len(violations)
+		result.Violations = append(result.Violations, violations...)
+	}
+	return result
+}
+
+// fileValue returns the value a per file rule checks and whether it breaks it
+func (r gateRule) fileValue(f *FileJob, vendor *vendorRules) (float64, bool) {
+	var value float64
+	switch r.Metric {
+	case gateMaxComplexity:
+		value = float64(f.Complexity)
+	case gateMaxCognitive:
+		value = float64(f.Cognitive)
+	case gateMaxLines:
+		value = float64(f.Lines)
+	case gateMaxCode:
+		value = float64(f.Code)
+	case gateMaxBytes:
+		value = float64(f.Bytes)
+	case gateNoMinified:
+		return 0, f.Minified && !vendor.match(f.Location, f.attributes.vendored)
+	case gateNoGenerated:
+		return 0, f.Generated && !vendor.match(f.Location, f.attributes.vendored)
+	}
+	return value, value > r.Limit
+}
+
+// commentRatioViolations returns the languages whose comments make up less
+// than the rule's percentage of their code and comment lines. Generated,
+// minified, vendored and documentation files count towards their language
+// as they do for language=, so each language gets one ratio.
+func commentRatioViolations(rule gateRule, files []*FileJob) []gateViolation {
+	type totals struct{ code, comment int64 }
+	byLanguage := map[string]*totals{}
+	for _, f := range files {
+		if !rule.applies(f) {
+			continue
+		}
+		language := baseLanguage(f.Language)
+		t, ok := byLanguage[language]
+		if !ok {
+			t = &totals{}
+			byLanguage[language] = t
+		}
+		t.code += f.Code
+		t.comment += f.Comment
+	}
+
+	var violations []gateViolation
+	for language, t := range byLanguage {
+		if t.code+t.comment == 0 {
+			continue
+		}
+		ratio := float64(t.comment) / float64(t.code+t.comment) * 100
+		if ratio < rule.Limit {
+			violations = append(violations, gateViolation{
+				Rule:     rule.Source,
+				Language: language,
+				Value:    ratio,
+				Limit:    rule.Limit,
+			})
+		}
+	}
+	return violations
+}
+
+// gateOptions returns opts with whatever the rules need counted switched on
+func gateOptions(opts Options, rules []gateRule) Options {
+	for _, rule := range rules {
+		switch rule.Metric {
+		case gateMaxCognitive:
+			opts.Cognitive = true
+		case gateMaxComplexity:
+			opts.NoComplexity = false
+		case gateNoMinified:
+			opts.Minified = true
+			opts.IgnoreMinified = false
+		case gateNoGenerated:
+			opts.Generated = true
+			opts.IgnoreGenerated = false
+		}
+	}
+	return opts
+}
+
+// runGateReport is the dispatch entry point called from Process() when
+// --gate or --gate-file is set. It reports whether every rule passed.
+func runGateReport(ctx context.Context) (bool, error) {
+	rules, err := gateRules()
+	if err != nil {
+		return false, err
+	}
+
+	opts := gateOptions(optionsFromGlobals(), rules)
+	_, files, err := NewAnalyzer(opts).Run(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result := evaluateGates(rules, files, newVendorRules(opts.VendorPatterns))
+	out, err := renderGates(result)
+	if err != nil {
+		return false, err
+	}
+	if FileOutput == "" {
+		fmt.Print(out)
+	} else {
+		if err := os.WriteFile(FileOutput, []byte(out), 0644); err != nil {
+			return false, err
+		}
+		fmt.Println("results written to " + FileOutput)
+	}
+	return result.passed(), nil
+}
+
+func renderGates(g *gateResult) (string, error) {
+	switch strings.ToLower(Format) {
+	case "", "tabular", "wide":
+		return renderGatesTabular(g), nil
+	case "json":
+		return renderGatesJSON(g)
+	default:
+		return "", fmt.Errorf("unsupported --format %q for --gate (supported: tabular, json)", Format)
+	}
+}
+
+// formatGateValue renders counts whole and comment ratios as percentages
+func formatGateValue(metric string, v float64) string {
+	if metric == gateMinCommentRatio {
+		return strconv.FormatFloat(v, 'f', 1, 64) + "%"
+	}
+	return strconv.FormatFloat(v, 'f', 0, 64)
+}
+
+// Tabular column formats.
+//
+//	%-6s %s
+//	%-34s %-26s %8s %8s
+//	34 + 1 + 26 + 1 + 8 + 1 + 8 = 79
+var tabularGateRuleFormat = "%-6s %s\n"
+var tabularGateViolationFormat = "%-34s %-26s %8s %8s\n"
+
+func renderGatesTabular(g *gateResult) string {
+	brk := tabularBreakFor(false)
+	var sb strings.Builder
+
+	sb.WriteString(brk)
+	_, _ = fmt.Fprintf(&sb, "Quality gates · %d rules · %d violations\n", len(g.Rules), len(g.Violations))
+	sb.WriteString(brk)
+	for _, rule := range g.Rules {
+		status := "pass"
+		if g.Failed[rule.Source] != 0 {
+			status = "FAIL"
+		}
+		_, _ = fmt.Fprintf(&sb, tabularGateRuleFormat, status, rule.Source)
+	}
+	sb.WriteString(brk)
+
+	if len(g.Violations) == 0 {
+		return sb.String()
+	}
+
+	metrics := map[string]string{}
+	for _, rule := range g.Rules {
+		metrics[rule.Source] = rule.Metric
+	}
+
+	_, _ = fmt.Fprintf(&sb, tabularGateViolationFormat, "File", "Rule", "Value", "Limit")
+	sb.WriteString(brk)
+	for _, v := range g.Violations {
+		metric := metrics[v.Rule]
+		name := v.File
+		if name == "" {
+			name = v.Language
+		}
+		limit := formatGateValue(metric, v.Limit)
+		value := formatGateValue(metric, v.Value)
+		if metric == gateNoMinified || metric == gateNoGenerated {
+			limit, value = "", ""
+		}
+		_, _ = fmt.Fprintf(&sb, tabularGateViolationFormat,
+			unicodeAwareRightPad(unicodeAwareTrim(name, 33), 34),
+			unicodeAwareRightPad(unicodeAwareTrim(v.Rule, 25), 26),
+			value, limit)
+	}
+	sb.WriteString(brk)
+	return sb.String()
+}
+
+type gateJSONRule struct {
+	Rule       string `json:"rule"`
+	Passed     bool   `json:"passed"`
+	Violations int    `json:"violations"`
+}
+
+type gateJSONViolation struct {
+	Rule     string  `json:"rule"`
+	File     string  `json:"file,omitempty"`
+	Language string  `json:"language"`
+	Value    float64 `json:"value"`
+	Limit    float64 `json:"limit"`
+}
+
+type gateJSONDoc struct {
+	Report     string              `json:"report"`
+	Passed     bool                `json:"passed"`
+	Rules      []gateJSONRule      `json:"rules"`
+	Violations []gateJSONViolation `json:"violations"`
+}
+
+func renderGatesJSON(g *gateResult) (string, error) {
+	doc := gateJSONDoc{
+		Report:     "gates",
+		Passed:     g.passed(),
+		Rules:      make([]gateJSONRule, 0, len(g.Rules)),
+		Violations: make([]gateJSONViolation, 0, len(g.Violations)),
+	}
+	for _, rule := range g.Rules {
+		doc.Rules = append(doc.Rules, gateJSONRule{
+			Rule:       rule.Source,
+			Passed:     g.Failed[rule.Source] == 0,
+			Violations: g.Failed[rule.Source],
+		})
+	}
+	for _, v := range g.Violations {
+		doc.Violations = append(doc.Violations, gateJSONViolation{
+			Rule:     v.Rule,
+			File:     v.File,
+			Language: v.Language,
+			Value:    round1(v.Value),
+			Limit:    v.Limit,
+		})
+	}
+	b, err := jsoniter.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
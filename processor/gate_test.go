@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGateRule(t *testing.T) {
+	rule, err := parseGateRule("  max-complexity=25   language=Go,Java path=src/*  ")
+	if err != nil {
+		t.Fatalf("parseGateRule: %v", err)
+	}
+	if rule.Source != "max-complexity=25 language=Go,Java path=src/*" || rule.Metric != gateMaxComplexity || rule.Limit != 25 || len(rule.Languages) != 2 {
+		t.Errorf("unexpected rule %+v", rule)
+	}
+	if !rule.Path.MatchString("src/a/b.go") || rule.Path.MatchString("lib/b.go") {
+		t.Errorf("path glob matched wrongly")
+	}
+
+	for _, bad := range []string{"", "max-loc=3", "max-lines", "no-minified=1", "max-lines=x", "max-lines=-1", "max-lines=3 owner=me", "max-lines=3 path"} {
+		if _, err := parseGateRule(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestEvaluateGates(t *testing.T) {
+	files := []*FileJob{
+		{Location: "src/a.go", Language: "Go", Complexity: 30, Lines: 100, Code: 90, Comment: 2},
+		{Location: "src/b.go", Language: "Go", Complexity: 40, Lines: 10, Code: 8, Comment: 2},
+		{Location: "lib/c.java", Language: "Java", Complexity: 50, Lines: 10, Code: 5, Comment: 5},
+		{Location: "web/app.js", Language: "JavaScript", Minified: true, Code: 1},
+		{Location: "web/vendor/lib.min.js", Language: "JavaScript", Minified: true, Code: 1},
+		{Location: "web/deps/dep.js", Language: "JavaScript", Minified: true, Code: 1},
+		{Location: "web/marked.js", Language: "JavaScript", Minified: true, Code: 1, attributes: linguistAttributes{vendored: attributeSet}},
+		{Location: "vendor/ours.min.js", Language: "JavaScript", Minified: true, Code: 1, attributes: linguistAttributes{vendored: attributeUnset}},
+	}
+
+	var rules []gateRule
+	for _, s := range []string{"max-complexity=25 language=go", "max-lines=50 path=src/*", "min-comment-ratio=20", "no-minified", "no-generated"} {
+		rule, err := parseGateRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, rule)
+	}
+
+	// Vendored by convention, --vendor-pattern and linguist-vendored alike
+	result := evaluateGates(rules, files, newVendorRules([]string{"web/deps/**"}))
+	if result.passed() {
+		t.Fatal("expected gates to fail")
+	}
+
+	expected := map[string]int{
+		"max-complexity=25 language=go": 2,
+		"max-lines=50 path=src/*":       1,
+		"min-comment-ratio=20":          2,
+		"no-minified":                   2,
+		"no-generated":                  0,
+	}
+	for rule, n := range expected {
+		if result.Failed[rule] != n {
+			t.Errorf("%s: expected %d violations got %d", rule, n, result.Failed[rule])
+		}
+	}
+
+	// Worst first within a rule
+	if result.Violations[0].File != "src/b.go" {
+		t.Errorf("expected src/b.go first got %+v", result.Violations[0])
+	}
+	for _, v := range result.Violations {
+		if v.Rule == "min-comment-ratio=20" && v.Language == "Go" && (v.File != "" || v.Value > 4.1 || v.Value < 3.9) {
+			t.Errorf("unexpected Go comment ratio %+v", v)
+		}
+		if v.Rule == "no-minified" && v.File != "web/app.js" && v.File != "vendor/ours.min.js" {
+			t.Errorf("vendored minified file reported %+v", v)
+		}
+	}
+}
+
+func TestGateRuleAppliesToBaseLanguage(t *testing.T) {
+	rule, _ := parseGateRule("max-lines=5 language=Go")
+	for _, language := range []string{"Go", "Go (gen)", "Go (min)", "Go (gen) (vendor)"} {
+		if !rule.applies(&FileJob{Location: "a.go", Language: language}) {
+			t.Errorf("expected language=Go to apply to %s", language)
+		}
+	}
+	if rule.applies(&FileJob{Location: "a.gox", Language: "Gox"}) {
+		t.Error("expected language=Go not to apply to Gox")
+	}
+}
+
+func TestCommentRatioByBaseLanguage(t *testing.T) {
+	rule, _ := parseGateRule("min-comment-ratio=20")
+	violations := commentRatioViolations(rule, []*FileJob{
+		{Location: "a.go", Language: "Go", Code: 10},
+		{Location: "a_gen.go", Language: "Go (gen)", Code: 10, Comment: 5},
+		{Location: "vendor/x/x.go", Language: "Go (vendor)", Code: 20},
+	})
+	// 5 comment lines of 45
+	if len(violations) != 1 || violations[0].Language != "Go" || violations[0].Value > 11.2 || violations[0].Value < 11.1 {
+		t.Errorf("expected one ratio for Go got %+v", violations)
+	}
+}
+
+func TestReadGateFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "gates")
+	content := "# team policy\n\nmax-lines=1000\nno-generated path=src/*\n"
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := readGateFile(name)
+	if err != nil {
+		t.Fatalf("readGateFile: %v", err)
+	}
+	if len(rules) != 2 || rules[1].Metric != gateNoGenerated {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	if err := os.WriteFile(name, []byte("max-lines=1000\nmax-bogus=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readGateFile(name); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("expected error naming line 2 got %v", err)
+	}
+}
+
+func TestGateOptionsDetectMinified(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go": "package main\n",
+		"app.js":  "var a=1;" + strings.Repeat("b", 400) + "\n",
+		// Minified but vendored by name, as the vendor counts have it
+		"jquery.min.js": "var a=1;" + strings.Repeat("b", 400) + "\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rule, _ := parseGateRule("no-minified")
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	// Dropping minified files would hide them from the gate
+	opts.IgnoreMinified = true
+
+	_, counted, err := NewAnalyzer(gateOptions(opts, []gateRule{rule})).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	result := evaluateGates([]gateRule{rule}, counted, newVendorRules(nil))
+	if len(result.Violations) != 1 || !strings.HasSuffix(result.Violations[0].File, "app.js") {
+		t.Errorf("expected app.js to break no-minified got %+v", result.Violations)
+	}
+}
+
+func TestRenderGates(t *testing.T) {
+	rule, _ := parseGateRule("max-lines=5")
+	result := evaluateGates([]gateRule{rule}, []*FileJob{{Location: "a.go", Language: "Go", Lines: 9}}, newVendorRules(nil))
+
+	saved := Format
+	t.Cleanup(func() { Format = saved })
+
+	Format = "json"
+	out, err := renderGates(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc gateJSONDoc
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc.Passed || len(doc.Rules) != 1 || doc.Rules[0].Violations != 1 || doc.Violations[0].File != "a.go" || doc.Violations[0].Value != 9 {
+		t.Errorf("unexpected json %s", out)
+	}
+
+	Format = "tabular"
+	out, err = renderGates(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "FAIL   max-lines=5") || !strings.Contains(out, "a.go") {
+		t.Errorf("unexpected tabular\n%s", out)
+	}
+
+	Format = "csv"
+	if _, err := renderGates(result); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
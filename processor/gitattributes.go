@@ -0,0 +1,322 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// attributeState is what the .gitattributes files in force say about one
+// attribute of a file
+type attributeState uint8
+
+const (
+	attributeUnspecified attributeState = iota // not mentioned, or reset with !attr
+	attributeSet                               // attr, or attr=true
+	attributeUnset                             // -attr, or attr=false
+)
+
+// linguistAttributes are the overrides GitHub's linguist reads from
+// .gitattributes, as they apply to one file
+type linguistAttributes struct {
+	language      string // canonical language name from linguist-language, empty when not set
+	languageFrom  string // the .gitattributes file that set language
+	vendored      attributeState
+	generated     attributeState
+	documentation attributeState
+}
+
+// gitAttribute is one linguist attribute set, unset or reset by a line of a
+// .gitattributes file. For linguist-language value holds the resolved
+// language name.
+type gitAttribute struct {
+	name  string
+	state attributeState
+	value string
+}
+
+// gitAttributeRule is a line of a .gitattributes file reduced to the linguist
+// attributes it touches
+type gitAttributeRule struct {
+	pattern    string
+	attributes []gitAttribute
+}
+
+// apply records attr, from the .gitattributes file source, over whatever an
+// earlier line said about it
+func (a *linguistAttributes) apply(attr gitAttribute, source string) {
+	var state *attributeState
+	switch attr.name {
+	case "linguist-language":
+		a.language, a.languageFrom = "", ""
+		if attr.state == attributeSet {
+			a.language, a.languageFrom = attr.value, source
+		}
+		return
+	case "linguist-vendored":
+		state = &a.vendored
+	case "linguist-generated":
+		state = &a.generated
+	case "linguist-documentation":
+		state = &a.documentation
+	default:
+		return
+	}
+	*state = attr.state
+}
+
+// parseGitAttributes reads the linguist attributes out of a .gitattributes
+// file. Lines setting nothing linguist reads are dropped, as are the ones git
+// itself rejects or that can never match a file: negated patterns, macros
+// and patterns ending in a slash.
+func parseGitAttributes(r io.Reader, source string) []gitAttributeRule {
+	var rules []gitAttributeRule
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		fields := strings.Fields(scan.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		pattern := fields[0]
+		if strings.HasPrefix(pattern, "#") || strings.HasPrefix(pattern, "!") || strings.HasPrefix(pattern, "[attr]") || strings.HasSuffix(pattern, "/") {
+			continue
+		}
+
+		var attributes []gitAttribute
+		for _, field := range fields[1:] {
+			if attr, ok := parseLinguistAttribute(field, source); ok {
+				attributes = append(attributes, attr)
+			}
+		}
+		if len(attributes) != 0 {
+			rules = append(rules, gitAttributeRule{pattern: pattern, attributes: attributes})
+		}
+	}
+	return rules
+}
+
+// parseLinguistAttribute parses one attribute of a .gitattributes line,
+// reporting false for anything that is not a linguist attribute scc uses.
+// Like linguist, any value other than false sets a boolean attribute.
+func parseLinguistAttribute(field, source string) (gitAttribute, bool) {
+	attr := gitAttribute{state: attributeSet}
+	switch field[0] {
+	case '-':
+		attr.state = attributeUnset
+		field = field[1:]
+	case '!':
+		attr.state = attributeUnspecified
+		field = field[1:]
+	}
+	name, value, hasValue := strings.Cut(field, "=")
+	attr.name = name
+
+	switch name {
+	case "linguist-language":
+		if attr.state != attributeSet {
+			return attr, true
+		}
+		if !hasValue {
+			return attr, false
+		}
+		language, ok := linguistLanguage(value)
+		if !ok {
+			printWarnF("%s: ignoring linguist-language=%s as it is not a known language", source, value)
+			return attr, false
+		}
+		attr.value = language
+		return attr, true
+	case "linguist-vendored", "linguist-generated", "linguist-documentation":
+		if attr.state == attributeSet && hasValue && strings.EqualFold(value, "false") {
+			attr.state = attributeUnset
+		}
+		return attr, true
+	}
+	return attr, false
+}
+
+// linguistLanguage resolves the value of linguist-language to a language
+// name. GitHub writes multi-word names with dashes, e.g. Objective-C, so that
+// form is tried after the value as given.
+func linguistLanguage(value string) (string, bool) {
+	if language, ok := resolveBaseLanguage(value); ok {
+		return language, true
+	}
+	return resolveBaseLanguage(strings.ReplaceAll(value, "-", " "))
+}
+
+// matchAttributePattern reports whether a .gitattributes pattern matches rel,
+// the slash separated path of a file below the directory holding the
+// .gitattributes. As in git a pattern without a slash matches the file name
+// at any depth and anything else is matched against the whole of rel, with **
+// standing for any number of directories.
+func matchAttributePattern(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchPathElements(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), strings.Split(rel, "/"))
+}
+
+func matchPathElements(pattern, parts []string) bool {
+	for len(pattern) != 0 {
+		if pattern[0] == "**" {
+			// A trailing ** matches everything inside, so something must be left
+			if len(pattern) == 1 {
+				return len(parts) != 0
+			}
+			for i := range len(parts) + 1 {
+				if matchPathElements(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// gitAttributes finds the linguist attributes in force for a file from the
+// .gitattributes files in its directory and those above it, reading each
+// directory once. Locations are OS paths on the local disk when fsys is nil,
+// where the search stops at the repository root, and slash separated paths
+// within fsys otherwise, where it stops at the root of fsys.
+type gitAttributes struct {
+	fsys fs.FS
+	mu   sync.Mutex
+	dirs map[string][]attributeScope
+}
+
+// attributeScope is a .gitattributes file and the directory it applies below
+type attributeScope struct {
+	dir    string
+	source string
+	rules  []gitAttributeRule
+}
+
+func newGitAttributes(fsys fs.FS) *gitAttributes {
+	return &gitAttributes{fsys: fsys, dirs: map[string][]attributeScope{}}
+}
+
+// lookup returns the attributes in force for the file at location. A nil
+// gitAttributes, which is what --no-gitattributes gives, sets nothing. Later
+// lines win over earlier ones and deeper files over those above them.
+func (g *gitAttributes) lookup(location string) linguistAttributes {
+	var attrs linguistAttributes
+	if g == nil {
+		return attrs
+	}
+	if g.fsys == nil {
+		abs, err := filepath.Abs(location)
+		if err != nil {
+			return attrs
+		}
+		location = abs
+	}
+
+	g.mu.Lock()
+	scopes := g.scopes(g.dir(location))
+	g.mu.Unlock()
+
+	for _, scope := range scopes {
+		rel := g.rel(scope.dir, location)
+		for _, rule := range scope.rules {
+			if !matchAttributePattern(rule.pattern, rel) {
+				continue
+			}
+			for _, attr := range rule.attributes {
+				attrs.apply(attr, scope.source)
+			}
+		}
+	}
+	return attrs
+}
+
+// scopes returns the .gitattributes files that apply in dir, outermost first
+func (g *gitAttributes) scopes(dir string) []attributeScope {
+	if scopes, ok := g.dirs[dir]; ok {
+		return scopes
+	}
+
+	var scopes []attributeScope
+	if parent, ok := g.parent(dir); ok {
+		// Clipped so sibling directories do not share what is appended
+		scopes = slices.Clip(g.scopes(parent))
+	}
+	source := g.join(dir, ".gitattributes")
+	if rules := g.read(source); len(rules) != 0 {
+		scopes = append(scopes, attributeScope{dir: dir, source: source, rules: rules})
+	}
+	g.dirs[dir] = scopes
+	return scopes
+}
+
+func (g *gitAttributes) read(name string) []gitAttributeRule {
+	var f io.ReadCloser
+	var err error
+	if g.fsys == nil {
+		f, err = os.Open(name)
+	} else {
+		f, err = g.fsys.Open(name)
+	}
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	return parseGitAttributes(f, name)
+}
+
+func (g *gitAttributes) dir(location string) string {
+	if g.fsys == nil {
+		return filepath.Dir(location)
+	}
+	return path.Dir(location)
+}
+
+// parent returns the directory above dir, or false when dir is as far up as
+// .gitattributes files apply
+func (g *gitAttributes) parent(dir string) (string, bool) {
+	if g.fsys != nil {
+		return path.Dir(dir), dir != "."
+	}
+	if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+		return "", false
+	}
+	parent := filepath.Dir(dir)
+	return parent, parent != dir
+}
+
+func (g *gitAttributes) join(dir, name string) string {
+	if g.fsys == nil {
+		return filepath.Join(dir, name)
+	}
+	return path.Join(dir, name)
+}
+
+// rel returns location as a slash separated path relative to dir, which is
+// always one of the directories above it
+func (g *gitAttributes) rel(dir, location string) string {
+	if g.fsys == nil {
+		rel, _ := filepath.Rel(dir, location)
+		return filepath.ToSlash(rel)
+	}
+	if dir == "." {
+		return location
+	}
+	return strings.TrimPrefix(location, dir+"/")
+}
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestParseGitAttributes(t *testing.T) {
+	ProcessConstants()
+	rules := parseGitAttributes(strings.NewReader(`# comment
+*.rules linguist-language=Python text eol=lf
+*.m linguist-language=Objective-C
+*.x linguist-language=NoSuchLanguage
+vendor/** linguist-vendored
+gen/*.go linguist-generated=false -linguist-documentation
+docs/ linguist-documentation
+!*.go linguist-vendored
+*.bin binary
+lib/** !linguist-vendored linguist-generated=yes
+`), ".gitattributes")
+
+	var got []string
+	for _, rule := range rules {
+		for _, attr := range rule.attributes {
+			got = append(got, rule.pattern+" "+attr.name+"="+attr.value+stateName(attr.state))
+		}
+	}
+	expected := []string{
+		"*.rules linguist-language=Python set",
+		"*.m linguist-language=Objective C set",
+		"vendor/** linguist-vendored= set",
+		"gen/*.go linguist-generated= unset",
+		"gen/*.go linguist-documentation= unset",
+		"lib/** linguist-vendored= unspecified",
+		"lib/** linguist-generated= set",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func stateName(state attributeState) string {
+	switch state {
+	case attributeSet:
+		return " set"
+	case attributeUnset:
+		return " unset"
+	}
+	return " unspecified"
+}
+
+func TestMatchAttributePattern(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, rel string
+		expected     bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "a/b/main.go", true},
+		{"main.go", "a/main.go", true},
+		{"/main.go", "a/main.go", false},
+		{"/main.go", "main.go", true},
+		{"a/*.go", "a/main.go", true},
+		{"a/*.go", "a/b/main.go", false},
+		{"a/**", "a/b/main.go", true},
+		{"a/**", "a", false},
+		{"**/gen/*.go", "gen/a.go", true},
+		{"**/gen/*.go", "x/y/gen/a.go", true},
+		{"a/**/b.go", "a/b.go", true},
+		{"a/**/b.go", "a/x/y/b.go", true},
+		{"vendor", "vendor/lib.go", false},
+	} {
+		if got := matchAttributePattern(tc.pattern, tc.rel); got != tc.expected {
+			t.Errorf("%s %s: expected %t got %t", tc.pattern, tc.rel, tc.expected, got)
+		}
+	}
+}
+
+func TestGitAttributesLookup(t *testing.T) {
+	ProcessConstants()
+	dir := writeTree(t, map[string]string{
+		".gitattributes":            "*.go linguist-vendored\n",
+		"repo/.git/HEAD":            "ref: refs/heads/main\n",
+		"repo/.gitattributes":       "*.rules linguist-language=Python\nlib/** linguist-vendored\n*.md linguist-documentation\n",
+		"repo/lib/.gitattributes":   "keep.go -linguist-vendored\n*.rules linguist-language=Ruby\n",
+		"repo/lib/keep.go":          "",
+		"repo/lib/dep.go":           "",
+		"repo/lib/x.rules":          "",
+		"repo/main.go":              "",
+		"repo/docs/README.md":       "",
+		"repo/docs/.gitattributes":  "README.md !linguist-documentation\n",
+		"repo/docs/guide/intro.md":  "",
+		"repo/docs/guide/intro.txt": "",
+	})
+	repo := filepath.Join(dir, "repo")
+	g := newGitAttributes(nil)
+
+	// The .gitattributes above the repository root does not apply
+	if attrs := g.lookup(filepath.Join(repo, "main.go")); attrs.vendored != attributeUnspecified {
+		t.Errorf("expected main.go not vendored got %+v", attrs)
+	}
+	if attrs := g.lookup(filepath.Join(repo, "lib", "dep.go")); attrs.vendored != attributeSet {
+		t.Errorf("expected lib/dep.go vendored got %+v", attrs)
+	}
+	if attrs := g.lookup(filepath.Join(repo, "lib", "keep.go")); attrs.vendored != attributeUnset {
+		t.Errorf("expected the deeper file to unset vendored got %+v", attrs)
+	}
+	if attrs := g.lookup(filepath.Join(repo, "lib", "x.rules")); attrs.language != "Ruby" || attrs.languageFrom != filepath.Join(repo, "lib", ".gitattributes") {
+		t.Errorf("expected the deeper file to win the language got %+v", attrs)
+	}
+	if attrs := g.lookup(filepath.Join(repo, "docs", "README.md")); attrs.documentation != attributeUnspecified {
+		t.Errorf("expected !linguist-documentation to reset got %+v", attrs)
+	}
+	if attrs := g.lookup(filepath.Join(repo, "docs", "guide", "intro.md")); attrs.documentation != attributeSet {
+		t.Errorf("expected docs/guide/intro.md documentation got %+v", attrs)
+	}
+
+	fsys := fstest.MapFS{
+		".gitattributes":     {Data: []byte("*.rules linguist-language=Python\n")},
+		"lib/.gitattributes": {Data: []byte("*.rules -linguist-language\n")},
+	}
+	g = newGitAttributes(fsys)
+	if attrs := g.lookup("a/b.rules"); attrs.language != "Python" || attrs.languageFrom != ".gitattributes" {
+		t.Errorf("unexpected attributes %+v", attrs)
+	}
+	if attrs := g.lookup("lib/b.rules"); attrs.language != "" {
+		t.Errorf("expected -linguist-language to unset got %+v", attrs)
+	}
+
+	var none *gitAttributes
+	if attrs := none.lookup("a/b.rules"); attrs != (linguistAttributes{}) {
+		t.Errorf("expected nothing from a nil lookup got %+v", attrs)
+	}
+}
+
+func TestGitAttributesRun(t *testing.T) {
+	files := map[string]string{
+		".gitattributes":     "vendor/** linguist-vendored\ndocs/** linguist-documentation\ngen/*.go linguist-generated\n*.rules linguist-language=Python\n",
+		"main.go":            "package main\n",
+		"vendor/lib/a.go":    "package lib\n",
+		"docs/guide.md":      "# Guide\n",
+		"gen/g.go":           "package gen\n",
+		"tool.rules":         "x = 1\n",
+		"sub/.gitattributes": "b.go -linguist-generated\n",
+		"sub/b.go":           "// Code generated, DO NOT EDIT.\npackage sub\n",
+	}
+	dir := writeTree(t, files)
+	opts := DefaultOptions()
+	if got := languageCounts(runTree(t, dir, opts)); got != "Go=4,Markdown=1,Python=1" {
+		t.Errorf("unexpected default run %s", got)
+	}
+
+	separate := opts
+	separate.Generated, separate.Vendored, separate.Documentation = true, true, true
+	if got := languageCounts(runTree(t, dir, separate)); got != "Go=2,Go (gen)=1,Go (vendor)=1,Markdown (docs)=1,Python=1" {
+		t.Errorf("unexpected separate run %s", got)
+	}
+
+	dropped := opts
+	dropped.IgnoreGenerated, dropped.IgnoreVendored, dropped.IgnoreDocumentation = true, true, true
+	if got := languageCounts(runTree(t, dir, dropped)); got != "Go=2,Python=1" {
+		t.Errorf("unexpected dropping run %s", got)
+	}
+
+	// vendor/ is still found by its path
+	disabled := separate
+	disabled.NoGitAttributes = true
+	if got := languageCounts(runTree(t, dir, disabled)); got != "Go=2,Go (gen)=1,Go (vendor)=1,Markdown=1,Snakemake=1" {
+		t.Errorf("unexpected run without .gitattributes %s", got)
+	}
+
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	separate.Paths = []string{"."}
+	summary, _, err := NewAnalyzer(separate).RunFS(context.Background(), fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := languageCounts(languagesByName(summary)); got != "Go=2,Go (gen)=1,Go (vendor)=1,Markdown (docs)=1,Python=1" {
+		t.Errorf("unexpected fs run %s", got)
+	}
+}
+
+func TestGitAttributesHistory(t *testing.T) {
+	ProcessConstants()
+	saved := IgnoreVendored
+	t.Cleanup(func() { IgnoreVendored = saved })
+
+	cache := newBlobClassifyCache()
+	cache.attributes = newGitAttributes(fstest.MapFS{
+		".gitattributes": {Data: []byte("/tool.rules linguist-language=Python\nvendor/** linguist-vendored\n")},
+	})
+	blob := []byte("x = 1\n")
+	hash := plumbing.ComputeHash(plumbing.BlobObject, blob)
+
+	if res := cache.classify(hash, "tool.rules", blob); !res.ok || res.language != "Python" {
+		t.Errorf("expected linguist-language to force Python got %+v", res)
+	}
+	// The same blob without the attribute is detected, and cached apart
+	if res := cache.classify(hash, "x/tool.rules", blob); !res.ok || res.language == "Python" {
+		t.Errorf("expected detection without the attribute got %+v", res)
+	}
+	if len(cache.entries) != 2 {
+		t.Errorf("expected 2 cache entries got %d", len(cache.entries))
+	}
+
+	IgnoreVendored = true
+	if res := cache.classify(hash, "vendor/tool.py", blob); res.ok {
+		t.Errorf("expected --no-vendor to drop vendored files got %+v", res)
+	}
+}
+
+func TestGitAttributesHistoryGenerated(t *testing.T) {
+	ProcessConstants()
+	savedGenerated, savedMarkers := Generated, GeneratedMarkers
+	t.Cleanup(func() { Generated, GeneratedMarkers = savedGenerated, savedMarkers })
+	Generated = true
+	GeneratedMarkers = []string{"do not edit"}
+
+	cache := newBlobClassifyCache()
+	cache.attributes = newGitAttributes(fstest.MapFS{
+		".gitattributes": {Data: []byte("gen/*.go linguist-generated\nkeep/*.go -linguist-generated\n")},
+	})
+	plain := []byte("package gen\n")
+	marked := []byte("// DO NOT EDIT\npackage keep\n")
+
+	if res := cache.classify(plumbing.ComputeHash(plumbing.BlobObject, plain), "gen/x.go", plain); res.language != "Go (gen)" {
+		t.Errorf("expected linguist-generated to mark the file got %+v", res)
+	}
+	// linguist-generated=false wins over the marker, and the same blob
+	// without the attribute is still marked by it, so they are cached apart
+	hash := plumbing.ComputeHash(plumbing.BlobObject, marked)
+	if res := cache.classify(hash, "keep/x.go", marked); res.language != "Go" {
+		t.Errorf("expected -linguist-generated to leave the file unmarked got %+v", res)
+	}
+	if res := cache.classify(hash, "other/x.go", marked); res.language != "Go (gen)" {
+		t.Errorf("expected the marker to mark the file got %+v", res)
+	}
+}
+
+func TestExplainGitAttributes(t *testing.T) {
+	ProcessConstants()
+	dir := writeTree(t, map[string]string{
+		".gitattributes": "*.rules linguist-language=Python\n",
+		"tool.rules":     "x = 1\n",
+	})
+	opts := DefaultOptions()
+	e, err := explainLanguage(&opts, filepath.Join(dir, "tool.rules"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Language != "Python" || e.MatchedBy != "gitattributes" || e.Attributes != filepath.Join(dir, ".gitattributes") {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+
+	opts.NoGitAttributes = true
+	if e, _ := explainLanguage(&opts, filepath.Join(dir, "tool.rules")); e.Language == "Python" {
+		t.Errorf("expected --no-gitattributes to skip the attribute got %+v", e)
+	}
+}
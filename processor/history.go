@@ -7,7 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-git/go-git/v5"
@@ -22,6 +25,38 @@ import (
 // means "entire history". Wired to --depth in main.go.
 var HistoryDepth = 1000
 
+// HistoryJobWorkers is the number of goroutines diffing and classifying
+// commits for the history reports. Wired to --history-job-workers.
+var HistoryJobWorkers = runtime.NumCPU()
+
+// HistoryCache keeps the classification of every blob the history reports
+// see on disk so later runs only classify the blobs that are new to them.
+// Wired to --history-cache.
+var HistoryCache = false
+
+// HistoryCacheDir is the directory --history-cache keeps its file in. Empty
+// means the repository's .git directory, or the user cache directory when
+// that cannot be written. Wired to --history-cache-dir.
+var HistoryCacheDir = ""
+
+// HistoryCacheSize is the size in megabytes past which --history-cache drops
+// the blobs least recently used. Wired to --history-cache-size.
+var HistoryCacheSize = 256
+
+// HistoryDepthSet records whether --depth was given. Without it a window
+// limited by HistorySince, HistoryUntil or HistoryRange is walked in full.
+var HistoryDepthSet = false
+
+// HistorySince and HistoryUntil limit the history reports to the commits
+// made between them, as a date such as 2026-01-31 or a relative time such as
+// "3 months ago". Empty means no limit. Wired to --since and --until.
+var HistorySince = ""
+var HistoryUntil = ""
+
+// HistoryRange is a git revision range such as v3.0.0..HEAD the history
+// reports walk instead of the commits leading up to HEAD. Wired to --range.
+var HistoryRange = ""
+
 // LineRange is a half-open line span [Start, Start+Count) in 1-based line
 // numbers. A FileChange carries one entry per contiguous run of added (or
 // removed) lines emitted by go-git's diff.
@@ -41,7 +76,8 @@ type CommitInfo struct {
 // FileChange is one changed file inside a commit. AddedRanges/RemovedRanges
 // describe the diff against the first parent; LineTypes and Complexity are
 // scc's classifier output for the new blob (one LineType per line, one entry
-// in Complexity per line that fired a complexity tick).
+// in Complexity per line that fired a complexity tick). ComplexityTotal and
+// CognitiveTotal are the new blob's whole-file scores, as in HeadFile.
 type FileChange struct {
 	Path             string
 	FromPath         string // != Path on a detected rename; "" on a pure add
@@ -51,6 +87,8 @@ type FileChange struct {
 	LineTypes        []LineType
 	RemovedLineTypes []LineType // old-blob line types, for code-filtered removals
 	Complexity       []int
+	ComplexityTotal  int64
+	CognitiveTotal   int64 // zero unless the Cognitive global is on
 	NewBlob          []byte
 }
 
@@ -70,6 +108,10 @@ type HistoryWindow struct {
 	From    time.Time
 	To      time.Time
 	Head    plumbing.Hash
+	Since   time.Time     // --since, zero when not limited
+	Until   time.Time     // --until, zero when not limited
+	Range   string        // --range as given, such as v3.0.0..HEAD
+	Base    plumbing.Hash // the commit --range starts after, zero without one
 }
 
 // HeadFile is one file in the HEAD tree, classified by scc's engine.
@@ -89,10 +131,12 @@ type HeadSnapshot struct {
 // scc's engine. Carries per-line type and complexity placement so observers
 // can attribute lines that survive untouched from before the window.
 type BaselineFile struct {
-	Path       string
-	Language   string
-	LineTypes  []LineType
-	Complexity []int // 1-based line numbers that fired a complexity tick
+	Path            string
+	Language        string
+	LineTypes       []LineType
+	Complexity      []int // 1-based line numbers that fired a complexity tick
+	ComplexityTotal int64
+	CognitiveTotal  int64 // zero unless the Cognitive global is on
 }
 
 // BaselineSnapshot is the optional pre-walk state handed to observers that
@@ -199,9 +243,12 @@ func emptySnapshot() HeadSnapshot {
 }
 
 // runHistory opens the repo at repoPath, walks up to HistoryDepth commits
-// (newest first → oldest first), and feeds every commit's first-parent diff
-// to the observer.
-func runHistory(repoPath string, observer CommitObserver) (HistoryWindow, error) {
+// (newest first → oldest first) within the HistorySince, HistoryUntil and
+// HistoryRange limits, and feeds every commit's first-parent diff to the
+// observer. When ctx is cancelled the walk stops between commits and
+// ctx.Err() is returned without finalising the observer. Commits diffed out
+// of the window are reported to any ProgressFunc attached to ctx.
+func runHistory(ctx context.Context, repoPath string, observer CommitObserver) (HistoryWindow, error) {
 	// Turn GC back on because we have no idea how much we are about to process
 	EnableGc()
 
@@ -219,8 +266,13 @@ func runHistory(repoPath string, observer CommitObserver) (HistoryWindow, error)
 		return HistoryWindow{}, fmt.Errorf("read HEAD: %w", err)
 	}
 
+	limits, err := resolveHistoryLimits(repo, head.Hash(), time.Now())
+	if err != nil {
+		return HistoryWindow{}, err
+	}
+
 	iter, err := repo.Log(&git.LogOptions{
-		From:  head.Hash(),
+		From:  limits.tip,
 		Order: git.LogOrderCommitterTime,
 	})
 	if err != nil {
@@ -229,8 +281,18 @@ func runHistory(repoPath string, observer CommitObserver) (HistoryWindow, error)
 
 	collected := make([]*object.Commit, 0)
 	walkErr := iter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		keep, stop := limits.check(c)
+		if stop {
+			return errStopIter
+		}
+		if !keep {
+			return nil
+		}
 		collected = append(collected, c)
-		if HistoryDepth > 0 && len(collected) >= HistoryDepth {
+		if limits.depth > 0 && len(collected) >= limits.depth {
 			return errStopIter
 		}
 		return nil
@@ -242,45 +304,70 @@ func runHistory(repoPath string, observer CommitObserver) (HistoryWindow, error)
 	// "no more history to walk" situation as the root commit reaching zero
 	// parents, just reached via a missing-object error instead of a count.
 	// Treat it as end-of-history and keep what we walked, rather than aborting.
+	if err := ctx.Err(); err != nil {
+		return HistoryWindow{}, err
+	}
 	if walkErr != nil && !errors.Is(walkErr, errStopIter) && !errors.Is(walkErr, plumbing.ErrObjectNotFound) {
 		return HistoryWindow{}, fmt.Errorf("collect commits: %w", walkErr)
 	}
 
 	if len(collected) == 0 {
-		observer.Finalise(HistoryWindow{Head: head.Hash()}, emptySnapshot())
-		return HistoryWindow{Head: head.Hash()}, nil
+		window := limits.window(HistoryWindow{})
+		observer.Finalise(window, emptySnapshot())
+		return window, nil
 	}
 
-	window := HistoryWindow{
-		Depth:   HistoryDepth,
+	window := limits.window(HistoryWindow{
 		Commits: len(collected),
 		From:    collected[len(collected)-1].Author.When,
 		To:      collected[0].Author.When,
-		Head:    head.Hash(),
-	}
+	})
 
-	ignore, err := buildHistoryIgnore(repo, head.Hash())
+	ignore, err := buildHistoryIgnore(repo, limits.tip)
 	if err != nil {
 		printWarnF("history: ignore matcher: %s", err)
 	}
 
+	// Held around every read of the object store once commits are diffed
+	// concurrently, see diffCommits
+	store := new(sync.Mutex)
 	cache := newBlobClassifyCache()
+	if HistoryCache {
+		cache.disk = openHistoryDiskCache(repo, repoPath)
+		defer cache.disk.save()
+	}
+	if !GitAttributes {
+		if tree, err := collected[0].Tree(); err == nil {
+			cache.attributes = newGitAttributes(&revisionFS{tree: tree, mu: store})
+		}
+	}
 
 	if mo, ok := observer.(MailmapObserver); ok {
 		mo.SetMailmap(loadMailmapForHead(collected[0]))
 	}
 
 	if bo, ok := observer.(BaselineObserver); ok {
-		baseline := buildBaselineForObserver(collected, ignore, cache)
+		baseline := buildBaselineForObserver(ctx, collected, ignore, cache)
+		if err := ctx.Err(); err != nil {
+			return HistoryWindow{}, err
+		}
 		bo.Seed(baseline)
 	}
 
-	ctx := context.Background()
-	for i := len(collected) - 1; i >= 0; i-- {
-		commit := collected[i]
-		changes, err := commitChanges(ctx, commit, ignore, cache)
-		if err != nil {
-			printWarnF("history: diff %s: %s", commit.Hash, err)
+	progress := newProgressReporter(ctx)
+	progress.commits(0, len(collected))
+	oldestFirst := slices.Clone(collected)
+	slices.Reverse(oldestFirst)
+	observed := 0
+	for diff := range diffCommits(ctx, oldestFirst, HistoryJobWorkers, store, ignore, cache) {
+		if err := ctx.Err(); err != nil {
+			return HistoryWindow{}, err
+		}
+		observed++
+		progress.commits(observed, len(collected))
+		commit := diff.commit
+		if diff.err != nil {
+			printWarnF("history: diff %s: %s", commit.Hash, diff.err)
 			continue
 		}
 		observer.Observe(CommitInfo{
@@ -288,16 +375,23 @@ func runHistory(repoPath string, observer CommitObserver) (HistoryWindow, error)
 			Author: commit.Author.Name,
 			Email:  commit.Author.Email,
 			When:   commit.Author.When,
-		}, changes)
+		}, diff.changes)
+	}
+	if err := ctx.Err(); err != nil {
+		return HistoryWindow{}, err
 	}
 
-	snapshot, err := buildHeadSnapshot(collected[0], ignore, cache)
+	snapshot, err := buildHeadSnapshot(ctx, collected[0], ignore, cache)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return HistoryWindow{}, ctxErr
+	}
 	if err != nil {
 		printWarnF("history: head snapshot: %s", err)
 		snapshot = emptySnapshot()
 	}
 
 	observer.Finalise(window, snapshot)
+	progress.done()
 	return window, nil
 }
 
@@ -321,7 +415,7 @@ func loadMailmapForHead(headCommit *object.Commit) *mailmap {
 // tree at the window's start commit. The start commit is the first-parent of
 // the oldest commit in the window; if that commit has no parents (the window
 // covers all history) the baseline files map is empty.
-func buildBaselineForObserver(collected []*object.Commit, ignore *historyIgnore, cache *blobClassifyCache) (baseline BaselineSnapshot) {
+func buildBaselineForObserver(ctx context.Context, collected []*object.Commit, ignore *historyIgnore, cache *blobClassifyCache) (baseline BaselineSnapshot) {
 	baseline = BaselineSnapshot{Files: map[string]BaselineFile{}}
 	// Backstop for panics outside the per-file recover below — go-git's
 	// tree.Files() iterator can itself panic on a corrupt object, and the
@@ -363,6 +457,9 @@ func buildBaselineForObserver(collected []*object.Commit, ignore *historyIgnore,
 				printWarnF("history: skipping %s in baseline — panicked: %v", name, r)
 			}
 		}()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if f.Mode == filemode.Dir || f.Mode == filemode.Submodule || f.Mode == filemode.Symlink {
 			return nil
 		}
@@ -383,10 +480,12 @@ func buildBaselineForObserver(collected []*object.Commit, ignore *historyIgnore,
 			return nil
 		}
 		baseline.Files[f.Name] = BaselineFile{
-			Path:       f.Name,
-			Language:   res.language,
-			LineTypes:  res.lineTypes,
-			Complexity: res.complexLine,
+			Path:            f.Name,
+			Language:        res.language,
+			LineTypes:       res.lineTypes,
+			Complexity:      res.complexLine,
+			ComplexityTotal: res.complexity,
+			CognitiveTotal:  res.cognitive,
 		}
 		return nil
 	})
@@ -397,7 +496,7 @@ func buildBaselineForObserver(collected []*object.Commit, ignore *historyIgnore,
 // buildHeadSnapshot walks the HEAD commit's tree and runs scc's classifier
 // on each file. Used by hotspots (and future reports) to know each surviving
 // file's current language and complexity.
-func buildHeadSnapshot(headCommit *object.Commit, ignore *historyIgnore, cache *blobClassifyCache) (snap HeadSnapshot, err error) {
+func buildHeadSnapshot(ctx context.Context, headCommit *object.Commit, ignore *historyIgnore, cache *blobClassifyCache) (snap HeadSnapshot, err error) {
 	snap = emptySnapshot()
 	// Backstop for panics outside the per-file recover below — go-git's
 	// tree.Files() iterator can itself panic on a corrupt object. Return the
@@ -425,6 +524,9 @@ func buildHeadSnapshot(headCommit *object.Commit, ignore *historyIgnore, cache *
 				printWarnF("history: skipping %s in HEAD snapshot — panicked: %v", name, r)
 			}
 		}()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if f.Mode == filemode.Dir || f.Mode == filemode.Submodule || f.Mode == filemode.Symlink {
 			return nil
 		}
@@ -467,12 +569,12 @@ var historyDiffOptions = &object.DiffTreeOptions{DetectRenames: true}
 // change into a FileChange. Skips paths that the engine can't count
 // (binary blobs, no language detected, submodules, symlinks, ignored paths).
 // Deletes are dropped because hotspots-style reports can't render files that
-// no longer exist.
+// no longer exist. store is held around every read of the object store.
 //
 // The outer recover catches anything the per-call wrappers don't (corrupt
 // packfiles via go-git object resolution, future regressions in the diff
 // pipeline). One bad commit becomes a warning, not a crash.
-func commitChanges(ctx context.Context, commit *object.Commit, ignore *historyIgnore, cache *blobClassifyCache) (out []FileChange, err error) {
+func commitChanges(ctx context.Context, commit *object.Commit, store *sync.Mutex, ignore *historyIgnore, cache *blobClassifyCache) (out []FileChange, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			printWarnF("history: skipping commit %s — diff pipeline panicked: %v", commit.Hash, r)
@@ -481,6 +583,28 @@ func commitChanges(ctx context.Context, commit *object.Commit, ignore *historyIg
 		}
 	}()
 
+	changes, err := commitTreeChanges(ctx, commit, store)
+	if err != nil || changes == nil {
+		return nil, err
+	}
+
+	out = make([]FileChange, 0, len(changes))
+	for _, change := range changes {
+		fc, ok := buildFileChange(change, store, ignore, cache)
+		if !ok {
+			continue
+		}
+		out = append(out, fc)
+	}
+	return out, nil
+}
+
+// commitTreeChanges diffs the tree of commit against its first parent's,
+// holding store throughout. The changes are nil for a shallow-clone boundary.
+func commitTreeChanges(ctx context.Context, commit *object.Commit, store *sync.Mutex) (object.Changes, error) {
+	store.Lock()
+	defer store.Unlock()
+
 	toTree, err := commit.Tree()
 	if err != nil {
 		return nil, err
@@ -508,24 +632,87 @@ func commitChanges(ctx context.Context, commit *object.Commit, ignore *historyIg
 		}
 	}
 
-	changes, err := object.DiffTreeWithOptions(ctx, fromTree, toTree, historyDiffOptions)
-	if err != nil {
-		return nil, err
-	}
+	return object.DiffTreeWithOptions(ctx, fromTree, toTree, historyDiffOptions)
+}
 
-	out = make([]FileChange, 0, len(changes))
-	for _, change := range changes {
-		fc, ok := buildFileChange(change, ignore, cache)
-		if !ok {
-			continue
+// commitDiff is the outcome of commitChanges for one commit
+type commitDiff struct {
+	commit  *object.Commit
+	changes []FileChange
+	err     error
+}
+
+// diffCommits runs commitChanges over commits on workers goroutines and
+// returns the diffs on the channel in the order of commits, so observers see
+// them exactly as a single loop would produce them. Workers only run a few
+// commits ahead of the receiver to bound the diffs held in memory. The
+// channel is closed once every commit is delivered or ctx is cancelled.
+//
+// go-git's object store is not safe for concurrent use, see revisionFS, so
+// the workers take store around the tree diff, the patch and the blob reads
+// and only classifying the blobs runs in parallel. Anything else reading the
+// repository while the workers run must hold store too.
+func diffCommits(ctx context.Context, commits []*object.Commit, workers int, store *sync.Mutex, ignore *historyIgnore, cache *blobClassifyCache) <-chan commitDiff {
+	workers = max(workers, 1)
+
+	type diffJob struct {
+		commit *object.Commit
+		out    chan commitDiff
+	}
+	jobs := make(chan diffJob)
+	// Each commit's result channel is queued in order as its job is handed
+	// out, so the receiver waits on them in turn whichever worker finishes
+	// first
+	pending := make(chan chan commitDiff, workers*2)
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		for _, commit := range commits {
+			out := make(chan commitDiff, 1)
+			select {
+			case jobs <- diffJob{commit: commit, out: out}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case pending <- out:
+			case <-ctx.Done():
+				return
+			}
 		}
-		out = append(out, fc)
+	}()
+
+	for range workers {
+		go func() {
+			for job := range jobs {
+				changes, err := commitChanges(ctx, job.commit, store, ignore, cache)
+				job.out <- commitDiff{commit: job.commit, changes: changes, err: err}
+			}
+		}()
 	}
-	return out, nil
+
+	ordered := make(chan commitDiff)
+	go func() {
+		defer close(ordered)
+		for out := range pending {
+			select {
+			case diff := <-out:
+				select {
+				case ordered <- diff:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ordered
 }
 
-// buildFileChange converts a single object.Change into a FileChange.
-func buildFileChange(change *object.Change, ignore *historyIgnore, cache *blobClassifyCache) (FileChange, bool) {
+// buildFileChange converts a single object.Change into a FileChange, holding
+// store while the patch and blobs are read but not while they are classified.
+func buildFileChange(change *object.Change, store *sync.Mutex, ignore *historyIgnore, cache *blobClassifyCache) (FileChange, bool) {
 	action, err := change.Action()
 	if err != nil {
 		return FileChange{}, false
@@ -545,12 +732,13 @@ func buildFileChange(change *object.Change, ignore *historyIgnore, cache *blobCl
 		return FileChange{}, false
 	}
 
+	// linguist-language can claim a file no extension does
 	languages, _ := DetectLanguage(path)
-	if len(languages) == 0 {
+	if len(languages) == 0 && (cache == nil || cache.attributes.lookup(path).language == "") {
 		return FileChange{}, false
 	}
 
-	patch, ok := safePatch(change)
+	patch, ok := safePatch(store, change)
 	if !ok {
 		return FileChange{}, false
 	}
@@ -581,7 +769,7 @@ func buildFileChange(change *object.Change, ignore *historyIgnore, cache *blobCl
 		}
 	}
 
-	blob, err := readBlob(change.To.Tree, &toEntry)
+	blob, err := readBlob(store, change.To.Tree, &toEntry)
 	if err != nil {
 		return FileChange{}, false
 	}
@@ -602,7 +790,7 @@ func buildFileChange(change *object.Change, ignore *historyIgnore, cache *blobCl
 		if fromEntry.Mode != filemode.Dir &&
 			fromEntry.Mode != filemode.Submodule &&
 			fromEntry.Mode != filemode.Symlink {
-			if oldBlob, rerr := readBlob(change.From.Tree, &fromEntry); rerr == nil {
+			if oldBlob, rerr := readBlob(store, change.From.Tree, &fromEntry); rerr == nil {
 				if oldRes := cache.classify(fromEntry.Hash, change.From.Name, oldBlob); oldRes.ok {
 					removedLineTypes = oldRes.lineTypes
 				}
@@ -619,6 +807,8 @@ func buildFileChange(change *object.Change, ignore *historyIgnore, cache *blobCl
 		LineTypes:        res.lineTypes,
 		RemovedLineTypes: removedLineTypes,
 		Complexity:       res.complexLine,
+		ComplexityTotal:  res.complexity,
+		CognitiveTotal:   res.cognitive,
 		NewBlob:          blob,
 	}, true
 }
@@ -627,8 +817,11 @@ func buildFileChange(change *object.Change, ignore *historyIgnore, cache *blobCl
 // sergi/go-diff line-to-rune encoding panics when a file has more distinct
 // lines than fit in the Unicode code-point space (generated SQL, huge
 // minified bundles, vendored data files). Treat any panic or error as
-// "skip this file" so one bad file does not abort the whole report.
-func safePatch(change *object.Change) (patch *object.Patch, ok bool) {
+// "skip this file" so one bad file does not abort the whole report. The
+// patch reads both blobs, so store is held throughout.
+func safePatch(store *sync.Mutex, change *object.Change) (patch *object.Patch, ok bool) {
+	store.Lock()
+	defer store.Unlock()
 	defer func() {
 		if r := recover(); r != nil {
 			path := ""
@@ -661,6 +854,13 @@ var classifyFn = classifyHistoryBlob
 // data). A panic in any one path-blob pair must not abort the report —
 // skip the file with a warning instead.
 func safeClassify(path string, blob []byte) (job *FileJob, lineTypes []LineType, ok bool) {
+	return safeClassifyAs(path, linguistAttributes{}, blob)
+}
+
+// safeClassifyAs is safeClassify for a file with the .gitattributes linguist
+// overrides attrs, such as a language given by linguist-language rather than
+// detected
+func safeClassifyAs(path string, attrs linguistAttributes, blob []byte) (job *FileJob, lineTypes []LineType, ok bool) {
 	defer func() {
 		if r := recover(); r != nil {
 			printWarnF("history: skipping %s — classifier panicked: %v", path, r)
@@ -669,11 +869,17 @@ func safeClassify(path string, blob []byte) (job *FileJob, lineTypes []LineType,
 			ok = false
 		}
 	}()
+	if attrs != (linguistAttributes{}) {
+		return classifyHistoryBlobAs(path, attrs, blob)
+	}
 	return classifyFn(path, blob)
 }
 
-// readBlob fetches the raw bytes for a tree entry.
-func readBlob(tree *object.Tree, entry *object.TreeEntry) ([]byte, error) {
+// readBlob fetches the raw bytes for a tree entry, holding store.
+func readBlob(store *sync.Mutex, tree *object.Tree, entry *object.TreeEntry) ([]byte, error) {
+	store.Lock()
+	defer store.Unlock()
+
 	file, err := tree.TreeEntryFile(entry)
 	if err != nil {
 		return nil, err
@@ -701,26 +907,79 @@ type blobClassifyResult struct {
 
 // blobClassifyCache memoises classifyHistoryBlob output keyed by blob hash so
 // the same blob seen in baseline, commit changes, and HEAD is classified once
-// per runHistory. The walk is sequential, so no mutex is required.
+// per runHistory, and across runs when disk is set.
 type blobClassifyCache struct {
-	entries map[plumbing.Hash]blobClassifyResult
+	mu         sync.Mutex // guards entries, as commits are diffed concurrently
+	entries    map[blobClassifyKey]blobClassifyResult
+	attributes *gitAttributes    // .gitattributes of the HEAD tree, nil with --no-gitattributes
+	vendor     *vendorRules      // vendored paths dropped with --no-vendor, nil without it
+	disk       *historyDiskCache // the --history-cache, nil without it
+}
+
+// blobClassifyKey tells apart a blob classified under the language a
+// linguist-language attribute forces from the same blob detected normally,
+// the same blob marked or unmarked by linguist-generated, and the same blob
+// under names detected differently, such as x.h and x.m. name is what
+// detection reads from the path, see classifyName.
+type blobClassifyKey struct {
+	hash      plumbing.Hash
+	name      string
+	language  string
+	generated attributeState
+}
+
+// classifyName is the part of path language detection goes by: the whole
+// name for files known by it, such as Makefile, otherwise the extension, so a
+// rename keeping the extension still shares the classification
+func classifyName(path string) string {
+	name := strings.ToLower(basename(path))
+	if _, ok := FilenameToLanguage[name]; ok {
+		return name
+	}
+	return getExtension(name)
 }
 
 func newBlobClassifyCache() *blobClassifyCache {
-	return &blobClassifyCache{entries: make(map[plumbing.Hash]blobClassifyResult)}
+	c := &blobClassifyCache{entries: make(map[blobClassifyKey]blobClassifyResult)}
+	if IgnoreVendored {
+		c.vendor = newVendorRules(VendorPatterns)
+	}
+	return c
 }
 
 // classify returns the classifier output for blob, computing and caching it
 // on first sight. Slices in the returned result are shared between callers —
 // they must be treated as read-only. Negative results (ok=false) are cached
-// too so binary/unknown blobs aren't re-attempted.
+// too so binary/unknown blobs aren't re-attempted. Vendored files, found by
+// path or marked in the HEAD tree's .gitattributes, and files marked
+// documentation are rejected under --no-vendor and --no-docs.
 func (c *blobClassifyCache) classify(hash plumbing.Hash, path string, blob []byte) blobClassifyResult {
+	var attrs linguistAttributes
+	var vendor *vendorRules
+	if c != nil {
+		attrs = c.attributes.lookup(path)
+		vendor = c.vendor
+	}
+	if (IgnoreVendored && vendor.match(path, attrs.vendored)) || (IgnoreDocumentation && attrs.documentation == attributeSet) {
+		return blobClassifyResult{}
+	}
+
+	key := blobClassifyKey{hash: hash, name: classifyName(path), language: attrs.language, generated: attrs.generated}
 	if c != nil {
-		if hit, found := c.entries[hash]; found {
+		c.mu.Lock()
+		hit, found := c.entries[key]
+		c.mu.Unlock()
+		if found {
+			return hit
+		}
+		if hit, found := c.disk.lookup(key); found {
+			c.mu.Lock()
+			c.entries[key] = hit
+			c.mu.Unlock()
 			return hit
 		}
 	}
-	job, lineTypes, ok := safeClassify(path, blob)
+	job, lineTypes, ok := safeClassifyAs(path, attrs, blob)
 	res := blobClassifyResult{ok: ok}
 	if ok {
 		res.language = job.Language
@@ -731,7 +990,10 @@ func (c *blobClassifyCache) classify(hash plumbing.Hash, path string, blob []byt
 		res.cognitiveLine = cognitiveLineNumbers(job)
 	}
 	if c != nil {
-		c.entries[hash] = res
+		c.mu.Lock()
+		c.entries[key] = res
+		c.mu.Unlock()
+		c.disk.store(key, res)
 	}
 	return res
 }
@@ -741,7 +1003,17 @@ func (c *blobClassifyCache) classify(hash plumbing.Hash, path string, blob []byt
 // / Blank populated) plus the per-line type vector. ok=false means the file
 // is binary or the language could not be resolved.
 func classifyHistoryBlob(path string, blob []byte) (*FileJob, []LineType, bool) {
+	return classifyHistoryBlobAs(path, linguistAttributes{}, blob)
+}
+
+// classifyHistoryBlobAs is classifyHistoryBlob applying the .gitattributes
+// linguist overrides attrs as processFile does: the language forced by
+// linguist-language and generated settled by linguist-generated
+func classifyHistoryBlobAs(path string, attrs linguistAttributes, blob []byte) (*FileJob, []LineType, bool) {
 	languages, extension := DetectLanguage(path)
+	if attrs.language != "" {
+		languages = []string{attrs.language}
+	}
 	if len(languages) == 0 {
 		return nil, nil, false
 	}
@@ -757,9 +1029,14 @@ func classifyHistoryBlob(path string, blob []byte) (*FileJob, []LineType, bool)
 		Bytes:                int64(len(blob)),
 		Content:              blob,
 		TrackComplexityLines: true,
+		attributes:           attrs,
 	}
 
 	job.Language = DetermineLanguage(job.Filename, job.Language, job.PossibleLanguages, job.Content)
+	if !slices.Contains(languages, job.Language) {
+		// Named by a modeline
+		LoadLanguageFeature(job.Language)
+	}
 	if job.Language == SheBang {
 		cutoff := min(len(blob), 200)
 		lang, err := DetectSheBang(blob[:cutoff])
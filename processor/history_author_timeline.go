@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"os"
@@ -189,9 +190,9 @@ func splitRemovedCodeLines(removed []LineRange, removedLineTypes []LineType) int
 // runAuthorTimelineReport is the dispatch entry point called from Process()
 // when --by-author --timeline is set. Opens the repo, walks the window with
 // the configured bucket count, and writes the chosen format.
-func runAuthorTimelineReport(repoPath string) error {
+func runAuthorTimelineReport(ctx context.Context, repoPath string) error {
 	observer := newHistoryAuthorTimelineObserver(HistoryBuckets)
-	if _, err := runHistory(repoPath, observer); err != nil {
+	if _, err := runHistory(ctx, repoPath, observer); err != nil {
 		return err
 	}
 	out, err := renderAuthorTimeline(observer)
@@ -407,6 +408,7 @@ type authorTimelineJSONWindow struct {
 	Commits int    `json:"commits"`
 	From    string `json:"from"`
 	To      string `json:"to"`
+	historyJSONRange
 }
 
 type authorTimelineJSONDoc struct {
@@ -428,6 +430,7 @@ func renderAuthorTimelineJSON(o *historyAuthorTimelineObserver) (string, error)
 		Buckets: o.bucket.N,
 		Authors: make([]authorTimelineJSONAuthor, 0, len(o.rows)),
 	}
+	doc.Window.historyJSONRange = jsonWindowRange(o.window)
 	for _, r := range o.rows {
 		ja := authorTimelineJSONAuthor{
 			Name:         r.Name,
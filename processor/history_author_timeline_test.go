@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"encoding/csv"
 	"os"
 	"path/filepath"
@@ -181,7 +182,7 @@ func TestAuthorTimelineSeriesShapes(t *testing.T) {
 	dir := makeTimelineRepo(t, commits)
 
 	obs := newHistoryAuthorTimelineObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -334,7 +335,7 @@ func TestAuthorTimelineCSVLongFormat(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorTimelineObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderAuthorTimeline(obs)
@@ -389,7 +390,7 @@ func TestAuthorTimelineJSONShape(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorTimelineObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderAuthorTimeline(obs)
@@ -457,7 +458,7 @@ func TestAuthorTimelineTabularContainsHeader(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorTimelineObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderAuthorTimeline(obs)
@@ -509,7 +510,7 @@ func TestAuthorTimelineMailmapFolding(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorTimelineObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	count := 0
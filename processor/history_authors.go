@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"os"
@@ -371,9 +372,9 @@ func registrableDomain(email string) string {
 // --by-author is set (and --timeline is not). Opens the repo at repoPath,
 // walks history with baseline seeding, and writes the chosen format to
 // stdout or FileOutput.
-func runAuthorsReport(repoPath string) error {
+func runAuthorsReport(ctx context.Context, repoPath string) error {
 	observer := newHistoryAuthorsObserver()
-	if _, err := runHistory(repoPath, observer); err != nil {
+	if _, err := runHistory(ctx, repoPath, observer); err != nil {
 		return err
 	}
 	out, err := renderAuthors(observer)
@@ -638,6 +639,7 @@ type authorsJSONWindow struct {
 	Commits int    `json:"commits"`
 	From    string `json:"from"`
 	To      string `json:"to"`
+	historyJSONRange
 }
 
 type authorsJSONDoc struct {
@@ -659,6 +661,7 @@ func renderAuthorsJSON(o *historyAuthorsObserver) (string, error) {
 		BusFactor: o.busFactor,
 		Authors:   make([]authorsJSONAuthor, 0, len(o.rows)),
 	}
+	doc.Window.historyJSONRange = jsonWindowRange(o.window)
 	for _, r := range o.rows {
 		a := authorsJSONAuthor{
 			Code:            r.Code,
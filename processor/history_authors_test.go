@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"encoding/csv"
 	"os"
 	"path/filepath"
@@ -100,7 +101,7 @@ func TestAuthorsLastToucherAttribution(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -126,7 +127,7 @@ func TestAuthorsPercentagesSumTo100(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -154,7 +155,7 @@ func TestAuthorsBaselineSentinel(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -194,7 +195,7 @@ func TestAuthorsMailmapFolding(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -223,7 +224,7 @@ func TestAuthorsBusFactorDominant(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -248,7 +249,7 @@ func TestAuthorsBusFactorBalanced(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -277,7 +278,7 @@ func TestAuthorsBusFactorIgnoresSentinel(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -331,7 +332,7 @@ func TestAuthorsBusFactorAllPreWindow(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -361,7 +362,7 @@ func TestAuthorsCSVIncludesEveryAuthorAndSentinel(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderAuthors(obs)
@@ -414,7 +415,7 @@ func TestAuthorsJSONShape(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderAuthors(obs)
@@ -514,7 +515,7 @@ func TestAuthorsDisambiguatesCollidingNames(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -557,7 +558,7 @@ func TestAuthorsTabularContainsBusFactorFooter(t *testing.T) {
 	})
 
 	obs := newHistoryAuthorsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderAuthors(obs)
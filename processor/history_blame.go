@@ -126,7 +126,14 @@ func (r *authorRegistry) record(id authorID) authorRecord {
 // truncated or sentinel-padded if the diff arithmetic disagrees with the
 // classifier's line count (e.g. renames, trailing-newline differences).
 func applyDiffToBlame(prev []authorID, newLines int, added, removed []LineRange, commit authorID) []authorID {
-	out := make([]authorID, 0, newLines)
+	return replayBlame(prev, newLines, added, removed, commit)
+}
+
+// replayBlame is applyDiffToBlame for any per-line attribution, such as the
+// commit that introduced each line. Padding uses the zero value of T, which
+// callers reserve for "(before window)" as sentinelAuthorID does.
+func replayBlame[T any](prev []T, newLines int, added, removed []LineRange, commit T) []T {
+	out := make([]T, 0, newLines)
 	oldPos := 1
 	newPos := 1
 	ai, ri := 0, 0
@@ -158,8 +165,9 @@ func applyDiffToBlame(prev []authorID, newLines int, added, removed []LineRange,
 	if len(out) > newLines {
 		out = out[:newLines]
 	}
+	var sentinel T
 	for len(out) < newLines {
-		out = append(out, sentinelAuthorID)
+		out = append(out, sentinel)
 	}
 	return out
 }
@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"testing"
 
 	"github.com/go-git/go-git/v5/plumbing"
@@ -110,7 +111,7 @@ func TestRunHistoryStillProducesHeadAndChanges(t *testing.T) {
 	})
 
 	cap := &captureObserver{}
-	if _, err := runHistory(dir, cap); err != nil {
+	if _, err := runHistory(context.Background(), dir, cap); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	for _, name := range []string{"a.go", "b.go", "c.go"} {
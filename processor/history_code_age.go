@@ -0,0 +1,472 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	glanguage "golang.org/x/text/language"
+	gmessage "golang.org/x/text/message"
+)
+
+// codeAgeTopN is the cap on the directory and file rows of the tabular code
+// age report, oldest first. CSV/JSON output is not capped.
+const codeAgeTopN = 15
+
+// codeAgeYears are the ages the report gives the share of code older than
+var codeAgeYears = [...]int{1, 2, 5}
+
+// commitStamp identifies the commit that introduced a line within a single
+// observer instance. 0 is reserved for lines surviving from before the
+// window's start commit, whose time is unknown.
+type commitStamp uint32
+
+// codeAgeRow is the age distribution of the code lines of one language,
+// directory or file.
+type codeAgeRow struct {
+	Name       string
+	Language   string // set on file rows only
+	Code       int64
+	MedianDays float64
+	// OlderPercent is the share of the code older than each of codeAgeYears
+	OlderPercent [len(codeAgeYears)]float64
+	// BeforeWindowPercent is the share of the code untouched since before
+	// the window. Its age is taken from the window's oldest commit, so it
+	// is a lower bound.
+	BeforeWindowPercent float64
+}
+
+// historyCodeAgeObserver replays the line-level blame as
+// historyAuthorsObserver does, recording the commit that introduced each
+// line rather than its author, then collapses the surviving code lines into
+// age distributions on Finalise. It implements BaselineObserver so the lines
+// of the tree at the window's start are seeded as before the window.
+type historyCodeAgeObserver struct {
+	stamps    map[string][]commitStamp
+	lineTypes map[string][]LineType
+	when      []time.Time // when[stamp] is the commit time, slot 0 the before-window sentinel
+
+	window HistoryWindow
+	asOf   time.Time
+
+	languages    []codeAgeRow
+	directories  []codeAgeRow
+	files        []codeAgeRow
+	total        codeAgeRow
+	directoryAll int
+	fileAll      int
+}
+
+func newHistoryCodeAgeObserver() *historyCodeAgeObserver {
+	return &historyCodeAgeObserver{
+		stamps:    map[string][]commitStamp{},
+		lineTypes: map[string][]LineType{},
+		when:      []time.Time{{}}, // slot 0 = before window
+	}
+}
+
+// Seed stamps every line of the baseline tree as before the window
+func (o *historyCodeAgeObserver) Seed(baseline BaselineSnapshot) {
+	for path, bf := range baseline.Files {
+		n := len(bf.LineTypes)
+		if n == 0 {
+			continue
+		}
+		o.stamps[path] = make([]commitStamp, n) // zero value = before window
+		o.lineTypes[path] = bf.LineTypes
+	}
+}
+
+func (o *historyCodeAgeObserver) Observe(c CommitInfo, changes []FileChange) {
+	stamp := commitStamp(len(o.when))
+	o.when = append(o.when, c.When)
+	for _, fc := range changes {
+		prev := o.stamps[fc.Path]
+		// A rename carries the old path's lines, and their age, forward
+		if fc.FromPath != "" && fc.FromPath != fc.Path {
+			if old, ok := o.stamps[fc.FromPath]; ok {
+				prev = old
+				delete(o.stamps, fc.FromPath)
+				delete(o.lineTypes, fc.FromPath)
+			}
+		}
+		o.stamps[fc.Path] = replayBlame(prev, len(fc.LineTypes), fc.AddedRanges, fc.RemovedRanges, stamp)
+		o.lineTypes[fc.Path] = fc.LineTypes
+	}
+}
+
+// codeAgeLines collects the commit times of the code lines of one row
+type codeAgeLines struct {
+	times  []int64 // unix seconds
+	before int64
+}
+
+func (o *historyCodeAgeObserver) Finalise(window HistoryWindow, head HeadSnapshot) {
+	o.window = window
+	// Ages are measured at the newest commit of the window rather than now,
+	// so the same window always reports the same ages
+	o.asOf = window.To
+	start := window.From.Unix()
+
+	languages := map[string]*codeAgeLines{}
+	directories := map[string]*codeAgeLines{}
+	files := map[string]*codeAgeLines{}
+	fileLanguage := map[string]string{}
+	total := &codeAgeLines{}
+
+	add := func(groups map[string]*codeAgeLines, name string, t int64, before bool) {
+		g := groups[name]
+		if g == nil {
+			g = &codeAgeLines{}
+			groups[name] = g
+		}
+		g.times = append(g.times, t)
+		if before {
+			g.before++
+		}
+	}
+
+	for file, stamps := range o.stamps {
+		hf, alive := head.Files[file]
+		if !alive {
+			continue
+		}
+		types := o.lineTypes[file]
+		dir := path.Dir(file)
+		for i := 0; i < len(stamps) && i < len(types); i++ {
+			if types[i] != LINE_CODE {
+				continue
+			}
+			t, before := start, stamps[i] == 0
+			if !before {
+				t = o.when[stamps[i]].Unix()
+			}
+			add(languages, hf.Language, t, before)
+			add(directories, dir, t, before)
+			add(files, file, t, before)
+			total.times = append(total.times, t)
+			if before {
+				total.before++
+			}
+		}
+		fileLanguage[file] = hf.Language
+	}
+
+	o.languages = o.codeAgeRows(languages)
+	o.directories = o.codeAgeRows(directories)
+	o.files = o.codeAgeRows(files)
+	for i := range o.files {
+		o.files[i].Language = fileLanguage[o.files[i].Name]
+	}
+	o.total = o.codeAgeRow("Total", total)
+	o.directoryAll = len(o.directories)
+	o.fileAll = len(o.files)
+
+	// Languages by code, directories and files oldest first as those are the
+	// ones worth a look
+	slices.SortFunc(o.languages, func(a, b codeAgeRow) int {
+		if a.Code != b.Code {
+			if a.Code < b.Code {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	oldestFirst := func(a, b codeAgeRow) int {
+		if a.MedianDays != b.MedianDays {
+			if a.MedianDays < b.MedianDays {
+				return 1
+			}
+			return -1
+		}
+		if a.Code != b.Code {
+			if a.Code < b.Code {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.Name, b.Name)
+	}
+	slices.SortFunc(o.directories, oldestFirst)
+	slices.SortFunc(o.files, oldestFirst)
+}
+
+func (o *historyCodeAgeObserver) codeAgeRows(groups map[string]*codeAgeLines) []codeAgeRow {
+	rows := make([]codeAgeRow, 0, len(groups))
+	for name, g := range groups {
+		rows = append(rows, o.codeAgeRow(name, g))
+	}
+	return rows
+}
+
+// codeAgeRow summarises the code lines of one row as ages at o.asOf
+func (o *historyCodeAgeObserver) codeAgeRow(name string, g *codeAgeLines) codeAgeRow {
+	row := codeAgeRow{Name: name, Code: int64(len(g.times))}
+	if row.Code == 0 {
+		return row
+	}
+
+	slices.Sort(g.times)
+	mid := len(g.times) / 2
+	median := float64(g.times[mid])
+	if len(g.times)%2 == 0 {
+		median = (float64(g.times[mid-1]) + median) / 2
+	}
+	row.MedianDays = math.Max(0, float64(o.asOf.Unix())-median) / (24 * 60 * 60)
+
+	for i, years := range codeAgeYears {
+		cutoff := o.asOf.AddDate(-years, 0, 0).Unix()
+		older, _ := slices.BinarySearch(g.times, cutoff)
+		row.OlderPercent[i] = float64(older) / float64(row.Code) * 100
+	}
+	row.BeforeWindowPercent = float64(g.before) / float64(row.Code) * 100
+	return row
+}
+
+// formatCodeAge renders an age in days as days, months or years, whichever
+// reads best at its size, such as 12d, 7mo or 3.4y
+func formatCodeAge(days float64) string {
+	switch {
+	case days < 61:
+		return fmt.Sprintf("%.0fd", days)
+	case days < 730:
+		return fmt.Sprintf("%.0fmo", days/30.44)
+	default:
+		return fmt.Sprintf("%.1fy", days/365.25)
+	}
+}
+
+// runCodeAgeReport is the dispatch entry point called from Process() when
+// --code-age is set. Opens the repo at repoPath, walks history with baseline
+// seeding, and writes the chosen format to stdout or FileOutput.
+func runCodeAgeReport(ctx context.Context, repoPath string) error {
+	observer := newHistoryCodeAgeObserver()
+	if _, err := runHistory(ctx, repoPath, observer); err != nil {
+		return err
+	}
+	out, err := renderCodeAge(observer)
+	if err != nil {
+		return err
+	}
+	if FileOutput == "" {
+		fmt.Print(out)
+	} else {
+		if err := os.WriteFile(FileOutput, []byte(out), 0644); err != nil {
+			return err
+		}
+		fmt.Println("results written to " + FileOutput)
+	}
+	return nil
+}
+
+func renderCodeAge(o *historyCodeAgeObserver) (string, error) {
+	switch strings.ToLower(Format) {
+	case "", "tabular", "wide":
+		return renderCodeAgeTabular(o), nil
+	case "csv":
+		return renderCodeAgeCSV(o)
+	case "json":
+		return renderCodeAgeJSON(o)
+	default:
+		return "", fmt.Errorf("unsupported --format %q for --code-age (supported: tabular, csv, json)", Format)
+	}
+}
+
+// Short tabular: %-36s %9s %8s %7s %7s %7s = 79.
+var tabularShortCodeAgeFormatHead = "%-36s %9s %8s %7s %7s %7s\n"
+
+// Wide tabular: the short columns plus the share of code from before the
+// window, with the name stretched to fill the 109-col rule.
+// %-57s %9s %8s %7s %7s %7s %8s = 109.
+var tabularWideCodeAgeFormatHead = "%-57s %9s %8s %7s %7s %7s %8s\n"
+
+func renderCodeAgeTabular(o *historyCodeAgeObserver) string {
+	wide := More || strings.EqualFold(Format, "wide")
+	brk := tabularBreakFor(wide)
+	p := gmessage.NewPrinter(glanguage.Make(os.Getenv("LANG")))
+
+	var sb strings.Builder
+	sb.WriteString(historyHeader("Code age", o.window, wide))
+
+	section := func(label string, rows []codeAgeRow) {
+		writeCodeAgeRow(&sb, wide, label, "Code", "Median", codeAgeOlderHeads(), "Before")
+		sb.WriteString(brk)
+		for _, r := range rows {
+			writeCodeAgeRow(&sb, wide, r.Name, formatWithCommas(p, r.Code), formatCodeAge(r.MedianDays),
+				codeAgePercents(r), fmt.Sprintf("%6.1f%%", r.BeforeWindowPercent))
+		}
+		sb.WriteString(brk)
+	}
+
+	section("Language", o.languages)
+	writeCodeAgeRow(&sb, wide, o.total.Name, formatWithCommas(p, o.total.Code), formatCodeAge(o.total.MedianDays),
+		codeAgePercents(o.total), fmt.Sprintf("%6.1f%%", o.total.BeforeWindowPercent))
+	sb.WriteString(brk)
+	section("Directory", o.directories[:min(len(o.directories), codeAgeTopN)])
+	section("File", o.files[:min(len(o.files), codeAgeTopN)])
+
+	if o.total.Code == 0 {
+		sb.WriteString("no code in window\n")
+		sb.WriteString(brk)
+		return sb.String()
+	}
+	footer := "ages as of " + formatWindowDate(o.asOf)
+	if o.directoryAll > codeAgeTopN || o.fileAll > codeAgeTopN {
+		footer += fmt.Sprintf(" · oldest %d of %d directories and %d of %d files",
+			min(o.directoryAll, codeAgeTopN), o.directoryAll, min(o.fileAll, codeAgeTopN), o.fileAll)
+	}
+	if o.total.BeforeWindowPercent > 0 {
+		footer += fmt.Sprintf("\n%.0f%% of code predates the window, its age is a lower bound", o.total.BeforeWindowPercent)
+	}
+	sb.WriteString(footer)
+	sb.WriteByte('\n')
+	sb.WriteString(brk)
+	return sb.String()
+}
+
+func codeAgeOlderHeads() []string {
+	heads := make([]string, 0, len(codeAgeYears))
+	for _, years := range codeAgeYears {
+		heads = append(heads, fmt.Sprintf(">%dy", years))
+	}
+	return heads
+}
+
+func codeAgePercents(r codeAgeRow) []string {
+	out := make([]string, 0, len(r.OlderPercent))
+	for _, pct := range r.OlderPercent {
+		out = append(out, fmt.Sprintf("%5.1f%%", pct))
+	}
+	return out
+}
+
+func writeCodeAgeRow(sb *strings.Builder, wide bool, name, code, median string, older []string, before string) {
+	trim, colWidth := 35, 36
+	if wide {
+		trim, colWidth = 56, 57
+	}
+	nameCol := unicodeAwareRightPad(unicodeAwareTrim(name, trim), colWidth)
+	if wide {
+		_, _ = fmt.Fprintf(sb, tabularWideCodeAgeFormatHead, nameCol, code, median, older[0], older[1], older[2], before)
+	} else {
+		_, _ = fmt.Fprintf(sb, tabularShortCodeAgeFormatHead, nameCol, code, median, older[0], older[1], older[2])
+	}
+}
+
+func renderCodeAgeCSV(o *historyCodeAgeObserver) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(formatWindowComment(o.window))
+	sb.WriteString(" asOf=" + formatWindowDate(o.asOf))
+	sb.WriteByte('\n')
+
+	w := csv.NewWriter(&sb)
+	head := []string{"Scope", "Name", "Language", "Code", "MedianAgeDays"}
+	for _, years := range codeAgeYears {
+		head = append(head, fmt.Sprintf("OlderThan%dy", years))
+	}
+	_ = w.Write(append(head, "BeforeWindow"))
+	for _, scope := range []struct {
+		name string
+		rows []codeAgeRow
+	}{
+		{"language", o.languages},
+		{"directory", o.directories},
+		{"file", o.files},
+	} {
+		for _, r := range scope.rows {
+			record := []string{scope.name, r.Name, r.Language, fmt.Sprintf("%d", r.Code), fmt.Sprintf("%.1f", r.MedianDays)}
+			for _, pct := range r.OlderPercent {
+				record = append(record, fmt.Sprintf("%.1f", pct))
+			}
+			_ = w.Write(append(record, fmt.Sprintf("%.1f", r.BeforeWindowPercent)))
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+type codeAgeJSONRow struct {
+	Name                string  `json:"name"`
+	Language            string  `json:"language,omitempty"`
+	Code                int64   `json:"code"`
+	MedianAgeDays       float64 `json:"medianAgeDays"`
+	OlderThan1yPercent  float64 `json:"olderThan1yPercent"`
+	OlderThan2yPercent  float64 `json:"olderThan2yPercent"`
+	OlderThan5yPercent  float64 `json:"olderThan5yPercent"`
+	BeforeWindowPercent float64 `json:"beforeWindowPercent"`
+}
+
+type codeAgeJSONWindow struct {
+	Depth   int    `json:"depth"`
+	Commits int    `json:"commits"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	AsOf    string `json:"asOf"`
+	historyJSONRange
+}
+
+type codeAgeJSONDoc struct {
+	Report      string            `json:"report"`
+	Window      codeAgeJSONWindow `json:"window"`
+	Total       codeAgeJSONRow    `json:"total"`
+	Languages   []codeAgeJSONRow  `json:"languages"`
+	Directories []codeAgeJSONRow  `json:"directories"`
+	Files       []codeAgeJSONRow  `json:"files"`
+}
+
+func codeAgeJSONRows(rows []codeAgeRow) []codeAgeJSONRow {
+	out := make([]codeAgeJSONRow, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, codeAgeJSONRowFor(r))
+	}
+	return out
+}
+
+func codeAgeJSONRowFor(r codeAgeRow) codeAgeJSONRow {
+	return codeAgeJSONRow{
+		Name:                r.Name,
+		Language:            r.Language,
+		Code:                r.Code,
+		MedianAgeDays:       round1(r.MedianDays),
+		OlderThan1yPercent:  round1(r.OlderPercent[0]),
+		OlderThan2yPercent:  round1(r.OlderPercent[1]),
+		OlderThan5yPercent:  round1(r.OlderPercent[2]),
+		BeforeWindowPercent: round1(r.BeforeWindowPercent),
+	}
+}
+
+func renderCodeAgeJSON(o *historyCodeAgeObserver) (string, error) {
+	doc := codeAgeJSONDoc{
+		Report: "codeAge",
+		Window: codeAgeJSONWindow{
+			Depth:   o.window.Depth,
+			Commits: o.window.Commits,
+			From:    formatWindowDate(o.window.From),
+			To:      formatWindowDate(o.window.To),
+			AsOf:    formatWindowDate(o.asOf),
+		},
+		Total:       codeAgeJSONRowFor(o.total),
+		Languages:   codeAgeJSONRows(o.languages),
+		Directories: codeAgeJSONRows(o.directories),
+		Files:       codeAgeJSONRows(o.files),
+	}
+	doc.Window.historyJSONRange = jsonWindowRange(o.window)
+	b, err := jsoniter.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func codeLines(n int) []LineType {
+	types := make([]LineType, n)
+	for i := range types {
+		types[i] = LINE_CODE
+	}
+	return types
+}
+
+func codeAgeFixture() *historyCodeAgeObserver {
+	o := newHistoryCodeAgeObserver()
+	// 2 lines from before the window, which starts on 2020-01-01
+	o.Seed(BaselineSnapshot{Files: map[string]BaselineFile{
+		"lib/old.go": {Path: "lib/old.go", LineTypes: codeLines(2)},
+	}})
+	o.Observe(CommitInfo{When: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}, []FileChange{
+		{Path: "lib/mid.go", LineTypes: codeLines(4), AddedRanges: []LineRange{{Start: 1, Count: 4}}},
+	})
+	o.Observe(CommitInfo{When: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}, []FileChange{
+		// Replaces the last line of lib/mid.go and adds a comment to it
+		{Path: "lib/mid.go", LineTypes: []LineType{LINE_CODE, LINE_CODE, LINE_CODE, LINE_CODE, LINE_COMMENT},
+			AddedRanges: []LineRange{{Start: 4, Count: 2}}, RemovedRanges: []LineRange{{Start: 4, Count: 1}}},
+		{Path: "main.py", LineTypes: codeLines(2), AddedRanges: []LineRange{{Start: 1, Count: 2}}},
+	})
+	o.Observe(CommitInfo{When: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}, []FileChange{
+		// A pure rename keeps the age of every line
+		{Path: "src/old.go", FromPath: "lib/old.go", LineTypes: codeLines(2)},
+	})
+	o.Finalise(HistoryWindow{
+		Commits: 3,
+		From:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+	}, HeadSnapshot{Files: map[string]HeadFile{
+		"lib/mid.go": {Path: "lib/mid.go", Language: "Go"},
+		"src/old.go": {Path: "src/old.go", Language: "Go"},
+		"main.py":    {Path: "main.py", Language: "Python"},
+	}})
+	return o
+}
+
+func TestCodeAgeObserver(t *testing.T) {
+	o := codeAgeFixture()
+
+	rows := map[string]codeAgeRow{}
+	for _, r := range append(append(o.languages, o.directories...), o.files...) {
+		rows[r.Name] = r
+	}
+
+	// 5 lines of Go from 2020-01-01, the 2 before the window dated by its
+	// start, and 1 from 2024-06-01
+	golang := rows["Go"]
+	if golang.Code != 6 || math.Abs(golang.BeforeWindowPercent-100.0/3) > 1e-9 {
+		t.Fatalf("unexpected Go row %+v", golang)
+	}
+	for _, pct := range golang.OlderPercent {
+		if math.Abs(pct-500.0/6) > 1e-9 {
+			t.Errorf("expected 5 of 6 lines older than 5 years got %v", golang.OlderPercent)
+		}
+	}
+	if days := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC).Sub(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)).Hours() / 24; golang.MedianDays != days {
+		t.Errorf("expected a median of %v days got %v", days, golang.MedianDays)
+	}
+	// Exactly a year old is not older than a year
+	if python := rows["Python"]; python.Code != 2 || python.MedianDays != 365 || python.OlderPercent != [3]float64{} {
+		t.Errorf("unexpected Python row %+v", python)
+	}
+
+	// Equally old files are ordered by code
+	if len(o.files) != 3 || o.files[0].Name != "lib/mid.go" || o.files[1].Name != "src/old.go" || o.files[2].Name != "main.py" {
+		t.Errorf("expected the files oldest first with the renamed file kept got %+v", o.files)
+	}
+	if old := rows["src/old.go"]; old.Language != "Go" || old.BeforeWindowPercent != 100 {
+		t.Errorf("expected the renamed file to keep its age got %+v", old)
+	}
+	if mid := rows["lib/mid.go"]; mid.Code != 4 || mid.BeforeWindowPercent != 0 {
+		t.Errorf("expected the comment left out of lib/mid.go got %+v", mid)
+	}
+	if _, ok := rows["lib/old.go"]; ok {
+		t.Error("expected the renamed path to be gone")
+	}
+	if len(o.directories) != 3 || o.directories[len(o.directories)-1].Name != "." {
+		t.Errorf("expected the root directory to be the newest got %+v", o.directories)
+	}
+	if o.total.Code != 8 || math.Abs(o.total.BeforeWindowPercent-25) > 1e-9 {
+		t.Errorf("unexpected total %+v", o.total)
+	}
+}
+
+func TestFormatCodeAge(t *testing.T) {
+	for days, expected := range map[float64]string{0: "0d", 12.4: "12d", 200: "7mo", 1241: "3.4y"} {
+		if got := formatCodeAge(days); got != expected {
+			t.Errorf("%v days: expected %s got %s", days, expected, got)
+		}
+	}
+}
+
+func TestRenderCodeAge(t *testing.T) {
+	o := codeAgeFixture()
+
+	tabular := renderCodeAgeTabular(o)
+	for _, want := range []string{"Code age · last 3 commits", "Language", "Directory", "File", "src/old.go", "5.4y", "ages as of 2025-06-01", "25% of code predates the window"} {
+		if !strings.Contains(tabular, want) {
+			t.Errorf("expected %q in\n%s", want, tabular)
+		}
+	}
+
+	csv, err := renderCodeAgeCSV(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv), "\n")
+	if !strings.HasSuffix(lines[0], "asOf=2025-06-01") || lines[1] != "Scope,Name,Language,Code,MedianAgeDays,OlderThan1y,OlderThan2y,OlderThan5y,BeforeWindow" {
+		t.Errorf("unexpected CSV head\n%s", csv)
+	}
+	if len(lines) != 2+2+3+3 || lines[2] != "language,Go,,6,1978.0,83.3,83.3,83.3,33.3" {
+		t.Errorf("unexpected CSV rows\n%s", csv)
+	}
+
+	out, err := renderCodeAgeJSON(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc codeAgeJSONDoc
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Report != "codeAge" || doc.Window.AsOf != "2025-06-01" || doc.Total.Code != 8 || len(doc.Files) != 3 || doc.Files[0].OlderThan5yPercent != 75 {
+		t.Errorf("unexpected JSON %s", out)
+	}
+}
+
+func TestRunHistoryCodeAge(t *testing.T) {
+	dir := makeFixtureRepo(t, []map[string]string{
+		{"a.go": "package a\n\nfunc A() {}\n"},
+		{"b/b.go": "package b\n"},
+	})
+	o := newHistoryCodeAgeObserver()
+	if _, err := runHistory(context.Background(), dir, o); err != nil {
+		t.Fatal(err)
+	}
+	if o.total.Code != 3 || len(o.directories) != 2 || len(o.files) != 2 || o.files[0].Name != "a.go" {
+		t.Errorf("unexpected code age %+v %+v", o.total, o.files)
+	}
+}
@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"reflect"
 	"testing"
 )
@@ -88,7 +89,7 @@ func TestHotspotsCognitiveRankingFlips(t *testing.T) {
 		t.Fatalf("Cognitive should default to false")
 	}
 	flatObs := newHotspotsObserver()
-	if _, err := runHistory(dir, flatObs); err != nil {
+	if _, err := runHistory(context.Background(), dir, flatObs); err != nil {
 		t.Fatalf("runHistory (flat): %v", err)
 	}
 	if got := rankOf(flatObs.records, "flat.go"); got != 0 {
@@ -102,7 +103,7 @@ func TestHotspotsCognitiveRankingFlips(t *testing.T) {
 	Cognitive = true
 	defer func() { Cognitive = false }()
 	cogObs := newHotspotsObserver()
-	if _, err := runHistory(dir, cogObs); err != nil {
+	if _, err := runHistory(context.Background(), dir, cogObs); err != nil {
 		t.Fatalf("runHistory (cognitive): %v", err)
 	}
 	if got := rankOf(cogObs.records, "nested.go"); got != 0 {
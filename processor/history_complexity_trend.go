@@ -0,0 +1,632 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	jsoniter "github.com/json-iterator/go"
+	glanguage "golang.org/x/text/language"
+	gmessage "golang.org/x/text/message"
+)
+
+// complexityTrendTopN is the cap on the rows of the tabular complexity trend
+// report, fastest growing first. CSV/JSON output is not capped.
+const complexityTrendTopN = 20
+
+// complexityTrendSparkCells is the width of the Trend sparkline in the
+// 79-column tabular report, and complexityTrendWideSparkCells with --wide.
+const complexityTrendSparkCells = 20
+const complexityTrendWideSparkCells = 32
+
+// complexityTrendPoint is a file's complexity after one commit changed it.
+// A zero Commit marks the file as it was in the tree at the window's start.
+type complexityTrendPoint struct {
+	When       time.Time
+	Commit     plumbing.Hash
+	Path       string // the file's name at this commit
+	Complexity int64
+	Cognitive  int64
+}
+
+// complexityTrendRow is the materialised per-file result. Start is the file
+// at the window's start, or as first committed when it was added within the
+// window.
+type complexityTrendRow struct {
+	File            string
+	Language        string
+	Commits         int
+	Added           bool
+	StartComplexity int64
+	Complexity      int64
+	StartCognitive  int64
+	Cognitive       int64
+	// Complexities and Cognitives hold the file's value at the end of each
+	// bucket, carried across the buckets no commit changed it in
+	Complexities []int64
+	Cognitives   []int64
+}
+
+// Growth is how much the file's complexity rose over the window, counted in
+// cognitive complexity when the Cognitive global is on, as --hotspots ranks
+func (r complexityTrendRow) Growth() int64 {
+	if Cognitive {
+		return r.Cognitive - r.StartCognitive
+	}
+	return r.Complexity - r.StartComplexity
+}
+
+// Trajectory is the per-bucket series Growth is measured on
+func (r complexityTrendRow) Trajectory() []int64 {
+	if Cognitive {
+		return r.Cognitives
+	}
+	return r.Complexities
+}
+
+func (r complexityTrendRow) now() int64 {
+	if Cognitive {
+		return r.Cognitive
+	}
+	return r.Complexity
+}
+
+// historyComplexityTrendObserver keeps a complexity time series per file,
+// the whole-file complexity of every blob a commit wrote to it. A rename
+// moves the series to the new path so it carries on unbroken. It implements
+// BaselineObserver so each series starts from the file as it was before the
+// window.
+type historyComplexityTrendObserver struct {
+	baseline    map[string]complexityTrendPoint
+	series      map[string][]complexityTrendPoint
+	bucketCount int
+
+	bucket Bucketing
+	window HistoryWindow
+	head   HeadSnapshot
+	rows   []complexityTrendRow
+}
+
+func newHistoryComplexityTrendObserver(buckets int) *historyComplexityTrendObserver {
+	if buckets <= 0 {
+		buckets = 60
+	}
+	return &historyComplexityTrendObserver{
+		baseline:    map[string]complexityTrendPoint{},
+		series:      map[string][]complexityTrendPoint{},
+		bucketCount: buckets,
+	}
+}
+
+// Seed records the complexity of every file in the baseline tree, which
+// starts the series of those the window goes on to change
+func (o *historyComplexityTrendObserver) Seed(baseline BaselineSnapshot) {
+	for path, bf := range baseline.Files {
+		o.baseline[path] = complexityTrendPoint{
+			Path:       path,
+			Complexity: bf.ComplexityTotal,
+			Cognitive:  bf.CognitiveTotal,
+		}
+	}
+}
+
+func (o *historyComplexityTrendObserver) Observe(c CommitInfo, changes []FileChange) {
+	for _, fc := range changes {
+		from := fc.Path
+		if fc.FromPath != "" {
+			from = fc.FromPath
+		}
+		points, ok := o.series[from]
+		if !ok {
+			if start, seeded := o.baseline[from]; seeded {
+				points = []complexityTrendPoint{start}
+			}
+		}
+		if from != fc.Path {
+			delete(o.series, from)
+		}
+		o.series[fc.Path] = append(points, complexityTrendPoint{
+			When:       c.When,
+			Commit:     c.Hash,
+			Path:       fc.Path,
+			Complexity: fc.ComplexityTotal,
+			Cognitive:  fc.CognitiveTotal,
+		})
+	}
+}
+
+func (o *historyComplexityTrendObserver) Finalise(window HistoryWindow, head HeadSnapshot) {
+	o.window = window
+	o.head = head
+	o.bucket = NewBucketing(window.From, window.To, o.bucketCount)
+	o.baseline = nil
+
+	rows := make([]complexityTrendRow, 0, len(o.series))
+	for path, points := range o.series {
+		hf, alive := head.Files[path]
+		if !alive {
+			continue
+		}
+		flat := true
+		for _, p := range points {
+			if p.Complexity != 0 || p.Cognitive != 0 {
+				flat = false
+				break
+			}
+		}
+		// Files without a branch in them, such as most data and markup
+		if flat {
+			continue
+		}
+
+		start, last := points[0], points[len(points)-1]
+		commits := len(points)
+		if start.Commit.IsZero() {
+			commits--
+		}
+		rows = append(rows, complexityTrendRow{
+			File:            path,
+			Language:        hf.Language,
+			Commits:         commits,
+			Added:           !start.Commit.IsZero(),
+			StartComplexity: start.Complexity,
+			Complexity:      last.Complexity,
+			StartCognitive:  start.Cognitive,
+			Cognitive:       last.Cognitive,
+			Complexities:    o.bucketSeries(points, func(p complexityTrendPoint) int64 { return p.Complexity }),
+			Cognitives:      o.bucketSeries(points, func(p complexityTrendPoint) int64 { return p.Cognitive }),
+		})
+	}
+
+	slices.SortFunc(rows, func(a, b complexityTrendRow) int {
+		if a.Growth() != b.Growth() {
+			if a.Growth() < b.Growth() {
+				return 1
+			}
+			return -1
+		}
+		if a.now() != b.now() {
+			if a.now() < b.now() {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.File, b.File)
+	})
+	o.rows = rows
+}
+
+// bucketSeries is the value of points at the end of each bucket. The buckets
+// before the first commit in the window hold the starting value.
+func (o *historyComplexityTrendObserver) bucketSeries(points []complexityTrendPoint, value func(complexityTrendPoint) int64) []int64 {
+	series := make([]int64, o.bucket.N)
+	set := make([]bool, o.bucket.N)
+	for _, p := range points {
+		if p.Commit.IsZero() {
+			continue
+		}
+		i := o.bucket.Index(p.When)
+		series[i] = value(p)
+		set[i] = true
+	}
+	running := value(points[0])
+	for i := range series {
+		if set[i] {
+			running = series[i]
+		}
+		series[i] = running
+	}
+	return series
+}
+
+// runComplexityTrendReport is the dispatch entry point called from Process()
+// when --complexity-trend or --complexity-trend-for is set. The drill-down
+// target is resolved against HEAD before the walk, as --coupling-for is.
+func runComplexityTrendReport(ctx context.Context, repoPath string) error {
+	target := ""
+	if ComplexityTrendFor != "" {
+		resolved, err := resolveCouplingTarget(repoPath, ComplexityTrendFor)
+		if err != nil {
+			return fmt.Errorf("--complexity-trend-for: %w", err)
+		}
+		target = resolved
+	}
+
+	observer := newHistoryComplexityTrendObserver(HistoryBuckets)
+	if _, err := runHistory(ctx, repoPath, observer); err != nil {
+		return err
+	}
+	var out string
+	var err error
+	if target != "" {
+		out, err = renderComplexityTrendFor(observer, target)
+	} else {
+		out, err = renderComplexityTrend(observer)
+	}
+	if err != nil {
+		return err
+	}
+	if FileOutput == "" {
+		fmt.Print(out)
+	} else {
+		if err := os.WriteFile(FileOutput, []byte(out), 0644); err != nil {
+			return err
+		}
+		fmt.Println("results written to " + FileOutput)
+	}
+	return nil
+}
+
+func renderComplexityTrend(o *historyComplexityTrendObserver) (string, error) {
+	switch strings.ToLower(Format) {
+	case "", "tabular", "wide":
+		return renderComplexityTrendTabular(o), nil
+	case "csv":
+		return renderComplexityTrendCSV(o)
+	case "json":
+		return renderComplexityTrendJSON(o)
+	default:
+		return "", fmt.Errorf("unsupported --format %q for --complexity-trend (supported: tabular, csv, json)", Format)
+	}
+}
+
+func renderComplexityTrendFor(o *historyComplexityTrendObserver, target string) (string, error) {
+	switch strings.ToLower(Format) {
+	case "", "tabular", "wide":
+		return renderComplexityTrendForTabular(o, target), nil
+	case "csv":
+		return renderComplexityTrendForCSV(o, target)
+	case "json":
+		return renderComplexityTrendForJSON(o, target)
+	default:
+		return "", fmt.Errorf("unsupported --format %q for --complexity-trend-for (supported: tabular, csv, json)", Format)
+	}
+}
+
+// Tabular column formats. 31+1+20+1+8+1+8+1+8 = 79.
+var tabularShortComplexityTrendFormat = "%-31s %-20s %8s %8s %8s\n"
+
+// Wide adds the commit count and a wider sparkline. 40+1+32+1+8+1+8+1+8+1+8 = 109.
+var tabularWideComplexityTrendFormat = "%-40s %-32s %8s %8s %8s %8s\n"
+
+// complexityTrendMetric names what the report measures in its footers
+func complexityTrendMetric() string {
+	if Cognitive {
+		return "cognitive complexity"
+	}
+	return "complexity"
+}
+
+func renderComplexityTrendTabular(o *historyComplexityTrendObserver) string {
+	wide := More || strings.EqualFold(Format, "wide")
+	brk := tabularBreakFor(wide)
+
+	var sb strings.Builder
+	sb.WriteString(historyHeader("Complexity Trend", o.window, wide))
+
+	p := gmessage.NewPrinter(glanguage.Make(os.Getenv("LANG")))
+	if wide {
+		_, _ = fmt.Fprintf(&sb, tabularWideComplexityTrendFormat, "File", "Trend", "Start", "Now", "Growth", "Commits")
+	} else {
+		_, _ = fmt.Fprintf(&sb, tabularShortComplexityTrendFormat, "File", "Trend", "Start", "Now", "Growth")
+	}
+	sb.WriteString(brk)
+
+	rows := o.rows[:min(len(o.rows), complexityTrendTopN)]
+	for _, r := range rows {
+		start, now := r.StartComplexity, r.Complexity
+		if Cognitive {
+			start, now = r.StartCognitive, r.Cognitive
+		}
+		if wide {
+			_, _ = fmt.Fprintf(&sb, tabularWideComplexityTrendFormat,
+				unicodeAwareRightPad(unicodeAwareTrim(r.File, 39), 40),
+				renderLanguagesTrajectorySparkline(r.Trajectory(), complexityTrendWideSparkCells),
+				formatWithCommas(p, start), formatWithCommas(p, now), formatCodeDelta(p, r.Growth()),
+				formatWithCommas(p, int64(r.Commits)))
+		} else {
+			_, _ = fmt.Fprintf(&sb, tabularShortComplexityTrendFormat,
+				unicodeAwareRightPad(unicodeAwareTrim(r.File, 30), 31),
+				renderLanguagesTrajectorySparkline(r.Trajectory(), complexityTrendSparkCells),
+				formatWithCommas(p, start), formatWithCommas(p, now), formatCodeDelta(p, r.Growth()))
+		}
+	}
+
+	sb.WriteString(brk)
+	if len(o.rows) == 0 {
+		sb.WriteString("no file with any complexity changed in the window\n")
+	} else {
+		_, _ = fmt.Fprintf(&sb, "ranked by %s growth · %d of %d files\n", complexityTrendMetric(), len(rows), len(o.rows))
+	}
+	sb.WriteString(brk)
+	return sb.String()
+}
+
+func renderComplexityTrendCSV(o *historyComplexityTrendObserver) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(formatWindowComment(o.window))
+	sb.WriteByte('\n')
+	_, _ = fmt.Fprintf(&sb, "# buckets: %d\n", o.bucket.N)
+
+	w := csv.NewWriter(&sb)
+	_ = w.Write([]string{
+		"File", "Language", "BucketStart", "Complexity", "Cognitive", "ComplexityGrowth", "CognitiveGrowth",
+	})
+	for _, r := range o.rows {
+		for i := range r.Complexities {
+			_ = w.Write([]string{
+				r.File,
+				r.Language,
+				o.bucket.Start(i).UTC().Format(historyDateLayout),
+				fmt.Sprintf("%d", r.Complexities[i]),
+				fmt.Sprintf("%d", r.Cognitives[i]),
+				fmt.Sprintf("%d", r.Complexity-r.StartComplexity),
+				fmt.Sprintf("%d", r.Cognitive-r.StartCognitive),
+			})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+type complexityTrendJSONBucket struct {
+	BucketStart string `json:"bucketStart"`
+	Complexity  int64  `json:"complexity"`
+	Cognitive   int64  `json:"cognitive"`
+}
+
+type complexityTrendJSONFile struct {
+	File             string                      `json:"file"`
+	Language         string                      `json:"language"`
+	Commits          int                         `json:"commits"`
+	Added            bool                        `json:"added"`
+	StartComplexity  int64                       `json:"startComplexity"`
+	Complexity       int64                       `json:"complexity"`
+	ComplexityGrowth int64                       `json:"complexityGrowth"`
+	StartCognitive   int64                       `json:"startCognitive"`
+	Cognitive        int64                       `json:"cognitive"`
+	CognitiveGrowth  int64                       `json:"cognitiveGrowth"`
+	Series           []complexityTrendJSONBucket `json:"series"`
+}
+
+type complexityTrendJSONDoc struct {
+	Report  string                    `json:"report"`
+	Window  hotspotsJSONWindow        `json:"window"`
+	Buckets int                       `json:"buckets"`
+	Metric  string                    `json:"metric"`
+	Files   []complexityTrendJSONFile `json:"files"`
+}
+
+func renderComplexityTrendJSON(o *historyComplexityTrendObserver) (string, error) {
+	doc := complexityTrendJSONDoc{
+		Report:  "complexity-trend",
+		Window:  complexityTrendJSONWindow(o.window),
+		Buckets: o.bucket.N,
+		Metric:  complexityTrendMetric(),
+		Files:   make([]complexityTrendJSONFile, 0, len(o.rows)),
+	}
+	for _, r := range o.rows {
+		jf := complexityTrendJSONFile{
+			File:             r.File,
+			Language:         r.Language,
+			Commits:          r.Commits,
+			Added:            r.Added,
+			StartComplexity:  r.StartComplexity,
+			Complexity:       r.Complexity,
+			ComplexityGrowth: r.Complexity - r.StartComplexity,
+			StartCognitive:   r.StartCognitive,
+			Cognitive:        r.Cognitive,
+			CognitiveGrowth:  r.Cognitive - r.StartCognitive,
+			Series:           make([]complexityTrendJSONBucket, 0, len(r.Complexities)),
+		}
+		for i := range r.Complexities {
+			jf.Series = append(jf.Series, complexityTrendJSONBucket{
+				BucketStart: o.bucket.Start(i).UTC().Format(historyDateLayout),
+				Complexity:  r.Complexities[i],
+				Cognitive:   r.Cognitives[i],
+			})
+		}
+		doc.Files = append(doc.Files, jf)
+	}
+	b, err := jsoniter.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func complexityTrendJSONWindow(w HistoryWindow) hotspotsJSONWindow {
+	jw := hotspotsJSONWindow{
+		Depth:   w.Depth,
+		Commits: w.Commits,
+		From:    formatWindowDate(w.From),
+		To:      formatWindowDate(w.To),
+	}
+	jw.historyJSONRange = jsonWindowRange(w)
+	return jw
+}
+
+// renames counts the names a series went by before its current one
+func complexityTrendRenames(points []complexityTrendPoint) int {
+	renames := 0
+	for i := 1; i < len(points); i++ {
+		if points[i].Path != points[i-1].Path {
+			renames++
+		}
+	}
+	return renames
+}
+
+// shortCommit is the abbreviated hash shown in the drill-down
+func shortCommit(h plumbing.Hash) string {
+	return h.String()[:7]
+}
+
+func renderComplexityTrendForTabular(o *historyComplexityTrendObserver, target string) string {
+	wide := More || strings.EqualFold(Format, "wide")
+	brk := tabularBreakFor(wide)
+
+	var sb strings.Builder
+	sb.WriteString(historyHeader("Complexity Trend", o.window, wide))
+
+	if _, alive := o.head.Files[target]; !alive {
+		_, _ = fmt.Fprintf(&sb, "%s is not in HEAD (deleted, ignored, or path typo)\n", target)
+		sb.WriteString(brk)
+		return sb.String()
+	}
+
+	// Date, Commit, File, Cmplx and Change fill 79 columns, 109 with --wide;
+	// Cogn takes its width from File
+	fileWidth := 43
+	if wide {
+		fileWidth = 73
+	}
+	if Cognitive {
+		fileWidth -= 8
+	}
+	row := func(date, commit, file, complexity, change, cognitive string) {
+		_, _ = fmt.Fprintf(&sb, "%-10s %-7s %s %7s %8s", date, commit,
+			unicodeAwareRightPad(unicodeAwareTrim(file, fileWidth-1), fileWidth), complexity, change)
+		if Cognitive {
+			_, _ = fmt.Fprintf(&sb, " %7s", cognitive)
+		}
+		sb.WriteByte('\n')
+	}
+
+	row("Date", "Commit", "File", "Cmplx", "Change", "Cogn")
+	sb.WriteString(brk)
+
+	p := gmessage.NewPrinter(glanguage.Make(os.Getenv("LANG")))
+	points := o.series[target]
+	for i, pt := range points {
+		date, commit, change := "before", "", ""
+		if !pt.Commit.IsZero() {
+			date = pt.When.UTC().Format(historyDateLayout)
+			commit = shortCommit(pt.Commit)
+		}
+		if i > 0 {
+			change = formatCodeDelta(p, pt.Complexity-points[i-1].Complexity)
+		}
+		row(date, commit, pt.Path, formatWithCommas(p, pt.Complexity), change, formatWithCommas(p, pt.Cognitive))
+	}
+
+	sb.WriteString(brk)
+	if len(points) == 0 {
+		hf := o.head.Files[target]
+		value := hf.Complexity
+		if Cognitive {
+			value = hf.Cognitive
+		}
+		_, _ = fmt.Fprintf(&sb, "%s did not change in the window · %s %s\n", target, complexityTrendMetric(), formatWithCommas(p, value))
+	} else {
+		start, last := points[0], points[len(points)-1]
+		startValue, lastValue := start.Complexity, last.Complexity
+		if Cognitive {
+			startValue, lastValue = start.Cognitive, last.Cognitive
+		}
+		commits := len(points)
+		if start.Commit.IsZero() {
+			commits--
+		}
+		footer := fmt.Sprintf("%s %s → %s (%s) over %d commits", complexityTrendMetric(),
+			formatWithCommas(p, startValue), formatWithCommas(p, lastValue), formatCodeDelta(p, lastValue-startValue), commits)
+		if renames := complexityTrendRenames(points); renames == 1 {
+			footer += " · followed 1 rename"
+		} else if renames > 1 {
+			footer += fmt.Sprintf(" · followed %d renames", renames)
+		}
+		sb.WriteString(footer)
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(brk)
+	return sb.String()
+}
+
+func renderComplexityTrendForCSV(o *historyComplexityTrendObserver, target string) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(formatWindowComment(o.window))
+	sb.WriteByte('\n')
+
+	w := csv.NewWriter(&sb)
+	_ = w.Write([]string{"Target", "Date", "Commit", "Path", "Complexity", "Cognitive"})
+	for _, pt := range o.series[target] {
+		date, commit := "", ""
+		if !pt.Commit.IsZero() {
+			date = pt.When.UTC().Format(historyDateLayout)
+			commit = pt.Commit.String()
+		}
+		_ = w.Write([]string{
+			target,
+			date,
+			commit,
+			pt.Path,
+			fmt.Sprintf("%d", pt.Complexity),
+			fmt.Sprintf("%d", pt.Cognitive),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+type complexityTrendForJSONCommit struct {
+	Date       string `json:"date"`
+	Commit     string `json:"commit"`
+	Path       string `json:"path"`
+	Complexity int64  `json:"complexity"`
+	Cognitive  int64  `json:"cognitive"`
+}
+
+type complexityTrendForJSONDoc struct {
+	Report          string                         `json:"report"`
+	Target          string                         `json:"target"`
+	Window          hotspotsJSONWindow             `json:"window"`
+	StartComplexity int64                          `json:"startComplexity"`
+	StartCognitive  int64                          `json:"startCognitive"`
+	Renames         int                            `json:"renames"`
+	Commits         []complexityTrendForJSONCommit `json:"commits"`
+}
+
+func renderComplexityTrendForJSON(o *historyComplexityTrendObserver, target string) (string, error) {
+	points := o.series[target]
+	doc := complexityTrendForJSONDoc{
+		Report:  "complexity-trend-for",
+		Target:  target,
+		Window:  complexityTrendJSONWindow(o.window),
+		Renames: complexityTrendRenames(points),
+		Commits: make([]complexityTrendForJSONCommit, 0, len(points)),
+	}
+	if len(points) > 0 {
+		doc.StartComplexity = points[0].Complexity
+		doc.StartCognitive = points[0].Cognitive
+	}
+	for _, pt := range points {
+		if pt.Commit.IsZero() {
+			continue
+		}
+		doc.Commits = append(doc.Commits, complexityTrendForJSONCommit{
+			Date:       pt.When.UTC().Format(historyDateLayout),
+			Commit:     pt.Commit.String(),
+			Path:       pt.Path,
+			Complexity: pt.Complexity,
+			Cognitive:  pt.Cognitive,
+		})
+	}
+	b, err := jsoniter.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
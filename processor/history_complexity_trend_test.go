@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func complexityTrendFixture() *historyComplexityTrendObserver {
+	o := newHistoryComplexityTrendObserver(4)
+	o.Seed(BaselineSnapshot{Files: map[string]BaselineFile{
+		"lib/old.go":  {Path: "lib/old.go", ComplexityTotal: 10, CognitiveTotal: 12},
+		"lib/keep.go": {Path: "lib/keep.go", ComplexityTotal: 30},
+	}})
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	o.Observe(CommitInfo{Hash: plumbing.Hash{1}, When: day(1)}, []FileChange{
+		{Path: "lib/old.go", FromPath: "lib/old.go", ComplexityTotal: 14, CognitiveTotal: 13},
+		{Path: "main.go", ComplexityTotal: 2, CognitiveTotal: 2},
+		{Path: "README.md"},
+	})
+	o.Observe(CommitInfo{Hash: plumbing.Hash{2}, When: day(5)}, []FileChange{
+		{Path: "src/old.go", FromPath: "lib/old.go", ComplexityTotal: 14, CognitiveTotal: 13},
+		{Path: "gone.go", ComplexityTotal: 50},
+	})
+	o.Observe(CommitInfo{Hash: plumbing.Hash{3}, When: day(9)}, []FileChange{
+		{Path: "src/old.go", FromPath: "src/old.go", ComplexityTotal: 18, CognitiveTotal: 30},
+		{Path: "main.go", FromPath: "main.go", ComplexityTotal: 9, CognitiveTotal: 4},
+		{Path: "lib/keep.go", FromPath: "lib/keep.go", ComplexityTotal: 25},
+	})
+	o.Finalise(HistoryWindow{Commits: 3, From: day(1), To: day(9)}, HeadSnapshot{Files: map[string]HeadFile{
+		"src/old.go":  {Path: "src/old.go", Language: "Go", Complexity: 18, Cognitive: 30},
+		"main.go":     {Path: "main.go", Language: "Go", Complexity: 9, Cognitive: 4},
+		"lib/keep.go": {Path: "lib/keep.go", Language: "Go", Complexity: 25},
+		"README.md":   {Path: "README.md", Language: "Markdown"},
+		"lib/same.go": {Path: "lib/same.go", Language: "Go", Complexity: 7},
+	}})
+	return o
+}
+
+func TestComplexityTrendObserver(t *testing.T) {
+	o := complexityTrendFixture()
+
+	var files []string
+	for _, r := range o.rows {
+		files = append(files, r.File)
+	}
+	// gone.go is not in HEAD and README.md never had any complexity
+	if !slices.Equal(files, []string{"src/old.go", "main.go", "lib/keep.go"}) {
+		t.Fatalf("expected the files ranked by growth got %v", files)
+	}
+
+	old := o.rows[0]
+	if old.StartComplexity != 10 || old.Complexity != 18 || old.Growth() != 8 || old.Commits != 3 || old.Added {
+		t.Errorf("expected the renamed file to keep its baseline got %+v", old)
+	}
+	if !slices.Equal(old.Complexities, []int64{14, 14, 14, 18}) {
+		t.Errorf("unexpected complexity series %v", old.Complexities)
+	}
+	if added := o.rows[1]; !added.Added || added.StartComplexity != 2 || added.Growth() != 7 || !slices.Equal(added.Complexities, []int64{2, 2, 2, 9}) {
+		t.Errorf("expected the added file to start from its first commit got %+v", added)
+	}
+	if keep := o.rows[2]; keep.Growth() != -5 || keep.Commits != 1 || !slices.Equal(keep.Complexities, []int64{30, 30, 30, 25}) {
+		t.Errorf("expected the untouched buckets to hold the baseline got %+v", keep)
+	}
+	if points := o.series["src/old.go"]; len(points) != 4 || points[0].Path != "lib/old.go" || complexityTrendRenames(points) != 1 {
+		t.Errorf("expected the series to follow the rename got %+v", points)
+	}
+	if _, ok := o.series["lib/old.go"]; ok {
+		t.Error("expected the renamed path to be gone")
+	}
+}
+
+func TestComplexityTrendCognitive(t *testing.T) {
+	Cognitive = true
+	defer func() { Cognitive = false }()
+
+	o := complexityTrendFixture()
+	if o.rows[0].File != "src/old.go" || o.rows[0].Growth() != 18 || o.rows[1].File != "main.go" || o.rows[1].Growth() != 2 {
+		t.Errorf("expected the files ranked by cognitive growth got %+v", o.rows)
+	}
+	if !slices.Equal(o.rows[0].Trajectory(), []int64{13, 13, 13, 30}) {
+		t.Errorf("expected the cognitive series got %v", o.rows[0].Trajectory())
+	}
+}
+
+func TestRenderComplexityTrend(t *testing.T) {
+	o := complexityTrendFixture()
+
+	tabular := renderComplexityTrendTabular(o)
+	for _, want := range []string{"Complexity Trend · last 3 commits", "Growth", "src/old.go", "+8", "-5", "ranked by complexity growth · 3 of 3 files"} {
+		if !strings.Contains(tabular, want) {
+			t.Errorf("expected %q in\n%s", want, tabular)
+		}
+	}
+
+	csv, err := renderComplexityTrendCSV(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv), "\n")
+	if lines[1] != "# buckets: 4" || lines[2] != "File,Language,BucketStart,Complexity,Cognitive,ComplexityGrowth,CognitiveGrowth" {
+		t.Errorf("unexpected CSV head\n%s", csv)
+	}
+	if len(lines) != 3+3*4 || lines[6] != "src/old.go,Go,2025-01-07,18,30,8,18" {
+		t.Errorf("unexpected CSV rows\n%s", csv)
+	}
+
+	out, err := renderComplexityTrendJSON(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc complexityTrendJSONDoc
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Report != "complexity-trend" || doc.Metric != "complexity" || doc.Buckets != 4 || len(doc.Files) != 3 ||
+		doc.Files[1].File != "main.go" || !doc.Files[1].Added || doc.Files[1].CognitiveGrowth != 2 || len(doc.Files[1].Series) != 4 {
+		t.Errorf("unexpected JSON %s", out)
+	}
+}
+
+func TestRenderComplexityTrendFor(t *testing.T) {
+	o := complexityTrendFixture()
+
+	tabular := renderComplexityTrendForTabular(o, "src/old.go")
+	for _, want := range []string{"before", "lib/old.go", "0200000", "+4", "complexity 10 → 18 (+8) over 3 commits · followed 1 rename"} {
+		if !strings.Contains(tabular, want) {
+			t.Errorf("expected %q in\n%s", want, tabular)
+		}
+	}
+	if unchanged := renderComplexityTrendForTabular(o, "lib/same.go"); !strings.Contains(unchanged, "lib/same.go did not change in the window · complexity 7") {
+		t.Errorf("expected the unchanged file to be called out got\n%s", unchanged)
+	}
+	if missing := renderComplexityTrendForTabular(o, "gone.go"); !strings.Contains(missing, "gone.go is not in HEAD") {
+		t.Errorf("expected the deleted file to be called out got\n%s", missing)
+	}
+
+	csv, err := renderComplexityTrendForCSV(o, "src/old.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(csv), "\n"); len(lines) != 2+4 || lines[2] != "src/old.go,,,lib/old.go,10,12" {
+		t.Errorf("unexpected CSV\n%s", csv)
+	}
+
+	out, err := renderComplexityTrendForJSON(o, "src/old.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc complexityTrendForJSONDoc
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Report != "complexity-trend-for" || doc.StartComplexity != 10 || doc.Renames != 1 || len(doc.Commits) != 3 || doc.Commits[1].Path != "src/old.go" {
+		t.Errorf("unexpected JSON %s", out)
+	}
+}
+
+func TestRunHistoryComplexityTrend(t *testing.T) {
+	dir := makeFixtureRepo(t, []map[string]string{
+		{"a.go": "package a\n\nfunc A(x int) {\n\tif x > 0 {\n\t}\n}\n"},
+		{"a.go": "package a\n\nfunc A(x int) {\n\tif x > 0 {\n\t}\n\tif x > 1 {\n\t}\n\tfor x > 2 {\n\t}\n}\n"},
+	})
+	o := newHistoryComplexityTrendObserver(10)
+	if _, err := runHistory(context.Background(), dir, o); err != nil {
+		t.Fatal(err)
+	}
+	if len(o.rows) != 1 || o.rows[0].StartComplexity != 1 || o.rows[0].Complexity != 3 || o.rows[0].Commits != 2 {
+		t.Errorf("unexpected complexity trend %+v", o.rows)
+	}
+}
@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
@@ -409,14 +410,15 @@ func (o *couplingObserver) partnersFor(target string) []CouplingPartner {
 //
 // target accepts the same forms as --coupling-for and is validated against HEAD
 // before the walk, so a caller passing a bad path gets an immediate error rather
-// than paying for a full traversal first.
-func CouplingForJSONReport(repoPath, target string, limit int) (string, error) {
+// than paying for a full traversal first. Cancelling ctx abandons the walk and
+// returns ctx.Err().
+func CouplingForJSONReport(ctx context.Context, repoPath, target string, limit int) (string, error) {
 	resolved, err := resolveCouplingTarget(repoPath, target)
 	if err != nil {
 		return "", err
 	}
 	observer := newCouplingObserver()
-	if _, err := runHistory(repoPath, observer); err != nil {
+	if _, err := runHistory(ctx, repoPath, observer); err != nil {
 		return "", err
 	}
 	return renderCouplingForJSONLimited(observer, resolved, limit)
@@ -426,9 +428,10 @@ func CouplingForJSONReport(repoPath, target string, limit int) (string, error) {
 // report as a JSON string — the programmatic entry point for the MCP server,
 // which needs the rendered data rather than stdout side effects. A limit > 0
 // caps the pair list (strongest first); limit <= 0 returns every pair.
-func CouplingJSONReport(repoPath string, limit int) (string, error) {
+// Cancelling ctx abandons the walk and returns ctx.Err().
+func CouplingJSONReport(ctx context.Context, repoPath string, limit int) (string, error) {
 	observer := newCouplingObserver()
-	if _, err := runHistory(repoPath, observer); err != nil {
+	if _, err := runHistory(ctx, repoPath, observer); err != nil {
 		return "", err
 	}
 	return renderCouplingJSONLimited(observer, limit)
@@ -517,10 +520,11 @@ func couplingTargetMiss(tree *object.Tree, target string) error {
 	return fmt.Errorf("target %q is not in HEAD (deleted, ignored, or path typo)", target)
 }
 
-// resolveCouplingTarget maps a user-supplied --coupling-for path onto the
-// git-style path the history engine keys on, verifying it against HEAD *before*
-// the caller pays for a full history walk. A typo previously cost a complete
-// walk (seconds to minutes) before reporting the miss.
+// resolveCouplingTarget maps a user-supplied --coupling-for (or
+// --complexity-trend-for) path onto the git-style path the history engine
+// keys on, verifying it against HEAD *before* the caller pays for a full
+// history walk. A typo previously cost a complete walk (seconds to minutes)
+// before reporting the miss.
 //
 // A repository with no HEAD (freshly initialised, no commits) is not an error
 // here: the path is normalised and the walk reports the empty window as usual.
@@ -563,7 +567,7 @@ func resolveCouplingTarget(repoPath, target string) (string, error) {
 // runCouplingReport is the dispatch entry point called from Process() when
 // --coupling is set. Walks history and writes the chosen format to stdout or
 // FileOutput.
-func runCouplingReport(repoPath string) error {
+func runCouplingReport(ctx context.Context, repoPath string) error {
 	// Resolve and validate the target before the walk — a bad path should fail
 	// in milliseconds, not after a full history traversal.
 	target := ""
@@ -579,7 +583,7 @@ func runCouplingReport(repoPath string) error {
 	}
 
 	observer := newCouplingObserver()
-	if _, err := runHistory(repoPath, observer); err != nil {
+	if _, err := runHistory(ctx, repoPath, observer); err != nil {
 		return err
 	}
 	var out string
@@ -768,6 +772,7 @@ func renderCouplingJSONLimited(o *couplingObserver, limit int) (string, error) {
 		},
 		Pairs: make([]couplingJSONPair, 0, len(o.pairs)),
 	}
+	doc.Window.historyJSONRange = jsonWindowRange(o.window)
 	for _, p := range o.pairs {
 		if limit > 0 && len(doc.Pairs) >= limit {
 			break
@@ -959,6 +964,7 @@ func renderCouplingForJSONLimited(o *couplingObserver, target string, limit int)
 		},
 		Partners: make([]couplingForJSONPartner, 0),
 	}
+	doc.Window.historyJSONRange = jsonWindowRange(o.window)
 	for _, p := range o.partnersFor(target) {
 		if limit > 0 && len(doc.Partners) >= limit {
 			break
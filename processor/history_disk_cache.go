@@ -0,0 +1,321 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// historyCacheName is the name of the --history-cache file inside the .git
+// directory or --history-cache-dir
+const historyCacheName = "scc-history-cache.gob"
+
+// historyCacheEntrySize is roughly what an entry costs on disk beyond its
+// line types and line numbers, used to keep the file under HistoryCacheSize
+const historyCacheEntrySize = 64
+
+// historyDiskCache is the --history-cache, blob classifications kept on disk
+// between runs so a history report only classifies the blobs it has not seen
+// before. Entries are keyed by blob hash, file name and forced language as in memory,
+// and the whole file by the scc version and a fingerprint of the language
+// definitions and settings the classifier depends on. A file written under
+// another fingerprint is discarded rather than read. A nil historyDiskCache
+// is a disabled one.
+type historyDiskCache struct {
+	paths       []string // where the file is read from and written to, in order of preference
+	fingerprint string
+	limit       int64 // bytes
+	now         int64 // unix time the entries used by this run are stamped with
+
+	mu      sync.Mutex // guards the fields below, as commits are classified concurrently
+	entries map[blobClassifyKey]historyCacheEntry
+	hits    int
+	dirty   bool
+}
+
+// historyCacheEntry is a blobClassifyResult as written to disk
+type historyCacheEntry struct {
+	Language      string
+	Complexity    int64
+	Cognitive     int64
+	LineTypes     []byte
+	ComplexLine   []int
+	CognitiveLine []int
+	OK            bool
+	Used          int64 // unix time of the last run that classified or read it
+}
+
+// historyCacheRecord is an entry alongside its key
+type historyCacheRecord struct {
+	Hash      plumbing.Hash
+	Name      string
+	Language  string
+	Generated attributeState
+	Entry     historyCacheEntry
+}
+
+// historyCacheFile is the content of the --history-cache file
+type historyCacheFile struct {
+	Fingerprint string
+	Records     []historyCacheRecord
+}
+
+// openHistoryDiskCache loads the --history-cache of repo, opened from
+// repoPath. A missing, unreadable or outdated file starts an empty cache.
+func openHistoryDiskCache(repo *git.Repository, repoPath string) *historyDiskCache {
+	d := &historyDiskCache{
+		paths:       historyCachePaths(repo, repoPath),
+		fingerprint: historyCacheFingerprint(),
+		limit:       int64(HistoryCacheSize) << 20,
+		now:         time.Now().Unix(),
+		entries:     map[blobClassifyKey]historyCacheEntry{},
+	}
+	if len(d.paths) == 0 {
+		printWarn("history cache: no directory to keep it in, set --history-cache-dir")
+		return nil
+	}
+
+	for _, path := range d.paths {
+		file, err := readHistoryCacheFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			printWarnF("history cache: ignoring %s: %s", path, err)
+			break
+		}
+		if file.Fingerprint != d.fingerprint {
+			printDebugF("history cache: %s was written by another scc version or language definitions, starting afresh", path)
+			break
+		}
+		for _, r := range file.Records {
+			d.entries[blobClassifyKey{hash: r.Hash, name: r.Name, language: r.Language, generated: r.Generated}] = r.Entry
+		}
+		printDebugF("history cache: loaded %d blobs from %s", len(d.entries), path)
+		break
+	}
+	return d
+}
+
+// historyCachePaths is where the --history-cache of repo may be kept, in
+// order of preference. Without --history-cache-dir it is the .git directory,
+// falling back to a file named after the repository in the user cache
+// directory for when that cannot be written.
+func historyCachePaths(repo *git.Repository, repoPath string) []string {
+	if HistoryCacheDir != "" {
+		return []string{filepath.Join(HistoryCacheDir, historyCacheName)}
+	}
+
+	var paths []string
+	if storage, ok := repo.Storer.(*filesystem.Storage); ok {
+		if root := storage.Filesystem().Root(); root != "" {
+			paths = append(paths, filepath.Join(root, historyCacheName))
+		}
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		if abs, err := filepath.Abs(repoPath); err == nil {
+			repoPath = abs
+		}
+		sum := sha256.Sum256([]byte(repoPath))
+		paths = append(paths, filepath.Join(dir, "scc", "history-"+hex.EncodeToString(sum[:8])+".gob"))
+	}
+	return paths
+}
+
+// historyCacheFingerprint identifies what a cached classification depends
+// on besides the blob: the scc version, the language definitions including
+// any loaded with --languages-file, and the flags changing how files are detected
+// or counted
+func historyCacheFingerprint() string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00%t\x00%t\x00", Version, CountAs, strings.Join(CountAsPattern, "\x00"), Complexity, Cognitive)
+	_, _ = fmt.Fprintf(h, "%t\x00%t\x00%s\x00", DisableClassifier, GitAttributes, strings.Join(AllowListExtensions, "\x00"))
+	// Blobs are counted with the global settings, which mark generated and
+	// minified files in their language and can stop a count early
+	_, _ = fmt.Fprintf(h, "%t\x00%s\x00%t\x00%d\x00%t\x00%t\x00%d\x00", Generated, strings.Join(GeneratedMarkers, "\x00"),
+		Minified, MinifiedGeneratedLineByteLength, DisableCheckBinary, NoLarge, LargeLineCount)
+	// Marshalling sorts the map keys, so the same definitions always hash alike
+	_ = json.NewEncoder(h).Encode(languageDatabase)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func readHistoryCacheFile(path string) (historyCacheFile, error) {
+	var file historyCacheFile
+	f, err := os.Open(path)
+	if err != nil {
+		return file, err
+	}
+	defer f.Close()
+	err = gob.NewDecoder(bufio.NewReader(f)).Decode(&file)
+	return file, err
+}
+
+// lookup returns the cached classification for key, marking it used by this
+// run so it outlives older entries when the cache is trimmed
+func (d *historyDiskCache) lookup(key blobClassifyKey) (blobClassifyResult, bool) {
+	if d == nil {
+		return blobClassifyResult{}, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry, ok := d.entries[key]
+	if !ok {
+		return blobClassifyResult{}, false
+	}
+	d.hits++
+	if entry.Used != d.now {
+		entry.Used = d.now
+		d.entries[key] = entry
+		d.dirty = true
+	}
+
+	res := blobClassifyResult{
+		language:      entry.Language,
+		complexity:    entry.Complexity,
+		cognitive:     entry.Cognitive,
+		complexLine:   entry.ComplexLine,
+		cognitiveLine: entry.CognitiveLine,
+		ok:            entry.OK,
+	}
+	if entry.OK {
+		res.lineTypes = make([]LineType, len(entry.LineTypes))
+		for i, t := range entry.LineTypes {
+			res.lineTypes[i] = LineType(t)
+		}
+		// The classifier never hands out nil slices for a counted blob
+		if res.complexLine == nil {
+			res.complexLine = []int{}
+		}
+		if res.cognitiveLine == nil {
+			res.cognitiveLine = []int{}
+		}
+	}
+	return res, true
+}
+
+// store adds a classification made by this run
+func (d *historyDiskCache) store(key blobClassifyKey, res blobClassifyResult) {
+	if d == nil {
+		return
+	}
+	entry := historyCacheEntry{
+		Language:      res.language,
+		Complexity:    res.complexity,
+		Cognitive:     res.cognitive,
+		ComplexLine:   res.complexLine,
+		CognitiveLine: res.cognitiveLine,
+		OK:            res.ok,
+		Used:          d.now,
+	}
+	entry.LineTypes = make([]byte, len(res.lineTypes))
+	for i, t := range res.lineTypes {
+		entry.LineTypes[i] = byte(t)
+	}
+
+	d.mu.Lock()
+	d.entries[key] = entry
+	d.dirty = true
+	d.mu.Unlock()
+}
+
+// save writes the cache back when this run changed it, dropping the least
+// recently used blobs beyond HistoryCacheSize. The file is replaced by a
+// rename so a concurrent run reads either the old or the new one.
+func (d *historyDiskCache) save() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	printDebugF("history cache: %d blobs read from the cache, %d known", d.hits, len(d.entries))
+	if !d.dirty {
+		return
+	}
+
+	file := historyCacheFile{Fingerprint: d.fingerprint, Records: d.trim()}
+	var err error
+	for _, path := range d.paths {
+		if err = writeHistoryCacheFile(path, file); err == nil {
+			printDebugF("history cache: wrote %d blobs to %s", len(file.Records), path)
+			d.dirty = false
+			return
+		}
+	}
+	printWarnF("history cache: unable to save: %s", err)
+}
+
+// trim returns the entries to write, the most recently used first and as
+// many as fit in the size limit
+func (d *historyDiskCache) trim() []historyCacheRecord {
+	records := make([]historyCacheRecord, 0, len(d.entries))
+	for key, entry := range d.entries {
+		records = append(records, historyCacheRecord{Hash: key.hash, Name: key.name, Language: key.language, Generated: key.generated, Entry: entry})
+	}
+	slices.SortFunc(records, func(a, b historyCacheRecord) int {
+		if c := cmp.Compare(b.Entry.Used, a.Entry.Used); c != 0 {
+			return c
+		}
+		if c := bytes.Compare(a.Hash[:], b.Hash[:]); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Language, b.Language)
+	})
+
+	var size int64
+	for i, r := range records {
+		size += historyCacheEntrySize + int64(len(r.Name)+len(r.Language)+len(r.Entry.Language)+len(r.Entry.LineTypes)) +
+			4*int64(len(r.Entry.ComplexLine)+len(r.Entry.CognitiveLine))
+		if size > d.limit {
+			return records[:i]
+		}
+	}
+	return records
+}
+
+func writeHistoryCacheFile(path string, file historyCacheFile) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, historyCacheName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := gob.NewEncoder(w).Encode(file); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestHistoryDiskCacheRoundTrip(t *testing.T) {
+	ProcessConstants()
+	HistoryCacheDir = t.TempDir()
+	t.Cleanup(func() { HistoryCacheDir = "" })
+
+	blob := []byte("package a\n\n// A is a\nfunc A() {\n\tif true {\n\t}\n}\n")
+	hash := plumbing.ComputeHash(plumbing.BlobObject, blob)
+	unknown := []byte("no language\n")
+	unknownHash := plumbing.ComputeHash(plumbing.BlobObject, unknown)
+
+	first := newBlobClassifyCache()
+	first.disk = openHistoryDiskCache(&git.Repository{}, ".")
+	expected := first.classify(hash, "a.go", blob)
+	first.classify(unknownHash, "b.nosuchextension", unknown)
+	first.disk.save()
+
+	second := newBlobClassifyCache()
+	second.disk = openHistoryDiskCache(&git.Repository{}, ".")
+	if len(second.disk.entries) != 2 {
+		t.Fatalf("expected both blobs to be read back got %d", len(second.disk.entries))
+	}
+	if got := second.classify(hash, "a.go", blob); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the cached classification %+v got %+v", expected, got)
+	}
+	if got := second.classify(unknownHash, "b.nosuchextension", unknown); got.ok {
+		t.Errorf("expected the unknown blob to stay rejected got %+v", got)
+	}
+	// Detection goes by the name, so the same blob elsewhere is its own entry
+	if got := second.classify(hash, "a.py", blob); got.language != "Python" {
+		t.Errorf("expected the blob under another name to be detected afresh got %+v", got)
+	}
+	if second.disk.hits != 2 {
+		t.Errorf("expected both lookups to hit the disk cache got %d", second.disk.hits)
+	}
+
+	for _, flag := range []*bool{&Cognitive, &DisableClassifier, &GitAttributes, &Generated, &Minified, &DisableCheckBinary, &NoLarge} {
+		*flag = !*flag
+		changed := openHistoryDiskCache(&git.Repository{}, ".")
+		*flag = !*flag
+		if len(changed.entries) != 0 {
+			t.Errorf("expected the cache to be discarded under another fingerprint got %d entries", len(changed.entries))
+		}
+	}
+}
+
+func TestHistoryDiskCacheTrim(t *testing.T) {
+	d := &historyDiskCache{entries: map[blobClassifyKey]historyCacheEntry{}, limit: 3 * (historyCacheEntrySize + 100)}
+	for i := range 5 {
+		key := blobClassifyKey{hash: plumbing.Hash{byte(i)}}
+		d.entries[key] = historyCacheEntry{LineTypes: make([]byte, 100), Used: int64(i)}
+	}
+
+	records := d.trim()
+	if len(records) != 3 {
+		t.Fatalf("expected 3 entries to fit got %d", len(records))
+	}
+	for i, r := range records {
+		if r.Entry.Used != int64(4-i) {
+			t.Errorf("expected the most recently used entries first got %d at %d", r.Entry.Used, i)
+		}
+	}
+}
+
+func TestRunHistoryDiskCache(t *testing.T) {
+	HistoryCache = true
+	t.Cleanup(func() { HistoryCache = false })
+
+	dir := makeFixtureRepo(t, []map[string]string{
+		{"a.go": "package a\n"},
+		{"b.go": "package b\n\nfunc B() {\n\tif true {\n\t}\n}\n"},
+	})
+	run := func() *captureObserver {
+		t.Helper()
+		cap := &captureObserver{}
+		if _, err := runHistory(context.Background(), dir, cap); err != nil {
+			t.Fatalf("runHistory: %v", err)
+		}
+		return cap
+	}
+
+	first := run()
+	path := filepath.Join(dir, ".git", historyCacheName)
+	file, err := readHistoryCacheFile(path)
+	if err != nil || len(file.Records) != 2 {
+		t.Fatalf("expected both blobs cached in %s got %+v %v", path, file.Records, err)
+	}
+
+	// A second run reads what the first classified rather than classifying
+	// it again, which a doctored entry makes visible
+	for i := range file.Records {
+		file.Records[i].Entry.Language = "Cached"
+	}
+	if err := writeHistoryCacheFile(path, file); err != nil {
+		t.Fatal(err)
+	}
+	second := run()
+	if len(second.snapshot.Files) != len(first.snapshot.Files) {
+		t.Fatalf("expected the same snapshot got %+v", second.snapshot.Files)
+	}
+	for name, f := range second.snapshot.Files {
+		if f.Language != "Cached" || f.Complexity != first.snapshot.Files[name].Complexity {
+			t.Errorf("expected %s to come from the cache got %+v", name, f)
+		}
+	}
+}
+
+func TestRunHistoryDiskCacheGenerated(t *testing.T) {
+	HistoryCache = true
+	savedMarkers := GeneratedMarkers
+	GeneratedMarkers = []string{"do not edit"}
+	t.Cleanup(func() {
+		HistoryCache, Generated, GeneratedMarkers = false, false, savedMarkers
+	})
+
+	dir := makeFixtureRepo(t, []map[string]string{
+		{"gen.go": "// Code generated by hand. DO NOT EDIT.\npackage gen\n"},
+	})
+	language := func(generated bool) string {
+		t.Helper()
+		Generated = generated
+		cap := &captureObserver{}
+		if _, err := runHistory(context.Background(), dir, cap); err != nil {
+			t.Fatalf("runHistory: %v", err)
+		}
+		return cap.snapshot.Files["gen.go"].Language
+	}
+
+	// Each run must classify afresh rather than read what the other cached
+	if got := language(true); got != "Go (gen)" {
+		t.Errorf("expected Go (gen) with --gen got %q", got)
+	}
+	if got := language(false); got != "Go" {
+		t.Errorf("expected Go without --gen got %q", got)
+	}
+	if got := language(true); got != "Go (gen)" {
+		t.Errorf("expected Go (gen) with --gen again got %q", got)
+	}
+}
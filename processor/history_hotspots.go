@@ -3,10 +3,12 @@
 package processor
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 
 	jsoniter "github.com/json-iterator/go"
@@ -20,9 +22,10 @@ import (
 // side effects of runHotspotsReport. A limit > 0 caps the number of files in
 // the output (highest-scoring first); limit <= 0 returns every scored file.
 // HistoryDepth and the mailmap folding behave exactly as on the CLI path.
-func HotspotsJSONReport(repoPath string, limit int) (string, error) {
+// Cancelling ctx abandons the walk and returns ctx.Err().
+func HotspotsJSONReport(ctx context.Context, repoPath string, limit int) (string, error) {
 	observer := newHotspotsObserver()
-	if _, err := runHistory(repoPath, observer); err != nil {
+	if _, err := runHistory(ctx, repoPath, observer); err != nil {
 		return "", err
 	}
 	return renderHotspotsJSONLimited(observer, limit)
@@ -31,9 +34,9 @@ func HotspotsJSONReport(repoPath string, limit int) (string, error) {
 // runHotspotsReport is the dispatch entry point called from Process() when
 // --hotspots is set. Opens the repo at repoPath, walks history, and writes
 // the chosen format to stdout or FileOutput.
-func runHotspotsReport(repoPath string) error {
+func runHotspotsReport(ctx context.Context, repoPath string) error {
 	observer := newHotspotsObserver()
-	if _, err := runHistory(repoPath, observer); err != nil {
+	if _, err := runHistory(ctx, repoPath, observer); err != nil {
 		return err
 	}
 	out, err := renderHotspots(observer)
@@ -63,6 +66,7 @@ type hotspotsRecord struct {
 	CodeChurn    int64
 	CommentChurn int64
 	Score        float64
+	Test         bool // a test file, set when the run separates tests
 }
 
 // hotspotsObserver accumulates per-file commit / churn / author stats during
@@ -76,14 +80,19 @@ type hotspotsObserver struct {
 	window   HistoryWindow
 	snapshot HeadSnapshot
 	records  []hotspotsRecord
-	totalRaw int // total files seen across the window (for the "X of Y" footer)
+	totalRaw int        // total files seen across the window (for the "X of Y" footer)
+	tests    *testRules // tells test files from production code, nil without --tests
 }
 
 func newHotspotsObserver() *hotspotsObserver {
-	return &hotspotsObserver{
+	o := &hotspotsObserver{
 		files:    map[string]*hotspotsRecord{},
 		registry: newAuthorRegistry(nil),
 	}
+	if Tests {
+		o.tests = newTestRules(TestPatterns)
+	}
+	return o
 }
 
 // SetMailmap satisfies MailmapObserver — rebuilds the registry with the
@@ -138,6 +147,7 @@ func (o *hotspotsObserver) Finalise(window HistoryWindow, head HeadSnapshot) {
 		rec.Language = hf.Language
 		rec.Complexity = hf.Complexity
 		rec.Cognitive = hf.Cognitive
+		rec.Test = o.tests.match(path, hf.Language)
 		o.totalRaw++
 
 		// When cognitive complexity is enabled, rank by nesting-weighted
@@ -277,7 +287,12 @@ func renderHotspotsTabular(o *hotspotsObserver) string {
 		if wide {
 			fileTrim, fileWidth = 31, 32
 		}
-		fileCol := unicodeAwareTrim(r.File, fileTrim)
+		var fileCol string
+		if r.Test {
+			fileCol = unicodeAwareTrim(r.File, fileTrim-7) + " (test)"
+		} else {
+			fileCol = unicodeAwareTrim(r.File, fileTrim)
+		}
 		fileCol = unicodeAwareRightPad(fileCol, fileWidth)
 		langCol := trimLanguageShort(r.Language, 8)
 		linesCol := formatWithCommas(printer, r.LinesChanged)
@@ -326,16 +341,20 @@ func renderHotspotsCSV(o *hotspotsObserver) (string, error) {
 	sb.WriteByte('\n')
 
 	w := csv.NewWriter(&sb)
-	_ = w.Write([]string{
+	header := []string{
 		"File", "Language", "Complexity", "Commits",
 		"LinesChanged", "Authors", "CodeChurn", "CommentChurn", "Score",
-	})
+	}
+	if Tests {
+		header = append(header, "Test")
+	}
+	_ = w.Write(header)
 
 	for _, r := range o.records {
 		if r.Score <= 0 {
 			continue
 		}
-		_ = w.Write([]string{
+		row := []string{
 			r.File,
 			r.Language,
 			fmt.Sprintf("%d", r.Complexity),
@@ -345,7 +364,11 @@ func renderHotspotsCSV(o *hotspotsObserver) (string, error) {
 			fmt.Sprintf("%d", r.CodeChurn),
 			fmt.Sprintf("%d", r.CommentChurn),
 			fmt.Sprintf("%.1f", r.Score),
-		})
+		}
+		if Tests {
+			row = append(row, strconv.FormatBool(r.Test))
+		}
+		_ = w.Write(row)
 	}
 	w.Flush()
 	if err := w.Error(); err != nil {
@@ -364,6 +387,7 @@ type hotspotsJSONFile struct {
 	CodeChurn    int64   `json:"codeChurn"`
 	CommentChurn int64   `json:"commentChurn"`
 	Score        float64 `json:"score"`
+	Test         bool    `json:"test,omitempty"`
 }
 
 type hotspotsJSONWindow struct {
@@ -371,6 +395,7 @@ type hotspotsJSONWindow struct {
 	Commits int    `json:"commits"`
 	From    string `json:"from"`
 	To      string `json:"to"`
+	historyJSONRange
 }
 
 type hotspotsJSONDoc struct {
@@ -397,6 +422,7 @@ func renderHotspotsJSONLimited(o *hotspotsObserver, limit int) (string, error) {
 		},
 		Files: make([]hotspotsJSONFile, 0, len(o.records)),
 	}
+	doc.Window.historyJSONRange = jsonWindowRange(o.window)
 	for _, r := range o.records {
 		if r.Score <= 0 {
 			continue
@@ -414,6 +440,7 @@ func renderHotspotsJSONLimited(o *hotspotsObserver, limit int) (string, error) {
 			CodeChurn:    r.CodeChurn,
 			CommentChurn: r.CommentChurn,
 			Score:        round1(r.Score),
+			Test:         r.Test,
 		})
 	}
 	b, err := jsoniter.Marshal(doc)
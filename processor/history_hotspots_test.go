@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"encoding/csv"
 	"strings"
 	"testing"
@@ -28,7 +29,7 @@ func TestHotspotsBasicRanking(t *testing.T) {
 	})
 
 	obs := newHotspotsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -71,7 +72,7 @@ func TestHotspotsDropsFilesNotInHead(t *testing.T) {
 	// by asserting that records.Length == len(head snapshot intersection).
 
 	obs := newHotspotsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	for _, r := range obs.records {
@@ -92,7 +93,7 @@ func TestHotspotsCSVHasWindowComment(t *testing.T) {
 	})
 
 	obs := newHotspotsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderHotspots(obs)
@@ -132,7 +133,7 @@ func TestHotspotsJSONShape(t *testing.T) {
 	})
 
 	obs := newHotspotsObserver()
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderHotspots(obs)
@@ -172,9 +173,9 @@ func TestHotspotsJSONReportLimit(t *testing.T) {
 	})
 
 	// Unlimited returns every scored file; this is the baseline count.
-	full, err := HotspotsJSONReport(dir, 0)
+	full, err := HotspotsJSONReport(context.Background(), dir, 0)
 	if err != nil {
-		t.Fatalf("HotspotsJSONReport(unlimited): %v", err)
+		t.Fatalf("HotspotsJSONReport(context.Background(), unlimited): %v", err)
 	}
 	var fullDoc hotspotsJSONDoc
 	if err := jsoniter.Unmarshal([]byte(full), &fullDoc); err != nil {
@@ -189,9 +190,9 @@ func TestHotspotsJSONReportLimit(t *testing.T) {
 
 	// A limit of 1 must cap the file list while leaving the highest-scoring
 	// file (sorted first) at the front, matching the uncapped ordering.
-	limited, err := HotspotsJSONReport(dir, 1)
+	limited, err := HotspotsJSONReport(context.Background(), dir, 1)
 	if err != nil {
-		t.Fatalf("HotspotsJSONReport(limit=1): %v", err)
+		t.Fatalf("HotspotsJSONReport(context.Background(), limit=1): %v", err)
 	}
 	var limitedDoc hotspotsJSONDoc
 	if err := jsoniter.Unmarshal([]byte(limited), &limitedDoc); err != nil {
@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"os"
@@ -184,9 +185,9 @@ func (o *historyLanguagesObserver) Finalise(window HistoryWindow, head HeadSnaps
 // Process() when --timeline is set without --by-author. Opens the repo,
 // walks the window with the configured bucket count, and writes the chosen
 // format.
-func runLanguagesTimelineReport(repoPath string) error {
+func runLanguagesTimelineReport(ctx context.Context, repoPath string) error {
 	observer := newHistoryLanguagesObserver(HistoryBuckets)
-	if _, err := runHistory(repoPath, observer); err != nil {
+	if _, err := runHistory(ctx, repoPath, observer); err != nil {
 		return err
 	}
 	out, err := renderLanguagesTimeline(observer)
@@ -322,6 +323,7 @@ type languagesTimelineJSONWindow struct {
 	Commits int    `json:"commits"`
 	From    string `json:"from"`
 	To      string `json:"to"`
+	historyJSONRange
 }
 
 type languagesTimelineJSONDoc struct {
@@ -343,6 +345,7 @@ func renderLanguagesTimelineJSON(o *historyLanguagesObserver) (string, error) {
 		Buckets:   o.bucket.N,
 		Languages: make([]languagesTimelineJSONLang, 0, len(o.rows)),
 	}
+	doc.Window.historyJSONRange = jsonWindowRange(o.window)
 	for _, r := range o.rows {
 		jl := languagesTimelineJSONLang{
 			Language:     r.Language,
@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"encoding/csv"
 	"strings"
 	"testing"
@@ -76,7 +77,7 @@ func TestLanguagesTimelineTSRisesJSFalls(t *testing.T) {
 	dir := makeTimelineRepo(t, commits)
 
 	obs := newHistoryLanguagesObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -138,7 +139,7 @@ func TestLanguagesTimelineLanguageRemoval(t *testing.T) {
 
 	dir := makeTimelineRepo(t, commits)
 	obs := newHistoryLanguagesObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -179,7 +180,7 @@ func TestLanguagesTimelineSharesSumToHundred(t *testing.T) {
 	})
 
 	obs := newHistoryLanguagesObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -218,7 +219,7 @@ func TestLanguagesTimelineCSVLongFormat(t *testing.T) {
 	})
 
 	obs := newHistoryLanguagesObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderLanguagesTimeline(obs)
@@ -273,7 +274,7 @@ func TestLanguagesTimelineJSONShape(t *testing.T) {
 	})
 
 	obs := newHistoryLanguagesObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderLanguagesTimeline(obs)
@@ -377,7 +378,7 @@ func TestLanguagesTimelineTabularHeader(t *testing.T) {
 	})
 
 	obs := newHistoryLanguagesObserver(HistoryBuckets)
-	if _, err := runHistory(dir, obs); err != nil {
+	if _, err := runHistory(context.Background(), dir, obs); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	out, err := renderLanguagesTimeline(obs)
@@ -3,6 +3,8 @@
 package processor
 
 import (
+	"context"
+	"sync"
 	"testing"
 )
 
@@ -72,7 +74,7 @@ func TestCommitChangesRecoversFromPanic(t *testing.T) {
 	})
 
 	cap := &captureObserver{}
-	if _, err := runHistory(dir, cap); err != nil {
+	if _, err := runHistory(context.Background(), dir, cap); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 
@@ -110,7 +112,7 @@ func TestCommitChangesRecoversFromPanic(t *testing.T) {
 // the go-git implementation, which is the simplest available panic trigger
 // that exercises safePatch's recover.
 func TestSafePatchRecoversFromPanicRegression(t *testing.T) {
-	patch, ok := safePatch(nil)
+	patch, ok := safePatch(new(sync.Mutex), nil)
 	if ok {
 		t.Fatalf("expected ok=false on nil change, got ok=true")
 	}
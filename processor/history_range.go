@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// historyDateLayouts are the absolute dates --since and --until accept, tried
+// in order. Dates without a zone are read in the local one as git does.
+var historyDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	historyDateLayout,
+}
+
+// historyDateUnits are the units of a relative date such as "3 months ago"
+var historyDateUnits = map[string]func(t time.Time, n int) time.Time{
+	"second": func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Second) },
+	"minute": func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Minute) },
+	"hour":   func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Hour) },
+	"day":    func(t time.Time, n int) time.Time { return t.AddDate(0, 0, -n) },
+	"week":   func(t time.Time, n int) time.Time { return t.AddDate(0, 0, -7*n) },
+	"month":  func(t time.Time, n int) time.Time { return t.AddDate(0, -n, 0) },
+	"year":   func(t time.Time, n int) time.Time { return t.AddDate(-n, 0, 0) },
+}
+
+// parseHistoryDate reads a --since or --until value, either an absolute date
+// such as 2026-01-31 or a time relative to now such as "2 weeks ago" or
+// "yesterday". An empty value is the zero time, meaning no limit.
+func parseHistoryDate(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range historyDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	switch strings.ToLower(value) {
+	case "now":
+		return now, nil
+	case "today":
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), nil
+	case "yesterday":
+		return time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, now.Location()), nil
+	}
+
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) == 3 && fields[2] == "ago" {
+		n, err := strconv.Atoi(fields[0])
+		if unit, ok := historyDateUnits[strings.TrimSuffix(fields[1], "s")]; ok && err == nil && n >= 0 {
+			return unit(now, n), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q, expected e.g. 2026-01-31 or \"3 months ago\"", value)
+}
+
+// splitHistoryRange splits a --range value into the revision it excludes and
+// the one it walks from. A bare revision walks from it with nothing excluded
+// and an empty end is HEAD, so v3.0.0.. is everything since the v3.0.0 tag.
+func splitHistoryRange(value string) (base, tip string, err error) {
+	if strings.Contains(value, "...") {
+		return "", "", errors.New("symmetric ranges (A...B) are not supported, use A..B")
+	}
+	base, tip, found := strings.Cut(value, "..")
+	if !found {
+		return "", value, nil
+	}
+	if base == "" {
+		base = "HEAD"
+	}
+	if tip == "" {
+		tip = "HEAD"
+	}
+	return base, tip, nil
+}
+
+// validateHistoryWindow checks the --since, --until and --range flags can be
+// read, so a typo fails before any history is walked
+func validateHistoryWindow(now time.Time) error {
+	since, err := parseHistoryDate(HistorySince, now)
+	if err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	until, err := parseHistoryDate(HistoryUntil, now)
+	if err != nil {
+		return fmt.Errorf("--until: %w", err)
+	}
+	if !since.IsZero() && !until.IsZero() && since.After(until) {
+		return errors.New("--since must be before --until")
+	}
+	if _, _, err := splitHistoryRange(HistoryRange); err != nil {
+		return fmt.Errorf("--range: %w", err)
+	}
+	return nil
+}
+
+// historyLimits is the commit window the history flags ask for, resolved
+// against a repository
+type historyLimits struct {
+	depth    int
+	since    time.Time
+	until    time.Time
+	base     plumbing.Hash
+	tip      plumbing.Hash
+	excluded map[plumbing.Hash]struct{} // commits reachable from base
+}
+
+// resolveHistoryLimits reads the history flags and resolves --range in repo,
+// walking from head when it names no other tip. Limiting the window by date
+// or range walks all of it unless --depth was set as well.
+func resolveHistoryLimits(repo *git.Repository, head plumbing.Hash, now time.Time) (*historyLimits, error) {
+	if err := validateHistoryWindow(now); err != nil {
+		return nil, err
+	}
+	limits := &historyLimits{depth: HistoryDepth, tip: head}
+	limits.since, _ = parseHistoryDate(HistorySince, now)
+	limits.until, _ = parseHistoryDate(HistoryUntil, now)
+
+	if !HistoryDepthSet && (HistorySince != "" || HistoryUntil != "" || HistoryRange != "") {
+		limits.depth = 0
+	}
+	if HistoryRange == "" {
+		return limits, nil
+	}
+
+	base, tip, _ := splitHistoryRange(HistoryRange)
+	resolved, err := repo.ResolveRevision(plumbing.Revision(tip))
+	if err != nil {
+		return nil, fmt.Errorf("resolve revision %s: %w", tip, err)
+	}
+	limits.tip = *resolved
+	if base == "" {
+		return limits, nil
+	}
+
+	resolved, err = repo.ResolveRevision(plumbing.Revision(base))
+	if err != nil {
+		return nil, fmt.Errorf("resolve revision %s: %w", base, err)
+	}
+	limits.base = *resolved
+	limits.excluded = map[plumbing.Hash]struct{}{}
+	commit, err := repo.CommitObject(limits.base)
+	if err != nil {
+		return nil, fmt.Errorf("read commit %s: %w", base, err)
+	}
+	// A shallow clone ends in a missing parent, which is the end of what
+	// the base can reach as it is for the walk itself
+	err = object.NewCommitPreorderIter(commit, nil, nil).ForEach(func(c *object.Commit) error {
+		limits.excluded[c.Hash] = struct{}{}
+		return nil
+	})
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("walk %s: %w", base, err)
+	}
+	return limits, nil
+}
+
+// check reports whether the walk, newest commit first by committer time,
+// keeps c and whether it can stop as every commit after it is too old
+func (l *historyLimits) check(c *object.Commit) (keep, stop bool) {
+	if !l.since.IsZero() && c.Committer.When.Before(l.since) {
+		return false, true
+	}
+	if !l.until.IsZero() && c.Committer.When.After(l.until) {
+		return false, false
+	}
+	if _, ok := l.excluded[c.Hash]; ok {
+		return false, false
+	}
+	return true, false
+}
+
+// window records the resolved limits in w
+func (l *historyLimits) window(w HistoryWindow) HistoryWindow {
+	w.Depth = l.depth
+	w.Since = l.since
+	w.Until = l.until
+	w.Range = HistoryRange
+	w.Base = l.base
+	w.Head = l.tip
+	return w
+}
+
+// historyJSONRange is the part of the window of every JSON history report
+// recording the --since, --until and --range limits it was walked with. It
+// is embedded so its fields sit alongside the rest of the window.
+type historyJSONRange struct {
+	Since string `json:"since,omitempty"`
+	Until string `json:"until,omitempty"`
+	Range string `json:"range,omitempty"`
+	Base  string `json:"base,omitempty"`
+	Head  string `json:"head,omitempty"`
+}
+
+// jsonWindowRange is the historyJSONRange of w, empty when nothing limited it
+// beyond --depth
+func jsonWindowRange(w HistoryWindow) historyJSONRange {
+	r := historyJSONRange{Range: w.Range}
+	if !w.Since.IsZero() {
+		r.Since = w.Since.UTC().Format(time.RFC3339)
+	}
+	if !w.Until.IsZero() {
+		r.Until = w.Until.UTC().Format(time.RFC3339)
+	}
+	if w.Range != "" {
+		r.Base = hashString(w.Base)
+		r.Head = hashString(w.Head)
+	}
+	return r
+}
+
+// hashString is the hex of h, or empty for the zero hash
+func hashString(h plumbing.Hash) string {
+	if h.IsZero() {
+		return ""
+	}
+	return h.String()
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHistoryDate(t *testing.T) {
+	now := time.Date(2026, 5, 20, 15, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		value    string
+		expected time.Time
+	}{
+		{"", time.Time{}},
+		{"2026-01-31", time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"2026-01-31 08:15", time.Date(2026, 1, 31, 8, 15, 0, 0, time.UTC)},
+		{"2026-01-31T08:15:00+02:00", time.Date(2026, 1, 31, 6, 15, 0, 0, time.UTC)},
+		{"3 months ago", time.Date(2026, 2, 20, 15, 30, 0, 0, time.UTC)},
+		{"1 week ago", time.Date(2026, 5, 13, 15, 30, 0, 0, time.UTC)},
+		{"2 Days Ago", time.Date(2026, 5, 18, 15, 30, 0, 0, time.UTC)},
+		{"12 hours ago", time.Date(2026, 5, 20, 3, 30, 0, 0, time.UTC)},
+		{"yesterday", time.Date(2026, 5, 19, 0, 0, 0, 0, time.UTC)},
+	} {
+		got, err := parseHistoryDate(tc.value, now)
+		if err != nil {
+			t.Errorf("%q: %v", tc.value, err)
+			continue
+		}
+		if !got.Equal(tc.expected) {
+			t.Errorf("%q: expected %s got %s", tc.value, tc.expected, got)
+		}
+	}
+
+	for _, value := range []string{"last tuesday", "3 fortnights ago", "-1 days ago", "2026-13-01"} {
+		if _, err := parseHistoryDate(value, now); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestSplitHistoryRange(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		base  string
+		tip   string
+	}{
+		{"v3.0.0..HEAD", "v3.0.0", "HEAD"},
+		{"v3.0.0..", "v3.0.0", "HEAD"},
+		{"..main", "HEAD", "main"},
+		{"main", "", "main"},
+	} {
+		base, tip, err := splitHistoryRange(tc.value)
+		if err != nil || base != tc.base || tip != tc.tip {
+			t.Errorf("%q: expected %q..%q got %q..%q %v", tc.value, tc.base, tc.tip, base, tip, err)
+		}
+	}
+	if _, _, err := splitHistoryRange("a...b"); err == nil {
+		t.Error("expected symmetric ranges to be rejected")
+	}
+}
+
+func TestValidateHistoryWindow(t *testing.T) {
+	t.Cleanup(func() { HistorySince, HistoryUntil, HistoryRange = "", "", "" })
+
+	HistorySince, HistoryUntil = "2026-03-01", "2026-01-01"
+	if err := validateHistoryWindow(time.Now()); err == nil || !strings.Contains(err.Error(), "--since must be before --until") {
+		t.Errorf("expected the inverted window to be rejected got %v", err)
+	}
+	HistorySince, HistoryUntil = "soon", ""
+	if err := validateHistoryWindow(time.Now()); err == nil || !strings.HasPrefix(err.Error(), "--since:") {
+		t.Errorf("expected the bad date to be rejected got %v", err)
+	}
+	HistorySince, HistoryRange = "", "a...b"
+	if err := validateHistoryWindow(time.Now()); err == nil || !strings.HasPrefix(err.Error(), "--range:") {
+		t.Errorf("expected the symmetric range to be rejected got %v", err)
+	}
+}
+
+func TestRunHistoryWindowLimits(t *testing.T) {
+	saveDepth := HistoryDepth
+	t.Cleanup(func() {
+		HistoryDepth, HistoryDepthSet = saveDepth, false
+		HistorySince, HistoryUntil, HistoryRange = "", "", ""
+	})
+
+	// One commit an hour from 2025-01-01 12:00 UTC
+	dir := makeFixtureRepo(t, []map[string]string{
+		{"a.go": "package a\n"},
+		{"b.go": "package b\n"},
+		{"c.go": "package c\n"},
+		{"d.go": "package d\n"},
+		{"e.go": "package e\n"},
+	})
+	run := func() (*captureObserver, HistoryWindow) {
+		t.Helper()
+		cap := &captureObserver{}
+		window, err := runHistory(context.Background(), dir, cap)
+		if err != nil {
+			t.Fatalf("runHistory: %v", err)
+		}
+		return cap, window
+	}
+
+	HistoryDepth = 1
+	HistorySince, HistoryUntil = "2025-01-01T13:30:00Z", "2025-01-01T15:30:00Z"
+	cap, window := run()
+	if len(cap.commits) != 2 || cap.commits[0].When.Hour() != 14 || cap.commits[1].When.Hour() != 15 {
+		t.Fatalf("expected the commits at 14:00 and 15:00 without --depth got %+v", cap.commits)
+	}
+	if window.Depth != 0 || window.Since.IsZero() || window.Until.IsZero() {
+		t.Errorf("expected the limits in the window got %+v", window)
+	}
+
+	HistoryDepthSet = true
+	if cap, _ = run(); len(cap.commits) != 1 || cap.commits[0].When.Hour() != 15 {
+		t.Errorf("expected --depth to still cap the window got %+v", cap.commits)
+	}
+
+	HistoryDepthSet = false
+	HistorySince, HistoryUntil = "", ""
+	HistoryRange = "HEAD~3..HEAD~1"
+	cap, window = run()
+	if len(cap.commits) != 2 || cap.commits[0].When.Hour() != 14 || cap.commits[1].When.Hour() != 15 {
+		t.Fatalf("expected the two commits in the range got %+v", cap.commits)
+	}
+	if _, ok := cap.snapshot.Files["e.go"]; ok {
+		t.Errorf("expected the snapshot to be of the end of the range got %v", cap.snapshot.Files)
+	}
+	if window.Range != HistoryRange || window.Base.IsZero() || window.Head != cap.commits[1].Hash {
+		t.Errorf("expected the resolved range in the window got %+v", window)
+	}
+
+	o := newHotspotsObserver()
+	o.Finalise(window, cap.snapshot)
+	got, err := renderHotspotsJSON(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Window map[string]any `json:"window"`
+	}
+	if err := json.Unmarshal([]byte(got), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Window["range"] != HistoryRange || doc.Window["base"] != window.Base.String() || doc.Window["head"] != window.Head.String() {
+		t.Errorf("expected the range in the JSON window got %v", doc.Window)
+	}
+	if header := formatHeaderLine("Hotspots", window); !strings.Contains(header, "2 commits in HEAD~3..HEAD~1") {
+		t.Errorf("unexpected header %s", header)
+	}
+
+	HistoryRange = "nosuchtag.."
+	if _, err := runHistory(context.Background(), dir, &captureObserver{}); err == nil {
+		t.Error("expected an unknown revision to fail")
+	}
+}
@@ -32,6 +32,9 @@ func formatHeaderLine(reportName string, w HistoryWindow) string {
 	}
 	from := w.From.UTC().Format(historyDateLayout)
 	to := w.To.UTC().Format(historyDateLayout)
+	if w.Range != "" {
+		return reportName + " · " + itoa(w.Commits) + " commits in " + w.Range + " · " + from + " → " + to
+	}
 	return reportName + " · last " + itoa(w.Commits) + " commits · " + from + " → " + to
 }
 
@@ -202,7 +205,8 @@ func itoa(n int) string {
 }
 
 // formatWindowComment builds the "# window: depth=… commits=… from=… to=…"
-// comment line used by CSV outputs.
+// comment line used by CSV outputs, followed by the since, until and range
+// limits when they were set.
 func formatWindowComment(w HistoryWindow) string {
 	var depth string
 	if w.Depth == 0 {
@@ -210,10 +214,26 @@ func formatWindowComment(w HistoryWindow) string {
 	} else {
 		depth = itoa(w.Depth)
 	}
-	return "# window: depth=" + depth +
+	comment := "# window: depth=" + depth +
 		" commits=" + itoa(w.Commits) +
 		" from=" + formatWindowDate(w.From) +
 		" to=" + formatWindowDate(w.To)
+
+	limits := jsonWindowRange(w)
+	if limits.Since != "" {
+		comment += " since=" + limits.Since
+	}
+	if limits.Until != "" {
+		comment += " until=" + limits.Until
+	}
+	if limits.Range != "" {
+		comment += " range=" + limits.Range
+		if limits.Base != "" {
+			comment += " base=" + limits.Base
+		}
+		comment += " head=" + limits.Head
+	}
+	return comment
 }
 
 func formatWindowDate(t time.Time) string {
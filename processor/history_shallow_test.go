@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -90,7 +91,7 @@ func TestRunHistoryOnShallowClone(t *testing.T) {
 	dir, observed := makeShallowFixtureRepo(t)
 
 	cap := &captureObserver{}
-	window, err := runHistory(dir, cap)
+	window, err := runHistory(context.Background(), dir, cap)
 	if err != nil {
 		t.Fatalf("runHistory on shallow clone errored: %v", err)
 	}
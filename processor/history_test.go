@@ -3,8 +3,10 @@
 package processor
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -92,7 +94,7 @@ func TestRunHistoryWalksOldestFirstAndCollectsChanges(t *testing.T) {
 	})
 
 	cap := &captureObserver{}
-	window, err := runHistory(dir, cap)
+	window, err := runHistory(context.Background(), dir, cap)
 	if err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
@@ -129,7 +131,7 @@ func TestRunHistoryDepthCap(t *testing.T) {
 	})
 
 	cap := &captureObserver{}
-	if _, err := runHistory(dir, cap); err != nil {
+	if _, err := runHistory(context.Background(), dir, cap); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	if len(cap.commits) != 2 {
@@ -151,7 +153,7 @@ func TestRunHistorySkipsBinaryAndUnknown(t *testing.T) {
 	})
 
 	cap := &captureObserver{}
-	if _, err := runHistory(dir, cap); err != nil {
+	if _, err := runHistory(context.Background(), dir, cap); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	if len(cap.changes) != 1 {
@@ -167,6 +169,147 @@ func TestRunHistorySkipsBinaryAndUnknown(t *testing.T) {
 	}
 }
 
+// TestRunHistoryParallelMatchesSequential diffs the same history on one
+// worker and on several and expects the observer to see identical commits
+// and changes in the same oldest-first order.
+func TestRunHistoryParallelMatchesSequential(t *testing.T) {
+	saveDepth, saveWorkers := HistoryDepth, HistoryJobWorkers
+	HistoryDepth = 0
+	t.Cleanup(func() { HistoryDepth, HistoryJobWorkers = saveDepth, saveWorkers })
+
+	var commits []map[string]string
+	for i := range 24 {
+		content := "package a\n"
+		for j := 0; j <= i; j++ {
+			content += "func F" + itoa(j) + "() { if true {} }\n"
+		}
+		commits = append(commits, map[string]string{
+			"a.go":                    content,
+			"pkg" + itoa(i%3) + ".go": "package a\n// " + itoa(i) + "\n",
+		})
+	}
+	dir := makeFixtureRepo(t, commits)
+
+	run := func(workers int) *captureObserver {
+		t.Helper()
+		HistoryJobWorkers = workers
+		cap := &captureObserver{}
+		if _, err := runHistory(context.Background(), dir, cap); err != nil {
+			t.Fatalf("runHistory: %v", err)
+		}
+		return cap
+	}
+	sequential, parallel := run(1), run(6)
+
+	if len(sequential.commits) != 24 || len(parallel.commits) != 24 {
+		t.Fatalf("expected 24 commits got %d and %d", len(sequential.commits), len(parallel.commits))
+	}
+	for i := range sequential.commits {
+		if sequential.commits[i].Hash != parallel.commits[i].Hash {
+			t.Fatalf("commit %d: expected %s got %s", i, sequential.commits[i].Hash, parallel.commits[i].Hash)
+		}
+		if len(sequential.changes[i]) != len(parallel.changes[i]) {
+			t.Fatalf("commit %d: expected %d changes got %d", i, len(sequential.changes[i]), len(parallel.changes[i]))
+		}
+		for j, fc := range sequential.changes[i] {
+			other := parallel.changes[i][j]
+			if fc.Path != other.Path || len(fc.AddedRanges) != len(other.AddedRanges) || len(fc.Complexity) != len(other.Complexity) {
+				t.Errorf("commit %d: expected %+v got %+v", i, fc, other)
+			}
+		}
+	}
+}
+
+// TestRunHistoryParallelPacked diffs a packed history, where every read goes
+// through the shared packfile index, on several workers while .gitattributes
+// is read from the HEAD tree under the same store lock, and expects the walk
+// to finish with what one worker sees. Meant to be run with -race as well.
+func TestRunHistoryParallelPacked(t *testing.T) {
+	saveDepth, saveWorkers := HistoryDepth, HistoryJobWorkers
+	HistoryDepth = 0
+	t.Cleanup(func() { HistoryDepth, HistoryJobWorkers = saveDepth, saveWorkers })
+
+	var commits []map[string]string
+	for i := range 16 {
+		commits = append(commits, map[string]string{
+			".gitattributes":                 "d" + itoa(i%4) + "/*.rules linguist-language=Python\n",
+			"d" + itoa(i%4) + "/x.rules":     "x = " + itoa(i) + "\n",
+			"d" + itoa(i%4) + "/sub/main.go": "package main\n// " + itoa(i) + "\n",
+		})
+	}
+	dir := makeFixtureRepo(t, commits)
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RepackObjects(&git.RepackConfig{}); err != nil {
+		t.Fatalf("repack: %v", err)
+	}
+
+	run := func(workers int) *captureObserver {
+		t.Helper()
+		HistoryJobWorkers = workers
+		cap := &captureObserver{}
+		if _, err := runHistory(context.Background(), dir, cap); err != nil {
+			t.Fatalf("runHistory: %v", err)
+		}
+		return cap
+	}
+	sequential, parallel := run(1), run(8)
+
+	if len(sequential.commits) != 16 || len(parallel.commits) != 16 {
+		t.Fatalf("expected 16 commits got %d and %d", len(sequential.commits), len(parallel.commits))
+	}
+	// Only the HEAD tree's .gitattributes applies, which names d3
+	python := 0
+	for _, changes := range parallel.changes {
+		for _, fc := range changes {
+			if fc.Language == "Python" {
+				python++
+			}
+		}
+	}
+	if python != 4 {
+		t.Errorf("expected linguist-language applied to the 4 d3/x.rules changes got %d", python)
+	}
+	for i := range sequential.changes {
+		if len(sequential.changes[i]) != len(parallel.changes[i]) {
+			t.Fatalf("commit %d: expected %d changes got %d", i, len(sequential.changes[i]), len(parallel.changes[i]))
+		}
+		for j, fc := range sequential.changes[i] {
+			if other := parallel.changes[i][j]; fc.Path != other.Path || fc.Language != other.Language {
+				t.Errorf("commit %d: expected %+v got %+v", i, fc, other)
+			}
+		}
+	}
+}
+
+// TestDiffCommitsCancelled checks a cancelled walk closes the channel
+// rather than leaving the receiver waiting.
+func TestDiffCommitsCancelled(t *testing.T) {
+	dir := makeFixtureRepo(t, []map[string]string{
+		{"a.go": "package a\n"},
+		{"a.go": "package a\nfunc A() {}\n"},
+	})
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for range diffCommits(ctx, []*object.Commit{commit, commit, commit, commit}, 2, new(sync.Mutex), nil, newBlobClassifyCache()) {
+	}
+}
+
 // TestRunHistoryWithoutGitInPath confirms the engine has no shell-out to the
 // git binary by running the walk with PATH stripped to /nonexistent.
 func TestRunHistoryWithoutGitInPath(t *testing.T) {
@@ -189,7 +332,7 @@ func TestRunHistoryWithoutGitInPath(t *testing.T) {
 	}
 
 	cap := &captureObserver{}
-	if _, err := runHistory(dir, cap); err != nil {
+	if _, err := runHistory(context.Background(), dir, cap); err != nil {
 		t.Fatalf("runHistory: %v", err)
 	}
 	if len(cap.commits) != 2 {
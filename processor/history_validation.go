@@ -7,14 +7,15 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 )
 
 // validateHistoryFlags checks the global flag state for the history reports
-// (--hotspots, --by-author, --timeline). Hard errors are returned and should
-// abort the run; recoverable conditions are written to warnDst as a single
-// line each and execution continues.
+// (--hotspots, --by-author, --timeline, --code-age, --complexity-trend). Hard
+// errors are returned and should abort the run; recoverable conditions are
+// written to warnDst as a single line each and execution continues.
 func validateHistoryFlags(warnDst io.Writer) error {
-	if !Hotspots && !ByAuthor && !Timeline {
+	if !Hotspots && !ByAuthor && !Timeline && !CodeAge && !ComplexityTrend {
 		return nil
 	}
 
@@ -22,10 +23,18 @@ func validateHistoryFlags(warnDst io.Writer) error {
 		return errors.New("--depth must be >= 0 (0 means entire history)")
 	}
 
-	if Timeline && HistoryBuckets < 1 {
+	if (Timeline || ComplexityTrend) && HistoryBuckets < 1 {
 		return errors.New("--buckets must be >= 1")
 	}
 
+	if HistoryCache && HistoryCacheSize < 1 {
+		return errors.New("--history-cache-size must be >= 1")
+	}
+
+	if err := validateHistoryWindow(time.Now()); err != nil {
+		return err
+	}
+
 	if len(DirFilePaths) > 1 {
 		_, _ = fmt.Fprintf(
 			warnDst,
@@ -69,6 +78,8 @@ func collectIgnoredHistoryFlags() []string {
 	add(IgnoreMinifiedGenerate, "--no-min-gen")
 	add(IgnoreMinified, "--no-min")
 	add(IgnoreGenerated, "--no-gen")
+	add(Vendored && !IgnoreVendored, "--vendor")
+	add(Documentation && !IgnoreDocumentation, "--docs")
 	add(Cocomo, "--no-cocomo")
 	add(Locomo, "--locomo")
 	add(CostComparison, "--cost-comparison")
@@ -101,6 +101,19 @@ func TestValidateRejectsZeroBuckets(t *testing.T) {
 	}
 }
 
+func TestValidateRejectsZeroBucketsForComplexityTrend(t *testing.T) {
+	resetHistoryFlagState(t)
+	ComplexityTrend = true
+	defer func() { ComplexityTrend = false }()
+	HistoryBuckets = 0
+
+	var buf bytes.Buffer
+	err := validateHistoryFlags(&buf)
+	if err == nil || !strings.Contains(err.Error(), "--buckets") {
+		t.Fatalf("expected a --buckets error for --complexity-trend, got: %v", err)
+	}
+}
+
 func TestValidateAllowsZeroBucketsWithoutTimeline(t *testing.T) {
 	resetHistoryFlagState(t)
 	Hotspots = true
@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bytes"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// jupyter is the language of .ipynb files, whose cells are counted in place
+// of the JSON holding them
+const jupyter = "Jupyter"
+
+// notebookFile is the part of a Jupyter notebook scc reads. Outputs, and the
+// images embedded in them, are never decoded.
+type notebookFile struct {
+	Cells      []notebookCell `json:"cells"`
+	Worksheets []struct {
+		Cells []notebookCell `json:"cells"`
+	} `json:"worksheets"` // nbformat 3 keeps the cells in worksheets
+	Metadata struct {
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+		Kernelspec struct {
+			Language string `json:"language"`
+			Name     string `json:"name"`
+		} `json:"kernelspec"`
+		Language string `json:"language"`
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	CellType string         `json:"cell_type"`
+	Source   notebookSource `json:"source"`
+	Input    notebookSource `json:"input"`    // nbformat 3 code cells
+	Language string         `json:"language"` // nbformat 3 code cells
+}
+
+// notebookSource is the text of a cell, which notebooks store either as one
+// string or as a list of lines
+type notebookSource string
+
+func (s *notebookSource) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := jsoniter.Unmarshal(data, &lines); err == nil {
+		*s = notebookSource(strings.Join(lines, ""))
+		return nil
+	}
+	var text string
+	if err := jsoniter.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*s = notebookSource(text)
+	return nil
+}
+
+// notebookText is what the Markdown cells of a notebook count to
+type notebookText struct {
+	language string
+	lines    int64
+	comment  int64
+	blank    int64
+}
+
+// readNotebook swaps the content of job, a Jupyter notebook, for the source of
+// its code cells and its language for the one of its kernel so CountStats
+// counts the code alone. The Markdown cells it returns are added as comments
+// by countNotebookText afterwards. It returns nil, leaving job to be counted
+// as JSON, when the notebook cannot be read or its language is unknown.
+func (ctx *processorContext) readNotebook(job *FileJob) *notebookText {
+	var nb notebookFile
+	if err := jsoniter.Unmarshal(job.Content, &nb); err != nil {
+		printWarnF("unable to read notebook %s: %s", job.Location, err.Error())
+		return nil
+	}
+
+	cells := nb.Cells
+	for _, worksheet := range nb.Worksheets {
+		cells = append(cells, worksheet.Cells...)
+	}
+
+	language, ok := notebookLanguage(nb, cells)
+	if !ok {
+		printWarnF("unable to determine the kernel language of notebook %s", job.Location)
+		return nil
+	}
+
+	text := &notebookText{language: language}
+	var code bytes.Buffer
+	for _, cell := range cells {
+		switch cell.CellType {
+		case "code":
+			source := cell.Source
+			if source == "" {
+				source = cell.Input
+			}
+			if source == "" {
+				continue
+			}
+			code.WriteString(string(source))
+			if !strings.HasSuffix(string(source), "\n") {
+				code.WriteByte('\n')
+			}
+		case "markdown":
+			text.add(string(cell.Source))
+		}
+	}
+
+	ctx.loadLanguageFeature(language)
+	job.Language = language
+	job.Content = code.Bytes()
+	job.Bytes = int64(code.Len())
+	return text
+}
+
+// notebookLanguage is the language the code cells of nb are written in,
+// taken from the metadata the kernel writes or else from the cells themselves
+func notebookLanguage(nb notebookFile, cells []notebookCell) (string, bool) {
+	names := []string{
+		nb.Metadata.LanguageInfo.Name,
+		nb.Metadata.Kernelspec.Language,
+		strings.TrimRight(nb.Metadata.Kernelspec.Name, "0123456789"),
+		nb.Metadata.Language,
+	}
+	for _, cell := range cells {
+		names = append(names, cell.Language)
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if language, ok := embeddedLanguageNamed(name, false); ok && language != jupyter {
+			return language, true
+		}
+	}
+	return "", false
+}
+
+// add counts the lines of a Markdown cell as comments
+func (t *notebookText) add(source string) {
+	if source == "" {
+		return
+	}
+	for line := range strings.Lines(source) {
+		t.lines++
+		if strings.TrimSpace(line) == "" {
+			t.blank++
+		} else {
+			t.comment++
+		}
+	}
+}
+
+// countNotebookText adds the Markdown cells of a notebook read by
+// readNotebook to job and names it after the notebook and its kernel
+// language, such as Jupyter (Python), keeping any suffix CountStats added
+func countNotebookText(job *FileJob, text *notebookText) {
+	job.Lines += text.lines
+	job.Comment += text.comment
+	job.Blank += text.blank
+	job.Language = jupyter + " (" + text.language + ")" + strings.TrimPrefix(job.Language, text.language)
+}
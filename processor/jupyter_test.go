@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const jupyterPython = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Analysis\n", "\n", "Load the data."]},
+  {"cell_type": "code", "execution_count": 1, "metadata": {}, "outputs": [{"output_type": "display_data", "data": {"image/png": "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJ\n"}}], "source": ["import os\n", "# comment\n", "\n", "if os.name:\n", "    print(1)"]},
+  {"cell_type": "raw", "metadata": {}, "source": "raw text"}
+ ],
+ "metadata": {"kernelspec": {"display_name": "Python 3", "language": "python", "name": "python3"}, "language_info": {"name": "python"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+
+func TestNotebookLanguage(t *testing.T) {
+	ProcessConstants()
+
+	for _, tc := range []struct {
+		content  string
+		expected string
+	}{
+		{`{"metadata": {"language_info": {"name": "python"}}}`, "Python"},
+		{`{"metadata": {"kernelspec": {"name": "ir", "language": "R"}}}`, "R"},
+		{`{"metadata": {"kernelspec": {"name": "julia-1.9"}, "language_info": {"name": "julia"}}}`, "Julia"},
+		{`{"metadata": {"kernelspec": {"name": "python3"}}}`, "Python"},
+		{`{"worksheets": [{"cells": [{"cell_type": "code", "language": "python", "input": ["x = 1"]}]}], "metadata": {}}`, "Python"},
+		{`{"metadata": {"kernelspec": {"name": "mystery"}}}`, ""},
+	} {
+		var nb notebookFile
+		if err := json.Unmarshal([]byte(tc.content), &nb); err != nil {
+			t.Fatal(err)
+		}
+		cells := nb.Cells
+		for _, worksheet := range nb.Worksheets {
+			cells = append(cells, worksheet.Cells...)
+		}
+		if got, _ := notebookLanguage(nb, cells); got != tc.expected {
+			t.Errorf("%s: expected %q got %q", tc.content, tc.expected, got)
+		}
+	}
+}
+
+func TestJupyterRun(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"a.ipynb":   jupyterPython,
+		"v3.ipynb":  `{"worksheets": [{"cells": [{"cell_type": "code", "language": "python", "input": ["x = 1\n", "y = 2"], "outputs": []}]}], "metadata": {}, "nbformat": 3}`,
+		"bad.ipynb": `{"cells": [`,
+	})
+	opts := DefaultOptions()
+	opts.ByFile = true
+	langs := runTree(t, dir, opts)
+
+	python, ok := langs["Jupyter (Python)"]
+	if !ok || python.Count != 2 {
+		t.Fatalf("expected both notebooks under Jupyter (Python) got %+v", langs)
+	}
+	// 2 lines of Markdown and a blank, and 5 lines of code with a comment
+	// and a blank, from the first, and 2 lines of code from the second
+	if python.Lines != 10 || python.Code != 5 || python.Comment != 3 || python.Blank != 2 || python.Complexity != 1 {
+		t.Errorf("unexpected notebook counts %+v", python)
+	}
+	if len(langs["Jupyter"].Files) != 1 || langs["Jupyter"].Files[0].Filename != "bad.ipynb" {
+		t.Errorf("expected the broken notebook to be counted as it is got %+v", langs["Jupyter"])
+	}
+}
@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// languageOverlay holds the names of the languages loaded from
+// --languages-file. They take priority over the built-in languages for their
+// extensions, filenames and shebangs.
+var languageOverlay = map[string]bool{}
+
+// LoadLanguagesFile adds the languages in the named file, which uses the same
+// schema as languages.json, to the language database. A language with the
+// same name as a built-in one replaces it. Call ProcessConstants afterwards
+// so the lookups pick them up.
+func LoadLanguagesFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("could not read languages file: %w", err)
+	}
+	languages, err := parseLanguages(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	LanguageFeaturesMutex.Lock()
+	for lang, value := range languages {
+		languageDatabase[lang] = value
+		languageOverlay[lang] = true
+		// Built-in features for a replaced language are stale
+		delete(LanguageFeatures, lang)
+	}
+	LanguageFeaturesMutex.Unlock()
+	return nil
+}
+
+// parseLanguages decodes and validates a languages.json style document,
+// rejecting fields that are not part of the schema
+func parseLanguages(data []byte) (map[string]Language, error) {
+	names, raw, err := splitLanguages(data)
+	if err != nil {
+		return nil, err
+	}
+
+	languages := make(map[string]Language, len(raw))
+	for _, name := range names {
+		l, err := decodeLanguage(raw[name])
+		if err != nil {
+			return nil, fmt.Errorf("language %q: %w", name, err)
+		}
+		if err := validateLanguage(name, l); err != nil {
+			return nil, fmt.Errorf("language %q: %w", name, err)
+		}
+		languages[name] = l
+	}
+	return languages, nil
+}
+
+// splitLanguages breaks a languages.json style document into the raw
+// definition of each language, returning the names sorted
+func splitLanguages(data []byte) ([]string, map[string]json.RawMessage, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("expected an object of language name to definition: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, nil, errors.New("no languages defined")
+	}
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, raw, nil
+}
+
+// decodeLanguage decodes a single language definition, rejecting fields that
+// are not part of the schema
+func decodeLanguage(definition json.RawMessage) (Language, error) {
+	decoder := json.NewDecoder(bytes.NewReader(definition))
+	decoder.DisallowUnknownFields()
+
+	var l Language
+	if err := decoder.Decode(&l); err != nil {
+		var field *json.UnmarshalTypeError
+		if errors.As(err, &field) {
+			err = fmt.Errorf("%s must be %s not %s", field.Field, field.Type, field.Value)
+		}
+		return Language{}, err
+	}
+	return l, nil
+}
+
+// validateLanguage checks the things processLanguageFeature and detection rely
+// on, returning the first problem found
+func validateLanguage(name string, l Language) error {
+	for _, problem := range lintLanguage(name, l) {
+		if problem.Severity == severityError {
+			return errors.New(problem.Message)
+		}
+	}
+	return nil
+}
+
+// lintLanguage checks a single language definition on its own, naming the
+// field at fault in each problem
+func lintLanguage(name string, l Language) []languageProblem {
+	var problems []languageProblem
+	report := func(format string, a ...any) {
+		problems = append(problems, languageProblem{Language: name, Severity: severityError, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if strings.TrimSpace(name) == "" {
+		report("name must not be empty")
+	}
+	if len(l.Extensions) == 0 && len(l.FileNames) == 0 && len(l.SheBangs) == 0 {
+		report("extensions, filenames or shebangs must be set so files can be matched to the language")
+	}
+
+	nonEmpty := func(field string, values []string) {
+		if i := slices.Index(values, ""); i != -1 {
+			report("%s[%d] must not be empty", field, i)
+		}
+	}
+	for _, list := range []struct {
+		field  string
+		values []string
+	}{
+		{"extensions", l.Extensions},
+		{"filenames", l.FileNames},
+		{"shebangs", l.SheBangs},
+		{"line_comment", l.LineComment},
+		{"complexitychecks", l.ComplexityChecks},
+		{"complexitychecks_postfix", l.ComplexityChecksPostfix},
+		{"complexitychecks_postfix_excludes", l.ComplexityChecksPostfixExcludes},
+		{"keywords", l.Keywords},
+	} {
+		nonEmpty(list.field, list.values)
+	}
+
+	for i, ext := range l.Extensions {
+		if ext != "" && (strings.HasPrefix(ext, ".") || ext != strings.ToLower(ext)) {
+			report("extensions[%d] %q must be lower case without the leading dot", i, ext)
+		}
+	}
+	for i, pair := range l.MultiLine {
+		switch {
+		case len(pair) != 2 || pair[0] == "" || pair[1] == "":
+			report("multi_line[%d] must be a [start, end] pair", i)
+		case l.NestedMultiLine && pair[0] == pair[1]:
+			// Every end would be read as the start of another nested comment
+			report("multi_line[%d] %q can never be closed when nestedmultiline is set as it starts and ends the same way", i, pair[0])
+		}
+	}
+	for i, q := range l.Quotes {
+		if q.Start == "" || q.End == "" {
+			report("quotes[%d] must set both start and end", i)
+		}
+	}
+	for i, h := range l.Heuristics {
+		if _, err := regexp.Compile(h.Pattern); err != nil {
+			report("heuristics[%d].pattern: %v", i, err)
+			continue
+		}
+		nonEmpty(fmt.Sprintf("heuristics[%d].literals", i), h.Literals)
+		if len(h.Literals) != 0 && !slices.Contains(h.Literals, "") && !literalsCover(h.Pattern, h.Literals) {
+			report("heuristics[%d].literals: the pattern can match content without any of %q, which the literal pre-check skips", i, h.Literals)
+		}
+	}
+	return problems
+}
+
+// applyLanguageOverlay points the extensions, filenames and shebangs of the
+// --languages-file languages at them alone, ahead of any built-in language
+// claiming the same ones
+func applyLanguageOverlay() {
+	if len(languageOverlay) == 0 {
+		return
+	}
+
+	extensions := map[string][]string{}
+	var shebangs []string
+	for name := range languageOverlay {
+		value := languageDatabase[name]
+		for _, ext := range value.Extensions {
+			extensions[ext] = append(extensions[ext], name)
+		}
+		for _, fname := range value.FileNames {
+			FilenameToLanguage[fname] = name
+		}
+		shebangs = append(shebangs, value.SheBangs...)
+	}
+	for ext, names := range extensions {
+		slices.Sort(names)
+		ExtensionToLanguage[ext] = names
+	}
+
+	for name, commands := range ShebangLookup {
+		if languageOverlay[name] {
+			continue
+		}
+		if slices.ContainsFunc(commands, func(c string) bool { return slices.Contains(shebangs, c) }) {
+			ShebangLookup[name] = slices.DeleteFunc(slices.Clone(commands), func(c string) bool {
+				return slices.Contains(shebangs, c)
+			})
+		}
+	}
+
+	// Compile them now so a broken definition shows up at startup rather than
+	// on the first matching file
+	for name := range languageOverlay {
+		processLanguageFeature(name, languageDatabase[name])
+	}
+}
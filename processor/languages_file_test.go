@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withLanguagesFile loads content as a --languages-file, undoing it once the
+// test is done
+func withLanguagesFile(t *testing.T, content string) error {
+	t.Helper()
+	saved := make(map[string]Language, len(languageDatabase))
+	for name, value := range languageDatabase {
+		saved[name] = value
+	}
+	t.Cleanup(func() {
+		clear(languageDatabase)
+		for name, value := range saved {
+			languageDatabase[name] = value
+		}
+		LanguageFeaturesMutex.Lock()
+		for name := range languageOverlay {
+			delete(LanguageFeatures, name)
+		}
+		LanguageFeaturesMutex.Unlock()
+		clear(languageOverlay)
+		ProcessConstants()
+	})
+
+	name := filepath.Join(t.TempDir(), "languages.json")
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLanguagesFile(name); err != nil {
+		return err
+	}
+	ProcessConstants()
+	return nil
+}
+
+func TestLoadLanguagesFile(t *testing.T) {
+	err := withLanguagesFile(t, `{
+		"Widget DSL": {
+			"extensions": ["wdg", "c"],
+			"filenames": ["widgetfile"],
+			"shebangs": ["wdgrun"],
+			"line_comment": ["--"],
+			"multi_line": [["{-", "-}"]],
+			"quotes": [{"start": "\"", "end": "\""}],
+			"complexitychecks": ["when "]
+		}
+	}`)
+	if err != nil {
+		t.Fatalf("LoadLanguagesFile: %v", err)
+	}
+
+	// The overlay wins the extensions it shares with built-in languages
+	if languages := ExtensionToLanguage["c"]; len(languages) != 1 || languages[0] != "Widget DSL" {
+		t.Errorf("expected .c to map to Widget DSL only got %v", languages)
+	}
+	if FilenameToLanguage["widgetfile"] != "Widget DSL" {
+		t.Errorf("expected widgetfile to map to Widget DSL")
+	}
+	if lang, err := DetectSheBang([]byte("#!/usr/bin/env wdgrun\n")); err != nil || lang != "Widget DSL" {
+		t.Errorf("expected shebang to detect Widget DSL got %q %v", lang, err)
+	}
+
+	dir := writeTree(t, map[string]string{
+		"a.wdg": "-- comment\nwhen x\n  y \"-- not a comment\"\n{- block\n-}\n",
+	})
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	summary, _, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary) != 1 || summary[0].Name != "Widget DSL" || summary[0].Code != 2 || summary[0].Comment != 3 || summary[0].Complexity != 1 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestLoadLanguagesFileErrors(t *testing.T) {
+	for content, expected := range map[string]string{
+		`[]`: "expected an object",
+		`{}`: "no languages defined",
+		`{"X": {"extensions": ["x"], "line_coment": ["#"]}}`:              `language "X": json: unknown field "line_coment"`,
+		`{"X": {"extensions": "x"}}`:                                      `language "X": extensions must be []string`,
+		`{"X": {"line_comment": ["#"]}}`:                                  `language "X": extensions, filenames or shebangs must be set`,
+		`{"X": {"extensions": [".x"]}}`:                                   `language "X": extensions[0] ".x" must be lower case`,
+		`{"X": {"extensions": ["x"], "multi_line": [["/*"]]}}`:            `language "X": multi_line[0] must be a [start, end] pair`,
+		`{"X": {"extensions": ["x"], "quotes": [{"start": "'"}]}}`:        `language "X": quotes[0] must set both start and end`,
+		`{"X": {"extensions": ["x"], "complexitychecks": ["if ", ""]}}`:   `language "X": complexitychecks[1] must not be empty`,
+		`{"X": {"extensions": ["x"], "heuristics": [{"pattern": "(a"}]}}`: `language "X": heuristics[0].pattern:`,
+	} {
+		err := withLanguagesFile(t, content)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected error containing %q got %v", content, expected, err)
+		}
+	}
+}
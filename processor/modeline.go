@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// modelineLines is how many lines at the start and at the end of a file are
+// checked for a modeline, which is the default of Vim's modelines option
+const modelineLines = 5
+
+// vimModelineRegex finds a Vim modeline, e.g. "vim: set ft=python :" or
+// "vi:syntax=sh", capturing the options after the marker
+var vimModelineRegex = regexp.MustCompile(`(?:^|\s)(?:vim?|Vim|ex)(?:[<=>]?\d+)?:(.*)`)
+
+// vimFiletypeRegex finds the filetype among the options of a Vim modeline
+var vimFiletypeRegex = regexp.MustCompile(`(?:^|[\s:])(?:ft|filetype|syntax)\s*=\s*([\w+-]+)`)
+
+// modelineAliases maps the Vim filetypes and Emacs modes whose name is not
+// the name or an extension of the scc language they stand for
+var modelineAliases = map[string]string{
+	"cperl":          "Perl",
+	"cs":             "C#",
+	"csharp":         "C#",
+	"dosbatch":       "Batch",
+	"dosini":         "INI",
+	"elisp":          "Emacs Lisp",
+	"emacs-lisp":     "Emacs Lisp",
+	"fsharp":         "F#",
+	"gitcommit":      "Plain Text",
+	"js2":            "JavaScript",
+	"lisp-data":      "Lisp",
+	"make":           "Makefile",
+	"makefile-gmake": "Makefile",
+	"nxml":           "XML",
+	"objc":           "Objective C",
+	"objcpp":         "Objective C++",
+	"ps1":            "Powershell",
+	"shell-script":   "Shell",
+	"text":           "Plain Text",
+	"yml":            "YAML",
+}
+
+// detectModeline looks for a Vim or Emacs modeline in the first and last
+// lines of content and returns the scc language it names along with the line
+// it is on. Names are resolved through modelineAliases and then as a
+// language name or extension, the way --count-as resolves its base language.
+func detectModeline(content []byte) (string, string, bool) {
+	for _, line := range modelineCandidates(content) {
+		name, ok := emacsMode(line)
+		if !ok {
+			name, ok = vimFiletype(line)
+		}
+		if !ok {
+			continue
+		}
+		if language, ok := modelineLanguage(name); ok {
+			return language, strings.TrimSpace(line), true
+		}
+	}
+	return "", "", false
+}
+
+// modelineChoice returns the language among candidates a modeline in
+// content names, along with the line it is on. Vim and Emacs use one name for
+// a language and its headers, so C++ picks C++ Header for a .h file.
+func modelineChoice(content []byte, candidates []string) (string, string, bool) {
+	language, line, ok := detectModeline(content)
+	if !ok {
+		return "", "", false
+	}
+	for _, choice := range []string{language, language + " Header"} {
+		if slices.Contains(candidates, choice) {
+			return choice, line, true
+		}
+	}
+	return "", "", false
+}
+
+// modelineCandidates returns the lines a modeline may be on, the first
+// modelineLines lines then the last modelineLines of those left
+func modelineCandidates(content []byte) []string {
+	var lines []string
+	rest := content
+	for i := 0; i < modelineLines && len(rest) != 0; i++ {
+		var line []byte
+		line, rest, _ = bytes.Cut(rest, []byte{'\n'})
+		lines = append(lines, string(bytes.TrimSuffix(line, []byte{'\r'})))
+	}
+
+	rest = bytes.TrimSuffix(rest, []byte{'\n'})
+	for i := 0; i < modelineLines && len(rest) != 0; i++ {
+		index := bytes.LastIndexByte(rest, '\n')
+		lines = append(lines, string(bytes.TrimSuffix(rest[index+1:], []byte{'\r'})))
+		if index < 0 {
+			break
+		}
+		rest = rest[:index]
+	}
+	return lines
+}
+
+// emacsMode returns the mode set by an Emacs file variables line, either
+// "-*- mode: python; tab-width: 4 -*-" or the short "-*- C++ -*-"
+func emacsMode(line string) (string, bool) {
+	_, rest, ok := strings.Cut(line, "-*-")
+	if !ok {
+		return "", false
+	}
+	variables, _, ok := strings.Cut(rest, "-*-")
+	if !ok {
+		return "", false
+	}
+
+	if !strings.Contains(variables, ":") {
+		fields := strings.Fields(variables)
+		if len(fields) != 1 {
+			return "", false
+		}
+		return strings.TrimSuffix(fields[0], ";"), true
+	}
+	for variable := range strings.SplitSeq(variables, ";") {
+		name, value, ok := strings.Cut(variable, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "mode") && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// vimFiletype returns the filetype set by a Vim modeline
+func vimFiletype(line string) (string, bool) {
+	m := vimModelineRegex.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	ft := vimFiletypeRegex.FindStringSubmatch(m[1])
+	if ft == nil {
+		return "", false
+	}
+	return ft[1], true
+}
+
+// modelineLanguage resolves a Vim filetype or Emacs mode to a language
+func modelineLanguage(name string) (string, bool) {
+	name = strings.TrimSuffix(strings.ToLower(name), "-mode")
+	if language, ok := modelineAliases[name]; ok {
+		if _, exists := languageDatabase[language]; exists {
+			return language, true
+		}
+	}
+	return resolveBaseLanguage(name)
+}
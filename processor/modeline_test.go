@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDetectModeline(t *testing.T) {
+	ProcessConstants()
+	for _, tc := range []struct {
+		content  string
+		expected string
+	}{
+		{"# vim: set ft=python :\nx = 1\n", "Python"},
+		{"# vim:ft=sh\necho\n", "Shell"},
+		{"# vi: syntax=ruby\nputs 1\n", "Ruby"},
+		{"// vim600: set filetype=cpp :\n", "C++"},
+		{"; vim: ft=dosini\n", "INI"},
+		{"// -*- C++ -*-\nint x;\n", "C++"},
+		{"# -*- mode: python; tab-width: 4 -*-\n", "Python"},
+		{";; -*- Mode: emacs-lisp; lexical-binding: t -*-\n", "Emacs Lisp"},
+		{"# -*- coding: utf-8 -*-\n", ""},
+		{"# -*- mode: no-such-mode -*-\n", ""},
+		{"reviewing the ft=python setting\n", ""},
+		{"Vim is great\n", ""},
+		{"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n# vim: ft=ruby\n", "Ruby"},
+		{"1\n2\n3\n4\n5\n# vim: ft=ruby\n7\n8\n9\n10\n11\n", ""},
+		{"1\r\n2\r\n# vim: ft=perl\r\n", "Perl"},
+	} {
+		got, _, _ := detectModeline([]byte(tc.content))
+		if got != tc.expected {
+			t.Errorf("%q: expected %q got %q", tc.content, tc.expected, got)
+		}
+	}
+}
+
+func TestModelineCandidates(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 12; i++ {
+		lines = append(lines, strconv.Itoa(i))
+	}
+	got := strings.Join(modelineCandidates([]byte(strings.Join(lines, "\n")+"\n")), ",")
+	if got != "1,2,3,4,5,12,11,10,9,8" {
+		t.Errorf("unexpected candidates %s", got)
+	}
+	if got := strings.Join(modelineCandidates([]byte("1\n2\n3")), ","); got != "1,2,3" {
+		t.Errorf("unexpected candidates %s", got)
+	}
+}
+
+func TestDetermineLanguageModeline(t *testing.T) {
+	ProcessConstants()
+	for _, tc := range []struct {
+		candidates []string
+		content    string
+		expected   string
+	}{
+		{[]string{SheBang}, "# vim: set ft=python :\n", "Python"},
+		{[]string{SheBang}, "#!/usr/bin/perl\n# vim: set ft=python :\n", SheBang},
+		{[]string{UnknownLanguage}, "; vim: ft=dosini\n", "INI"},
+		{[]string{"Go"}, "// vim: ft=python\n", "Go"},
+		{[]string{"C Header", "C++ Header", "Objective C"}, "// vim: ft=cpp\nint x;\n", "C++ Header"},
+		{[]string{"C Header", "C++ Header", "Objective C"}, "// -*- mode: objc -*-\nint x;\n", "Objective C"},
+		{[]string{"C Header", "C++ Header", "Objective C"}, "// vim: ft=python\nint x;\n", "C Header"},
+	} {
+		got := DetermineLanguage("file", tc.candidates[0], tc.candidates, []byte(tc.content))
+		if got != tc.expected {
+			t.Errorf("%v %q: expected %s got %s", tc.candidates, tc.content, tc.expected, got)
+		}
+	}
+}
+
+func TestModelineRun(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"script": "# vim: set ft=python :\nx = 1\n",
+		"tool":   "#!/usr/bin/perl\n# -*- mode: python -*-\nprint 1;\n",
+		"legacy": "// -*- C++ -*-\nint x;\n",
+		"amb.h":  "// vim: ft=cpp\nint x;\n",
+	})
+	if got := languageCounts(runTree(t, dir, DefaultOptions())); got != "C++=1,C++ Header=1,Perl=1,Python=1" {
+		t.Errorf("unexpected run %s", got)
+	}
+
+	// An explicit remap still wins over the modeline
+	opts := DefaultOptions()
+	opts.RemapUnknown = "-*- C++ -*-:C Header"
+	if got := languageCounts(runTree(t, dir, opts)); got != "C Header=1,C++ Header=1,Perl=1,Python=1" {
+		t.Errorf("unexpected run with --remap-unknown %s", got)
+	}
+}
+
+func TestExplainModeline(t *testing.T) {
+	ProcessConstants()
+	dir := writeTree(t, map[string]string{
+		"amb.h":  "// vim: ft=cpp\nint x;\n",
+		"script": "# vim: set ft=python :\nx = 1\n",
+	})
+	opts := DefaultOptions()
+	e, err := explainLanguage(&opts, filepath.Join(dir, "amb.h"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Language != "C++ Header" || e.Modeline != "// vim: ft=cpp" || !strings.Contains(e.Reason, "modeline") {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+	if e, _ := explainLanguage(&opts, filepath.Join(dir, "script")); e.Language != "Python" || e.Modeline == "" {
+		t.Errorf("unexpected explanation %+v", e)
+	}
+}
@@ -0,0 +1,402 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+	glanguage "golang.org/x/text/language"
+	gmessage "golang.org/x/text/message"
+)
+
+// moduleManifests are the build files whose directory is taken to be the
+// root of a module. Project files such as *.csproj are matched by extension.
+var moduleManifests = map[string]bool{
+	"go.mod":         true,
+	"package.json":   true,
+	"Cargo.toml":     true,
+	"pom.xml":        true,
+	"pyproject.toml": true,
+}
+
+var moduleManifestExtensions = []string{".csproj"}
+
+// noModule is the name files outside of any module are rolled up under
+const noModule = "(no module)"
+
+func isModuleManifest(name string) bool {
+	if moduleManifests[name] {
+		return true
+	}
+	for _, ext := range moduleManifestExtensions {
+		if strings.HasSuffix(name, ext) && len(name) > len(ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleSet collects the directories holding a build manifest as the walk
+// finds them. The methods do nothing on a nil set.
+type moduleSet struct {
+	mu        sync.Mutex
+	manifests map[string][]string // directory to the manifests found in it
+}
+
+func newModuleSet() *moduleSet {
+	return &moduleSet{manifests: map[string][]string{}}
+}
+
+// see records location as a module root when name is a manifest
+func (m *moduleSet) see(location, name string) {
+	if m == nil || !isModuleManifest(name) {
+		return
+	}
+	dir := filepath.Dir(location)
+	m.mu.Lock()
+	m.manifests[dir] = append(m.manifests[dir], name)
+	m.mu.Unlock()
+}
+
+// roots returns a copy of the module directories and their sorted manifests
+func (m *moduleSet) roots() map[string][]string {
+	if m == nil {
+		return map[string][]string{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	roots := make(map[string][]string, len(m.manifests))
+	for dir, names := range m.manifests {
+		names = append([]string(nil), names...)
+		sort.Strings(names)
+		roots[dir] = names
+	}
+	return roots
+}
+
+// ModuleSummary is the language summary and cost estimates for the files
+// under one module root
+type ModuleSummary struct {
+	Path       string   // directory holding the manifest, or "(no module)"
+	Manifests  []string // manifest file names found in Path
+	Files      int64
+	Lines      int64
+	Code       int64
+	Comment    int64
+	Blank      int64
+	Complexity int64
+	Bytes      int64
+	Languages  []LanguageSummary
+	Cocomo     *CocomoResult `json:",omitempty"`
+	Locomo     *LocomoResult `json:",omitempty"`
+}
+
+// nearestModule walks up from the file's directory to the closest module root
+func nearestModule(roots map[string][]string, location string) string {
+	dir := filepath.Dir(location)
+	for {
+		if _, ok := roots[dir]; ok {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return noModule
+		}
+		dir = parent
+	}
+}
+
+// buildModuleSummaries rolls every file up to its nearest enclosing module,
+// each module holding its own language summary sorted by opts.SortBy
+func buildModuleSummaries(files []*FileJob, roots map[string][]string, opts Options) []ModuleSummary {
+	grouped := map[string][]*FileJob{}
+	for _, f := range files {
+		module := nearestModule(roots, f.Location)
+		grouped[module] = append(grouped[module], f)
+	}
+
+	sortBy := strings.ToLower(opts.SortBy)
+	modules := make([]ModuleSummary, 0, len(grouped))
+	for p, jobs := range grouped {
+		m := ModuleSummary{Path: p, Manifests: roots[p]}
+		input := make(chan *FileJob, len(jobs))
+		for _, f := range jobs {
+			m.Files++
+			m.Lines += f.Lines
+			m.Code += f.Code
+			m.Comment += f.Comment
+			m.Blank += f.Blank
+			m.Complexity += f.Complexity
+			m.Bytes += f.Bytes
+			input <- f
+		}
+		close(input)
+		m.Languages = aggregateLanguageSummaryFor(input, newUlocCounter(), false, opts.Tests, opts.Embedded)
+		if opts.Uloc {
+			ulocs := moduleUloc(jobs)
+			for i := range m.Languages {
+				m.Languages[i].ULOC = ulocs[m.Languages[i].Name]
+			}
+		}
+		m.Languages = sortLanguageSummaryBy(m.Languages, sortBy)
+
+		if !opts.NoCocomo {
+			c := computeCocomo(m.Code)
+			m.Cocomo = &c
+		}
+		if opts.Locomo {
+			l := computeLocomo(m.Code, m.Complexity)
+			m.Locomo = &l
+		}
+		modules = append(modules, m)
+	}
+
+	sortModuleSummaries(modules, sortBy)
+	return modules
+}
+
+// moduleUloc counts the unique lines of each language in the files of one
+// module from their line digests, as the run wide count cannot be split up.
+// Trailing blank lines are left out as the run wide count leaves them out.
+func moduleUloc(files []*FileJob) map[string]int {
+	empty := lineDigests(nil)[0]
+	lines := map[string]map[uint64]struct{}{}
+	for _, f := range files {
+		digests := f.lineDigests
+		for len(digests) > 1 && digests[len(digests)-1] == empty {
+			digests = digests[:len(digests)-1]
+		}
+		seen, ok := lines[f.Language]
+		if !ok {
+			seen = map[uint64]struct{}{}
+			lines[f.Language] = seen
+		}
+		for _, d := range digests {
+			seen[d] = struct{}{}
+		}
+	}
+
+	counts := make(map[string]int, len(lines))
+	for language, seen := range lines {
+		counts[language] = len(seen)
+	}
+	return counts
+}
+
+// sortModuleSummaries orders modules by the --sort column, largest first,
+// with the files outside any module last
+func sortModuleSummaries(modules []ModuleSummary, sortBy string) {
+	value := func(m ModuleSummary) int64 {
+		switch sortBy {
+		case "line", "lines":
+			return m.Lines
+		case "blank", "blanks":
+			return m.Blank
+		case "code":
+			return m.Code
+		case "comment", "comments":
+			return m.Comment
+		case "complexity":
+			return m.Complexity
+		case "byte", "bytes":
+			return m.Bytes
+		case "name", "names", "language", "languages", "lang":
+			return 0
+		}
+		return m.Files
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		if (modules[i].Path == noModule) != (modules[j].Path == noModule) {
+			return modules[j].Path == noModule
+		}
+		vi, vj := value(modules[i]), value(modules[j])
+		if vi != vj {
+			return vi > vj
+		}
+		return modules[i].Path < modules[j].Path
+	})
+}
+
+// countModules runs opts and groups what it counted by module
+func countModules(ctx context.Context, opts Options) ([]ModuleSummary, error) {
+	// Unique lines are counted per module from the digests of each file
+	opts.lineDigests = opts.Uloc
+	a := NewAnalyzer(opts)
+	run, output, err := a.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, files, err := a.collect(ctx, run, output)
+	if err != nil {
+		return nil, err
+	}
+	return buildModuleSummaries(files, run.modules.roots(), opts), nil
+}
+
+// runByModuleReport is the dispatch entry point called from Process() when
+// --by-module is set
+func runByModuleReport(ctx context.Context) error {
+	modules, err := countModules(ctx, optionsFromGlobals())
+	if err != nil {
+		return err
+	}
+
+	out, err := renderModules(modules)
+	if err != nil {
+		return err
+	}
+	if FileOutput == "" {
+		fmt.Print(out)
+	} else {
+		if err := os.WriteFile(FileOutput, []byte(out), 0644); err != nil {
+			return err
+		}
+		fmt.Println("results written to " + FileOutput)
+	}
+	return nil
+}
+
+func renderModules(modules []ModuleSummary) (string, error) {
+	switch strings.ToLower(Format) {
+	case "", "tabular", "wide":
+		return renderModulesTabular(modules), nil
+	case "json":
+		return renderModulesJSON(modules)
+	case "csv":
+		return renderModulesCSV(modules)
+	default:
+		return "", fmt.Errorf("unsupported --format %q for --by-module (supported: tabular, json, csv)", Format)
+	}
+}
+
+// Tabular column formats, the same columns as --by-dir with the languages
+// of each module indented below it.
+//
+//	%-24s %6s %9s %7s %8s %9s %10s
+//	24 + 1 + 6 + 1 + 9 + 1 + 7 + 1 + 8 + 1 + 9 + 1 + 10 = 79
+var tabularModuleFormat = "%-24s %6s %9s %7s %8s %9s %10s\n"
+
+func renderModulesTabular(modules []ModuleSummary) string {
+	brk := tabularBreakFor(false)
+	p := gmessage.NewPrinter(glanguage.Make(os.Getenv("LANG")))
+	count := func(n int64) string { return formatWithCommas(p, n) }
+
+	var sb strings.Builder
+	sb.WriteString(brk)
+	_, _ = fmt.Fprintf(&sb, tabularModuleFormat, "Module", "Files", "Lines", "Blanks", "Comments", "Code", "Complexity")
+
+	var total ModuleSummary
+	for _, m := range modules {
+		sb.WriteString(brk)
+		name := filepath.ToSlash(m.Path)
+		_, _ = fmt.Fprintf(&sb, tabularModuleFormat, unicodeAwareRightPad(unicodeAwareTrim(name, 23), 24),
+			count(m.Files), count(m.Lines), count(m.Blank), count(m.Comment), count(m.Code), count(m.Complexity))
+		if len(m.Manifests) != 0 {
+			_, _ = fmt.Fprintf(&sb, "  %s\n", unicodeAwareTrim(strings.Join(m.Manifests, ", "), 76))
+		}
+		for _, l := range m.Languages {
+			_, _ = fmt.Fprintf(&sb, tabularModuleFormat, "  · "+unicodeAwareRightPad(unicodeAwareTrim(l.Name, 19), 20),
+				count(l.Count), count(l.Lines), count(l.Blank), count(l.Comment), count(l.Code), count(l.Complexity))
+		}
+
+		var estimates []string
+		if m.Cocomo != nil {
+			estimates = append(estimates, p.Sprintf("COCOMO %s%d, %.2f months, %.2f people", m.Cocomo.CurrencySymbol, int64(m.Cocomo.EstimatedCost), m.Cocomo.ScheduleMonths, m.Cocomo.PeopleRequired))
+		}
+		if m.Locomo != nil {
+			estimates = append(estimates, p.Sprintf("LOCOMO %s%.0f", CurrencySymbol, m.Locomo.Cost))
+		}
+		if len(estimates) != 0 {
+			_, _ = fmt.Fprintf(&sb, "  %s\n", strings.Join(estimates, " · "))
+		}
+
+		total.Files += m.Files
+		total.Lines += m.Lines
+		total.Blank += m.Blank
+		total.Comment += m.Comment
+		total.Code += m.Code
+		total.Complexity += m.Complexity
+	}
+
+	sb.WriteString(brk)
+	_, _ = fmt.Fprintf(&sb, tabularModuleFormat, "Total",
+		count(total.Files), count(total.Lines), count(total.Blank), count(total.Comment), count(total.Code), count(total.Complexity))
+	sb.WriteString(brk)
+	return sb.String()
+}
+
+type moduleJSONDoc struct {
+	Report  string          `json:"report"`
+	Modules []ModuleSummary `json:"modules"`
+}
+
+func renderModulesJSON(modules []ModuleSummary) (string, error) {
+	for i := range modules {
+		modules[i].Path = filepath.ToSlash(modules[i].Path)
+	}
+	b, err := jsoniter.Marshal(moduleJSONDoc{Report: "modules", Modules: modules})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// renderModulesCSV writes a row per module followed by a row per language in
+// it. Language and the estimates are only set on the rows they apply to.
+func renderModulesCSV(modules []ModuleSummary) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	_ = w.Write([]string{"Module", "Manifests", "Language", "Files", "Lines", "Code", "Comments", "Blanks", "Complexity", "Bytes", "EstimatedCost", "ScheduleMonths", "PeopleRequired", "LocomoCost"})
+
+	for _, m := range modules {
+		module := filepath.ToSlash(m.Path)
+		var cost, schedule, people, locomo string
+		if m.Cocomo != nil {
+			cost = strconv.FormatFloat(m.Cocomo.EstimatedCost, 'f', 2, 64)
+			schedule = strconv.FormatFloat(m.Cocomo.ScheduleMonths, 'f', 2, 64)
+			people = strconv.FormatFloat(m.Cocomo.PeopleRequired, 'f', 2, 64)
+		}
+		if m.Locomo != nil {
+			locomo = strconv.FormatFloat(m.Locomo.Cost, 'f', 2, 64)
+		}
+		_ = w.Write([]string{
+			module, strings.Join(m.Manifests, " "), "",
+			strconv.FormatInt(m.Files, 10),
+			strconv.FormatInt(m.Lines, 10),
+			strconv.FormatInt(m.Code, 10),
+			strconv.FormatInt(m.Comment, 10),
+			strconv.FormatInt(m.Blank, 10),
+			strconv.FormatInt(m.Complexity, 10),
+			strconv.FormatInt(m.Bytes, 10),
+			cost, schedule, people, locomo,
+		})
+		for _, l := range m.Languages {
+			_ = w.Write([]string{
+				module, "", l.Name,
+				strconv.FormatInt(l.Count, 10),
+				strconv.FormatInt(l.Lines, 10),
+				strconv.FormatInt(l.Code, 10),
+				strconv.FormatInt(l.Comment, 10),
+				strconv.FormatInt(l.Blank, 10),
+				strconv.FormatInt(l.Complexity, 10),
+				strconv.FormatInt(l.Bytes, 10),
+				"", "", "", "",
+			})
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
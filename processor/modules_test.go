@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestIsModuleManifest(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"go.mod":         true,
+		"package.json":   true,
+		"Cargo.toml":     true,
+		"pom.xml":        true,
+		"pyproject.toml": true,
+		"App.csproj":     true,
+		".csproj":        false,
+		"go.sum":         false,
+		"package.jsonc":  false,
+	} {
+		if isModuleManifest(name) != expected {
+			t.Errorf("%s: expected %t", name, expected)
+		}
+	}
+}
+
+func TestCountModules(t *testing.T) {
+	ProcessConstants()
+	dir := writeTree(t, map[string]string{
+		"README.md":             "# mono\n",
+		"svc/api/go.mod":        "module api\n",
+		"svc/api/main.go":       "package main\n\nfunc main() {\n\tif true {\n\t}\n}\n",
+		"svc/api/internal/x.go": "package internal\n",
+		"web/package.json":      "{\"name\": \"web\"}\n",
+		"web/src/app.js":        "let a = 1;\n",
+		"web/src/lib/b.js":      "let b = 2;\n",
+	})
+
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	opts.Locomo = true
+	modules, err := countModules(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("countModules: %v", err)
+	}
+	if len(modules) != 3 {
+		t.Fatalf("expected 3 modules got %+v", modules)
+	}
+
+	got := map[string]ModuleSummary{}
+	for _, m := range modules {
+		got[strings.TrimPrefix(strings.TrimPrefix(m.Path, dir), "/")] = m
+	}
+	// go.mod marks the module but is not a counted language itself
+	if m := got["svc/api"]; m.Files != 2 || len(m.Manifests) != 1 || m.Manifests[0] != "go.mod" || m.Complexity != 1 || m.Cocomo == nil || m.Locomo == nil {
+		t.Errorf("unexpected svc/api %+v", m)
+	}
+	if m := got["web"]; m.Files != 3 || len(m.Languages) != 2 {
+		t.Errorf("unexpected web %+v", m)
+	}
+	if modules[len(modules)-1].Path != noModule || modules[len(modules)-1].Files != 1 {
+		t.Errorf("expected README.md last outside any module got %+v", modules[len(modules)-1])
+	}
+}
+
+func TestCountModulesOptions(t *testing.T) {
+	ProcessConstants()
+	dir := writeTree(t, map[string]string{
+		"a/go.mod":    "module a\n",
+		"a/x.go":      "package a\n\nvar x = 1\n",
+		"a/y.go":      "package a\n\nvar y = 1\n\n",
+		"b/go.mod":    "module b\n",
+		"b/x.go":      "package a\n\nvar x = 1\n",
+		"b/x_test.go": "package a\n",
+	})
+
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	opts.Uloc = true
+	opts.Tests = true
+	opts.NoCocomo = true
+	modules, err := countModules(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("countModules: %v", err)
+	}
+
+	ulocs := map[string]int{}
+	for _, m := range modules {
+		if m.Cocomo != nil || m.Locomo != nil {
+			t.Errorf("expected no estimates for %s got %+v %+v", m.Path, m.Cocomo, m.Locomo)
+		}
+		for _, l := range m.Languages {
+			ulocs[strings.TrimPrefix(strings.TrimPrefix(m.Path, dir), "/")+" "+l.Name] = l.ULOC
+		}
+	}
+	// Unique lines are counted within each module, so the lines shared
+	// between them count in both and the blank line once in each
+	if ulocs["a Go"] != 4 || ulocs["b Go"] != 3 {
+		t.Errorf("unexpected module ULOC %v", ulocs)
+	}
+	for _, m := range modules {
+		if strings.HasSuffix(m.Path, "/b") && (len(m.Languages) != 1 || m.Languages[0].Test == nil || m.Languages[0].Test.Count != 1) {
+			t.Errorf("expected the test file counted in b got %+v", m.Languages)
+		}
+	}
+}
+
+func TestRenderModules(t *testing.T) {
+	roots := map[string][]string{"svc": {"go.mod"}}
+	modules := buildModuleSummaries([]*FileJob{
+		{Location: "svc/a.go", Language: "Go", Lines: 3, Code: 3},
+		{Location: "svc/b/c.go", Language: "Go", Lines: 2, Code: 2},
+		{Location: "notes.txt", Language: "Plain Text", Lines: 1, Code: 1},
+	}, roots, Options{SortBy: "files"})
+
+	saved := Format
+	t.Cleanup(func() { Format = saved })
+
+	Format = "json"
+	out, err := renderModules(modules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc moduleJSONDoc
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc.Report != "modules" || len(doc.Modules) != 2 || doc.Modules[0].Code != 5 || doc.Modules[0].Cocomo == nil {
+		t.Errorf("unexpected json %s", out)
+	}
+
+	Format = "csv"
+	out, err = renderModules(modules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if len(records) != 5 || records[1][0] != "svc" || records[1][10] == "" || records[2][2] != "Go" {
+		t.Errorf("unexpected csv\n%s", out)
+	}
+
+	Format = "tabular"
+	out, err = renderModules(modules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "\n  go.mod\n") || !strings.Contains(out, "COCOMO") || !strings.Contains(out, noModule) {
+		t.Errorf("unexpected tabular\n%s", out)
+	}
+
+	Format = "html"
+	if _, err := renderModules(modules); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
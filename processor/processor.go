@@ -3,10 +3,10 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"regexp"
 	"runtime"
 	"runtime/debug"
@@ -14,8 +14,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-
-	"github.com/boyter/gocodewalker"
 )
 
 // Version indicates the version of the application
@@ -35,6 +33,9 @@ var Verbose = false
 // Debug enables debug logging output
 var Debug = false
 
+// ShowProgress prints the progress of long runs to stderr
+var ShowProgress = false
+
 // Trace enables trace logging output which is extremely verbose
 var Trace = false
 
@@ -65,6 +66,35 @@ var GeneratedMarkers []string
 // IgnoreGenerated ignore printing counts for generated files
 var IgnoreGenerated = false
 
+// Vendored enables reporting vendored files separately, found by path or
+// marked linguist-vendored
+var Vendored = false
+
+// VendorPatterns are additional globs marking vendored files, or files that
+// are not when they start with !, applied after the built-in conventions
+var VendorPatterns = []string{}
+
+// IgnoreVendored ignore printing counts for vendored files
+var IgnoreVendored = false
+
+// Documentation enables reporting files marked linguist-documentation separately
+var Documentation = false
+
+// IgnoreDocumentation ignore printing counts for documentation files
+var IgnoreDocumentation = false
+
+// Tests enables separating test files from production code in the output
+var Tests = false
+
+// TestPatterns are additional globs marking test files, or production code
+// when they start with !, applied after the built-in conventions
+var TestPatterns = []string{}
+
+// Embedded counts the code embedded in Markdown, HTML, Vue, Svelte and
+// heredocs with its own language, either nested under the host language or
+// attributed to the embedded language. Empty leaves it to the host.
+var Embedded = ""
+
 // Complexity toggles complexity calculation
 var Complexity = false
 
@@ -101,6 +131,9 @@ var GitIgnore = false
 // GitModuleIgnore disables .gitmodules checks
 var GitModuleIgnore = false
 
+// GitAttributes disables .gitattributes linguist overrides
+var GitAttributes = false
+
 // Ignore disables ignore file checks
 var Ignore = false
 
@@ -123,6 +156,10 @@ var IgnoreFiles = []string{}
 // DisableCheckBinary toggles checking for binary files using NUL bytes
 var DisableCheckBinary = false
 
+// DisableClassifier stops the statistical classifier picking among the
+// languages sharing an extension when heuristics and keywords cannot
+var DisableClassifier = false
+
 // UlocMode toggles checking for binary files using NUL bytes
 var UlocMode = false
 
@@ -169,8 +206,116 @@ type remapConfig struct {
 	unknown []remapRule
 }
 
+// processorContext is the state for a single counting run. The zero value,
+// as used by tests and older callers, reads the package-level flags and
+// accumulators; newProcessorContext gives a run its own.
 type processorContext struct {
-	remap remapConfig
+	remap      remapConfig
+	opts       *Options
+	settings   *countSettings
+	visited    *sync.Map
+	duplicates *CheckDuplicates
+	uloc       *ulocCounter
+	run        context.Context // cancels the run; nil never cancels
+	progress   *progressReporter
+	closers    []io.Closer    // archives held open until every file has been read
+	modules    *moduleSet     // directories holding a build manifest seen while walking
+	attributes *gitAttributes // .gitattributes read from disk, nil with --no-gitattributes
+	tests      *testRules     // tells test files from production code, nil without --tests
+	vendor     *vendorRules   // tells vendored files apart, nil without --vendor
+
+	embeddedSettings *countSettings // counts embedded code, nil without --embedded
+}
+
+// newProcessorContext returns a context with fresh duplicate, visited-path and
+// ULOC state for a run using opts, cancelled and reporting progress through
+// run. When features is nil the shared LanguageFeatures map is used for
+// counting.
+func newProcessorContext(run context.Context, opts *Options, features *featureCache) *processorContext {
+	ctx := &processorContext{
+		remap:    newRemapConfig(opts.RemapAll, opts.RemapUnknown),
+		opts:     opts,
+		settings: opts.countSettings(features),
+		visited:  &sync.Map{},
+		duplicates: &CheckDuplicates{
+			hashes: make(map[int64][][]byte),
+		},
+		uloc:     newUlocCounter(),
+		modules:  newModuleSet(),
+		run:      run,
+		progress: newProgressReporter(run),
+	}
+	if !opts.NoGitAttributes {
+		ctx.attributes = newGitAttributes(nil)
+	}
+	if opts.Tests {
+		ctx.tests = newTestRules(opts.TestPatterns)
+	}
+	if opts.Vendored {
+		ctx.vendor = newVendorRules(opts.VendorPatterns)
+	}
+	if opts.Embedded != "" {
+		// Embedded code is counted apart from the file, so it is never
+		// hashed for duplicates or checked for being minified or generated
+		settings := *ctx.settings
+		settings.duplicates, settings.uloc, settings.maxMean = false, false, false
+		settings.generated, settings.minified = false, false
+		ctx.embeddedSettings = &settings
+	}
+	return ctx
+}
+
+// cancelled reports whether the run has been cancelled, in which case the
+// remaining files are drained without being read.
+func (ctx *processorContext) cancelled() bool {
+	return ctx.run != nil && ctx.run.Err() != nil
+}
+
+// options returns the run options, falling back to a snapshot of the
+// package-level flags for a zero-value context.
+func (ctx *processorContext) options() *Options {
+	if ctx.opts != nil {
+		return ctx.opts
+	}
+	opts := optionsFromGlobals()
+	return &opts
+}
+
+func (ctx *processorContext) visitedPaths() *sync.Map {
+	if ctx.visited != nil {
+		return ctx.visited
+	}
+	return &visitedPaths
+}
+
+func (ctx *processorContext) duplicateHashes() *CheckDuplicates {
+	if ctx.duplicates != nil {
+		return ctx.duplicates
+	}
+	return &duplicates
+}
+
+func (ctx *processorContext) ulocCounts() *ulocCounter {
+	if ctx.uloc != nil {
+		return ctx.uloc
+	}
+	return ulocCounts
+}
+
+// feature returns the counting features for a language
+func (ctx *processorContext) feature(name string) LanguageFeature {
+	if ctx.settings != nil {
+		return ctx.settings.feature(name)
+	}
+	return sharedLanguageFeature(name)
+}
+
+// loadLanguageFeature makes sure the features for name are available before
+// detection needs them. Runs with their own feature cache build on demand.
+func (ctx *processorContext) loadLanguageFeature(name string) {
+	if ctx.settings == nil || ctx.settings.features == nil {
+		LoadLanguageFeature(name)
+	}
 }
 
 func parseRemapRules(value string) []remapRule {
@@ -227,10 +372,20 @@ var CountRules []CountRule
 // is parsed into a CountRule at setup. Library users may set CountRules directly.
 var CountAsPattern []string
 
+// LanguagesFile is a JSON file of extra or replacement languages in the languages.json schema
+var LanguagesFile = ""
+
+// ValidateLanguages if set true lints the language definitions, those of the given files or the built-in ones, and checks their samples
+var ValidateLanguages = false
+
+// Explain if set is a file to walk through the language detection of instead of counting
+var Explain = ""
+
 // compiledCountRule is the runtime form scanned by newFileJob
 type compiledCountRule struct {
-	re   *regexp.Regexp
-	name string
+	re      *regexp.Regexp
+	name    string
+	pattern string // as given, for --explain
 }
 
 var compiledCountRules []compiledCountRule
@@ -333,6 +488,36 @@ var NoLarge = false
 // IncludeSymLinks if set true will count symlink files
 var IncludeSymLinks = false
 
+// Rev if set counts the tree of this git revision instead of the files on disk
+var Rev = ""
+
+// DiffAgainst if set reports the change in counts from this path or git revision to the current paths
+var DiffAgainst = ""
+
+// Gates are the quality gate rules given with --gate, e.g. "max-complexity=25 language=Go"
+var Gates []string
+
+// GateFile is a file of quality gate rules, one per line
+var GateFile = ""
+
+// ByDir if set true reports the counts rolled up into the directory tree instead of by language
+var ByDir = false
+
+// ByModule if set true reports the counts of each module, found by its build manifest, with cost estimates
+var ByModule = false
+
+// ByFunction if set true reports every function found with its span, complexity and cognitive complexity
+var ByFunction = false
+
+// TopFunctions limits --by-function to this many of the most complex functions, 0 for all of them
+var TopFunctions = 0
+
+// DirDepth is how many directory levels below each path --by-dir reports, 0 for all of them
+var DirDepth = 0
+
+// Archive if set true will count the contents of every file path and of any tar, tar.gz or zip archive found while walking
+var Archive = false
+
 // LargeLineCount number of lines before being counted as a large file based on https://github.com/pinpt/ripsrc/blob/master/ripsrc/fileinfo/fileinfo.go#L44
 var LargeLineCount int64 = 40000
 
@@ -360,6 +545,19 @@ var CouplingWeighted = false
 // ByAuthor toggles the author-rollup git-history report
 var ByAuthor = false
 
+// CodeAge toggles the code age git-history report (how long ago the
+// surviving code was written, per language, directory and file)
+var CodeAge = false
+
+// ComplexityTrend toggles the complexity trend git-history report (files
+// ranked by how much their complexity grew across the window)
+var ComplexityTrend = false
+
+// ComplexityTrendFor, when non-empty, switches the complexity trend report to
+// the commit-by-commit view of the given path, following it through renames.
+// Implies ComplexityTrend.
+var ComplexityTrendFor = ""
+
 // Timeline selects an over-time view. With ByAuthor, runs the author
 // timeline report (plan 04); alone, runs the languages-over-time report
 // (plan 05). With Hotspots set, the combination errors out.
@@ -424,23 +622,8 @@ func ProcessConstants() {
 	// once per tool call) would accumulate duplicate languages for every
 	// extension. scc was historically a one-shot CLI where this ran exactly
 	// once, so it never surfaced until server mode.
-	clear(ExtensionToLanguage)
-	clear(FilenameToLanguage)
-	clear(ShebangLookup)
-
-	for name, value := range languageDatabase {
-		for _, ext := range value.Extensions {
-			ExtensionToLanguage[ext] = append(ExtensionToLanguage[ext], name)
-		}
-
-		for _, fname := range value.FileNames {
-			FilenameToLanguage[fname] = name
-		}
-
-		if len(value.SheBangs) != 0 {
-			ShebangLookup[name] = value.SheBangs
-		}
-	}
+	buildLanguageMaps()
+	applyLanguageOverlay()
 
 	// If we have anything in CountAs set it up now
 	if len(CountAs) != 0 {
@@ -507,6 +690,28 @@ func ProcessConstants() {
 	PathDenyList = fixedPath
 }
 
+// buildLanguageMaps rebuilds the extension, filename and shebang lookups from
+// the language database.
+func buildLanguageMaps() {
+	clear(ExtensionToLanguage)
+	clear(FilenameToLanguage)
+	clear(ShebangLookup)
+
+	for name, value := range languageDatabase {
+		for _, ext := range value.Extensions {
+			ExtensionToLanguage[ext] = append(ExtensionToLanguage[ext], name)
+		}
+
+		for _, fname := range value.FileNames {
+			FilenameToLanguage[fname] = name
+		}
+
+		if len(value.SheBangs) != 0 {
+			ShebangLookup[name] = value.SheBangs
+		}
+	}
+}
+
 // Configure and setup any count-as params the use has supplied
 func setupCountAs() {
 	for s := range strings.SplitSeq(CountAs, ",") {
@@ -671,7 +876,7 @@ func setupCountRules() {
 			processLanguageFeature(rule.Name, cloned)
 		}
 
-		compiledCountRules = append(compiledCountRules, compiledCountRule{re: re, name: rule.Name})
+		compiledCountRules = append(compiledCountRules, compiledCountRule{re: re, name: rule.Name, pattern: rule.Pattern})
 		printDebugF("set to count path matching %q as new language %s based on %s", rule.Pattern, rule.Name, base)
 	}
 }
@@ -705,6 +910,17 @@ func LoadLanguageFeature(loadName string) {
 }
 
 func processLanguageFeature(name string, value Language) {
+	feature := buildLanguageFeature(name, value, Complexity)
+
+	LanguageFeaturesMutex.Lock()
+	LanguageFeatures[name] = feature
+	LanguageFeaturesMutex.Unlock()
+}
+
+// buildLanguageFeature converts a Language into the tries and masks used for
+// matching. When noComplexity is set the complexity checks are left out of the
+// token trie so they are never matched while counting.
+func buildLanguageFeature(name string, value Language, noComplexity bool) LanguageFeature {
 	complexityTrie := &Trie{}
 	slCommentTrie := &Trie{}
 	mlCommentTrie := &Trie{}
@@ -722,16 +938,16 @@ func processLanguageFeature(name string, value Language) {
 	for _, v := range value.ComplexityChecks {
 		complexityMask |= v[0]
 		complexityTrie.Insert(TComplexity, []byte(v))
-		if !Complexity {
+		if !noComplexity {
 			tokenTrie.Insert(TComplexity, []byte(v))
 		}
 	}
-	if !Complexity {
+	if !noComplexity {
 		processMask |= complexityMask
 	}
 
 	for _, v := range value.ComplexityChecksPostfix {
-		if !Complexity {
+		if !noComplexity {
 			tokenTrie.Insert(TComplexityPostfix, []byte(v))
 			processMask |= v[0]
 		}
@@ -786,8 +1002,7 @@ func processLanguageFeature(name string, value Language) {
 		heuristics = append(heuristics, CompiledHeuristic{Re: re, Literals: literals, Anchored: v.Anchored})
 	}
 
-	LanguageFeaturesMutex.Lock()
-	LanguageFeatures[name] = LanguageFeature{
+	return LanguageFeature{
 		Complexity:            complexityTrie,
 		MultiLineComments:     mlCommentTrie,
 		MultiLine:             value.MultiLine,
@@ -807,7 +1022,6 @@ func processLanguageFeature(name string, value Language) {
 		Heuristics:            heuristics,
 		Quotes:                value.Quotes,
 	}
-	LanguageFeaturesMutex.Unlock()
 }
 
 func processFlags() {
@@ -837,6 +1051,18 @@ func processFlags() {
 		Generated = true
 	}
 
+	if IgnoreVendored || len(VendorPatterns) != 0 {
+		Vendored = true
+	}
+
+	if len(TestPatterns) != 0 {
+		Tests = true
+	}
+
+	if IgnoreDocumentation {
+		Documentation = true
+	}
+
 	if Dryness {
 		UlocMode = true
 	}
@@ -875,7 +1101,23 @@ func processFlags() {
 	printDebugF("Locomo: %t", Locomo)
 	printDebugF("Minified/Generated Detection: %t/%t", Minified, Generated)
 	printDebugF("Ignore Minified/Generated: %t/%t", IgnoreMinified, IgnoreGenerated)
+	printDebugF("Vendored/Documentation: %t/%t", Vendored, Documentation)
+	printDebugF("Ignore Vendored/Documentation: %t/%t", IgnoreVendored, IgnoreDocumentation)
+	printDebugF("VendorPatterns: %v", VendorPatterns)
+	printDebugF("Tests: %t TestPatterns: %v", Tests, TestPatterns)
+	printDebugF("Embedded: %s", Embedded)
+	printDebugF("GitAttributes: %t", !GitAttributes)
+	printDebugF("Classifier: %t", !DisableClassifier)
 	printDebugF("IncludeSymLinks: %t", IncludeSymLinks)
+	printDebugF("Archive: %t", Archive)
+	printDebugF("Rev: %s", Rev)
+	printDebugF("DiffAgainst: %s", DiffAgainst)
+	printDebugF("Gates: %v GateFile: %s", Gates, GateFile)
+	printDebugF("ByDir: %t DirDepth: %d", ByDir, DirDepth)
+	printDebugF("ByModule: %t", ByModule)
+	printDebugF("LanguagesFile: %s", LanguagesFile)
+	printDebugF("Explain: %s", Explain)
+	printDebugF("ByFunction: %t TopFunctions: %d", ByFunction, TopFunctions)
 	printDebugF("Uloc: %t", UlocMode)
 	printDebugF("Dryness: %t", Dryness)
 }
@@ -901,13 +1143,98 @@ func PrintLanguages(dst io.Writer) {
 	}
 }
 
-// global variables to deal with ULOC calculations
-var ulocMutex = sync.Mutex{}
-var ulocGlobalCount = map[string]struct{}{}
-var ulocLanguageCount = map[string]map[string]struct{}{}
+// ulocCounter collects the unique lines seen during a run, both across the
+// whole run and per language
+type ulocCounter struct {
+	mu       sync.Mutex
+	global   map[string]struct{}
+	language map[string]map[string]struct{}
+}
+
+func newUlocCounter() *ulocCounter {
+	return &ulocCounter{
+		global:   map[string]struct{}{},
+		language: map[string]map[string]struct{}{},
+	}
+}
+
+// add records every line of content against the run and the language
+func (u *ulocCounter) add(language string, content []byte) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for l := range strings.SplitSeq(strings.TrimRight(string(content), "\n"), "\n") {
+		u.global[l] = struct{}{}
+
+		_, ok := u.language[language]
+		if !ok {
+			u.language[language] = map[string]struct{}{}
+		}
+		u.language[language][l] = struct{}{}
+	}
+}
+
+// languageCount returns the number of unique lines seen for a language
+func (u *ulocCounter) languageCount(language string) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.language[language])
+}
+
+// globalCount returns the number of unique lines seen across every language
+func (u *ulocCounter) globalCount() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.global)
+}
+
+// ulocCounts holds the ULOC results of the last CLI run, read by the formatters
+var ulocCounts = newUlocCounter()
+
+// countReportModes lists the flags set that pick a report other than the
+// usual counts, ignoring the history reports which are checked apart
+func countReportModes() []string {
+	var modes []string
+	add := func(set bool, flag string) {
+		if set {
+			modes = append(modes, flag)
+		}
+	}
+	add(len(Gates) != 0 || GateFile != "", "--gate/--gate-file")
+	add(DiffAgainst != "", "--diff-against")
+	add(ByDir, "--by-dir")
+	add(ByModule, "--by-module")
+	add(ByFunction || TopFunctions > 0, "--by-function/--top-functions")
+	return modes
+}
 
 // Process is the main entry point of the command line it sets everything up and starts running
 func Process() {
+	// The files to check are the arguments, so this runs before they are
+	// treated as paths or any of them is loaded
+	if ValidateLanguages {
+		files := slices.Clone(DirFilePaths)
+		if LanguagesFile != "" {
+			files = append(files, LanguagesFile)
+		}
+		passed, err := runValidateLanguagesReport(files)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if !passed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if LanguagesFile != "" {
+		if err := LoadLanguagesFile(LanguagesFile); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
 	if Languages {
 		PrintLanguages(os.Stdout)
 		return
@@ -917,11 +1244,24 @@ func Process() {
 	processFlags()
 	cleanVisitedPaths()
 
+	if Embedded != "" && Embedded != embeddedNested && Embedded != embeddedLanguage {
+		fmt.Printf("unknown --embedded mode %q, expected %s or %s\n", Embedded, embeddedNested, embeddedLanguage)
+		os.Exit(1)
+	}
+
 	// Clean up any invalid arguments before setting everything up
 	if len(DirFilePaths) == 0 {
 		DirFilePaths = append(DirFilePaths, ".")
 	}
 
+	if Explain != "" {
+		if err := runExplainReport(Explain); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// --report mode short-circuits the normal format dispatch and writes a
 	// self-contained HTML report. Mutually exclusive with --format / -f: if
 	// the user passed both, warn on stderr and let --report win.
@@ -933,7 +1273,7 @@ func Process() {
 		if len(DirFilePaths) > 1 {
 			fmt.Fprintf(os.Stderr, "warning: --report only analyses the first positional path (%s); other paths ignored\n", DirFilePaths[0])
 		}
-		if err := runReport(DirFilePaths); err != nil {
+		if err := runReport(cliContext(), DirFilePaths); err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
@@ -957,7 +1297,23 @@ func Process() {
 		os.Exit(1)
 	}
 
-	if Hotspots || Coupling || ByAuthor || Timeline {
+	// Code age is a standalone report too
+	if CodeAge && (Hotspots || Coupling || ByAuthor || Timeline) {
+		fmt.Println("--code-age is mutually exclusive with --hotspots / --coupling / --by-author / --timeline; pick one report")
+		os.Exit(1)
+	}
+
+	// --complexity-trend-for implies the complexity trend report, which is
+	// standalone as well
+	if ComplexityTrendFor != "" {
+		ComplexityTrend = true
+	}
+	if ComplexityTrend && (Hotspots || Coupling || ByAuthor || Timeline || CodeAge) {
+		fmt.Println("--complexity-trend/--complexity-trend-for is mutually exclusive with --hotspots / --coupling / --by-author / --timeline / --code-age; pick one report")
+		os.Exit(1)
+	}
+
+	if Hotspots || Coupling || ByAuthor || Timeline || CodeAge || ComplexityTrend {
 		if err := validateHistoryFlags(os.Stderr); err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -965,7 +1321,7 @@ func Process() {
 	}
 
 	if Hotspots {
-		if err := runHotspotsReport(DirFilePaths[0]); err != nil {
+		if err := runHotspotsReport(cliContext(), DirFilePaths[0]); err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
@@ -973,7 +1329,23 @@ func Process() {
 	}
 
 	if Coupling {
-		if err := runCouplingReport(DirFilePaths[0]); err != nil {
+		if err := runCouplingReport(cliContext(), DirFilePaths[0]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if CodeAge {
+		if err := runCodeAgeReport(cliContext(), DirFilePaths[0]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if ComplexityTrend {
+		if err := runComplexityTrendReport(cliContext(), DirFilePaths[0]); err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
@@ -981,7 +1353,7 @@ func Process() {
 	}
 
 	if ByAuthor && Timeline {
-		if err := runAuthorTimelineReport(DirFilePaths[0]); err != nil {
+		if err := runAuthorTimelineReport(cliContext(), DirFilePaths[0]); err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
@@ -989,7 +1361,7 @@ func Process() {
 	}
 
 	if ByAuthor {
-		if err := runAuthorsReport(DirFilePaths[0]); err != nil {
+		if err := runAuthorsReport(cliContext(), DirFilePaths[0]); err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
@@ -997,121 +1369,73 @@ func Process() {
 	}
 
 	if Timeline {
-		if err := runLanguagesTimelineReport(DirFilePaths[0]); err != nil {
+		if err := runLanguagesTimelineReport(cliContext(), DirFilePaths[0]); err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
 		return
 	}
 
-	filePaths := []string{}
-	dirPaths := []string{}
-
-	// Check if the paths or files added exist and exit if not
-	for _, f := range DirFilePaths {
-		fpath := filepath.Clean(f)
+	// Each of these replaces the usual output, so only one can be asked for
+	if modes := countReportModes(); len(modes) > 1 {
+		fmt.Printf("%s are mutually exclusive; pick one report\n", strings.Join(modes, " / "))
+		os.Exit(1)
+	}
 
-		s, err := os.Stat(fpath)
+	if len(Gates) != 0 || GateFile != "" {
+		passed, err := runGateReport(cliContext())
 		if err != nil {
-			fmt.Println("file or directory could not be read: " + fpath)
+			fmt.Println(err)
 			os.Exit(1)
 		}
-
-		if s.IsDir() {
-			dirPaths = append(dirPaths, fpath)
-		} else {
-			filePaths = append(filePaths, fpath)
+		if !passed {
+			os.Exit(1)
 		}
+		return
 	}
 
-	SortBy = strings.ToLower(SortBy)
-	ctx := processorContext{remap: newRemapConfig(RemapAll, RemapUnknown)}
-
-	printDebugF("NumCPU: %d", runtime.NumCPU())
-	printDebugF("SortBy: %s", SortBy)
-	printDebugF("PathDenyList: %v", PathDenyList)
-
-	potentialFilesQueue := make(chan *gocodewalker.File, FileListQueueSize) // files that pass the .gitignore checks
-	fileListQueue := make(chan *FileJob, FileListQueueSize)                 // Files ready to be read from disk
-	fileSummaryJobQueue := make(chan *FileJob, FileSummaryJobQueueSize)     // Files ready to be summarised
-
-	fileWalker := gocodewalker.NewParallelFileWalker(dirPaths, potentialFilesQueue)
-	fileWalker.SetErrorHandler(func(e error) bool {
-		printError(e.Error())
-		return true
-	})
-	fileWalker.IgnoreGitIgnore = GitIgnore
-	fileWalker.IgnoreIgnoreFile = Ignore
-	fileWalker.IgnoreGitModules = GitModuleIgnore
-	fileWalker.IncludeHidden = true
-	fileWalker.ExcludeDirectory = PathDenyList
-	fileWalker.SetConcurrency(DirectoryWalkerJobWorkers)
-
-	if !SccIgnore {
-		fileWalker.CustomIgnore = []string{".sccignore"}
-	}
-	fileWalker.CustomIgnoreFiles = IgnoreFiles
-
-	var excludePathRegexes []*regexp.Regexp
-	for _, exclude := range Exclude {
-		regexpResult, err := regexp.Compile(exclude)
-		if err == nil {
-			fileWalker.ExcludeFilenameRegex = append(fileWalker.ExcludeFilenameRegex, regexpResult)
-			fileWalker.ExcludeDirectoryRegex = append(fileWalker.ExcludeDirectoryRegex, regexpResult)
-			excludePathRegexes = append(excludePathRegexes, regexpResult)
-		} else {
-			printError(err.Error())
+	if DiffAgainst != "" {
+		if err := runDiffReport(cliContext()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
+		return
 	}
 
-	go func() {
-		err := fileWalker.Start()
-		if err != nil {
-			printError(err.Error())
+	if ByDir {
+		if err := runByDirReport(cliContext()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
-	}()
-
-	go func() {
-		for _, f := range filePaths {
-			fileInfo, err := os.Lstat(f)
-			if err != nil {
-				continue
-			}
+		return
+	}
 
-			fileJob := newFileJob(f, f, fileInfo)
-			if fileJob != nil {
-				fileListQueue <- fileJob
-			}
+	if ByModule {
+		if err := runByModuleReport(cliContext()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
+		return
+	}
 
-		for fi := range potentialFilesQueue {
-			shouldExclude := false
-			for _, re := range excludePathRegexes {
-				if re.MatchString(fi.Location) {
-					shouldExclude = true
-					break
-				}
-			}
-			if shouldExclude {
-				continue
-			}
+	if ByFunction || TopFunctions > 0 {
+		if err := runByFunctionReport(cliContext()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
 
-			fileInfo, err := os.Lstat(fi.Location)
-			if err != nil {
-				continue
-			}
+	SortBy = strings.ToLower(SortBy)
 
-			if !fileInfo.IsDir() {
-				fileJob := newFileJob(fi.Location, fi.Filename, fileInfo)
-				if fileJob != nil {
-					fileListQueue <- fileJob
-				}
-			}
-		}
-		close(fileListQueue)
-	}()
+	ctx, fileSummaryJobQueue, err := NewAnalyzer(optionsFromGlobals()).start(cliContext())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-	go ctx.fileProcessorWorker(fileListQueue, fileSummaryJobQueue)
+	// The formatters read the ULOC results from the package level
+	ulocCounts = ctx.uloc
 
 	result := fileSummarize(fileSummaryJobQueue)
 	if FileOutput == "" {
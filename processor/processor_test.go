@@ -102,6 +102,18 @@ func TestProcessFlags(t *testing.T) {
 	}
 }
 
+func TestCountReportModes(t *testing.T) {
+	if modes := countReportModes(); len(modes) != 0 {
+		t.Fatalf("expected no report modes by default got %v", modes)
+	}
+
+	ByDir, TopFunctions = true, 5
+	defer func() { ByDir, TopFunctions = false, 0 }()
+	if modes := strings.Join(countReportModes(), " / "); modes != "--by-dir / --by-function/--top-functions" {
+		t.Errorf("expected both modes to be reported got %q", modes)
+	}
+}
+
 func TestPrintLanguages(t *testing.T) {
 	result := &strings.Builder{}
 	PrintLanguages(result)
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Progress reports how far a long-running count or history walk has got.
+// Counters that do not apply to the run stay zero.
+type Progress struct {
+	FilesWalked    int64 // files found by the walker and queued for counting
+	FilesProcessed int64 // files read and counted
+	CommitsDiffed  int64 // commits whose diff has been handed to the report
+	CommitsTotal   int64 // commits in the history window, known once the log has been read
+	Done           bool  // set on the last update of a run
+}
+
+// ProgressFunc receives progress updates. Calls for one run never overlap
+// and are throttled to one per progressInterval, except the final update
+// which is always delivered.
+type ProgressFunc func(Progress)
+
+type progressKey struct{}
+
+// progressInterval is the minimum time between two updates of a run
+var progressInterval = 100 * time.Millisecond
+
+// WithProgress returns a copy of ctx that reports the progress of any
+// Analyzer run, ProcessResult or history report started with it to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progressReporter accumulates the counters of one run and forwards them to
+// the ProgressFunc attached to its context. A nil reporter ignores every call
+// so the pipeline need not check whether progress was asked for.
+type progressReporter struct {
+	fn    ProgressFunc
+	mu    sync.Mutex
+	state Progress
+	last  time.Time
+}
+
+func newProgressReporter(ctx context.Context) *progressReporter {
+	fn, ok := ctx.Value(progressKey{}).(ProgressFunc)
+	if !ok || fn == nil {
+		return nil
+	}
+	return &progressReporter{fn: fn}
+}
+
+func (p *progressReporter) update(change func(*Progress)) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	change(&p.state)
+	if now := time.Now(); p.state.Done || now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.fn(p.state)
+	}
+}
+
+func (p *progressReporter) fileWalked() {
+	p.update(func(s *Progress) { s.FilesWalked++ })
+}
+
+func (p *progressReporter) fileProcessed() {
+	p.update(func(s *Progress) { s.FilesProcessed++ })
+}
+
+func (p *progressReporter) commits(diffed, total int) {
+	p.update(func(s *Progress) {
+		s.CommitsDiffed = int64(diffed)
+		s.CommitsTotal = int64(total)
+	})
+}
+
+func (p *progressReporter) done() {
+	p.update(func(s *Progress) { s.Done = true })
+}
+
+// cliContext returns the context the CLI runs with, printing progress to
+// stderr when --progress is set.
+func cliContext() context.Context {
+	ctx := context.Background()
+	if !ShowProgress {
+		return ctx
+	}
+	return WithProgress(ctx, progressPrinter(os.Stderr))
+}
+
+// progressPrinter renders progress as a single line that is rewritten in
+// place and terminated once the run is done.
+func progressPrinter(w io.Writer) ProgressFunc {
+	return func(p Progress) {
+		if p.CommitsTotal != 0 {
+			_, _ = fmt.Fprintf(w, "\rcommits diffed %d/%d", p.CommitsDiffed, p.CommitsTotal)
+		} else {
+			_, _ = fmt.Fprintf(w, "\rfiles processed %d/%d", p.FilesProcessed, p.FilesWalked)
+		}
+		if p.Done {
+			_, _ = fmt.Fprintln(w)
+		}
+	}
+}
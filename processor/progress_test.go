@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// collectProgress returns a context recording every update along with a
+// function returning what was recorded so far.
+func collectProgress() (context.Context, func() []Progress) {
+	var mu sync.Mutex
+	var updates []Progress
+	ctx := WithProgress(context.Background(), func(p Progress) {
+		mu.Lock()
+		updates = append(updates, p)
+		mu.Unlock()
+	})
+	return ctx, func() []Progress {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]Progress(nil), updates...)
+	}
+}
+
+func TestAnalyzerReportsProgress(t *testing.T) {
+	dir := writeAnalyzerFixture(t)
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+
+	ctx, updates := collectProgress()
+	if _, err := NewAnalyzer(opts).Languages(ctx); err != nil {
+		t.Fatalf("Languages: %v", err)
+	}
+
+	got := updates()
+	if len(got) == 0 {
+		t.Fatal("expected progress updates")
+	}
+	last := got[len(got)-1]
+	if !last.Done || last.FilesWalked != 4 || last.FilesProcessed != 4 {
+		t.Errorf("expected final update with 4 files walked and processed got %+v", last)
+	}
+}
+
+func TestAnalyzerCancelled(t *testing.T) {
+	dir := writeAnalyzerFixture(t)
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := NewAnalyzer(opts).Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled got %v", err)
+	}
+}
+
+func TestRunHistoryReportsProgress(t *testing.T) {
+	saveDepth := HistoryDepth
+	HistoryDepth = 100
+	t.Cleanup(func() { HistoryDepth = saveDepth })
+
+	dir := makeFixtureRepo(t, []map[string]string{
+		{"a.go": "package a\n"},
+		{"a.go": "package a\n\nfunc A() {}\n"},
+		{"a.go": "package a\n\nfunc A() {}\nfunc B() {}\n"},
+	})
+
+	ctx, updates := collectProgress()
+	if _, err := runHistory(ctx, dir, &captureObserver{}); err != nil {
+		t.Fatalf("runHistory: %v", err)
+	}
+
+	got := updates()
+	if len(got) == 0 {
+		t.Fatal("expected progress updates")
+	}
+	last := got[len(got)-1]
+	if !last.Done || last.CommitsDiffed != 3 || last.CommitsTotal != 3 {
+		t.Errorf("expected final update with 3/3 commits got %+v", last)
+	}
+}
+
+func TestRunHistoryCancelled(t *testing.T) {
+	dir := makeFixtureRepo(t, []map[string]string{
+		{"a.go": "package a\n"},
+		{"a.go": "package a\n\nfunc A() {}\n"},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	observer := &captureObserver{}
+	_, err := runHistory(ctx, dir, observer)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled got %v", err)
+	}
+	if len(observer.commits) != 0 {
+		t.Errorf("expected no commits observed got %d", len(observer.commits))
+	}
+}
+
+func TestProgressPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	printer := progressPrinter(&buf)
+
+	printer(Progress{FilesWalked: 10, FilesProcessed: 4})
+	printer(Progress{CommitsDiffed: 3, CommitsTotal: 3, Done: true})
+
+	expected := "\rfiles processed 4/10\rcommits diffed 3/3\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q got %q", expected, buf.String())
+	}
+}
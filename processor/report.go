@@ -3,18 +3,14 @@
 package processor
 
 import (
-	"fmt"
+	"context"
 	"html/template"
-	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/boyter/gocodewalker"
 	"github.com/go-git/go-git/v5"
 )
 
@@ -53,6 +49,7 @@ var reportSkipRecognised = map[string]bool{
 	"hotspots":   true,
 	"coupling":   true,
 	"authors":    true,
+	"age":        true,
 	"timeline":   true,
 	"files":      true,
 	"uloc":       true,
@@ -81,6 +78,8 @@ type Totals struct {
 	Blank      int64
 	Complexity int64
 	Bytes      int64
+	Production *CodeSplit // counts of the production code, set when the run separates tests
+	Test       *CodeSplit // counts of the test code, set when the run separates tests
 }
 
 // ULOCResult is the unique-lines-of-code rollup. Maps are converted to a
@@ -145,6 +144,7 @@ type HotspotRow struct {
 	CodeChurn    int64
 	CommentChurn int64
 	Score        float64
+	Test         bool
 }
 
 // CouplingResult mirrors the all-pairs change-coupling data. Pairs is already
@@ -193,6 +193,30 @@ type AuthorRow struct {
 	Sentinel        bool
 }
 
+// CodeAgeResult mirrors the data the code age tabular formatter consumes.
+// Languages are sorted by code, Directories and Files oldest first.
+type CodeAgeResult struct {
+	Window      HistoryWindow
+	AsOf        time.Time
+	Total       CodeAgeRow
+	Languages   []CodeAgeRow
+	Directories []CodeAgeRow
+	Files       []CodeAgeRow
+}
+
+// CodeAgeRow is one row of the code age tables. Mirrors codeAgeRow but
+// public for template consumers.
+type CodeAgeRow struct {
+	Name                string
+	Language            string
+	Code                int64
+	MedianDays          float64
+	Older1Percent       float64
+	Older2Percent       float64
+	Older5Percent       float64
+	BeforeWindowPercent float64
+}
+
 // LangTimelineResult mirrors the language-timeline observer output.
 type LangTimelineResult struct {
 	Window  HistoryWindow
@@ -255,6 +279,7 @@ type ReportData struct {
 	Hotspots         *HotspotsResult
 	Coupling         *CouplingResult
 	Authors          *AuthorsResult
+	CodeAge          *CodeAgeResult
 	LanguageTimeline *LangTimelineResult
 	AuthorTimeline   *AuthorTimelineResult
 	Files            []*FileJob
@@ -271,30 +296,21 @@ type ReportData struct {
 // reportFlagState snapshots the package-level flag vars CollectReportData
 // flips on entry so they can be restored on exit.
 //
-// scc's analysis modes (ULOC, line-length, per-file table) are gated by
-// process-wide globals. The report mode flips them on inside a single
-// invocation; we snapshot and restore via defer so panics, errors, or
-// in-process re-entrancy don't leak state into a later scc call.
+// The counting modes the report needs (ULOC, line-length, per-file table)
+// are passed to the walk as Analyzer options, but the history observers still
+// read CouplingWeighted. We snapshot and restore via defer so panics, errors,
+// or in-process re-entrancy don't leak state into a later scc call.
 type reportFlagState struct {
-	UlocMode         bool
-	MaxMean          bool
-	Files            bool
 	CouplingWeighted bool
 }
 
 func saveReportFlags() reportFlagState {
 	return reportFlagState{
-		UlocMode:         UlocMode,
-		MaxMean:          MaxMean,
-		Files:            Files,
 		CouplingWeighted: CouplingWeighted,
 	}
 }
 
 func (s reportFlagState) restore() {
-	UlocMode = s.UlocMode
-	MaxMean = s.MaxMean
-	Files = s.Files
 	CouplingWeighted = s.CouplingWeighted
 }
 
@@ -303,33 +319,22 @@ func (s reportFlagState) restore() {
 // observers (when git is available), computes cost estimates, and returns a
 // ReportData ready for HTML templating.
 //
-// IMPORTANT: this function mutates the package-level analysis flags
-// (UlocMode, MaxMean, Files) while it runs. The previous values are
-// snapshotted and restored via defer, but callers should not assume the
-// flags retain their on-entry values during the call.
+// IMPORTANT: this function clears the package-level CouplingWeighted flag
+// while it runs. The previous value is snapshotted and restored via defer,
+// but callers should not assume the flag retains its on-entry value during
+// the call.
 func CollectReportData(path string) (ReportData, error) {
+	return collectReportData(context.Background(), path)
+}
+
+// collectReportData is CollectReportData stopping early when ctx is
+// cancelled and reporting the progress of each walk to ctx.
+func collectReportData(ctx context.Context, path string) (ReportData, error) {
 	start := time.Now()
 
 	saved := saveReportFlags()
 	defer saved.restore()
 
-	if !ReportSkipped("uloc") {
-		UlocMode = true
-	}
-	if !ReportSkipped("linelength") {
-		MaxMean = true
-	}
-	if !ReportSkipped("files") {
-		Files = true
-	}
-
-	// Reset the package-level ULOC accumulators so repeated in-process
-	// invocations don't see stale data from an earlier walk.
-	ulocMutex.Lock()
-	ulocGlobalCount = map[string]struct{}{}
-	ulocLanguageCount = map[string]map[string]struct{}{}
-	ulocMutex.Unlock()
-
 	gitAvailable := detectGit(path)
 
 	data := ReportData{
@@ -339,7 +344,7 @@ func CollectReportData(path string) (ReportData, error) {
 		RepoName:     detectRepoName(path),
 	}
 
-	files, summary, totals, err := walkAndAggregate(path)
+	files, summary, totals, uloc, err := walkAndAggregate(ctx, path)
 	if err != nil {
 		return ReportData{}, err
 	}
@@ -348,7 +353,7 @@ func CollectReportData(path string) (ReportData, error) {
 	data.Totals = totals
 
 	if !ReportSkipped("uloc") {
-		data.ULOC = snapshotULOC(totals.Lines)
+		data.ULOC = snapshotULOC(uloc, totals.Lines)
 	}
 
 	if !ReportSkipped("linelength") {
@@ -362,7 +367,7 @@ func CollectReportData(path string) (ReportData, error) {
 	if gitAvailable {
 		if !ReportSkipped("hotspots") {
 			obs := newHotspotsObserver()
-			if window, err := runHistory(path, obs); err == nil {
+			if window, err := runHistory(ctx, path, obs); err == nil {
 				data.Hotspots = hotspotsResultFromObserver(obs, window)
 			} else {
 				printWarnF("report: hotspots observer failed: %s", err)
@@ -370,7 +375,7 @@ func CollectReportData(path string) (ReportData, error) {
 		}
 		if !ReportSkipped("coupling") {
 			obs := newCouplingObserver()
-			if window, err := runHistory(path, obs); err == nil {
+			if window, err := runHistory(ctx, path, obs); err == nil {
 				data.Coupling = couplingResultFromObserver(obs, window)
 			} else {
 				printWarnF("report: coupling observer failed: %s", err)
@@ -378,21 +383,29 @@ func CollectReportData(path string) (ReportData, error) {
 		}
 		if !ReportSkipped("authors") {
 			obs := newHistoryAuthorsObserver()
-			if window, err := runHistory(path, obs); err == nil {
+			if window, err := runHistory(ctx, path, obs); err == nil {
 				data.Authors = authorsResultFromObserver(obs, window)
 			} else {
 				printWarnF("report: authors observer failed: %s", err)
 			}
 		}
+		if !ReportSkipped("age") {
+			obs := newHistoryCodeAgeObserver()
+			if window, err := runHistory(ctx, path, obs); err == nil {
+				data.CodeAge = codeAgeResultFromObserver(obs, window)
+			} else {
+				printWarnF("report: code age observer failed: %s", err)
+			}
+		}
 		if !ReportSkipped("timeline") {
 			lObs := newHistoryLanguagesObserver(HistoryBuckets)
-			if window, err := runHistory(path, lObs); err == nil {
+			if window, err := runHistory(ctx, path, lObs); err == nil {
 				data.LanguageTimeline = languageTimelineResultFromObserver(lObs, window)
 			} else {
 				printWarnF("report: language timeline observer failed: %s", err)
 			}
 			aObs := newHistoryAuthorTimelineObserver(HistoryBuckets)
-			if window, err := runHistory(path, aObs); err == nil {
+			if window, err := runHistory(ctx, path, aObs); err == nil {
 				data.AuthorTimeline = authorTimelineResultFromObserver(aObs, window)
 			} else {
 				printWarnF("report: author timeline observer failed: %s", err)
@@ -470,124 +483,39 @@ func remoteOriginName(path string) string {
 	return url
 }
 
-// walkAndAggregate runs scc's standard file walker against path, drains the
-// resulting FileJob channel once, and tees the results into the language
-// rollup and a flat per-file slice. Reuses aggregateLanguageSummary by
-// feeding it the same FileJobs through a buffered channel.
-func walkAndAggregate(path string) ([]*FileJob, []LanguageSummary, Totals, error) {
+// walkAndAggregate runs scc's standard file walker against path through an
+// Analyzer configured from the package flags, with ULOC, line-length and
+// per-file collection switched on unless the report skips them. Returns the
+// flat per-file slice, the language rollup, the totals and the run's ULOC
+// counts.
+func walkAndAggregate(ctx context.Context, path string) ([]*FileJob, []LanguageSummary, Totals, *ulocCounter, error) {
 	if path == "" {
 		path = "."
 	}
 
-	fpath := filepath.Clean(path)
-	info, err := os.Stat(fpath)
-	if err != nil {
-		return nil, nil, Totals{}, fmt.Errorf("file or directory could not be read: %s", fpath)
-	}
-
-	dirPaths := []string{}
-	filePaths := []string{}
-	if info.IsDir() {
-		dirPaths = append(dirPaths, fpath)
-	} else {
-		filePaths = append(filePaths, fpath)
-	}
-
-	ctx := processorContext{remap: newRemapConfig(RemapAll, RemapUnknown)}
-
-	potentialFilesQueue := make(chan *gocodewalker.File, FileListQueueSize)
-	fileListQueue := make(chan *FileJob, FileListQueueSize)
-	fileSummaryJobQueue := make(chan *FileJob, FileSummaryJobQueueSize)
+	opts := optionsFromGlobals()
+	opts.Paths = []string{path}
+	opts.Uloc = opts.Uloc || !ReportSkipped("uloc")
+	opts.MaxMean = opts.MaxMean || !ReportSkipped("linelength")
+	opts.ByFile = opts.ByFile || !ReportSkipped("files")
 
-	if len(dirPaths) > 0 {
-		fileWalker := gocodewalker.NewParallelFileWalker(dirPaths, potentialFilesQueue)
-		fileWalker.SetErrorHandler(func(e error) bool {
-			printError(e.Error())
-			return true
-		})
-		fileWalker.IgnoreGitIgnore = GitIgnore
-		fileWalker.IgnoreIgnoreFile = Ignore
-		fileWalker.IgnoreGitModules = GitModuleIgnore
-		fileWalker.IncludeHidden = true
-		fileWalker.ExcludeDirectory = PathDenyList
-		fileWalker.SetConcurrency(DirectoryWalkerJobWorkers)
-		if !SccIgnore {
-			fileWalker.CustomIgnore = []string{".sccignore"}
-		}
-		fileWalker.CustomIgnoreFiles = IgnoreFiles
-
-		var excludePathRegexes []*regexp.Regexp
-		for _, exclude := range Exclude {
-			re, err := regexp.Compile(exclude)
-			if err == nil {
-				fileWalker.ExcludeFilenameRegex = append(fileWalker.ExcludeFilenameRegex, re)
-				fileWalker.ExcludeDirectoryRegex = append(fileWalker.ExcludeDirectoryRegex, re)
-				excludePathRegexes = append(excludePathRegexes, re)
-			} else {
-				printError(err.Error())
-			}
-		}
-
-		go func() {
-			if err := fileWalker.Start(); err != nil {
-				printError(err.Error())
-			}
-		}()
-
-		go func() {
-			for fi := range potentialFilesQueue {
-				shouldExclude := false
-				for _, re := range excludePathRegexes {
-					if re.MatchString(fi.Location) {
-						shouldExclude = true
-						break
-					}
-				}
-				if shouldExclude {
-					continue
-				}
-				fileInfo, err := os.Lstat(fi.Location)
-				if err != nil {
-					continue
-				}
-				if !fileInfo.IsDir() {
-					if job := newFileJob(fi.Location, fi.Filename, fileInfo); job != nil {
-						fileListQueue <- job
-					}
-				}
-			}
-			close(fileListQueue)
-		}()
-	} else {
-		go func() {
-			for _, f := range filePaths {
-				fileInfo, err := os.Lstat(f)
-				if err != nil {
-					continue
-				}
-				if job := newFileJob(f, f, fileInfo); job != nil {
-					fileListQueue <- job
-				}
-			}
-			close(fileListQueue)
-		}()
+	analyzer := NewAnalyzer(opts)
+	run, output, err := analyzer.start(ctx)
+	if err != nil {
+		return nil, nil, Totals{}, nil, err
 	}
 
-	go ctx.fileProcessorWorker(fileListQueue, fileSummaryJobQueue)
-
 	// Tee: as each FileJob arrives, append to the flat slice and forward to
-	// a buffered channel that aggregateLanguageSummary drains. We forward
+	// a buffered channel that aggregateLanguageSummaryFor drains. We forward
 	// synchronously so totals/files always see the same set.
-	aggregateInput := make(chan *FileJob, FileSummaryJobQueueSize)
+	aggregateInput := make(chan *FileJob, analyzer.opts.FileSummaryJobQueueSize)
 	var (
 		files  []*FileJob
 		totals Totals
-		mu     sync.Mutex
 	)
 
 	go func() {
-		for job := range fileSummaryJobQueue {
-			mu.Lock()
+		for job := range output {
 			files = append(files, job)
 			totals.Files++
 			totals.Lines += job.Lines
@@ -596,14 +524,26 @@ func walkAndAggregate(path string) ([]*FileJob, []LanguageSummary, Totals, error
 			totals.Blank += job.Blank
 			totals.Complexity += job.Complexity
 			totals.Bytes += job.Bytes
-			mu.Unlock()
+			if analyzer.opts.Tests {
+				if totals.Production == nil {
+					totals.Production, totals.Test = &CodeSplit{}, &CodeSplit{}
+				}
+				if job.Test {
+					totals.Test.add(job)
+				} else {
+					totals.Production.add(job)
+				}
+			}
 			aggregateInput <- job
 		}
 		close(aggregateInput)
 	}()
 
-	summary := aggregateLanguageSummary(aggregateInput)
-	summary = sortLanguageSummary(summary)
+	summary := aggregateLanguageSummaryFor(aggregateInput, run.uloc, analyzer.opts.ByFile, analyzer.opts.Tests, analyzer.opts.Embedded)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, Totals{}, nil, err
+	}
+	summary = sortLanguageSummaryBy(summary, analyzer.opts.SortBy)
 
 	// Ensure deterministic ordering of the flat Files slice — the worker
 	// pool can interleave file emissions.
@@ -611,26 +551,26 @@ func walkAndAggregate(path string) ([]*FileJob, []LanguageSummary, Totals, error
 		return files[i].Location < files[j].Location
 	})
 
-	return files, summary, totals, nil
+	return files, summary, totals, run.uloc, nil
 }
 
-// snapshotULOC converts the package-level ULOC maps into a sorted slice so
-// the template can range deterministically. totalLines drives the DRYness
+// snapshotULOC converts a run's ULOC maps into a sorted slice so the
+// template can range deterministically. totalLines drives the DRYness
 // number — unique lines / total lines, capped at 1.0.
-func snapshotULOC(totalLines int64) *ULOCResult {
-	ulocMutex.Lock()
-	defer ulocMutex.Unlock()
+func snapshotULOC(uloc *ulocCounter, totalLines int64) *ULOCResult {
+	uloc.mu.Lock()
+	defer uloc.mu.Unlock()
 
 	res := &ULOCResult{
-		Global:     len(ulocGlobalCount),
+		Global:     len(uloc.global),
 		TotalLines: totalLines,
 	}
 	if totalLines > 0 {
 		res.Dryness = float64(res.Global) / float64(totalLines)
 	}
 
-	res.PerLanguage = make([]ULOCLanguage, 0, len(ulocLanguageCount))
-	for lang, set := range ulocLanguageCount {
+	res.PerLanguage = make([]ULOCLanguage, 0, len(uloc.language))
+	for lang, set := range uloc.language {
 		res.PerLanguage = append(res.PerLanguage, ULOCLanguage{Language: lang, ULOC: len(set)})
 	}
 	sort.Slice(res.PerLanguage, func(i, j int) bool {
@@ -762,6 +702,7 @@ func hotspotsResultFromObserver(o *hotspotsObserver, window HistoryWindow) *Hots
 			CodeChurn:    r.CodeChurn,
 			CommentChurn: r.CommentChurn,
 			Score:        r.Score,
+			Test:         r.Test,
 		})
 	}
 	return res
@@ -811,6 +752,37 @@ func authorsResultFromObserver(o *historyAuthorsObserver, window HistoryWindow)
 	return res
 }
 
+func codeAgeResultFromObserver(o *historyCodeAgeObserver, window HistoryWindow) *CodeAgeResult {
+	rows := func(in []codeAgeRow) []CodeAgeRow {
+		out := make([]CodeAgeRow, 0, len(in))
+		for _, r := range in {
+			out = append(out, codeAgeRowFor(r))
+		}
+		return out
+	}
+	return &CodeAgeResult{
+		Window:      window,
+		AsOf:        o.asOf,
+		Total:       codeAgeRowFor(o.total),
+		Languages:   rows(o.languages),
+		Directories: rows(o.directories),
+		Files:       rows(o.files),
+	}
+}
+
+func codeAgeRowFor(r codeAgeRow) CodeAgeRow {
+	return CodeAgeRow{
+		Name:                r.Name,
+		Language:            r.Language,
+		Code:                r.Code,
+		MedianDays:          r.MedianDays,
+		Older1Percent:       r.OlderPercent[0],
+		Older2Percent:       r.OlderPercent[1],
+		Older5Percent:       r.OlderPercent[2],
+		BeforeWindowPercent: r.BeforeWindowPercent,
+	}
+}
+
 func languageTimelineResultFromObserver(o *historyLanguagesObserver, window HistoryWindow) *LangTimelineResult {
 	res := &LangTimelineResult{
 		Window:  window,
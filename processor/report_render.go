@@ -5,6 +5,7 @@ package processor
 import (
 	"bufio"
 	"bytes"
+	"context"
 	_ "embed"
 	"fmt"
 	"html/template"
@@ -76,7 +77,7 @@ func parseReportSkipTo(raw string, warnW io.Writer) {
 // ReportOut is set. It first prompts the user before clobbering an
 // existing default-named file (so a bare `--report` is non-destructive),
 // then collects data and renders the HTML output.
-func runReport(paths []string) error {
+func runReport(ctx context.Context, paths []string) error {
 	path := "."
 	if len(paths) > 0 {
 		path = paths[0]
@@ -86,7 +87,7 @@ func runReport(paths []string) error {
 	if err := confirmReportOverwrite(ReportOut, usedDefault, stdinIsTTY, os.Stdin, os.Stderr); err != nil {
 		return err
 	}
-	data, err := CollectReportData(path)
+	data, err := collectReportData(ctx, path)
 	if err != nil {
 		return err
 	}
@@ -238,6 +239,13 @@ type Bar struct {
 // reportFuncs is the template func map registered against both the page
 // template and the share card. Everything in here is pure (no I/O, no
 // globals beyond the colour map) so templates remain deterministic.
+// reportSplit is the production or test part of a language or of the totals,
+// named for its row in the Languages table
+type reportSplit struct {
+	Name string
+	CodeSplit
+}
+
 var reportFuncs = template.FuncMap{
 	"comma": func(n int64) string {
 		// Manual implementation avoids pulling text/message's MatchString
@@ -275,6 +283,7 @@ var reportFuncs = template.FuncMap{
 	"bucketBars":      bucketBars,
 	"histoBars":       histoBars,
 	"authorActivity":  authorActivity,
+	"codeAge":         formatCodeAge,
 	"durationSeconds": func(d any) float64 {
 		switch v := d.(type) {
 		case float64:
@@ -308,6 +317,9 @@ var reportFuncs = template.FuncMap{
 		}
 		return a / b
 	},
+	"testSplits": func(production, test *CodeSplit) []reportSplit {
+		return []reportSplit{{"production", *production}, {"test", *test}}
+	},
 	"int64":     func(n int) int64 { return int64(n) },
 	"fromInt64": func(n int64) int { return int(n) },
 	"fmtTime": func(layout string, t any) string {
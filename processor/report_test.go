@@ -81,6 +81,9 @@ func TestCollectReportDataOnFixtureRepo(t *testing.T) {
 	if data.Authors == nil {
 		t.Errorf("expected Authors section to be populated when git available")
 	}
+	if data.CodeAge == nil {
+		t.Errorf("expected CodeAge section to be populated when git available")
+	}
 	if data.LanguageTimeline == nil {
 		t.Errorf("expected LanguageTimeline section to be populated when git available")
 	}
@@ -121,6 +124,9 @@ func TestCollectReportDataOnNonGitDir(t *testing.T) {
 	if data.Authors != nil {
 		t.Errorf("expected Authors=nil when git unavailable, got %+v", data.Authors)
 	}
+	if data.CodeAge != nil {
+		t.Errorf("expected CodeAge=nil when git unavailable, got %+v", data.CodeAge)
+	}
 	if data.LanguageTimeline != nil {
 		t.Errorf("expected LanguageTimeline=nil when git unavailable")
 	}
@@ -525,7 +531,7 @@ func TestReportImplicitlyEnablesULOCAndLineLength(t *testing.T) {
 // spec 05 enumerates as recognised so a future code change can't silently
 // add or drop one without updating the spec.
 func TestReportSkipRecognisedListMatchesSpec(t *testing.T) {
-	want := []string{"cocomo", "locomo", "hotspots", "coupling", "authors", "timeline", "files", "uloc", "linelength", "card"}
+	want := []string{"cocomo", "locomo", "hotspots", "coupling", "authors", "age", "timeline", "files", "uloc", "linelength", "card"}
 	if len(reportSkipRecognised) != len(want) {
 		t.Errorf("reportSkipRecognised size = %d, want %d", len(reportSkipRecognised), len(want))
 	}
@@ -787,6 +793,22 @@ func goldenReportFixture() ReportData {
 				{Name: "(before window)", Sentinel: true},
 			},
 		},
+		CodeAge: &CodeAgeResult{
+			AsOf:  lastCommit,
+			Total: CodeAgeRow{Name: "Total", Code: 10640, MedianDays: 410, Older1Percent: 55.1, Older2Percent: 20.4, Older5Percent: 2.3, BeforeWindowPercent: 12.5},
+			Languages: []CodeAgeRow{
+				{Name: "Go", Code: 8000, MedianDays: 520, Older1Percent: 61.0, Older2Percent: 25.2, Older5Percent: 3.1},
+				{Name: "JavaScript", Code: 2400, MedianDays: 150, Older1Percent: 38.4, Older2Percent: 6.0},
+			},
+			Directories: []CodeAgeRow{
+				{Name: "internal", Code: 6400, MedianDays: 940, Older1Percent: 80.2, Older2Percent: 44.9, Older5Percent: 3.8},
+				{Name: "web", Code: 2400, MedianDays: 150, Older1Percent: 38.4, Older2Percent: 6.0},
+			},
+			Files: []CodeAgeRow{
+				{Name: "internal/cache.go", Language: "Go", Code: 1800, MedianDays: 1900, Older1Percent: 96.0, Older2Percent: 88.1, Older5Percent: 12.0},
+				{Name: "web/app.js", Language: "JavaScript", Code: 2400, MedianDays: 150, Older1Percent: 38.4, Older2Percent: 6.0},
+			},
+		},
 		LanguageTimeline: &LangTimelineResult{
 			Buckets: 4,
 			Rows: []LangTimelineRow{
@@ -0,0 +1,291 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// startRevision is start for Options.Rev. The paths must all be inside one
+// repository, which can be bare, and are counted as they are in the tree of
+// the revision rather than on disk. Nothing is checked out so the worktree,
+// index and HEAD are left alone.
+func (a *Analyzer) startRevision(ctx context.Context) (*processorContext, chan *FileJob, error) {
+	repo, inTree, err := revisionPaths(a.opts.Paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	tree, err := revisionTree(repo, a.opts.Rev)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The tree takes the place of the disk, so from here it is an fs.FS walk
+	// with the paths moved into the tree
+	revision := *a
+	revision.opts.Paths = inTree
+	revision.opts.Rev = ""
+	run, output, err := revision.startFS(ctx, tree)
+	if err != nil {
+		return nil, nil, fmt.Errorf("--rev %s: %w", a.opts.Rev, err)
+	}
+	return run, output, nil
+}
+
+// revisionPaths opens the repository holding paths and returns them as slash
+// separated paths relative to its root, which is how they appear in a tree
+func revisionPaths(paths []string) (*git.Repository, []string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	repo, root, err := openRevisionRepo(paths[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inTree := make([]string, 0, len(paths))
+	for _, p := range paths {
+		rel, err := repoRelative(root, p)
+		if err != nil {
+			return nil, nil, err
+		}
+		inTree = append(inTree, rel)
+	}
+	return repo, inTree, nil
+}
+
+// openRevisionRepo opens the repository holding p and returns it along with
+// the directory paths in it are relative to: the worktree root, or for a bare
+// repository the repository itself.
+func openRevisionRepo(p string) (*git.Repository, string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Looking for a .git directory would walk straight past a bare repository
+	// into any repository above it, so check for one first
+	if isBareRepo(abs) {
+		repo, err := git.PlainOpen(abs)
+		if err != nil {
+			return nil, "", fmt.Errorf("open git repository: %w", err)
+		}
+		return repo, abs, nil
+	}
+
+	repo, err := git.PlainOpenWithOptions(abs, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("open git repository: %w", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, "", fmt.Errorf("open git repository: %w", err)
+	}
+	return repo, wt.Filesystem.Root(), nil
+}
+
+// isBareRepo reports whether dir looks like the inside of a git directory
+func isBareRepo(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// repoRelative returns p as a slash separated path relative to the repository
+// root, "." being the root itself
+func repoRelative(root, p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	// Compare resolved paths so a symlinked checkout or temp dir still matches
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not inside the git repository at %s", p, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// revisionTree resolves rev, anything git rev-parse accepts that go-git
+// supports such as a branch, tag, hash or HEAD~2, to the tree of its commit
+func revisionTree(repo *git.Repository, rev string) (*revisionFS, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("resolve revision %s: %w", rev, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("read commit %s: %w", rev, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("read tree %s: %w", rev, err)
+	}
+	return &revisionFS{tree: tree, modTime: commit.Committer.When, mu: new(sync.Mutex)}, nil
+}
+
+// revisionFS is a read-only fs.FS over a commit's tree. Blobs are read from
+// the object store when opened. Submodules are left out as their content is
+// not in the repository, and symlinks are listed but cannot be opened.
+//
+// go-git's trees and object store are not safe for concurrent use, while the
+// file workers open blobs in parallel with the walk, so every access to them
+// goes through mu and a blob is read in full before it is handed out. mu is
+// shared with anything else reading the same repository at the same time.
+type revisionFS struct {
+	tree    *object.Tree
+	modTime time.Time // every entry carries the commit time
+	mu      *sync.Mutex
+}
+
+func (r *revisionFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &revisionDir{fsys: r, tree: r.tree, info: r.dirInfo(".")}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, err := r.tree.FindEntry(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	switch entry.Mode {
+	case filemode.Dir:
+		tree, err := r.tree.Tree(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &revisionDir{fsys: r, tree: tree, info: r.dirInfo(path.Base(name))}, nil
+	case filemode.Regular, filemode.Executable, filemode.Deprecated:
+		file, err := r.tree.TreeEntryFile(entry)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		content, err := file.Contents()
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &revisionFile{info: r.fileInfo(path.Base(name), file.Size, entry.Mode), reader: strings.NewReader(content)}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (r *revisionFS) dirInfo(name string) *revisionInfo {
+	return &revisionInfo{name: name, mode: fs.ModeDir | 0o555, modTime: r.modTime}
+}
+
+func (r *revisionFS) fileInfo(name string, size int64, mode filemode.FileMode) *revisionInfo {
+	perm := fs.FileMode(0o444)
+	if mode == filemode.Executable {
+		perm = 0o555
+	}
+	return &revisionInfo{name: name, size: size, mode: perm, modTime: r.modTime}
+}
+
+// revisionInfo is the fs.FileInfo and fs.DirEntry of a revisionFS entry
+type revisionInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *revisionInfo) Name() string               { return i.name }
+func (i *revisionInfo) Size() int64                { return i.size }
+func (i *revisionInfo) Mode() fs.FileMode          { return i.mode }
+func (i *revisionInfo) ModTime() time.Time         { return i.modTime }
+func (i *revisionInfo) IsDir() bool                { return i.mode.IsDir() }
+func (i *revisionInfo) Sys() any                   { return nil }
+func (i *revisionInfo) Type() fs.FileMode          { return i.mode.Type() }
+func (i *revisionInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+// revisionFile is an open blob of a revisionFS, already read from the store
+type revisionFile struct {
+	info   *revisionInfo
+	reader io.Reader
+}
+
+func (f *revisionFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *revisionFile) Read(b []byte) (int, error) { return f.reader.Read(b) }
+func (f *revisionFile) Close() error               { return nil }
+
+// revisionDir is an open tree of a revisionFS
+type revisionDir struct {
+	fsys    *revisionFS
+	tree    *object.Tree
+	info    *revisionInfo
+	entries []fs.DirEntry
+	read    bool
+	offset  int
+}
+
+func (d *revisionDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *revisionDir) Close() error               { return nil }
+func (d *revisionDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *revisionDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		d.read = true
+		d.fsys.mu.Lock()
+		defer d.fsys.mu.Unlock()
+		for i := range d.tree.Entries {
+			entry := &d.tree.Entries[i]
+			switch entry.Mode {
+			case filemode.Dir:
+				d.entries = append(d.entries, d.fsys.dirInfo(entry.Name))
+			case filemode.Regular, filemode.Executable, filemode.Deprecated:
+				// The size is needed up front for --no-large and the byte
+				// counts, and only comes from the blob's header
+				file, err := d.tree.TreeEntryFile(entry)
+				if err != nil {
+					return nil, err
+				}
+				d.entries = append(d.entries, d.fsys.fileInfo(entry.Name, file.Size, entry.Mode))
+			case filemode.Symlink:
+				d.entries = append(d.entries, &revisionInfo{name: entry.Name, mode: fs.ModeSymlink | 0o777, modTime: d.fsys.modTime})
+			}
+		}
+	}
+
+	remaining := d.entries[d.offset:]
+	if n > 0 && len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(remaining) {
+		remaining = remaining[:n]
+	}
+	d.offset += len(remaining)
+	return remaining, nil
+}
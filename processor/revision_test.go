@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func revisionFixtureRepo(t *testing.T) string {
+	t.Helper()
+	return makeFixtureRepo(t, []map[string]string{
+		{
+			"main.go":     "package main\n\nfunc main() {\n\tif true {\n\t}\n}\n",
+			"lib/util.py": "def f():\n    return 1\n",
+			".gitignore":  "gen/\n",
+		},
+		{
+			"main.go":    "package main\n\nfunc main() {}\n",
+			"lib/new.go": "package lib\n",
+			"gen/gen.go": "package gen\n",
+		},
+	})
+}
+
+func TestAnalyzerRunRevision(t *testing.T) {
+	dir := revisionFixtureRepo(t)
+
+	// Uncommitted files are not in any tree so must not be counted
+	if err := os.WriteFile(filepath.Join(dir, "scratch.go"), []byte("package scratch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	opts.Rev = "HEAD~1"
+
+	language, files, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := locations(files); !slices.Equal(got, []string{"lib/util.py", "main.go"}) {
+		t.Fatalf("expected the first commit's files got %v", got)
+	}
+	goSummary, _ := findLanguage(language, "Go")
+	if goSummary.Code != 5 || goSummary.Complexity != 1 {
+		t.Errorf("expected the first commit's main.go got %+v", goSummary)
+	}
+
+	// gen/ is ignored by the .gitignore committed in the tree
+	opts.Rev = "HEAD"
+	opts.Paths = []string{filepath.Join(dir, "lib")}
+	_, files, err = NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := locations(files); !slices.Equal(got, []string{"lib/new.go", "lib/util.py"}) {
+		t.Errorf("expected lib at HEAD got %v", got)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "scratch.go")); err != nil {
+		t.Errorf("worktree was touched: %v", err)
+	}
+}
+
+func TestAnalyzerRunRevisionBare(t *testing.T) {
+	dir := revisionFixtureRepo(t)
+	bare := filepath.Join(t.TempDir(), "repo.git")
+	if _, err := git.PlainClone(bare, true, &git.CloneOptions{URL: dir}); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Paths = []string{bare}
+	opts.Rev = "HEAD"
+
+	_, files, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := locations(files); !slices.Equal(got, []string{"lib/new.go", "lib/util.py", "main.go"}) {
+		t.Errorf("unexpected files %v", got)
+	}
+}
+
+func TestAnalyzerRunRevisionErrors(t *testing.T) {
+	dir := revisionFixtureRepo(t)
+
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	opts.Rev = "no-such-branch"
+	if _, err := NewAnalyzer(opts).Languages(context.Background()); err == nil {
+		t.Error("expected error for unknown revision")
+	}
+
+	opts.Rev = "HEAD~1"
+	opts.Paths = []string{filepath.Join(dir, "gen")}
+	if _, err := NewAnalyzer(opts).Languages(context.Background()); err == nil {
+		t.Error("expected error for a path missing from the revision")
+	}
+
+	opts.Paths = []string{t.TempDir()}
+	if _, err := NewAnalyzer(opts).Languages(context.Background()); err == nil {
+		t.Error("expected error for a path outside any repository")
+	}
+}
+
+func TestAnalyzerRunRevisionPackedConcurrent(t *testing.T) {
+	commit := map[string]string{}
+	for i := range 40 {
+		commit["pkg"+itoa(i%4)+"/f"+itoa(i)+".go"] = "package pkg\n\nfunc F() {\n\tif true {\n\t}\n}\n"
+	}
+	dir := makeFixtureRepo(t, []map[string]string{commit})
+
+	// Packed objects go through go-git's shared pack and tree caches, which
+	// is where unguarded concurrent reads race
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RepackObjects(&git.RepackConfig{}); err != nil {
+		t.Fatalf("repack: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	opts.Rev = "HEAD"
+	opts.FileProcessJobWorkers = 8
+
+	language, files, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	goSummary, _ := findLanguage(language, "Go")
+	if len(files) != 40 || goSummary.Code != 200 || goSummary.Complexity != 40 {
+		t.Errorf("expected every packed file counted got %d files %+v", len(files), goSummary)
+	}
+}
@@ -5,6 +5,7 @@ package processor
 import (
 	"bytes"
 	"hash"
+	"io/fs"
 	"regexp"
 	"slices"
 	"sync"
@@ -65,19 +66,30 @@ type Heuristic struct {
 
 // Language is a struct which contains the values for each language stored in languages.json
 type Language struct {
-	LineComment                     []string    `json:"line_comment"`
-	ComplexityChecks                []string    `json:"complexitychecks"`
-	ComplexityChecksPostfix         []string    `json:"complexitychecks_postfix"`
-	ComplexityChecksPostfixExcludes []string    `json:"complexitychecks_postfix_excludes"`
-	Extensions                      []string    `json:"extensions"`
-	MultiLine                       [][]string  `json:"multi_line"`
-	Quotes                          []Quote     `json:"quotes"`
-	Keywords                        []string    `json:"keywords"`
-	Heuristics                      []Heuristic `json:"heuristics"`
-	FileNames                       []string    `json:"filenames"`
-	SheBangs                        []string    `json:"shebangs"`
-	ExtensionFile                   bool        `json:"extensionFile"`
-	NestedMultiLine                 bool        `json:"nestedmultiline"`
+	LineComment                     []string         `json:"line_comment"`
+	ComplexityChecks                []string         `json:"complexitychecks"`
+	ComplexityChecksPostfix         []string         `json:"complexitychecks_postfix"`
+	ComplexityChecksPostfixExcludes []string         `json:"complexitychecks_postfix_excludes"`
+	Extensions                      []string         `json:"extensions"`
+	MultiLine                       [][]string       `json:"multi_line"`
+	Quotes                          []Quote          `json:"quotes"`
+	Keywords                        []string         `json:"keywords"`
+	Heuristics                      []Heuristic      `json:"heuristics"`
+	FileNames                       []string         `json:"filenames"`
+	SheBangs                        []string         `json:"shebangs"`
+	ExtensionFile                   bool             `json:"extensionFile"`
+	NestedMultiLine                 bool             `json:"nestedmultiline"`
+	Samples                         []LanguageSample `json:"samples"`
+}
+
+// LanguageSample is a snippet of a language along with the line counts scc is
+// expected to produce for it. They are checked by --validate-languages so the
+// definitions double as a regression corpus.
+type LanguageSample struct {
+	Content string `json:"content"`
+	Code    int64  `json:"code"`
+	Comment int64  `json:"comment"`
+	Blank   int64  `json:"blank"`
 }
 
 // LanguageFeature is a struct which represents the conversion from Language into what is used for matching
@@ -140,13 +152,23 @@ type FileJob struct {
 	Binary               bool
 	Minified             bool
 	Generated            bool
+	Vendored             bool // marked linguist-vendored, set when the run identifies vendored files
+	Documentation        bool // marked linguist-documentation, set when the run identifies documentation
+	Test                 bool // test rather than production code, set when the run separates tests
 	EndPoint             int
 	Uloc                 int
-	LineLength           []int  `json:"-"`
-	ClassifyContent      bool   `json:"-"` // When true, CountStats populates ContentByteType
-	ContentByteType      []byte `json:"-"` // Per-byte classification, allocated by CountStats when ClassifyContent is true
-	TrackComplexityLines bool   `json:"-"` // When true, CountStats populates ComplexityLine
-	cognitiveNesting     int    // transient per-line nesting level used during CountStats when Cognitive is enabled
+	LineLength           []int              `json:"-"`
+	Embedded             []EmbeddedCount    `json:",omitempty"` // code in other languages embedded in the file, set with --embedded
+	ClassifyContent      bool               `json:"-"`          // When true, CountStats populates ContentByteType
+	ContentByteType      []byte             `json:"-"`          // Per-byte classification, allocated by CountStats when ClassifyContent is true
+	TrackComplexityLines bool               `json:"-"`          // When true, CountStats populates ComplexityLine
+	cognitiveNesting     int                // transient per-line nesting level used during CountStats when Cognitive is enabled
+	settings             *countSettings     // run options attached by the pipeline; nil means CountStats reads the package flags
+	fsys                 fs.FS              // file system the file is read from; nil reads Location from the local disk
+	fsysName             string             // name of the file within fsys
+	lineDigests          []uint64           // hash of every line, kept when the run asks for it as Content is reused
+	functions            []FunctionSummary  // functions found in the file when the run asks for them
+	attributes           linguistAttributes // overrides from the .gitattributes files in force for the file
 }
 
 // MarshalJSON emits FileJob with the Cognitive field present (even when 0) while
@@ -210,13 +232,19 @@ type LanguageSummary struct {
 	Files              []*FileJob
 	LineLength         []int
 	ULOC               int
-	CodePercent        *float64 `json:",omitempty"`
-	CommentPercent     *float64 `json:",omitempty"`
-	BlankPercent       *float64 `json:",omitempty"`
-	LinePercent        *float64 `json:",omitempty"`
-	ComplexityPercent  *float64 `json:",omitempty"`
-	BytePercent        *float64 `json:",omitempty"`
-	FilePercent        *float64 `json:",omitempty"`
+	CodePercent        *float64   `json:",omitempty"`
+	CommentPercent     *float64   `json:",omitempty"`
+	BlankPercent       *float64   `json:",omitempty"`
+	LinePercent        *float64   `json:",omitempty"`
+	ComplexityPercent  *float64   `json:",omitempty"`
+	BytePercent        *float64   `json:",omitempty"`
+	FilePercent        *float64   `json:",omitempty"`
+	Production         *CodeSplit `json:",omitempty"` // counts of the production code, set when the run separates tests
+	Test               *CodeSplit `json:",omitempty"` // counts of the test code, set when the run separates tests
+
+	// Embedded is the code in other languages embedded in files of the
+	// language, set with --embedded nested
+	Embedded []EmbeddedCount `json:",omitempty"`
 }
 
 // MarshalJSON gates the language-level Cognitive field on the Cognitive global,
@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// testDirConventions are the directories whose files are tests whatever their
+// language. They and the conventions below are matched as a pathRule is.
+var testDirConventions = []string{
+	"**/__tests__/**",
+	"**/test/**",
+	"**/tests/**",
+	"**/spec/**",
+}
+
+// testConventions are the test file naming conventions of each language
+var testConventions = map[string][]string{
+	"C":           {"*_test.c", "test_*.c"},
+	"C#":          {"*Test.cs", "*Tests.cs", "**/*.Tests/**", "**/*.UnitTests/**"},
+	"C++":         {"*_test.cc", "*_test.cpp", "*_unittest.cc", "*_unittest.cpp", "test_*.cpp"},
+	"Clojure":     {"*_test.clj", "*_test.cljs", "*_test.cljc"},
+	"Crystal":     {"*_spec.cr"},
+	"Dart":        {"*_test.dart"},
+	"Elixir":      {"*_test.exs"},
+	"Erlang":      {"*_SUITE.erl", "*_tests.erl"},
+	"F#":          {"*Test.fs", "*Tests.fs", "**/*.Tests/**"},
+	"Go":          {"*_test.go"},
+	"Groovy":      {"*Spec.groovy", "*Test.groovy", "**/src/test/**"},
+	"Haskell":     {"*Spec.hs", "*Test.hs"},
+	"Java":        {"*Test.java", "*Tests.java", "*IT.java", "**/src/test/**"},
+	"JavaScript":  {"*.test.*", "*.spec.*"},
+	"JSX":         {"*.test.*", "*.spec.*"},
+	"Julia":       {"runtests.jl"},
+	"Kotlin":      {"*Test.kt", "*Tests.kt", "**/src/test/**"},
+	"Lua":         {"*_spec.lua", "*_test.lua"},
+	"Objective C": {"*Tests.m"},
+	"PHP":         {"*Test.php"},
+	"Python":      {"test_*.py", "*_test.py", "conftest.py"},
+	"R":           {"test-*.R", "test_*.R"},
+	"Ruby":        {"*_spec.rb", "*_test.rb", "test_*.rb"},
+	"Scala":       {"*Spec.scala", "*Suite.scala", "*Test.scala", "**/src/test/**"},
+	"Swift":       {"*Tests.swift", "*Test.swift"},
+	"TypeScript":  {"*.test.*", "*.spec.*"},
+}
+
+// pathRule is a --test-pattern or --vendor-pattern glob, marking the files it
+// matches or with a leading ! unmarking them. Patterns are matched like
+// .gitattributes ones: without a slash against the file name, otherwise
+// against the whole path with ** standing for any number of directories.
+type pathRule struct {
+	pattern string
+	set     bool
+}
+
+// newPathRules compiles the globs given to flag, dropping malformed ones
+func newPathRules(flag string, patterns []string) []pathRule {
+	var rules []pathRule
+	for _, pattern := range patterns {
+		rule := pathRule{pattern: strings.TrimPrefix(pattern, "!"), set: !strings.HasPrefix(pattern, "!")}
+		if err := checkPathPattern(rule.pattern); err != nil {
+			printError(fmt.Sprintf("ignoring malformed %s %q: %s", flag, pattern, err))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func checkPathPattern(pattern string) error {
+	if strings.Trim(pattern, "/") == "" {
+		return fmt.Errorf("empty pattern")
+	}
+	for _, element := range strings.Split(pattern, "/") {
+		if _, err := path.Match(element, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rulePath is location as the path rules match it, with forward slashes and
+// without a leading ./ or /
+func rulePath(location string) string {
+	return strings.TrimLeft(strings.TrimPrefix(filepath.ToSlash(location), "./"), "/")
+}
+
+// matchPathRules applies rules in order to rel, starting from matched, so the
+// last rule matching wins. rel is the location as scc reports it, made into a
+// rulePath, so patterns are relative to the paths given on the command line.
+func matchPathRules(rules []pathRule, rel string, matched bool) bool {
+	for _, rule := range rules {
+		if matchAttributePattern(rule.pattern, rel) {
+			matched = rule.set
+		}
+	}
+	return matched
+}
+
+// testRules tells test files from production code using the conventions
+// above and the --test-pattern globs, which are applied after them in order
+// so the last one matching a file wins
+type testRules struct {
+	rules []pathRule
+}
+
+// newTestRules compiles the --test-pattern globs, dropping malformed ones
+func newTestRules(patterns []string) *testRules {
+	return &testRules{rules: newPathRules("test-pattern", patterns)}
+}
+
+// match reports whether the file at location in language is a test, see
+// matchPathRules. A nil testRules, which is what a run without --tests has,
+// marks nothing.
+func (t *testRules) match(location, language string) bool {
+	if t == nil {
+		return false
+	}
+	rel := rulePath(location)
+
+	test := false
+	for _, pattern := range testDirConventions {
+		if matchAttributePattern(pattern, rel) {
+			test = true
+			break
+		}
+	}
+	if !test {
+		for _, pattern := range testConventions[language] {
+			if matchAttributePattern(pattern, rel) {
+				test = true
+				break
+			}
+		}
+	}
+	return matchPathRules(t.rules, rel, test)
+}
+
+// CodeSplit is the part of the counts of a language from its test files or
+// from its production code
+type CodeSplit struct {
+	Count      int64
+	Lines      int64
+	Code       int64
+	Comment    int64
+	Blank      int64
+	Complexity int64
+	Cognitive  int64 `json:",omitempty"`
+	Bytes      int64
+}
+
+func (s *CodeSplit) add(job *FileJob) {
+	s.Count++
+	s.Lines += job.Lines
+	s.Code += job.Code
+	s.Comment += job.Comment
+	s.Blank += job.Blank
+	s.Complexity += job.Complexity
+	s.Cognitive += job.Cognitive
+	s.Bytes += job.Bytes
+}
+
+// withTests returns l with job added to its Test or Production split
+func (l LanguageSummary) withTests(job *FileJob) LanguageSummary {
+	if l.Production == nil {
+		l.Production, l.Test = &CodeSplit{}, &CodeSplit{}
+	}
+	if job.Test {
+		l.Test.add(job)
+	} else {
+		l.Production.add(job)
+	}
+	return l
+}
+
+// testRatio is the lines of test code for each line of production code
+func testRatio(production, test CodeSplit) float64 {
+	if production.Code == 0 {
+		return 0
+	}
+	return float64(test.Code) / float64(production.Code)
+}
@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTestRulesMatch(t *testing.T) {
+	rules := newTestRules(nil)
+	for _, tc := range []struct {
+		location string
+		language string
+		expected bool
+	}{
+		{"processor/workers_test.go", "Go", true},
+		{"processor/workers.go", "Go", false},
+		{"./pkg/test_util.py", "Python", true},
+		{"pkg/conftest.py", "Python", true},
+		{"pkg/util_test.py", "Python", true},
+		{"src/app.test.ts", "TypeScript", true},
+		{"src/app.ts", "TypeScript", false},
+		{"spec/models/user_spec.rb", "Ruby", true},
+		{"lib/user.rb", "Ruby", false},
+		{"src/test/java/com/FooTest.java", "Java", true},
+		{"src/main/java/com/Foo.java", "Java", false},
+		{"/abs/project/tests/fixture.json", "JSON", true},
+		{"web/__tests__/button.jsx", "JSX", true},
+		{"testing/helpers.go", "Go", false},
+		{"contest_entry.py", "Python", false},
+		// Conventions belong to their language only
+		{"notes_test.go", "Markdown", false},
+	} {
+		if got := rules.match(tc.location, tc.language); got != tc.expected {
+			t.Errorf("%s (%s): expected %t got %t", tc.location, tc.language, tc.expected, got)
+		}
+	}
+
+	var none *testRules
+	if none.match("pkg/a_test.go", "Go") {
+		t.Error("expected nil rules to mark nothing")
+	}
+}
+
+func TestTestRulesPatterns(t *testing.T) {
+	rules := newTestRules([]string{"**/testdata/**", "*_check.sh", "!**/tests/fixtures/**", "[", "!"})
+	if len(rules.rules) != 3 {
+		t.Fatalf("expected the malformed patterns to be dropped got %+v", rules.rules)
+	}
+	for _, tc := range []struct {
+		location string
+		language string
+		expected bool
+	}{
+		{"pkg/testdata/input.txt", "Plain Text", true},
+		{"scripts/smoke_check.sh", "Shell", true},
+		{"tests/fixtures/app.py", "Python", false},
+		{"tests/test_app.py", "Python", true},
+		// The last matching pattern wins
+		{"tests/fixtures/test_data.py", "Python", false},
+	} {
+		if got := rules.match(tc.location, tc.language); got != tc.expected {
+			t.Errorf("%s: expected %t got %t", tc.location, tc.expected, got)
+		}
+	}
+
+	last := newTestRules([]string{"!*_test.go", "pkg/*_test.go"})
+	if !last.match("pkg/a_test.go", "Go") || last.match("cmd/a_test.go", "Go") {
+		t.Error("expected later patterns to override earlier ones")
+	}
+}
+
+func TestTestRatio(t *testing.T) {
+	if got := testRatio(CodeSplit{Code: 200}, CodeSplit{Code: 50}); got != 0.25 {
+		t.Errorf("expected 0.25 got %f", got)
+	}
+	if got := testRatio(CodeSplit{}, CodeSplit{Code: 50}); got != 0 {
+		t.Errorf("expected 0 without production code got %f", got)
+	}
+}
+
+func TestTestsRun(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"pkg/a.go":           "package pkg\n\nfunc A(x int) int {\n\tif x > 1 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n",
+		"pkg/a_test.go":      "package pkg\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {\n\tif A(2) != 1 {\n\t\tt.Fatal()\n\t}\n}\n",
+		"pkg/testdata/in.go": "package testdata\n",
+		"app.py":             "x = 1\n",
+	})
+	if got := runTree(t, dir, DefaultOptions())["Go"]; got.Production != nil || got.Test != nil {
+		t.Errorf("expected no split without --tests got %+v %+v", got.Production, got.Test)
+	}
+
+	opts := DefaultOptions()
+	opts.Tests = true
+	langs := runTree(t, dir, opts)
+	golang := langs["Go"]
+	if golang.Count != 3 || golang.Production == nil || golang.Production.Count != 2 || golang.Test.Count != 1 {
+		t.Fatalf("unexpected Go split %+v %+v", golang.Production, golang.Test)
+	}
+	if golang.Production.Code+golang.Test.Code != golang.Code || golang.Production.Complexity+golang.Test.Complexity != golang.Complexity {
+		t.Errorf("expected the split to add up got %+v %+v of %+v", golang.Production, golang.Test, golang)
+	}
+	if python := langs["Python"]; python.Production.Count != 1 || python.Test.Count != 0 {
+		t.Errorf("unexpected Python split %+v %+v", python.Production, python.Test)
+	}
+
+	// A pattern can pull files in and push them out again
+	opts.TestPatterns = []string{"**/testdata/**", "!*_test.go"}
+	golang = runTree(t, dir, opts)["Go"]
+	if golang.Production.Count != 2 || golang.Test.Count != 1 {
+		t.Errorf("unexpected Go split with patterns %+v %+v", golang.Production, golang.Test)
+	}
+}
+
+func TestTestsRunGenerated(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"pkg/mock_test.go": "// Code generated by mockgen. DO NOT EDIT.\npackage pkg\n",
+	})
+	opts := DefaultOptions()
+	opts.Paths = []string{dir}
+	opts.Tests = true
+	opts.Generated = true
+	opts.GeneratedMarkers = []string{"do not edit"}
+
+	_, files, err := NewAnalyzer(opts).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The (gen) mark must not hide the Go naming convention
+	if len(files) != 1 || files[0].Language != "Go (gen)" || !files[0].Test {
+		t.Errorf("expected a generated Go test got %+v", files)
+	}
+}
+
+func testsJobs() chan *FileJob {
+	input := make(chan *FileJob, 3)
+	input <- &FileJob{Language: "Go", Location: "a.go", Filename: "a.go", Lines: 10, Code: 8, Blank: 2, Complexity: 2}
+	input <- &FileJob{Language: "Go", Location: "a_test.go", Filename: "a_test.go", Lines: 5, Code: 4, Blank: 1, Complexity: 1, Test: true}
+	input <- &FileJob{Language: "Python", Location: "b.py", Filename: "b.py", Lines: 3, Code: 3}
+	close(input)
+	return input
+}
+
+func TestFileSummarizeTests(t *testing.T) {
+	Tests = true
+	defer func() { Tests = false }()
+
+	for name, format := range map[string]func(chan *FileJob) string{"short": fileSummarizeShort, "wide": fileSummarizeLong} {
+		got := format(testsJobs())
+		for _, expected := range []string{"(production)", "(test)", "Production ", "Test ", "Test to Production Code"} {
+			if !strings.Contains(got, expected) {
+				t.Errorf("%s: expected %q in\n%s", name, expected, got)
+			}
+		}
+		if strings.Count(got, "(test)") != 1 {
+			t.Errorf("%s: expected only Go to be split\n%s", name, got)
+		}
+		if !strings.Contains(got, "0.36\n") {
+			t.Errorf("%s: expected a ratio of 4 test to 11 production lines of code\n%s", name, got)
+		}
+	}
+}
+
+func TestToCSVTests(t *testing.T) {
+	Tests = true
+	defer func() { Tests = false }()
+
+	got := toCSVSummary(testsJobs())
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if !strings.HasSuffix(lines[0], ",Production Files,Production Lines,Production Code,Production Comments,Production Blanks,Production Complexity,Test Files,Test Lines,Test Code,Test Comments,Test Blanks,Test Complexity") {
+		t.Errorf("unexpected header %s", lines[0])
+	}
+	if !strings.Contains(got, "Go,15,12,0,3,3,0,2,0,1,10,8,0,2,2,1,5,4,0,1,1\n") {
+		t.Errorf("unexpected Go row in\n%s", got)
+	}
+
+	Files = true
+	defer func() { Files = false }()
+	got = toCSVFiles(testsJobs())
+	if !strings.Contains(got, ",ULOC,Test\n") || !strings.Contains(got, "a_test.go,5,4,0,1,1,0,0,true\n") {
+		t.Errorf("unexpected by file output\n%s", got)
+	}
+}
+
+func TestHotspotsTests(t *testing.T) {
+	Tests = true
+	defer func() { Tests = false }()
+
+	o := newHotspotsObserver()
+	o.files["pkg/a.go"] = &hotspotsRecord{File: "pkg/a.go", Commits: 2, Authors: map[authorID]struct{}{}}
+	o.files["pkg/a_test.go"] = &hotspotsRecord{File: "pkg/a_test.go", Commits: 1, Authors: map[authorID]struct{}{}}
+	o.Finalise(HistoryWindow{}, HeadSnapshot{Files: map[string]HeadFile{
+		"pkg/a.go":      {Path: "pkg/a.go", Language: "Go", Complexity: 3},
+		"pkg/a_test.go": {Path: "pkg/a_test.go", Language: "Go", Complexity: 1},
+	}})
+
+	if got := renderHotspotsTabular(o); !strings.Contains(got, "pkg/a_test.go (test)") || strings.Contains(got, "pkg/a.go (test)") {
+		t.Errorf("expected the test file to be tagged\n%s", got)
+	}
+	got, err := renderHotspotsJSON(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(got, `"test":true`) != 1 {
+		t.Errorf("expected one test file in %s", got)
+	}
+	if got, _ := renderHotspotsCSV(o); !strings.Contains(got, ",Score,Test\n") || !strings.Contains(got, "pkg/a_test.go,Go,1,1,0,0,0,0,16.7,true\n") {
+		t.Errorf("unexpected csv\n%s", got)
+	}
+}
@@ -0,0 +1,480 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"fmt"
+	"os"
+	"regexp/syntax"
+	"slices"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// builtinLanguages is the source reported for problems in the compiled in
+// language database
+const builtinLanguages = "languages.json"
+
+// languageProblem is a single finding of --validate-languages
+type languageProblem struct {
+	Source   string `json:"source"`
+	Language string `json:"language"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// languageValidation is the result of --validate-languages
+type languageValidation struct {
+	Languages int               `json:"languages"`
+	Samples   int               `json:"samples"`
+	Problems  []languageProblem `json:"problems"`
+}
+
+func (v languageValidation) count(severity string) int {
+	count := 0
+	for _, p := range v.Problems {
+		if p.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// maxLiteralStrings caps how many distinct strings literalsCover expands part
+// of a pattern into before treating it as unbounded
+const maxLiteralStrings = 256
+
+// literalsCover reports whether every possible match of pattern contains at
+// least one of literals. When it does not the literal pre-check in
+// guessByHeuristics can skip content the pattern would have matched. Anchored
+// heuristics are checked the same way, as anchoring only narrows where the
+// literal may appear.
+func literalsCover(pattern string, literals []string) bool {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	return regexCovered(re.Simplify(), literals)
+}
+
+func regexCovered(re *syntax.Regexp, literals []string) bool {
+	if strs, ok := regexStrings(re); ok {
+		return allContainLiteral(strs, literals)
+	}
+
+	switch re.Op {
+	case syntax.OpCapture, syntax.OpPlus:
+		return regexCovered(re.Sub[0], literals)
+	case syntax.OpRepeat:
+		return re.Min >= 1 && regexCovered(re.Sub[0], literals)
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if !regexCovered(sub, literals) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpConcat:
+		// Either one part always contains a literal, or a run of parts that
+		// can only match a handful of strings does once joined together
+		var run []string
+		for _, sub := range re.Sub {
+			if regexCovered(sub, literals) {
+				return true
+			}
+			strs, ok := regexStrings(sub)
+			if ok && run == nil {
+				run = strs
+				continue
+			}
+			if ok {
+				if joined, ok := crossStrings(run, strs); ok {
+					run = joined
+					continue
+				}
+			}
+			if run != nil && allContainLiteral(run, literals) {
+				return true
+			}
+			run = nil
+			if ok {
+				run = strs
+			}
+		}
+		return run != nil && allContainLiteral(run, literals)
+	}
+	return false
+}
+
+// regexStrings returns every string re can match when that is a small finite
+// set. Zero width assertions match the empty string.
+func regexStrings(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return []string{string(re.Rune)}, true
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return []string{""}, true
+	case syntax.OpCharClass:
+		var strs []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if len(strs)+int(re.Rune[i+1]-re.Rune[i]) >= 16 {
+				return nil, false
+			}
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				strs = append(strs, string(r))
+			}
+		}
+		return strs, true
+	case syntax.OpCapture:
+		return regexStrings(re.Sub[0])
+	case syntax.OpQuest:
+		strs, ok := regexStrings(re.Sub[0])
+		return append(strs, ""), ok
+	case syntax.OpConcat:
+		strs := []string{""}
+		for _, sub := range re.Sub {
+			next, ok := regexStrings(sub)
+			if !ok {
+				return nil, false
+			}
+			if strs, ok = crossStrings(strs, next); !ok {
+				return nil, false
+			}
+		}
+		return strs, true
+	case syntax.OpAlternate:
+		var strs []string
+		for _, sub := range re.Sub {
+			next, ok := regexStrings(sub)
+			if !ok || len(strs)+len(next) > maxLiteralStrings {
+				return nil, false
+			}
+			strs = append(strs, next...)
+		}
+		return strs, true
+	}
+	return nil, false
+}
+
+func crossStrings(left, right []string) ([]string, bool) {
+	if len(left)*len(right) > maxLiteralStrings {
+		return nil, false
+	}
+	strs := make([]string, 0, len(left)*len(right))
+	for _, l := range left {
+		for _, r := range right {
+			strs = append(strs, l+r)
+		}
+	}
+	return strs, true
+}
+
+// classified reports whether the statistical classifier has samples of the
+// language named, and so can tell it apart from another it has samples of
+func classified(name string) bool {
+	_, ok := classifierModels[name]
+	return ok && !DisableClassifier
+}
+
+func allContainLiteral(strs []string, literals []string) bool {
+	for _, s := range strs {
+		if !slices.ContainsFunc(literals, func(literal string) bool { return strings.Contains(s, literal) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// languageConflicts finds extensions, filenames and shebangs claimed by more
+// than one language in db in a way detection cannot settle. When overlay is
+// not empty only the claims of those languages are checked, and they are
+// treated as replacing the built-in languages as applyLanguageOverlay does.
+func languageConflicts(db map[string]Language, overlay map[string]bool) []languageProblem {
+	var problems []languageProblem
+	report := func(name, format string, a ...any) {
+		problems = append(problems, languageProblem{Language: name, Severity: severityWarning, Message: fmt.Sprintf(format, a...)})
+	}
+
+	names := make([]string, 0, len(db))
+	for name := range db {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	extensions := map[string][]string{}
+	filenames := map[string][]string{}
+	shebangs := map[string][]string{}
+	for _, name := range names {
+		for _, ext := range db[name].Extensions {
+			extensions[ext] = append(extensions[ext], name)
+		}
+		for _, fname := range db[name].FileNames {
+			filenames[fname] = append(filenames[fname], name)
+		}
+		for _, command := range db[name].SheBangs {
+			shebangs[command] = append(shebangs[command], name)
+		}
+	}
+
+	// owners splits the languages claiming something into the ones that
+	// keep it and the built-in ones an overlay language takes it from
+	owners := func(claimants []string) ([]string, []string, bool) {
+		if len(overlay) == 0 {
+			return claimants, nil, true
+		}
+		kept := slices.DeleteFunc(slices.Clone(claimants), func(name string) bool { return !overlay[name] })
+		replaced := slices.DeleteFunc(slices.Clone(claimants), func(name string) bool { return overlay[name] })
+		return kept, replaced, len(kept) != 0
+	}
+
+	for _, ext := range sortedKeys(extensions) {
+		kept, replaced, ok := owners(extensions[ext])
+		if !ok {
+			continue
+		}
+		for _, name := range kept {
+			for _, other := range replaced {
+				report(name, "extension %q is also claimed by the built-in %s, which it replaces for those files", ext, other)
+			}
+		}
+
+		// Candidates without heuristics or keywords are only a fallback, so
+		// with more than one the alphabetically first always wins, unless the
+		// classifier has samples of both to pick between them
+		var fallback []string
+		for _, name := range kept {
+			if len(db[name].Heuristics) == 0 && len(db[name].Keywords) == 0 {
+				fallback = append(fallback, name)
+			}
+		}
+		for _, name := range fallback[min(1, len(fallback)):] {
+			if classified(name) && classified(fallback[0]) {
+				continue
+			}
+			report(name, "extension %q is shared with %s and neither has heuristics or keywords to tell them apart, so those files are always counted as %s", ext, fallback[0], fallback[0])
+		}
+	}
+
+	for _, kind := range []struct {
+		field  string
+		claims map[string][]string
+	}{
+		{"filename", filenames},
+		{"shebang", shebangs},
+	} {
+		for _, value := range sortedKeys(kind.claims) {
+			kept, replaced, ok := owners(kind.claims[value])
+			if !ok {
+				continue
+			}
+			for _, name := range kept {
+				for _, other := range replaced {
+					report(name, "%s %q is also claimed by the built-in %s, which it replaces for those files", kind.field, value, other)
+				}
+				for _, other := range kept {
+					if other != name {
+						report(name, "%s %q is also claimed by %s, so which one is used is not deterministic", kind.field, value, other)
+					}
+				}
+			}
+		}
+	}
+	return problems
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// checkSamples counts each of the samples of a language with its definition
+// and compares the result to the expected counts. The definition must have
+// passed lintLanguage without errors.
+func checkSamples(name string, l Language) []languageProblem {
+	var problems []languageProblem
+	report := func(format string, a ...any) {
+		problems = append(problems, languageProblem{Language: name, Severity: severityError, Message: fmt.Sprintf(format, a...)})
+	}
+
+	// The samples are counted against this definition rather than the one in
+	// LanguageFeatures, which may be a different language of the same name
+	features := newFeatureCache(false)
+	features.features[name] = buildLanguageFeature(name, l, false)
+	settings := &countSettings{features: features, checkBinary: true}
+
+	for i, sample := range l.Samples {
+		if sample.Content == "" {
+			report("samples[%d].content must not be empty", i)
+			continue
+		}
+		job := &FileJob{
+			Language: name,
+			Filename: fmt.Sprintf("samples[%d]", i),
+			Location: fmt.Sprintf("samples[%d]", i),
+			Content:  []byte(sample.Content),
+			Bytes:    int64(len(sample.Content)),
+			settings: settings,
+		}
+		CountStats(job)
+		if job.Code != sample.Code || job.Comment != sample.Comment || job.Blank != sample.Blank {
+			report("samples[%d]: expected %d code, %d comment and %d blank lines got %d, %d and %d", i, sample.Code, sample.Comment, sample.Blank, job.Code, job.Comment, job.Blank)
+		}
+	}
+	return problems
+}
+
+// validateLanguages lints the languages in files, or the built-in ones when
+// there are none, along with the conflicts between them and the built-ins
+func validateLanguages(files []string) (languageValidation, error) {
+	var result languageValidation
+
+	db := make(map[string]Language, len(languageDatabase))
+	for name, value := range languageDatabase {
+		if !languageOverlay[name] {
+			db[name] = value
+		}
+	}
+	overlay := map[string]bool{}
+	sources := map[string]string{}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return result, fmt.Errorf("could not read languages file: %w", err)
+		}
+		names, raw, err := splitLanguages(data)
+		if err != nil {
+			result.Problems = append(result.Problems, languageProblem{Source: file, Severity: severityError, Message: err.Error()})
+			continue
+		}
+		for _, name := range names {
+			l, err := decodeLanguage(raw[name])
+			if err != nil {
+				result.Problems = append(result.Problems, languageProblem{Source: file, Language: name, Severity: severityError, Message: err.Error()})
+				continue
+			}
+			db[name] = l
+			overlay[name] = true
+			sources[name] = file
+		}
+	}
+
+	var names []string
+	for name := range db {
+		if len(files) == 0 || overlay[name] {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var problems []languageProblem
+	for _, name := range names {
+		lint := lintLanguage(name, db[name])
+		problems = append(problems, lint...)
+		// A definition with structural errors cannot be built into the
+		// features its samples are counted with
+		if slices.ContainsFunc(lint, func(p languageProblem) bool { return p.Severity == severityError }) {
+			continue
+		}
+		problems = append(problems, checkSamples(name, db[name])...)
+		result.Samples += len(db[name].Samples)
+	}
+	result.Languages = len(names)
+	problems = append(problems, languageConflicts(db, overlay)...)
+
+	for i := range problems {
+		problems[i].Source = builtinLanguages
+		if source, ok := sources[problems[i].Language]; ok {
+			problems[i].Source = source
+		}
+	}
+	slices.SortStableFunc(problems, func(a, b languageProblem) int {
+		return strings.Compare(a.Language, b.Language)
+	})
+	result.Problems = append(result.Problems, problems...)
+	return result, nil
+}
+
+// runValidateLanguagesReport is the dispatch entry point called from Process()
+// when --validate-languages is set. It reports whether no errors were found.
+func runValidateLanguagesReport(files []string) (bool, error) {
+	result, err := validateLanguages(files)
+	if err != nil {
+		return false, err
+	}
+
+	out, err := renderLanguageValidation(result)
+	if err != nil {
+		return false, err
+	}
+	if FileOutput == "" {
+		fmt.Print(out)
+	} else {
+		if err := os.WriteFile(FileOutput, []byte(out), 0644); err != nil {
+			return false, err
+		}
+		fmt.Println("results written to " + FileOutput)
+	}
+	return result.count(severityError) == 0, nil
+}
+
+func renderLanguageValidation(result languageValidation) (string, error) {
+	switch strings.ToLower(Format) {
+	case "", "tabular", "wide":
+		return renderLanguageValidationTabular(result), nil
+	case "json":
+		return renderLanguageValidationJSON(result)
+	default:
+		return "", fmt.Errorf("unsupported --format %q for --validate-languages (supported: tabular, json)", Format)
+	}
+}
+
+func renderLanguageValidationTabular(result languageValidation) string {
+	var sb strings.Builder
+	for _, p := range result.Problems {
+		where := p.Source
+		if p.Language != "" {
+			where = p.Language
+		}
+		fmt.Fprintf(&sb, "%-7s %s: %s\n", p.Severity, where, p.Message)
+	}
+	fmt.Fprintf(&sb, "checked %d languages and %d samples: %d errors, %d warnings\n",
+		result.Languages, result.Samples, result.count(severityError), result.count(severityWarning))
+	return sb.String()
+}
+
+type languageValidationJSONDoc struct {
+	Report string `json:"report"`
+	languageValidation
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+}
+
+func renderLanguageValidationJSON(result languageValidation) (string, error) {
+	if result.Problems == nil {
+		result.Problems = []languageProblem{}
+	}
+	out, err := jsoniter.Marshal(languageValidationJSONDoc{
+		Report:             "languages",
+		languageValidation: result,
+		Errors:             result.count(severityError),
+		Warnings:           result.count(severityWarning),
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLiteralsCover(t *testing.T) {
+	for _, tc := range []struct {
+		pattern  string
+		literals []string
+		expected bool
+	}{
+		{`std::\w+`, []string{"std::"}, true},
+		{`(?m)^[ \t]*(try|constexpr)`, []string{"try", "constexpr"}, true},
+		{`(?m)^[ \t]*(try|constexpr)`, []string{"try"}, false},
+		{`@(interface|end)\b|#import\s+.+`, []string{"@interface", "@end", "#import"}, true},
+		{`include <(i|o|io)stream>`, []string{"<istream>", "<ostream>", "<iostream>"}, true},
+		{`(foo)+bar`, []string{"foo"}, true},
+		{`(foo)*bar`, []string{"foo"}, false},
+		{`fo+`, []string{"foo"}, false},
+		{`(?i)widget`, []string{"widget"}, false},
+		{`widget`, []string{"gadget"}, false},
+	} {
+		if got := literalsCover(tc.pattern, tc.literals); got != tc.expected {
+			t.Errorf("%s %q: expected %t got %t", tc.pattern, tc.literals, tc.expected, got)
+		}
+	}
+}
+
+func TestLintLanguage(t *testing.T) {
+	problems := lintLanguage("X", Language{
+		Extensions:      []string{"x"},
+		MultiLine:       [][]string{{"#|", "#|"}},
+		NestedMultiLine: true,
+		Heuristics:      []Heuristic{{Pattern: "fo+", Literals: []string{"foo"}}},
+	})
+	if len(problems) != 2 || !strings.Contains(problems[0].Message, "multi_line[0]") || !strings.Contains(problems[1].Message, "heuristics[0].literals") {
+		t.Errorf("unexpected problems %+v", problems)
+	}
+}
+
+func TestLanguageConflicts(t *testing.T) {
+	db := map[string]Language{
+		"A":      {Extensions: []string{"a", "shared"}, FileNames: []string{"build"}},
+		"B":      {Extensions: []string{"shared"}, FileNames: []string{"build"}},
+		"C":      {Extensions: []string{"shared"}, Keywords: []string{"c"}},
+		"Keyed":  {Extensions: []string{"k"}, Keywords: []string{"k"}},
+		"Keyed2": {Extensions: []string{"k"}},
+		// The classifier has samples of both so can tell them apart
+		"LaTeX": {Extensions: []string{"tex"}},
+		"TeX":   {Extensions: []string{"tex"}},
+	}
+	problems := languageConflicts(db, nil)
+	var messages []string
+	for _, p := range problems {
+		messages = append(messages, p.Language+": "+p.Message)
+	}
+	got := strings.Join(messages, "\n")
+	for _, expected := range []string{
+		`B: extension "shared" is shared with A and neither`,
+		`A: filename "build" is also claimed by B`,
+		`B: filename "build" is also claimed by A`,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected %q in\n%s", expected, got)
+		}
+	}
+	// One fallback with keywords to beat it is how disambiguation is meant to work
+	if len(problems) != 3 {
+		t.Errorf("expected 3 problems got\n%s", got)
+	}
+
+	DisableClassifier = true
+	problems = languageConflicts(db, nil)
+	DisableClassifier = false
+	if len(problems) != 4 || problems[1].Message != `extension "tex" is shared with LaTeX and neither has heuristics or keywords to tell them apart, so those files are always counted as LaTeX` {
+		t.Errorf("expected tex to conflict without the classifier got %+v", problems)
+	}
+
+	// An overlay language owns what it claims, so only the replacement is noted
+	problems = languageConflicts(db, map[string]bool{"B": true})
+	if len(problems) != 3 || problems[0].Message != `extension "shared" is also claimed by the built-in A, which it replaces for those files` {
+		t.Errorf("unexpected overlay problems %+v", problems)
+	}
+}
+
+func TestCheckSamples(t *testing.T) {
+	l := Language{
+		Extensions:  []string{"x"},
+		LineComment: []string{"#"},
+		Samples: []LanguageSample{
+			{Content: "# comment\ncode\n\n", Code: 1, Comment: 1, Blank: 1},
+			{Content: "code\n", Code: 2},
+			{},
+		},
+	}
+	problems := checkSamples("X", l)
+	if len(problems) != 2 || problems[0].Message != "samples[1]: expected 2 code, 0 comment and 0 blank lines got 1, 0 and 0" || problems[1].Message != "samples[2].content must not be empty" {
+		t.Errorf("unexpected problems %+v", problems)
+	}
+}
+
+func TestValidateBuiltinLanguages(t *testing.T) {
+	result, err := validateLanguages(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Languages != len(languageDatabase) || result.Samples == 0 {
+		t.Errorf("expected every language and some samples checked got %d languages %d samples", result.Languages, result.Samples)
+	}
+	for _, p := range result.Problems {
+		if p.Severity == severityError || strings.Contains(p.Message, "always counted as") {
+			t.Errorf("%s: %s", p.Language, p.Message)
+		}
+	}
+}
+
+func TestValidateLanguagesFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "languages.json")
+	err := os.WriteFile(name, []byte(`{
+		"Widget": {
+			"extensions": ["wdg"],
+			"line_comment": ["--"],
+			"samples": [{"content": "-- c\nx\n", "code": 1, "comment": 1}]
+		},
+		"Broken": {"extension": ["b"]},
+		"Unclosed": {
+			"extensions": ["unc"],
+			"multi_line": [["/*"]],
+			"samples": [{"content": "/* c */\nx\n", "code": 1, "comment": 1}]
+		}
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := validateLanguages([]string{name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The malformed pair is reported rather than its samples counted
+	if result.Languages != 2 || result.Samples != 1 || len(result.Problems) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if p := result.Problems[0]; p.Source != name || p.Language != "Broken" || p.Severity != severityError {
+		t.Errorf("unexpected problem %+v", p)
+	}
+	if p := result.Problems[1]; p.Language != "Unclosed" || p.Message != "multi_line[0] must be a [start, end] pair" {
+		t.Errorf("unexpected problem %+v", p)
+	}
+
+	saved := Format
+	t.Cleanup(func() { Format = saved })
+	Format = "json"
+	out, err := renderLanguageValidation(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc languageValidationJSONDoc
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc.Report != "languages" || doc.Errors != 2 || doc.Warnings != 0 || len(doc.Problems) != 2 {
+		t.Errorf("unexpected json %s", out)
+	}
+
+	Format = "tabular"
+	out, err = renderLanguageValidation(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "error   Broken: ") || !strings.Contains(out, "checked 2 languages and 1 samples: 2 errors, 0 warnings") {
+		t.Errorf("unexpected tabular\n%s", out)
+	}
+
+	if _, err := validateLanguages([]string{filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+// vendorConventions are the paths of checked in third party code, after the
+// vendor.yml list linguist uses, matched as a pathRule is
+var vendorConventions = []string{
+	// Dependency directories of package managers and build tools
+	"**/vendor/**",
+	"**/vendors/**",
+	"**/node_modules/**",
+	"**/bower_components/**",
+	"**/jspm_packages/**",
+	"**/third_party/**",
+	"**/third-party/**",
+	"**/thirdparty/**",
+	"**/3rdparty/**",
+	"**/extern/**",
+	"**/external/**",
+	"**/Godeps/_workspace/**",
+	"**/Pods/**",
+	"**/Carthage/Checkouts/**",
+	"**/Carthage/Build/**",
+	"**/site-packages/**",
+	"**/.yarn/releases/**",
+	"**/.yarn/plugins/**",
+
+	// Wrapper scripts generated by Gradle and Maven
+	"gradlew",
+	"gradlew.bat",
+	"**/gradle/wrapper/**",
+	"mvnw",
+	"mvnw.cmd",
+	"**/.mvn/wrapper/**",
+
+	// Minified and copied in libraries
+	"*.min.js",
+	"*.min.css",
+	"*-min.js",
+	"jquery*.js",
+	"jquery*.css",
+	"bootstrap*.js",
+	"bootstrap*.css",
+	"angular.js",
+	"angular.min.js",
+	"backbone.js",
+	"backbone-min.js",
+	"underscore.js",
+	"underscore-min.js",
+	"lodash.js",
+	"lodash.min.js",
+	"lodash.core.js",
+	"modernizr.js",
+	"modernizr-*.js",
+	"modernizr.custom.*.js",
+	"require.js",
+	"d3.js",
+	"d3.min.js",
+	"d3.v*.js",
+	"three.js",
+	"three.min.js",
+	"three.module.js",
+	"normalize.css",
+	"font-awesome*.css",
+}
+
+// vendorRules tells vendored files apart using the conventions above, the
+// --vendor-pattern globs applied after them in order so the last one matching
+// a file wins, and then linguist-vendored in .gitattributes which overrides
+// both
+type vendorRules struct {
+	rules []pathRule
+}
+
+// newVendorRules compiles the --vendor-pattern globs, dropping malformed ones
+func newVendorRules(patterns []string) *vendorRules {
+	return &vendorRules{rules: newPathRules("vendor-pattern", patterns)}
+}
+
+// match reports whether the file at location is vendored, given the state of
+// its linguist-vendored attribute, see matchPathRules. A nil vendorRules only
+// honours the attribute.
+func (v *vendorRules) match(location string, attribute attributeState) bool {
+	switch attribute {
+	case attributeSet:
+		return true
+	case attributeUnset:
+		return false
+	}
+	if v == nil {
+		return false
+	}
+	rel := rulePath(location)
+
+	vendored := false
+	for _, pattern := range vendorConventions {
+		if matchAttributePattern(pattern, rel) {
+			vendored = true
+			break
+		}
+	}
+	return matchPathRules(v.rules, rel, vendored)
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: MIT
+
+package processor
+
+import (
+	"testing"
+)
+
+func TestVendorRulesMatch(t *testing.T) {
+	rules := newVendorRules(nil)
+	for _, tc := range []struct {
+		location string
+		expected bool
+	}{
+		{"vendor/github.com/x/y.go", true},
+		{"./web/node_modules/react/index.js", true},
+		{"src/third_party/zlib/inflate.c", true},
+		{"ios/Pods/Alamofire/Source/AF.swift", true},
+		{"gradlew", true},
+		{"gradle/wrapper/gradle-wrapper.properties", true},
+		{"static/js/jquery-3.1.1.js", true},
+		{"static/app.min.js", true},
+		{"static/css/bootstrap.min.css", true},
+		{"static/js/three.module.js", true},
+		{"static/js/d3.v7.min.js", true},
+		{"static/js/angular.js", true},
+		{"static/js/underscore.js", true},
+		{"static/js/backbone-min.js", true},
+		{"static/js/modernizr-2.8.3.js", true},
+		{"/abs/project/Godeps/_workspace/src/a.go", true},
+		{"src/vendored.go", false},
+		{"src/vendor.go", false},
+		{"static/js/app.js", false},
+		{"src/threeWayMerge.js", false},
+		{"src/d3Helpers.js", false},
+		{"src/angularSetup.js", false},
+		{"src/underscoreKeys.js", false},
+		{"src/backboneSync.js", false},
+		{"src/lodashMixins.js", false},
+		{"cmd/main.go", false},
+	} {
+		if got := rules.match(tc.location, attributeUnspecified); got != tc.expected {
+			t.Errorf("%s: expected %t got %t", tc.location, tc.expected, got)
+		}
+	}
+
+	// .gitattributes wins over the conventions either way
+	if rules.match("vendor/a.go", attributeUnset) || !rules.match("lib/a.go", attributeSet) {
+		t.Error("expected linguist-vendored to override the built-in paths")
+	}
+
+	var none *vendorRules
+	if none.match("vendor/a.go", attributeUnspecified) || !none.match("lib/a.go", attributeSet) {
+		t.Error("expected nil rules to honour only the attribute")
+	}
+}
+
+func TestVendorRulesPatterns(t *testing.T) {
+	rules := newVendorRules([]string{"**/lib/ext/**", "!**/external/**", "[", "!/"})
+	if len(rules.rules) != 2 {
+		t.Fatalf("expected the malformed patterns to be dropped got %+v", rules.rules)
+	}
+	for _, tc := range []struct {
+		location string
+		expected bool
+	}{
+		{"src/lib/ext/a.c", true},
+		{"src/external/b.c", false},
+		{"src/extern/c.c", true},
+	} {
+		if got := rules.match(tc.location, attributeUnspecified); got != tc.expected {
+			t.Errorf("%s: expected %t got %t", tc.location, tc.expected, got)
+		}
+	}
+}
+
+func TestVendorRun(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"main.go":           "package main\n\nfunc main() {}\n",
+		"vendor/x/x.go":     "package x\n",
+		"web/jquery.min.js": "var a=1;\n",
+		"external/a.c":      "int x;\n",
+		"docs/b.c":          "int y;\n",
+	})
+	if got := languageCounts(runTree(t, dir, DefaultOptions())); got != "C=2,Go=2,JavaScript=1" {
+		t.Errorf("expected nothing vendored by default got %s", got)
+	}
+
+	opts := DefaultOptions()
+	opts.Vendored = true
+	if got := languageCounts(runTree(t, dir, opts)); got != "C=1,C (vendor)=1,Go=1,Go (vendor)=1,JavaScript (vendor)=1" {
+		t.Errorf("unexpected --vendor run %s", got)
+	}
+
+	opts = DefaultOptions()
+	opts.IgnoreVendored = true
+	opts.VendorPatterns = []string{"!**/external/**"}
+	if got := languageCounts(runTree(t, dir, opts)); got != "C=2,Go=1" {
+		t.Errorf("unexpected --no-vendor run %s", got)
+	}
+
+	// A pattern alone turns detection on
+	opts = DefaultOptions()
+	opts.VendorPatterns = []string{"**/docs/**"}
+	if got := languageCounts(runTree(t, dir, opts)); got != "C (vendor)=2,Go=1,Go (vendor)=1,JavaScript (vendor)=1" {
+		t.Errorf("unexpected --vendor-pattern run %s", got)
+	}
+}
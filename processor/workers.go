@@ -5,7 +5,9 @@ package processor
 import (
 	"bytes"
 	"hash"
+	"io/fs"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -67,6 +69,50 @@ var duplicates = CheckDuplicates{
 	hashes: make(map[int64][][]byte),
 }
 
+// countSettings is the subset of a run's options read while counting a single
+// file. The pipeline attaches it to every FileJob so CountStats never consults
+// the package-level flags mid-run; a FileJob without settings, as passed to
+// CountStats directly by library users, reads the flags instead.
+type countSettings struct {
+	features               *featureCache // nil uses the shared LanguageFeatures map
+	cognitive              bool
+	duplicates             bool
+	checkBinary            bool
+	noLarge                bool
+	largeLineCount         int64
+	uloc                   bool
+	maxMean                bool
+	generated              bool
+	generatedMarkers       []string
+	minified               bool
+	minifiedLineByteLength int
+}
+
+// globalCountSettings snapshots the package-level flags into countSettings
+func globalCountSettings() *countSettings {
+	return &countSettings{
+		cognitive:              Cognitive,
+		duplicates:             Duplicates,
+		checkBinary:            !DisableCheckBinary,
+		noLarge:                NoLarge,
+		largeLineCount:         LargeLineCount,
+		uloc:                   UlocMode,
+		maxMean:                MaxMean,
+		generated:              Generated,
+		generatedMarkers:       GeneratedMarkers,
+		minified:               Minified,
+		minifiedLineByteLength: MinifiedGeneratedLineByteLength,
+	}
+}
+
+// feature returns the counting features for a language
+func (s *countSettings) feature(name string) LanguageFeature {
+	if s.features != nil {
+		return s.features.get(name)
+	}
+	return sharedLanguageFeature(name)
+}
+
 func checkForMatchSingle(currentByte byte, index int, endPoint int, matches []byte, fileJob *FileJob) bool {
 	potentialMatch := true
 	if currentByte == matches[0] {
@@ -175,7 +221,7 @@ func (fileJob *FileJob) bumpComplexityLine() {
 // line it appears on. An approximation of nested complexity, but with almost
 // no calculation overhead.
 func (fileJob *FileJob) bumpCognitive() {
-	if Cognitive {
+	if fileJob.cognitiveEnabled() {
 		weight := 1 + int64(fileJob.cognitiveNesting)
 		fileJob.Cognitive += weight
 		if n := len(fileJob.CognitiveLine); n > 0 {
@@ -184,10 +230,23 @@ func (fileJob *FileJob) bumpCognitive() {
 	}
 }
 
+// cognitiveEnabled reports whether cognitive complexity is being counted for
+// this file
+func (fileJob *FileJob) cognitiveEnabled() bool {
+	if fileJob.settings != nil {
+		return fileJob.settings.cognitive
+	}
+	return Cognitive
+}
+
 // Check if this file is binary by checking for nul byte and if so bail out
 // this is how GNU Grep, git and ripgrep check for binary files
 func isBinary(index int, currentByte byte) bool {
-	return index < 10000 && !DisableCheckBinary && currentByte == 0
+	return isBinaryByte(index, currentByte, !DisableCheckBinary)
+}
+
+func isBinaryByte(index int, currentByte byte, checkBinary bool) bool {
+	return index < 10000 && checkBinary && currentByte == 0
 }
 
 func shouldProcess(currentByte, processBytesMask byte) bool {
@@ -338,13 +397,13 @@ func codeState(
 			return i, currentState, endString, endComments, false
 		}
 
-		if isBinary(i, curByte) {
+		if isBinaryByte(i, curByte, fileJob.settings.checkBinary) {
 			fileJob.Binary = true
 			return i, currentState, endString, endComments, false
 		}
 
 		if shouldProcess(curByte, langFeatures.ProcessMask) {
-			if Duplicates {
+			if fileJob.settings.duplicates {
 				// Technically this is wrong because we skip bytes, so this is not a true
 				// hash of the file contents, but for duplicate files it shouldn't matter
 				// as both will skip the same way
@@ -388,6 +447,7 @@ func codeState(
 				}
 				// Skip past the matched token so a shorter token overlapping it
 				// (e.g. 為是 inside 恆為是) is not also counted. See #466.
+				markTokenCode(fileJob, i, offsetJump)
 				i += offsetJump - 1
 
 			case TComplexityPostfix:
@@ -446,6 +506,17 @@ func commentState(fileJob *FileJob, index int, endPoint int, currentState int64,
 	return index, currentState, endString, endComments
 }
 
+// markTokenCode classifies the bytes of a complexity token skipped over
+// after its first as code, as the state machine never visits them
+func markTokenCode(fileJob *FileJob, index int, offsetJump int) {
+	if fileJob.ContentByteType == nil {
+		return
+	}
+	for i := index + 1; i < index+offsetJump && i < len(fileJob.ContentByteType); i++ {
+		fileJob.ContentByteType[i] = ByteTypeCode
+	}
+}
+
 func blankState(
 	fileJob *FileJob,
 	index int,
@@ -503,6 +574,7 @@ func blankState(
 		}
 		// Skip past the matched token so a shorter token overlapping it
 		// (e.g. 為是 inside 恆為是) is not also counted. See #466.
+		markTokenCode(fileJob, index, offsetJump)
 		index += offsetJump - 1
 
 	case TComplexityPostfix:
@@ -564,12 +636,20 @@ func verifyIgnoreEscape(langFeatures LanguageFeature, fileJob *FileJob, index in
 // Newlines belong to the line they started on so a file of \n means only 1 line
 // This is the 'hot' path for the application and needs to be as fast as possible
 func CountStats(fileJob *FileJob) {
+	settings := fileJob.settings
+	if settings == nil {
+		settings = globalCountSettings()
+		fileJob.settings = settings
+		defer func() { fileJob.settings = nil }()
+	}
+	cognitive := settings.cognitive
+
 	// For determining duplicates we need the below. The reason for creating
 	// the byte array here is to avoid GC pressure. MD5 is in the standard library
 	// and is fast enough to not warrant murmur3 hashing. No need to be
 	// crypto secure here either so no need to eat the performance cost of a better
 	// hash method
-	if Duplicates {
+	if settings.duplicates {
 		fileJob.Hash, _ = blake2b.New256(nil)
 	}
 
@@ -579,9 +659,7 @@ func CountStats(fileJob *FileJob) {
 		return
 	}
 
-	LanguageFeaturesMutex.Lock()
-	langFeatures := LanguageFeatures[fileJob.Language]
-	LanguageFeaturesMutex.Unlock()
+	langFeatures := settings.feature(fileJob.Language)
 
 	if langFeatures.Complexity == nil {
 		langFeatures.Complexity = &Trie{}
@@ -608,7 +686,7 @@ func CountStats(fileJob *FileJob) {
 	ignoreEscape := false
 	if fileJob.TrackComplexityLines {
 		fileJob.ComplexityLine = append(fileJob.ComplexityLine, 0)
-		if Cognitive {
+		if cognitive {
 			fileJob.CognitiveLine = append(fileJob.CognitiveLine, 0)
 		}
 	}
@@ -656,7 +734,7 @@ func CountStats(fileJob *FileJob) {
 			// nesting depth. Lines that begin a comment must not move the stack;
 			// lines inside a multiline comment/string never reach here in a
 			// blank-derived state so they are excluded automatically.
-			if Cognitive && needIndent && (currentState == SBlank || currentState == SMulticommentBlank) {
+			if cognitive && needIndent && (currentState == SBlank || currentState == SMulticommentBlank) {
 				if tokenType, _, _ := langFeatures.Tokens.Match(fileJob.Content[index:]); tokenType != TSlcomment && tokenType != TMlcomment {
 					indent := index - lineStart
 					for len(indentStack) > 0 && indent < indentStack[len(indentStack)-1] {
@@ -733,18 +811,18 @@ func CountStats(fileJob *FileJob) {
 		// we are currently in
 		if fileJob.Content[index] == '\n' || index >= endPoint {
 			fileJob.Lines++
-			if Cognitive {
+			if cognitive {
 				lineStart = index + 1
 				needIndent = true
 			}
 			if fileJob.TrackComplexityLines {
 				fileJob.ComplexityLine = append(fileJob.ComplexityLine, 0)
-				if Cognitive {
+				if cognitive {
 					fileJob.CognitiveLine = append(fileJob.CognitiveLine, 0)
 				}
 			}
 
-			if NoLarge && fileJob.Lines >= LargeLineCount {
+			if settings.noLarge && fileJob.Lines >= settings.largeLineCount {
 				// Save memory by unsetting the content as we no longer require it
 				fileJob.Content = nil
 				return
@@ -801,7 +879,7 @@ func CountStats(fileJob *FileJob) {
 		}
 	}
 
-	if UlocMode {
+	if settings.uloc {
 		uloc := map[string]struct{}{}
 		for l := range strings.SplitSeq(strings.TrimRight(string(fileJob.Content), "\n"), "\n") {
 			uloc[l] = struct{}{}
@@ -809,7 +887,7 @@ func CountStats(fileJob *FileJob) {
 		fileJob.Uloc = len(uloc)
 	}
 
-	if MaxMean {
+	if settings.maxMean {
 		for l := range strings.SplitSeq(strings.TrimRight(string(fileJob.Content), "\n"), "\n") {
 			fileJob.LineLength = append(fileJob.LineLength, len(l))
 		}
@@ -817,10 +895,17 @@ func CountStats(fileJob *FileJob) {
 
 	isGenerated := false
 
-	if Generated {
+	// linguist-generated in .gitattributes settles it either way, otherwise
+	// the head of the file is checked for a marker
+	if settings.generated && fileJob.attributes.generated == attributeSet {
+		fileJob.Generated = true
+		fileJob.Language = fileJob.Language + " (gen)"
+		isGenerated = true
+		printWarnF("%s identified as isGenerated with linguist-generated", fileJob.Filename)
+	} else if settings.generated && fileJob.attributes.generated == attributeUnspecified {
 		headLen := min(1000, len(fileJob.Content))
 		head := bytes.ToLower(fileJob.Content[0:headLen])
-		for _, marker := range GeneratedMarkers {
+		for _, marker := range settings.generatedMarkers {
 			if bytes.Contains(head, bytes.ToLower([]byte(marker))) {
 				fileJob.Generated = true
 				fileJob.Language = fileJob.Language + " (gen)"
@@ -832,26 +917,31 @@ func CountStats(fileJob *FileJob) {
 	}
 
 	// check if 0 as well to avoid divide by zero https://github.com/boyter/scc/issues/223
-	if !isGenerated && Minified && fileJob.Lines != 0 {
+	if !isGenerated && settings.minified && fileJob.Lines != 0 {
 		avgLineByteCount := len(fileJob.Content) / int(fileJob.Lines)
 		minifiedGeneratedCheck(avgLineByteCount, fileJob)
 	}
 
 	if fileJob.TrackComplexityLines {
 		fileJob.ComplexityLine = fileJob.ComplexityLine[:fileJob.Lines]
-		if Cognitive {
+		if cognitive {
 			fileJob.CognitiveLine = fileJob.CognitiveLine[:fileJob.Lines]
 		}
 	}
 }
 
 func minifiedGeneratedCheck(avgLineByteCount int, fileJob *FileJob) {
-	if avgLineByteCount >= MinifiedGeneratedLineByteLength {
+	lineByteLength := MinifiedGeneratedLineByteLength
+	if fileJob.settings != nil {
+		lineByteLength = fileJob.settings.minifiedLineByteLength
+	}
+
+	if avgLineByteCount >= lineByteLength {
 		fileJob.Minified = true
 		fileJob.Language = fileJob.Language + " (min)"
-		printWarnF("%s identified as minified/generated with average line byte length of %d >= %d", fileJob.Filename, avgLineByteCount, MinifiedGeneratedLineByteLength)
+		printWarnF("%s identified as minified/generated with average line byte length of %d >= %d", fileJob.Filename, avgLineByteCount, lineByteLength)
 	} else {
-		printDebugF("%s not identified as minified/generated with average line byte length of %d < %d", fileJob.Filename, avgLineByteCount, MinifiedGeneratedLineByteLength)
+		printDebugF("%s not identified as minified/generated with average line byte length of %d < %d", fileJob.Filename, avgLineByteCount, lineByteLength)
 	}
 }
 
@@ -878,17 +968,20 @@ func checkBomSkip(fileJob *FileJob) int {
 
 // Reads and processes files from input chan in parallel, and sends results to
 // output chan
-func (ctx processorContext) fileProcessorWorker(input chan *FileJob, output chan *FileJob) {
+func (ctx *processorContext) fileProcessorWorker(input chan *FileJob, output chan *FileJob) {
 	var startTime int64
 	var fileCount int64
 	var gcEnabled int64
 	var wg sync.WaitGroup
 
-	for i := 0; i < FileProcessJobWorkers; i++ {
+	for i := 0; i < ctx.options().FileProcessJobWorkers; i++ {
 		wg.Go(func() {
 			reader := NewFileReader()
 
 			for job := range input {
+				if ctx.cancelled() {
+					continue
+				}
 				atomic.CompareAndSwapInt64(&startTime, 0, makeTimestampMilli())
 
 				loc := job.Location
@@ -897,7 +990,15 @@ func (ctx processorContext) fileProcessorWorker(input chan *FileJob, output chan
 				}
 
 				fileStartTime := makeTimestampNano()
-				content, err := reader.ReadFile(loc, int(job.Bytes))
+				var content []byte
+				var err error
+				if job.fsys != nil {
+					content, err = fs.ReadFile(job.fsys, job.fsysName)
+					// The job outlives the run, and must not keep an archive alive
+					job.fsys = nil
+				} else {
+					content, err = reader.ReadFile(loc, int(job.Bytes))
+				}
 				atomic.AddInt64(&fileCount, 1)
 
 				if atomic.LoadInt64(&gcEnabled) == 0 && atomic.LoadInt64(&fileCount) >= int64(GcFileCount) {
@@ -916,6 +1017,7 @@ func (ctx processorContext) fileProcessorWorker(input chan *FileJob, output chan
 				} else {
 					printWarnF("error reading: %s %s", job.Location, err)
 				}
+				ctx.progress.fileProcessed()
 			}
 
 		})
@@ -923,6 +1025,8 @@ func (ctx processorContext) fileProcessorWorker(input chan *FileJob, output chan
 
 	go func() {
 		wg.Wait()
+		closeAll(ctx.closers)
+		ctx.progress.done()
 		close(output)
 
 		printDebugF("milliseconds reading files into memory: %d", makeTimestampMilli()-startTime)
@@ -931,67 +1035,126 @@ func (ctx processorContext) fileProcessorWorker(input chan *FileJob, output chan
 
 // Process a single file
 // File must have been read to job.Content already
-func (ctx processorContext) processFile(job *FileJob) bool {
+func (ctx *processorContext) processFile(job *FileJob) bool {
 	fileStartTime := makeTimestampNano()
 
+	opts := ctx.options()
 	contents := job.Content
+	job.settings = ctx.settings
 
-	// Needs to always run to ensure the language is set
-	job.Language = DetermineLanguage(job.Filename, job.Language, job.PossibleLanguages, job.Content)
-
+	// --remap-unknown is asked for explicitly so for files with no extension
+	// it is tried before anything in the file itself, such as a modeline
 	remapped := false
+	if job.attributes.language == "" && len(ctx.remap.unknown) != 0 && slices.Equal(job.PossibleLanguages, []string{SheBang}) {
+		job.Language = SheBang
+		remapped = ctx.unknownRemapLanguage(job)
+	}
+
+	// Needs to always run to ensure the language is set, unless .gitattributes
+	// forces it with linguist-language
+	switch {
+	case remapped:
+	case job.attributes.language != "":
+		job.Language = job.attributes.language
+	default:
+		job.Language = determineLanguage(job.Filename, job.Language, job.PossibleLanguages, job.Content, ctx.feature, !opts.DisableClassifier)
+
+		// A modeline can name a language the file was not a candidate for
+		if len(job.PossibleLanguages) != 0 && !slices.Contains(job.PossibleLanguages, job.Language) {
+			ctx.loadLanguageFeature(job.Language)
+		}
+	}
+
 	if len(ctx.remap.all) != 0 {
 		ctx.hardRemapLanguage(job)
 	}
 
 	// If the type is #! we should check to see if we can identify
 	if job.Language == SheBang {
-		if len(ctx.remap.unknown) != 0 {
-			remapped = ctx.unknownRemapLanguage(job)
+		cutoff := min(200, len(contents))
+
+		lang, err := DetectSheBang(contents[:cutoff])
+		if err != nil {
+			printWarnF("unable to determine #! language for %s", job.Location)
+			return false
 		}
 
-		// if we didn't remap we then want to see if it's a #! map
-		if !remapped {
-			cutoff := min(200, len(contents))
+		printWarnF("detected #! %s for %s", lang, job.Location)
+		job.Language = lang
+		ctx.loadLanguageFeature(lang)
+	}
 
-			lang, err := DetectSheBang(contents[:cutoff])
-			if err != nil {
-				printWarnF("unable to determine #! language for %s", job.Location)
-				return false
-			}
+	// Finding functions needs to know what each byte and line is
+	var lineTypes *historyLineCallback
+	if opts.functions && job.Callback == nil {
+		lineTypes = &historyLineCallback{}
+		job.Callback = lineTypes
+		job.TrackComplexityLines = true
+		job.ClassifyContent = true
+	}
 
-			printWarnF("detected #! %s for %s", lang, job.Location)
-			job.Language = lang
-			LoadLanguageFeature(lang)
-		}
+	// Notebooks are counted by their cells rather than the JSON holding them
+	var notebook *notebookText
+	if job.Language == jupyter {
+		notebook = ctx.readNotebook(job)
 	}
 
+	// CountStats may mark the language minified or generated
+	host := job.Language
 	CountStats(job)
 
-	if Duplicates {
-		duplicates.mux.Lock()
+	if notebook != nil {
+		countNotebookText(job, notebook)
+		host = job.Language
+	}
+
+	if opts.Duplicates {
+		dups := ctx.duplicateHashes()
+		dups.mux.Lock()
 		jobHash := job.Hash.Sum(nil)
-		if duplicates.Check(job.Bytes, jobHash) {
+		if dups.Check(job.Bytes, jobHash) {
 			printWarnF("skipping duplicate file: %s", job.Location)
-			duplicates.mux.Unlock()
+			dups.mux.Unlock()
 			return false
 		}
 
-		duplicates.Add(job.Bytes, jobHash)
-		duplicates.mux.Unlock()
+		dups.Add(job.Bytes, jobHash)
+		dups.mux.Unlock()
 	}
 
-	if IgnoreMinified && job.Minified {
+	if opts.IgnoreMinified && job.Minified {
 		printWarnF("skipping minified file: %s", job.Location)
 		return false
 	}
 
-	if IgnoreGenerated && job.Generated {
+	if opts.IgnoreGenerated && job.Generated {
 		printWarnF("skipping generated file: %s", job.Location)
 		return false
 	}
 
-	if NoLarge && job.Lines >= LargeLineCount {
+	// Matched on the language without the (gen) or (min) mark counting may
+	// have added, so the language conventions still apply
+	job.Test = ctx.tests.match(job.Location, baseLanguage(job.Language))
+
+	if opts.Vendored && ctx.vendor.match(job.Location, job.attributes.vendored) {
+		if opts.IgnoreVendored {
+			printWarnF("skipping vendored file: %s", job.Location)
+			return false
+		}
+		job.Vendored = true
+		job.Language = job.Language + " (vendor)"
+	}
+
+	if opts.Documentation && job.attributes.documentation == attributeSet {
+		if opts.IgnoreDocumentation {
+			printWarnF("skipping documentation file: %s", job.Location)
+			return false
+		}
+		job.Documentation = true
+		job.Language = job.Language + " (docs)"
+	}
+
+	if opts.NoLarge && job.Lines >= opts.LargeLineCount {
 		printWarnF("skipping large file due to line length: %s", job.Location)
 		return false
 	}
@@ -1003,27 +1166,32 @@ func (ctx processorContext) processFile(job *FileJob) bool {
 		return false
 	}
 
+	if opts.Embedded != "" {
+		ctx.countEmbedded(job, host, opts.Embedded)
+	}
+
 	// This needs to be at the end so we can ensure duplicate detection et.al run first
 	// avoiding inflating the counts
-	if UlocMode {
-		ulocMutex.Lock()
+	if opts.Uloc {
+		ctx.ulocCounts().add(job.Language, job.Content)
+	}
 
-		for l := range strings.SplitSeq(strings.TrimRight(string(job.Content), "\n"), "\n") {
-			ulocGlobalCount[l] = struct{}{}
+	if opts.lineDigests {
+		job.lineDigests = lineDigests(job.Content)
+	}
 
-			_, ok := ulocLanguageCount[job.Language]
-			if !ok {
-				ulocLanguageCount[job.Language] = map[string]struct{}{}
-			}
-			ulocLanguageCount[job.Language][l] = struct{}{}
-		}
-		ulocMutex.Unlock()
+	if lineTypes != nil {
+		job.functions = findFunctions(job, lineTypes.lineTypes)
+		job.Callback = nil
+		job.ContentByteType = nil
+		job.ComplexityLine = nil
+		job.CognitiveLine = nil
 	}
 
 	return true
 }
 
-func (ctx processorContext) hardRemapLanguage(job *FileJob) bool {
+func (ctx *processorContext) hardRemapLanguage(job *FileJob) bool {
 	remapped := false
 	cutoff := min(1000, len(job.Content)) // at most 1000 bytes into the file to look
 
@@ -1038,7 +1206,7 @@ func (ctx processorContext) hardRemapLanguage(job *FileJob) bool {
 	return remapped
 }
 
-func (ctx processorContext) unknownRemapLanguage(job *FileJob) bool {
+func (ctx *processorContext) unknownRemapLanguage(job *FileJob) bool {
 	remapped := false
 	cutoff := min(1000, len(job.Content)) // at most 1000 bytes into the file to look
 
@@ -1257,6 +1257,27 @@ func TestClassifyContentCodeOnly(t *testing.T) {
 	}
 }
 
+// Complexity tokens are skipped over once matched, yet every byte of them
+// should still be ByteTypeCode
+func TestClassifyContentComplexityToken(t *testing.T) {
+	ProcessConstants()
+	fileJob := FileJob{Language: "Go", ClassifyContent: true}
+	fileJob.SetContent("if a && b || c {\n\tfor x := range y {\n\t}\n}\n")
+	CountStats(&fileJob)
+
+	if fileJob.Complexity != 4 {
+		t.Errorf("Expected 4 complexity tokens, got %d", fileJob.Complexity)
+	}
+	for i, b := range fileJob.Content {
+		if b == ' ' || b == '\t' || b == '\n' {
+			continue
+		}
+		if bt := fileJob.ContentByteType[i]; bt != ByteTypeCode {
+			t.Errorf("byte %d (%q): expected ByteTypeCode(%d), got %d", i, string(b), ByteTypeCode, bt)
+		}
+	}
+}
+
 // Comment-only file: "// comment" bytes after // should be ByteTypeComment
 func TestClassifyContentCommentOnly(t *testing.T) {
 	ProcessConstants()
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: MIT
+
+// Command classifier trains the statistical classifier scc uses to tell apart
+// languages sharing an extension. Every file in samples/<Language>/ is split
+// into tokens with processor.ClassifierTokens and the counts are written to
+// processor/classifier_models.go. Run it from the root of the repository,
+// after scripts/include.go when languages were renamed.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/boyter/scc/v3/processor"
+)
+
+const (
+	samplesDir = "./scripts/classifier/samples"
+	modelsFile = "./processor/classifier_models.go"
+)
+
+// train counts the tokens of the samples of each language
+func train() (map[string]processor.ClassifierModel, error) {
+	dirs, err := os.ReadDir(samplesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read samples: %v", err)
+	}
+
+	processor.ProcessConstants()
+	models := map[string]processor.ClassifierModel{}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		name := dir.Name()
+		if _, ok := processor.LanguageFeatures[name]; !ok {
+			return nil, fmt.Errorf("samples for unknown language '%s'", name)
+		}
+
+		files, err := os.ReadDir(filepath.Join(samplesDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read samples for '%s': %v", name, err)
+		}
+		model := processor.ClassifierModel{Tokens: map[string]int{}}
+		for _, f := range files {
+			content, err := os.ReadFile(filepath.Join(samplesDir, name, f.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read sample '%s': %v", f.Name(), err)
+			}
+			for _, token := range processor.ClassifierTokens(content) {
+				model.Tokens[token]++
+				model.Total++
+			}
+		}
+		if model.Total == 0 {
+			return nil, fmt.Errorf("no tokens in the samples for '%s'", name)
+		}
+		models[name] = model
+	}
+	return models, nil
+}
+
+// generateModels writes the models as Go source for the processor package
+func generateModels(models map[string]processor.ClassifierModel) error {
+	vocabulary := map[string]bool{}
+	for _, model := range models {
+		for token := range model.Tokens {
+			vocabulary[token] = true
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("// Code generated by scripts/classifier using 'go generate'. DO NOT EDIT.\npackage processor\n\n")
+	buf.WriteString("// classifierVocabulary is how many distinct tokens all the samples hold\n")
+	fmt.Fprintf(buf, "const classifierVocabulary = %d\n\n", len(vocabulary))
+	buf.WriteString("// classifierModels are the token counts of the samples of each language\n")
+	buf.WriteString("var classifierModels = map[string]ClassifierModel{\n")
+	for _, name := range slices.Sorted(maps.Keys(models)) {
+		model := models[name]
+		fmt.Fprintf(buf, "%s: {\nTotal: %d,\nTokens: map[string]int{\n", strconv.Quote(name), model.Total)
+		for _, token := range slices.Sorted(maps.Keys(model.Tokens)) {
+			fmt.Fprintf(buf, "%s: %d,\n", strconv.Quote(token), model.Tokens[token])
+		}
+		buf.WriteString("},\n},\n")
+	}
+	buf.WriteString("}\n")
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format code: %v", err)
+	}
+	if err := os.WriteFile(modelsFile, source, 0o644); err != nil {
+		return fmt.Errorf("failed to write models file: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	models, err := train()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to train classifier: %v\n", err)
+		os.Exit(1)
+	}
+	if err := generateModels(models); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to generate classifier models: %v\n", err)
+		os.Exit(1)
+	}
+}
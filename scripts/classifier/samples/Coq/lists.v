@@ -0,0 +1,26 @@
+Require Import Coq.Lists.List.
+Require Import Coq.Arith.PeanoNat.
+Import ListNotations.
+
+(** * Reversing lists *)
+
+Fixpoint rev_append {A : Type} (l acc : list A) : list A :=
+  match l with
+  | [] => acc
+  | x :: xs => rev_append xs (x :: acc)
+  end.
+
+Definition rev' {A : Type} (l : list A) : list A := rev_append l [].
+
+Lemma rev_append_rev : forall (A : Type) (l acc : list A),
+  rev_append l acc = rev l ++ acc.
+Proof.
+  intros A l. induction l as [| x xs IH]; intros acc; simpl.
+  - reflexivity.
+  - rewrite IH. rewrite <- app_assoc. reflexivity.
+Qed.
+
+Theorem rev'_correct : forall (A : Type) (l : list A), rev' l = rev l.
+Proof.
+  intros. unfold rev'. rewrite rev_append_rev. apply app_nil_r.
+Qed.
@@ -0,0 +1,35 @@
+(* Natural numbers and evenness *)
+
+Inductive even : nat -> Prop :=
+  | ev_0 : even 0
+  | ev_SS (n : nat) (H : even n) : even (S (S n)).
+
+Fixpoint double (n : nat) : nat :=
+  match n with
+  | O => O
+  | S n' => S (S (double n'))
+  end.
+
+Lemma double_even : forall n, even (double n).
+Proof.
+  induction n as [| n' IHn'].
+  - simpl. apply ev_0.
+  - simpl. apply ev_SS. exact IHn'.
+Qed.
+
+Section Monoid.
+  Variable A : Type.
+  Hypothesis op_assoc : forall (op : A -> A -> A) x y z, op x (op y z) = op (op x y) z.
+
+  Record monoid := {
+    carrier_op : A -> A -> A;
+    unit : A
+  }.
+End Monoid.
+
+Theorem plus_comm' : forall n m : nat, n + m = m + n.
+Proof.
+  intros n m. destruct n; auto with arith.
+  - simpl. rewrite <- plus_n_O. reflexivity.
+  - omega.
+Admitted.
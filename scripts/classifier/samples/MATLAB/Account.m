@@ -0,0 +1,31 @@
+classdef Account < handle
+    % Account a simple bank account
+    properties
+        Balance = 0
+        Owner char
+    end
+    properties (Access = private)
+        History = {}
+    end
+    methods
+        function obj = Account(owner, balance)
+            if nargin > 0
+                obj.Owner = owner;
+                obj.Balance = balance;
+            end
+        end
+        function deposit(obj, amount)
+            if amount <= 0
+                error('Account:invalid', 'amount must be positive');
+            end
+            obj.Balance = obj.Balance + amount;
+            obj.History{end+1} = struct('type', 'deposit', 'amount', amount);
+        end
+        function ok = withdraw(obj, amount)
+            ok = amount <= obj.Balance;
+            if ok
+                obj.Balance = obj.Balance - amount;
+            end
+        end
+    end
+end
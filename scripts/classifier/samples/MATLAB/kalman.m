@@ -0,0 +1,15 @@
+function [x, P] = kalman(z, x, P, A, H, Q, R)
+% KALMAN one predict and update step of a linear Kalman filter
+%   [x, P] = kalman(z, x, P, A, H, Q, R) returns the new state estimate
+%   and covariance given the measurement z.
+
+% Predict
+x = A * x;
+P = A * P * A' + Q;
+
+% Update
+S = H * P * H' + R;
+K = P * H' / S;
+x = x + K * (z - H * x);
+P = (eye(size(P, 1)) - K * H) * P;
+end
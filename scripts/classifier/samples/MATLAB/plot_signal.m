@@ -0,0 +1,35 @@
+%% Plot a noisy signal and its moving average
+clear all; close all; clc;
+
+fs = 1000;                 % sampling frequency
+t = 0:1/fs:1-1/fs;
+signal = sin(2*pi*50*t) + 0.5*randn(size(t));
+
+window = 25;
+smoothed = zeros(size(signal));
+for k = window:length(signal)
+    smoothed(k) = mean(signal(k-window+1:k));
+end
+
+if any(isnan(smoothed))
+    error('smoothing produced NaN values');
+elseif numel(smoothed) ~= numel(signal)
+    warning('length mismatch');
+end
+
+figure;
+subplot(2,1,1);
+plot(t, signal, 'b-');
+title('Raw signal'); xlabel('Time (s)'); ylabel('Amplitude');
+subplot(2,1,2);
+plot(t, smoothed, 'r-', 'LineWidth', 2);
+title(sprintf('Moving average, window %d', window));
+grid on;
+disp(['peak: ', num2str(max(abs(smoothed)))]);
+
+switch lower(getenv('SAVE'))
+    case {'yes', 'true'}
+        saveas(gcf, 'signal.png');
+    otherwise
+        fprintf('not saving\n');
+end
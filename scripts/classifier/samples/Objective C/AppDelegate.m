@@ -0,0 +1,39 @@
+#import "AppDelegate.h"
+#import "ViewController.h"
+
+@implementation AppDelegate
+
+- (BOOL)application:(UIApplication *)application didFinishLaunchingWithOptions:(NSDictionary *)launchOptions {
+    self.window = [[UIWindow alloc] initWithFrame:[[UIScreen mainScreen] bounds]];
+    ViewController *controller = [[ViewController alloc] initWithNibName:nil bundle:nil];
+    self.window.rootViewController = [[UINavigationController alloc] initWithRootViewController:controller];
+    [self.window makeKeyAndVisible];
+
+    NSUserDefaults *defaults = [NSUserDefaults standardUserDefaults];
+    if (![defaults boolForKey:@"launched"]) {
+        [defaults setBool:YES forKey:@"launched"];
+        [defaults synchronize];
+    }
+
+    dispatch_async(dispatch_get_main_queue(), ^{
+        [[NSNotificationCenter defaultCenter] postNotificationName:@"AppReady" object:nil];
+    });
+    return YES;
+}
+
+- (void)applicationWillResignActive:(UIApplication *)application {
+    NSError *error = nil;
+    if (![self saveState:&error]) {
+        NSLog(@"could not save: %@", error.localizedDescription);
+    }
+}
+
+- (BOOL)saveState:(NSError **)error {
+    @try {
+        return [self.store save:error];
+    } @catch (NSException *exception) {
+        return NO;
+    }
+}
+
+@end
@@ -0,0 +1,36 @@
+#import "Widget.h"
+#import <UIKit/UIKit.h>
+
+@interface Widget ()
+@property (nonatomic, strong) NSMutableArray<NSString *> *items;
+@property (nonatomic, assign) BOOL loaded;
+@end
+
+@implementation Widget
+
+- (instancetype)initWithTitle:(NSString *)title {
+    self = [super init];
+    if (self) {
+        _title = [title copy];
+        _items = [NSMutableArray array];
+    }
+    return self;
+}
+
+- (void)addItem:(NSString *)item {
+    if (item == nil) {
+        return;
+    }
+    [self.items addObject:item];
+    NSLog(@"added %@ to %@", item, self.title);
+}
+
+- (NSUInteger)count {
+    return self.items.count;
+}
+
++ (Widget *)widgetWithTitle:(NSString *)title {
+    return [[self alloc] initWithTitle:title];
+}
+
+@end
@@ -0,0 +1,25 @@
+#import <Foundation/Foundation.h>
+
+@protocol Greeter <NSObject>
+- (NSString *)greet:(NSString *)name;
+@end
+
+@interface Friendly : NSObject <Greeter>
+@end
+
+@implementation Friendly
+- (NSString *)greet:(NSString *)name {
+    return [NSString stringWithFormat:@"Hello, %@!", name];
+}
+@end
+
+int main(int argc, const char * argv[]) {
+    @autoreleasepool {
+        id<Greeter> greeter = [[Friendly alloc] init];
+        NSArray *names = @[@"Ada", @"Grace"];
+        for (NSString *name in names) {
+            NSLog(@"%@", [greeter greet:name]);
+        }
+    }
+    return 0;
+}
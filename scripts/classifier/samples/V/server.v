@@ -0,0 +1,38 @@
+module server
+
+import net.http
+import sync
+import time
+
+pub struct Counter {
+mut:
+	mu    sync.Mutex
+	count int
+}
+
+pub fn (mut c Counter) inc() int {
+	c.mu.@lock()
+	defer {
+		c.mu.unlock()
+	}
+	c.count++
+	return c.count
+}
+
+pub fn serve(port int) ! {
+	mut counter := &Counter{}
+	ch := chan int{cap: 10}
+	spawn fn [ch] () {
+		for {
+			time.sleep(1 * time.second)
+			ch <- 1
+		}
+	}()
+	match port {
+		80, 443 { println('privileged port') }
+		else { println('listening on ${port}') }
+	}
+	resp := http.get('http://localhost:${port}/') or { return error('no server') }
+	assert resp.status_code == 200
+	_ = counter.inc()
+}
@@ -0,0 +1,37 @@
+module main
+
+import os
+import json
+
+struct User {
+	name string
+	age  int
+mut:
+	active bool
+}
+
+fn (u User) can_vote() bool {
+	return u.age >= 18
+}
+
+fn (mut u User) deactivate() {
+	u.active = false
+}
+
+fn load_users(path string) ![]User {
+	data := os.read_file(path)!
+	return json.decode([]User, data)!
+}
+
+fn main() {
+	mut users := load_users('users.json') or {
+		eprintln('failed: ${err}')
+		exit(1)
+	}
+	for mut user in users {
+		if !user.can_vote() {
+			user.deactivate()
+		}
+		println('${user.name}: ${user.active}')
+	}
+}
@@ -0,0 +1,24 @@
+`timescale 1ns / 1ps
+
+// 8 bit counter with synchronous reset and enable
+module counter #(
+    parameter WIDTH = 8
+) (
+    input  wire             clk,
+    input  wire             rst,
+    input  wire             en,
+    output reg  [WIDTH-1:0] count,
+    output wire             overflow
+);
+
+    assign overflow = &count;
+
+    always @(posedge clk) begin
+        if (rst) begin
+            count <= {WIDTH{1'b0}};
+        end else if (en) begin
+            count <= count + 1'b1;
+        end
+    end
+
+endmodule
@@ -0,0 +1,53 @@
+module uart_tx (
+    input            clk,
+    input            reset_n,
+    input      [7:0] data,
+    input            start,
+    output reg       tx,
+    output reg       busy
+);
+    localparam IDLE  = 2'b00;
+    localparam START = 2'b01;
+    localparam DATA  = 2'b10;
+    localparam STOP  = 2'b11;
+
+    reg [1:0] state;
+    reg [2:0] bit_index;
+    reg [7:0] shift;
+
+    always @(posedge clk or negedge reset_n) begin
+        if (!reset_n) begin
+            state <= IDLE;
+            tx    <= 1'b1;
+            busy  <= 1'b0;
+        end else begin
+            case (state)
+                IDLE: if (start) begin
+                    shift <= data;
+                    busy  <= 1'b1;
+                    state <= START;
+                end
+                START: begin
+                    tx    <= 1'b0;
+                    state <= DATA;
+                    bit_index <= 3'd0;
+                end
+                DATA: begin
+                    tx <= shift[bit_index];
+                    if (bit_index == 3'd7) state <= STOP;
+                    else bit_index <= bit_index + 1;
+                end
+                STOP: begin
+                    tx    <= 1'b1;
+                    busy  <= 1'b0;
+                    state <= IDLE;
+                end
+                default: state <= IDLE;
+            endcase
+        end
+    end
+
+    initial begin
+        $display("uart_tx ready");
+    end
+endmodule